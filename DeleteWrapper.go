@@ -2,6 +2,7 @@ package gomp
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"gorm.io/gorm"
@@ -10,23 +11,45 @@ import (
 
 // DeleteWrapper 删除条件构造器
 type DeleteWrapper[T any] struct {
-	scopes        []func(*gorm.DB) *gorm.DB
+	scopes        []scope
 	or            bool // 下一个条件是否使用 OR 连接
 	useSoftDelete bool
 	tableName     string
-	joinClauses   []string
+	joinClauses   []joinClause
+	label         string // 调试标签
+	strict        bool   // 未影响任何行时返回 ErrNoRowsAffected
 }
 
 // NewDeleteWrapper 创建删除条件构造器
 func NewDeleteWrapper[T any]() *DeleteWrapper[T] {
 	return &DeleteWrapper[T]{
-		scopes:        make([]func(*gorm.DB) *gorm.DB, 0),
+		scopes:        make([]scope, 0, wrapperScopeCap),
 		or:            false,
 		useSoftDelete: true,
-		joinClauses:   make([]string, 0),
+		joinClauses:   make([]joinClause, 0),
 	}
 }
 
+// Label 设置调试标签，以注释形式写入该语句的 SQL，并出现在 SQL 日志、指标与拦截器的 ctx (QueryLabel) 中
+func (w *DeleteWrapper[T]) Label(label string) *DeleteWrapper[T] {
+	w.label = label
+	return w
+}
+
+// queryLabel 调试标签，wrapper 为 nil 时为空
+func (w *DeleteWrapper[T]) queryLabel() string {
+	if w == nil {
+		return ""
+	}
+	return w.label
+}
+
+// Strict 开启严格模式，未删除任何记录时返回 ErrNoRowsAffected，用于发现条件过期导致的静默空操作
+func (w *DeleteWrapper[T]) Strict() *DeleteWrapper[T] {
+	w.strict = true
+	return w
+}
+
 // Table 指定表名 (用于设置别名等)
 func (w *DeleteWrapper[T]) Table(name string) *DeleteWrapper[T] {
 	w.tableName = name
@@ -40,14 +63,19 @@ func (w *DeleteWrapper[T]) UseSoftDelete(enabled bool) *DeleteWrapper[T] {
 
 // addCondition 添加条件 (内部辅助方法)
 func (w *DeleteWrapper[T]) addCondition(query any, args ...any) {
-	isOr := w.or
+	w.scopes = append(w.scopes, scope{query: query, args: args, or: w.or})
+	w.or = false
+}
+
+// addValue 添加只有一个参数的条件，参数保存在 scope 中，不再分配参数切片 (内部辅助方法)
+func (w *DeleteWrapper[T]) addValue(query string, val any) {
+	w.scopes = append(w.scopes, scope{query: query, val: [1]any{val}, isVal: true, or: w.or})
 	w.or = false
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		if isOr {
-			return db.Or(query, args...)
-		}
-		return db.Where(query, args...)
-	})
+}
+
+// addScope 添加在 Apply 时执行的操作 (内部辅助方法)
+func (w *DeleteWrapper[T]) addScope(fn func(*gorm.DB) *gorm.DB) {
+	w.scopes = append(w.scopes, scope{fn: fn})
 }
 
 // Or 设置下一个条件为 OR 连接，或者添加嵌套 OR 条件
@@ -56,7 +84,7 @@ func (w *DeleteWrapper[T]) Or(conditions ...func(*DeleteWrapper[T])) *DeleteWrap
 		f := conditions[0]
 		isOr := w.or
 		w.or = false
-		w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+		w.addScope(func(db *gorm.DB) *gorm.DB {
 			subWrapper := NewDeleteWrapper[T]()
 			f(subWrapper)
 
@@ -67,6 +95,11 @@ func (w *DeleteWrapper[T]) Or(conditions ...func(*DeleteWrapper[T])) *DeleteWrap
 			}
 			return db.Or(subDB)
 		})
+		markGroup(w.scopes, true, func() ([]scope, bool) {
+			sub := NewDeleteWrapper[T]()
+			f(sub)
+			return sub.scopes, sub.or
+		})
 		return w
 	}
 	w.or = true
@@ -79,7 +112,7 @@ func (w *DeleteWrapper[T]) And(conditions ...func(*DeleteWrapper[T])) *DeleteWra
 		f := conditions[0]
 		isOr := w.or
 		w.or = false
-		w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+		w.addScope(func(db *gorm.DB) *gorm.DB {
 			subWrapper := NewDeleteWrapper[T]()
 			f(subWrapper)
 
@@ -90,167 +123,285 @@ func (w *DeleteWrapper[T]) And(conditions ...func(*DeleteWrapper[T])) *DeleteWra
 			}
 			return db.Where(subDB)
 		})
+		markGroup(w.scopes, isOr, func() ([]scope, bool) {
+			sub := NewDeleteWrapper[T]()
+			f(sub)
+			return sub.scopes, sub.or
+		})
+	}
+	w.or = false
+	return w
+}
+
+// When cond 为 true 时调用 fn 向当前 Wrapper 添加一组条件 (不加括号)，避免为每个条件单独传入 condition 参数
+//
+//	w.When(req.Keyword != "", func(w *gomp.DeleteWrapper[User]) {
+//		w.Like("name", req.Keyword).Or().Like("email", req.Keyword)
+//	})
+func (w *DeleteWrapper[T]) When(cond bool, fn func(w *DeleteWrapper[T])) *DeleteWrapper[T] {
+	if cond && fn != nil {
+		fn(w)
+	}
+	return w
+}
+
+// Func 调用 fn 向当前 Wrapper 添加一组条件 (不加括号)，便于将可复用的条件构造逻辑放在一处
+func (w *DeleteWrapper[T]) Func(fn func(w *DeleteWrapper[T])) *DeleteWrapper[T] {
+	if fn != nil {
+		fn(w)
+	}
+	return w
+}
+
+// AndWrapper 将已构造的 QueryWrapper 的条件作为嵌套 AND 条件: AND ( ... )，规则同 QueryWrapper.AndWrapper
+func (w *DeleteWrapper[T]) AndWrapper(sub *QueryWrapper[T]) *DeleteWrapper[T] {
+	isOr := w.or
+	w.or = false
+	if sub == nil || len(sub.scopes) == 0 {
+		return w
 	}
+	scopes, dangling := slices.Clone(sub.scopes), sub.or
+	w.addScope(groupScope(scopes, isOr))
+	markGroup(w.scopes, isOr, func() ([]scope, bool) { return scopes, dangling })
+	return w
+}
+
+// OrWrapper 将已构造的 sub 的条件作为嵌套 OR 条件: OR ( ... )，规则同 QueryWrapper.AndWrapper
+func (w *DeleteWrapper[T]) OrWrapper(sub *QueryWrapper[T]) *DeleteWrapper[T] {
 	w.or = false
+	if sub == nil || len(sub.scopes) == 0 {
+		return w
+	}
+	scopes, dangling := slices.Clone(sub.scopes), sub.or
+	w.addScope(groupScope(scopes, true))
+	markGroup(w.scopes, true, func() ([]scope, bool) { return scopes, dangling })
+	return w
+}
+
+// Where 添加由 Field 创建的类型安全条件，多个条件之间为 AND
+func (w *DeleteWrapper[T]) Where(conds ...Cond[T]) *DeleteWrapper[T] {
+	for _, c := range conds {
+		w.addCondition(c.query, c.args...)
+	}
 	return w
 }
 
 // Eq 等于 =
-func (w *DeleteWrapper[T]) Eq(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Eq(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s = ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" = ?", val)
+	} else {
+		w.addCondition(columnCond(column, "=", val))
+	}
 	return w
 }
 
 // Ne 不等于 <>
-func (w *DeleteWrapper[T]) Ne(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Ne(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <> ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" <> ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<>", val))
+	}
 	return w
 }
 
 // Gt 大于 >
-func (w *DeleteWrapper[T]) Gt(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Gt(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s > ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" > ?", val)
+	} else {
+		w.addCondition(columnCond(column, ">", val))
+	}
 	return w
 }
 
 // Ge 大于等于 >=
-func (w *DeleteWrapper[T]) Ge(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Ge(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s >= ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" >= ?", val)
+	} else {
+		w.addCondition(columnCond(column, ">=", val))
+	}
 	return w
 }
 
 // Lt 小于 <
-func (w *DeleteWrapper[T]) Lt(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Lt(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s < ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" < ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<", val))
+	}
 	return w
 }
 
 // Le 小于等于 <=
-func (w *DeleteWrapper[T]) Le(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Le(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <= ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" <= ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<=", val))
+	}
 	return w
 }
 
 // Like 模糊查询 LIKE '%值%'
-func (w *DeleteWrapper[T]) Like(column string, val string, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Like(column any, val string, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val+"%")
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", "%"+val+"%")
+	} else {
+		w.addCondition(columnCond(column, "LIKE", "%"+val+"%"))
+	}
 	return w
 }
 
 // LikeLeft 左模糊 LIKE '%值'
-func (w *DeleteWrapper[T]) LikeLeft(column string, val string, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) LikeLeft(column any, val string, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", "%"+val)
+	} else {
+		w.addCondition(columnCond(column, "LIKE", "%"+val))
+	}
 	return w
 }
 
 // LikeRight 右模糊 LIKE '值%'
-func (w *DeleteWrapper[T]) LikeRight(column string, val string, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) LikeRight(column any, val string, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), val+"%")
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", val+"%")
+	} else {
+		w.addCondition(columnCond(column, "LIKE", val+"%"))
+	}
 	return w
 }
 
 // In IN 查询
-func (w *DeleteWrapper[T]) In(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) In(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IN (?)", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" IN (?)", val)
+	} else {
+		w.addCondition(columnCond(column, "IN", val))
+	}
 	return w
 }
 
 // NotIn NOT IN 查询
-func (w *DeleteWrapper[T]) NotIn(column string, val any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) NotIn(column, val any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT IN (?)", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" NOT IN (?)", val)
+	} else {
+		w.addCondition(columnCond(column, "NOT IN", val))
+	}
 	return w
 }
 
 // IsNull IS NULL
-func (w *DeleteWrapper[T]) IsNull(column string, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) IsNull(column any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NULL", column))
+	if name, ok := column.(string); ok {
+		w.addCondition(name + " IS NULL")
+	} else {
+		w.addCondition(columnCond(column, "IS NULL"))
+	}
 	return w
 }
 
 // IsNotNull IS NOT NULL
-func (w *DeleteWrapper[T]) IsNotNull(column string, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) IsNotNull(column any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NOT NULL", column))
+	if name, ok := column.(string); ok {
+		w.addCondition(name + " IS NOT NULL")
+	} else {
+		w.addCondition(columnCond(column, "IS NOT NULL"))
+	}
 	return w
 }
 
 // Between BETWEEN AND
-func (w *DeleteWrapper[T]) Between(column string, val1, val2 any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) Between(column any, val1, val2 any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s BETWEEN ? AND ?", column), val1, val2)
+	if name, ok := column.(string); ok {
+		w.addCondition(name+" BETWEEN ? AND ?", val1, val2)
+	} else {
+		w.addCondition(columnCond(column, "BETWEEN", val1, val2))
+	}
 	return w
 }
 
 // NotBetween NOT BETWEEN AND
-func (w *DeleteWrapper[T]) NotBetween(column string, val1, val2 any, condition ...bool) *DeleteWrapper[T] {
+func (w *DeleteWrapper[T]) NotBetween(column any, val1, val2 any, condition ...bool) *DeleteWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT BETWEEN ? AND ?", column), val1, val2)
+	if name, ok := column.(string); ok {
+		w.addCondition(name+" NOT BETWEEN ? AND ?", val1, val2)
+	} else {
+		w.addCondition(columnCond(column, "NOT BETWEEN", val1, val2))
+	}
 	return w
 }
 
 // LeftJoin 左连接
-func (w *DeleteWrapper[T]) LeftJoin(table string, leftColumn string, rightColumn string) *DeleteWrapper[T] {
-	w.joinClauses = append(w.joinClauses, fmt.Sprintf("LEFT JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *DeleteWrapper[T]) LeftJoin(table string, leftColumn, rightColumn any) *DeleteWrapper[T] {
+	w.joinClauses = append(w.joinClauses, joinClause{kind: "LEFT JOIN", table: table, left: leftColumn, right: rightColumn})
 	return w
 }
 
 // RightJoin 右连接
-func (w *DeleteWrapper[T]) RightJoin(table string, leftColumn string, rightColumn string) *DeleteWrapper[T] {
-	w.joinClauses = append(w.joinClauses, fmt.Sprintf("RIGHT JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *DeleteWrapper[T]) RightJoin(table string, leftColumn, rightColumn any) *DeleteWrapper[T] {
+	w.joinClauses = append(w.joinClauses, joinClause{kind: "RIGHT JOIN", table: table, left: leftColumn, right: rightColumn})
 	return w
 }
 
 // InnerJoin 内连接
-func (w *DeleteWrapper[T]) InnerJoin(table string, leftColumn string, rightColumn string) *DeleteWrapper[T] {
-	w.joinClauses = append(w.joinClauses, fmt.Sprintf("INNER JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *DeleteWrapper[T]) InnerJoin(table string, leftColumn, rightColumn any) *DeleteWrapper[T] {
+	w.joinClauses = append(w.joinClauses, joinClause{kind: "INNER JOIN", table: table, left: leftColumn, right: rightColumn})
 	return w
 }
 
 // LeftJoinOn 左连接(自定义条件)
-func (w *DeleteWrapper[T]) LeftJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *DeleteWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *DeleteWrapper[T]) LeftJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *DeleteWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -262,14 +413,14 @@ func (w *DeleteWrapper[T]) LeftJoinOn(table string, leftColumn string, rightColu
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
 // RightJoinOn 右连接(自定义条件)
-func (w *DeleteWrapper[T]) RightJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *DeleteWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *DeleteWrapper[T]) RightJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *DeleteWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -281,14 +432,14 @@ func (w *DeleteWrapper[T]) RightJoinOn(table string, leftColumn string, rightCol
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
 // InnerJoinOn 内连接(自定义条件)
-func (w *DeleteWrapper[T]) InnerJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *DeleteWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *DeleteWrapper[T]) InnerJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *DeleteWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -300,20 +451,19 @@ func (w *DeleteWrapper[T]) InnerJoinOn(table string, leftColumn string, rightCol
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
 // Apply 应用条件到 GORM DB
 func (w *DeleteWrapper[T]) Apply(db *gorm.DB) *gorm.DB {
-	for _, scope := range w.scopes {
-		db = scope(db)
-	}
+	db = applyScopes(db, w.scopes)
+	ctx := db.Statement.Context
 
 	// 处理连接查询 (GORM Delete 默认忽略 Joins，需手动合并到 Table)
 	if len(w.joinClauses) > 0 {
-		fullTable := w.tableName
+		fullTable := resolveTableExpr(ctx, w.tableName)
 		if fullTable == "" {
 			// 如果没有显式设置表名，尝试从 model 获取 (注意：这里假设 db 已经绑定了 model，或者由 Service 设置)
 			// 但 Apply 时 db 可能还没有 model 信息，或者 model 是 T
@@ -326,7 +476,7 @@ func (w *DeleteWrapper[T]) Apply(db *gorm.DB) *gorm.DB {
 			sb.WriteString(fullTable)
 			for _, join := range w.joinClauses {
 				sb.WriteString(" ")
-				sb.WriteString(join)
+				sb.WriteString(join.render(db))
 			}
 			db = db.Table(sb.String())
 
@@ -344,11 +494,11 @@ func (w *DeleteWrapper[T]) Apply(db *gorm.DB) *gorm.DB {
 		} else {
 			// 如果没设置表名，尝试回退到 standard Joins (虽然 Delete 可能忽略)
 			for _, join := range w.joinClauses {
-				db = db.Joins(join)
+				db = db.Joins(join.render(db))
 			}
 		}
 	} else if w.tableName != "" {
-		db = db.Table(w.tableName)
+		db = db.Table(resolveTableExpr(ctx, w.tableName))
 	}
 
 	return db
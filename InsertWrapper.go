@@ -1,8 +1,15 @@
 package gomp
 
-// InsertWrapper 插入构造器
+import "errors"
+
+// ErrReturningUnsupported 当前数据库不支持 INSERT ... RETURNING (如 MySQL)，InsertReturning 无法在一次往返中返回插入的记录
+var ErrReturningUnsupported = errors.New("insert returning is not supported for this database")
+
+// InsertWrapper 插入构造器，通过 NextRow 可一次插入多行
 type InsertWrapper[T any] struct {
-	values map[string]any
+	values  map[string]any   // 当前行
+	done    []map[string]any // NextRow 之前的行
+	replace bool
 }
 
 // NewInsertWrapper 创建插入构造器
@@ -20,3 +27,29 @@ func (w *InsertWrapper[T]) Set(column string, val any, condition ...bool) *Inser
 	w.values[column] = val
 	return w
 }
+
+// NextRow 结束当前行，之后的 Set 写入新的一行；当前行没有设置任何字段时不产生新行
+//
+//	w := gomp.NewInsertWrapper[Tag]().Set("name", "go").NextRow().Set("name", "sql")
+func (w *InsertWrapper[T]) NextRow() *InsertWrapper[T] {
+	if len(w.values) > 0 {
+		w.done = append(w.done, w.values)
+		w.values = make(map[string]any)
+	}
+	return w
+}
+
+// rows 要插入的全部行
+func (w *InsertWrapper[T]) rows() []map[string]any {
+	if len(w.values) == 0 && len(w.done) > 0 {
+		return w.done
+	}
+	return append(w.done[:len(w.done):len(w.done)], w.values)
+}
+
+// Replace 主键或唯一键与已有记录冲突时先删除已有记录再插入 (物理删除)
+// MySQL 生成 REPLACE INTO，其他数据库在事务中先删除冲突记录再插入；只支持单行插入
+func (w *InsertWrapper[T]) Replace() *InsertWrapper[T] {
+	w.replace = true
+	return w
+}
@@ -2,22 +2,35 @@ package gomp
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // QueryWrapper 查询条件构造器
 type QueryWrapper[T any] struct {
-	scopes  []func(*gorm.DB) *gorm.DB
-	selects []string // 存储需要查询的字段
-	or      bool     // 下一个条件是否使用 OR 连接
+	scopes   []scope
+	selects  []string      // 存储需要查询的字段
+	fields   []string      // 按结构体字段名指定的查询字段
+	omits    []string      // 按结构体字段名排除的查询字段
+	or       bool          // 下一个条件是否使用 OR 连接
+	cacheTTL time.Duration // 查询结果缓存有效期
+	limit    *int          // 最大返回条数
+	label    string        // 调试标签
 }
 
+// groupColumn GroupBy 中按列名处理的普通列 (可带表名)
+var groupColumn = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
 // NewQueryWrapper 创建查询条件构造器
 func NewQueryWrapper[T any]() *QueryWrapper[T] {
 	return &QueryWrapper[T]{
-		scopes:  make([]func(*gorm.DB) *gorm.DB, 0),
+		scopes:  make([]scope, 0, wrapperScopeCap),
 		selects: make([]string, 0),
 		or:      false,
 	}
@@ -34,6 +47,109 @@ type joinCondition struct {
 	isOr  bool
 }
 
+// joinClause 联表子句 (UpdateWrapper/DeleteWrapper 在执行时合并到 Table)
+type joinClause struct {
+	kind        string
+	table       string
+	left, right any // ON 条件两侧的列
+}
+
+// wrapperScopeCap 条件构造器预分配的容量，覆盖大多数查询的条件数量，避免追加条件时反复扩容
+const wrapperScopeCap = 16
+
+// scope 条件构造器中按顺序应用的一项
+// 普通条件只保存 query 与参数，由 apply 直接调用 Where / Or，不为每个条件分配闭包；其他操作保存在 fn 中
+type scope struct {
+	fn    func(*gorm.DB) *gorm.DB
+	query any
+	args  []any
+	val   [1]any // 只有一个参数的条件 (addValue) 的参数
+	isVal bool   // 参数保存在 val 中
+	or    bool
+	group func() ([]scope, bool) // 嵌套条件 (And / Or 的函数参数) 构造出的条件与是否遗留 Or()，仅用于 Validate
+}
+
+// groupScope 将 scopes 作为嵌套条件 ( ... ) 应用，or 为 true 时以 OR 连接
+func groupScope(scopes []scope, or bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		subDB := applyScopes(db.Session(&gorm.Session{NewDB: true}), scopes)
+		if or {
+			return db.Or(subDB)
+		}
+		return db.Where(subDB)
+	}
+}
+
+// markGroup 将最后添加的一项标记为嵌套条件
+func markGroup(scopes []scope, or bool, group func() ([]scope, bool)) {
+	scopes[len(scopes)-1].or = or
+	scopes[len(scopes)-1].group = group
+}
+
+// params 条件的参数，参数保存在 val 中时引用 val 而不产生新的分配
+func (s *scope) params() []any {
+	if s.isVal {
+		return s.val[:]
+	}
+	return s.args
+}
+
+// whereExpr 普通 AND 条件对应的 clause.Expr，与 gorm 的 Where 对字符串条件的处理一致；其他形式的条件返回 false
+func (s *scope) whereExpr() (clause.Expr, bool) {
+	query, ok := s.query.(string)
+	if s.fn != nil || s.or || !ok || query == "" {
+		return clause.Expr{}, false
+	}
+	args := s.params()
+	if len(args) > 0 && !strings.Contains(query, "?") {
+		return clause.Expr{}, false
+	}
+	if _, err := strconv.Atoi(query); err == nil {
+		// 数字作为主键条件
+		return clause.Expr{}, false
+	}
+	return clause.Expr{SQL: query, Vars: args}, true
+}
+
+// apply 将该项应用到 GORM DB
+func (s *scope) apply(db *gorm.DB) *gorm.DB {
+	if s.fn != nil {
+		return s.fn(db)
+	}
+	if s.or {
+		return db.Or(s.query, s.params()...)
+	}
+	return db.Where(s.query, s.params()...)
+}
+
+// applyScopes 按顺序应用 scopes，连续的普通 AND 条件合并为一个 WHERE 子句添加，避免 gorm 逐个构建、合并条件
+func applyScopes(db *gorm.DB, scopes []scope) *gorm.DB {
+	var exprs []clause.Expression
+	for i := range scopes {
+		if expr, ok := scopes[i].whereExpr(); ok {
+			if exprs == nil {
+				exprs = make([]clause.Expression, 0, len(scopes)-i)
+			}
+			exprs = append(exprs, expr)
+			continue
+		}
+		if len(exprs) > 0 {
+			db = db.Clauses(clause.Where{Exprs: exprs})
+			exprs = nil
+		}
+		db = scopes[i].apply(db)
+	}
+	if len(exprs) > 0 {
+		db = db.Clauses(clause.Where{Exprs: exprs})
+	}
+	return db
+}
+
+// render 渲染联表子句，执行时解析表名并为 clause.Column / Field 形式的列加引号
+func (j joinClause) render(db *gorm.DB) string {
+	return fmt.Sprintf("%s %s ON %s = %s", j.kind, resolveTableExpr(db.Statement.Context, j.table), columnText(db, j.left), columnText(db, j.right))
+}
+
 func NewJoinOnWrapper() *JoinOnWrapper {
 	return &JoinOnWrapper{
 		conditions: make([]joinCondition, 0),
@@ -86,131 +202,147 @@ func (w *JoinOnWrapper) Raw(query string, args ...any) *JoinOnWrapper {
 	return w
 }
 
-func (w *JoinOnWrapper) Eq(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Eq(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s = ?", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" = ?", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) EqColumn(leftColumn string, rightColumn string, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) EqColumn(leftColumn, rightColumn any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s = %s", leftColumn, rightColumn))
+	on, args := columnPair(leftColumn, rightColumn)
+	w.addCondition(on, args...)
 	return w
 }
 
-func (w *JoinOnWrapper) Ne(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Ne(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <> ?", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" <> ?", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) Gt(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Gt(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s > ?", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" > ?", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) Ge(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Ge(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s >= ?", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" >= ?", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) Lt(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Lt(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s < ?", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" < ?", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) Le(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Le(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <= ?", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" <= ?", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) Like(column string, val string, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Like(column any, val string, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val+"%")
+	name, args := columnSQL(column)
+	w.addCondition(name+" LIKE ?", append(args, "%"+val+"%")...)
 	return w
 }
 
-func (w *JoinOnWrapper) LikeLeft(column string, val string, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) LikeLeft(column any, val string, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" LIKE ?", append(args, "%"+val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) LikeRight(column string, val string, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) LikeRight(column any, val string, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), val+"%")
+	name, args := columnSQL(column)
+	w.addCondition(name+" LIKE ?", append(args, val+"%")...)
 	return w
 }
 
-func (w *JoinOnWrapper) In(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) In(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IN (?)", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" IN (?)", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) NotIn(column string, val any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) NotIn(column, val any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT IN (?)", column), val)
+	name, args := columnSQL(column)
+	w.addCondition(name+" NOT IN (?)", append(args, val)...)
 	return w
 }
 
-func (w *JoinOnWrapper) IsNull(column string, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) IsNull(column any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NULL", column))
+	name, args := columnSQL(column)
+	w.addCondition(name+" IS NULL", args...)
 	return w
 }
 
-func (w *JoinOnWrapper) IsNotNull(column string, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) IsNotNull(column any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NOT NULL", column))
+	name, args := columnSQL(column)
+	w.addCondition(name+" IS NOT NULL", args...)
 	return w
 }
 
-func (w *JoinOnWrapper) Between(column string, val1, val2 any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) Between(column any, val1, val2 any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s BETWEEN ? AND ?", column), val1, val2)
+	name, args := columnSQL(column)
+	w.addCondition(name+" BETWEEN ? AND ?", append(args, val1, val2)...)
 	return w
 }
 
-func (w *JoinOnWrapper) NotBetween(column string, val1, val2 any, condition ...bool) *JoinOnWrapper {
+func (w *JoinOnWrapper) NotBetween(column any, val1, val2 any, condition ...bool) *JoinOnWrapper {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT BETWEEN ? AND ?", column), val1, val2)
+	name, args := columnSQL(column)
+	w.addCondition(name+" NOT BETWEEN ? AND ?", append(args, val1, val2)...)
 	return w
 }
 
@@ -238,14 +370,19 @@ func (w *JoinOnWrapper) Build() (string, []any) {
 
 // addCondition 添加条件 (内部辅助方法)
 func (w *QueryWrapper[T]) addCondition(query any, args ...any) {
-	isOr := w.or
+	w.scopes = append(w.scopes, scope{query: query, args: args, or: w.or})
 	w.or = false
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		if isOr {
-			return db.Or(query, args...)
-		}
-		return db.Where(query, args...)
-	})
+}
+
+// addValue 添加只有一个参数的条件，参数保存在 scope 中，不再分配参数切片 (内部辅助方法)
+func (w *QueryWrapper[T]) addValue(query string, val any) {
+	w.scopes = append(w.scopes, scope{query: query, val: [1]any{val}, isVal: true, or: w.or})
+	w.or = false
+}
+
+// addScope 添加在 Apply 时执行的操作 (内部辅助方法)
+func (w *QueryWrapper[T]) addScope(fn func(*gorm.DB) *gorm.DB) {
+	w.scopes = append(w.scopes, scope{fn: fn})
 }
 
 // Or 设置下一个条件为 OR 连接，或者添加嵌套 OR 条件
@@ -256,7 +393,7 @@ func (w *QueryWrapper[T]) Or(conditions ...func(*QueryWrapper[T])) *QueryWrapper
 		f := conditions[0]
 		isOr := w.or // 捕获当前连接符
 		w.or = false
-		w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+		w.addScope(func(db *gorm.DB) *gorm.DB {
 			subWrapper := NewQueryWrapper[T]()
 			f(subWrapper)
 
@@ -267,6 +404,11 @@ func (w *QueryWrapper[T]) Or(conditions ...func(*QueryWrapper[T])) *QueryWrapper
 			}
 			return db.Or(subDB)
 		})
+		markGroup(w.scopes, true, func() ([]scope, bool) {
+			sub := NewQueryWrapper[T]()
+			f(sub)
+			return sub.scopes, sub.or
+		})
 		return w
 	}
 	w.or = true
@@ -280,7 +422,7 @@ func (w *QueryWrapper[T]) And(conditions ...func(*QueryWrapper[T])) *QueryWrappe
 		f := conditions[0]
 		isOr := w.or
 		w.or = false
-		w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+		w.addScope(func(db *gorm.DB) *gorm.DB {
 			subWrapper := NewQueryWrapper[T]()
 			f(subWrapper)
 
@@ -291,185 +433,334 @@ func (w *QueryWrapper[T]) And(conditions ...func(*QueryWrapper[T])) *QueryWrappe
 			}
 			return db.Where(subDB)
 		})
+		markGroup(w.scopes, isOr, func() ([]scope, bool) {
+			sub := NewQueryWrapper[T]()
+			f(sub)
+			return sub.scopes, sub.or
+		})
 	}
 	// 如果没有参数，重置为 AND (默认就是 AND，所以其实不做操作，或者强制 w.or = false)
 	w.or = false
 	return w
 }
 
+// When cond 为 true 时调用 fn 向当前 Wrapper 添加一组条件 (不加括号)，避免为每个条件单独传入 condition 参数
+//
+//	w.When(req.Keyword != "", func(w *gomp.QueryWrapper[User]) {
+//		w.Like("name", req.Keyword).Or().Like("email", req.Keyword)
+//	})
+func (w *QueryWrapper[T]) When(cond bool, fn func(w *QueryWrapper[T])) *QueryWrapper[T] {
+	if cond && fn != nil {
+		fn(w)
+	}
+	return w
+}
+
+// Func 调用 fn 向当前 Wrapper 添加一组条件 (不加括号)，便于将可复用的条件构造逻辑放在一处
+func (w *QueryWrapper[T]) Func(fn func(w *QueryWrapper[T])) *QueryWrapper[T] {
+	if fn != nil {
+		fn(w)
+	}
+	return w
+}
+
+// AndWrapper 将已构造的 sub 的条件作为嵌套 AND 条件: AND ( ... )，用于组合在别处构造的可复用条件片段
+// 使用调用时 sub 中的条件，之后对 sub 的修改不影响当前 Wrapper；sub 的 Select、排序、分组、Limit 等非条件部分被忽略
+// sub 为 nil 或没有条件时不添加
+//
+//	active := gomp.NewQueryWrapper[User]().Eq("status", 1).IsNull("locked_at")
+//	w := gomp.NewQueryWrapper[User]().Like("name", kw).AndWrapper(active)
+func (w *QueryWrapper[T]) AndWrapper(sub *QueryWrapper[T]) *QueryWrapper[T] {
+	isOr := w.or
+	w.or = false
+	if sub == nil || len(sub.scopes) == 0 {
+		return w
+	}
+	scopes, dangling := slices.Clone(sub.scopes), sub.or
+	w.addScope(groupScope(scopes, isOr))
+	markGroup(w.scopes, isOr, func() ([]scope, bool) { return scopes, dangling })
+	return w
+}
+
+// OrWrapper 将已构造的 sub 的条件作为嵌套 OR 条件: OR ( ... )，规则同 AndWrapper
+func (w *QueryWrapper[T]) OrWrapper(sub *QueryWrapper[T]) *QueryWrapper[T] {
+	w.or = false
+	if sub == nil || len(sub.scopes) == 0 {
+		return w
+	}
+	scopes, dangling := slices.Clone(sub.scopes), sub.or
+	w.addScope(groupScope(scopes, true))
+	markGroup(w.scopes, true, func() ([]scope, bool) { return scopes, dangling })
+	return w
+}
+
+// Where 添加由 Field 创建的类型安全条件，多个条件之间为 AND
+func (w *QueryWrapper[T]) Where(conds ...Cond[T]) *QueryWrapper[T] {
+	for _, c := range conds {
+		w.addCondition(c.query, c.args...)
+	}
+	return w
+}
+
 // Eq 等于 =
-func (w *QueryWrapper[T]) Eq(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Eq(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s = ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" = ?", val)
+	} else {
+		w.addCondition(columnCond(column, "=", val))
+	}
 	return w
 }
 
 // Ne 不等于 <>
-func (w *QueryWrapper[T]) Ne(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Ne(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <> ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" <> ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<>", val))
+	}
 	return w
 }
 
 // Gt 大于 >
-func (w *QueryWrapper[T]) Gt(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Gt(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s > ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" > ?", val)
+	} else {
+		w.addCondition(columnCond(column, ">", val))
+	}
 	return w
 }
 
 // Ge 大于等于 >=
-func (w *QueryWrapper[T]) Ge(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Ge(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s >= ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" >= ?", val)
+	} else {
+		w.addCondition(columnCond(column, ">=", val))
+	}
 	return w
 }
 
 // Lt 小于 <
-func (w *QueryWrapper[T]) Lt(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Lt(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s < ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" < ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<", val))
+	}
 	return w
 }
 
 // Le 小于等于 <=
-func (w *QueryWrapper[T]) Le(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Le(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <= ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" <= ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<=", val))
+	}
 	return w
 }
 
 // Like 模糊查询 LIKE '%值%'
-func (w *QueryWrapper[T]) Like(column string, val string, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Like(column any, val string, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val+"%")
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", "%"+val+"%")
+	} else {
+		w.addCondition(columnCond(column, "LIKE", "%"+val+"%"))
+	}
 	return w
 }
 
 // LikeLeft 左模糊 LIKE '%值'
-func (w *QueryWrapper[T]) LikeLeft(column string, val string, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) LikeLeft(column any, val string, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", "%"+val)
+	} else {
+		w.addCondition(columnCond(column, "LIKE", "%"+val))
+	}
 	return w
 }
 
 // LikeRight 右模糊 LIKE '值%'
-func (w *QueryWrapper[T]) LikeRight(column string, val string, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) LikeRight(column any, val string, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), val+"%")
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", val+"%")
+	} else {
+		w.addCondition(columnCond(column, "LIKE", val+"%"))
+	}
 	return w
 }
 
 // In IN 查询
-func (w *QueryWrapper[T]) In(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) In(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IN (?)", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" IN (?)", val)
+	} else {
+		w.addCondition(columnCond(column, "IN", val))
+	}
 	return w
 }
 
 // NotIn NOT IN 查询
-func (w *QueryWrapper[T]) NotIn(column string, val any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) NotIn(column, val any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT IN (?)", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" NOT IN (?)", val)
+	} else {
+		w.addCondition(columnCond(column, "NOT IN", val))
+	}
 	return w
 }
 
 // IsNull IS NULL
-func (w *QueryWrapper[T]) IsNull(column string, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) IsNull(column any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NULL", column))
+	if name, ok := column.(string); ok {
+		w.addCondition(name + " IS NULL")
+	} else {
+		w.addCondition(columnCond(column, "IS NULL"))
+	}
 	return w
 }
 
 // IsNotNull IS NOT NULL
-func (w *QueryWrapper[T]) IsNotNull(column string, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) IsNotNull(column any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NOT NULL", column))
+	if name, ok := column.(string); ok {
+		w.addCondition(name + " IS NOT NULL")
+	} else {
+		w.addCondition(columnCond(column, "IS NOT NULL"))
+	}
 	return w
 }
 
 // Between BETWEEN AND
-func (w *QueryWrapper[T]) Between(column string, val1, val2 any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) Between(column any, val1, val2 any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s BETWEEN ? AND ?", column), val1, val2)
+	if name, ok := column.(string); ok {
+		w.addCondition(name+" BETWEEN ? AND ?", val1, val2)
+	} else {
+		w.addCondition(columnCond(column, "BETWEEN", val1, val2))
+	}
 	return w
 }
 
 // NotBetween NOT BETWEEN AND
-func (w *QueryWrapper[T]) NotBetween(column string, val1, val2 any, condition ...bool) *QueryWrapper[T] {
+func (w *QueryWrapper[T]) NotBetween(column any, val1, val2 any, condition ...bool) *QueryWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT BETWEEN ? AND ?", column), val1, val2)
+	if name, ok := column.(string); ok {
+		w.addCondition(name+" NOT BETWEEN ? AND ?", val1, val2)
+	} else {
+		w.addCondition(columnCond(column, "NOT BETWEEN", val1, val2))
+	}
 	return w
 }
 
 // Table 指定表名/别名
 func (w *QueryWrapper[T]) Table(name string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		return db.Table(name)
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		return db.Table(resolveTableExpr(db.Statement.Context, name))
 	})
 	return w
 }
 
 // OrderByDesc 降序
-func (w *QueryWrapper[T]) OrderByDesc(column string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		return db.Order(column + " DESC")
+func (w *QueryWrapper[T]) OrderByDesc(column any) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		if name, ok := column.(string); ok {
+			return db.Order(name + " DESC")
+		}
+		col, err := toColumn(column)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		return db.Order(clause.OrderByColumn{Column: col, Desc: true})
 	})
 	return w
 }
 
 // OrderByAsc 升序
-func (w *QueryWrapper[T]) OrderByAsc(column string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		return db.Order(column + " ASC")
+func (w *QueryWrapper[T]) OrderByAsc(column any) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		if name, ok := column.(string); ok {
+			return db.Order(name + " ASC")
+		}
+		col, err := toColumn(column)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		return db.Order(clause.OrderByColumn{Column: col, Desc: false})
 	})
 	return w
 }
 
-// GroupBy 分组 GROUP BY
+// GroupBy 分组 GROUP BY，多个列合并为一个子句 (GROUP BY a, b, c)；普通列名 (可带表名) 按方言加引号，表达式 (如 DATE(created_at)) 原样输出
+// 多次调用时列依次追加到同一个子句
+//
+//	w.Select("dept_id", "DATE(created_at) AS day", "COUNT(*) AS total").
+//		GroupBy("dept_id", "DATE(created_at)").Having("COUNT(*) > ?", 5)
 func (w *QueryWrapper[T]) GroupBy(columns ...string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		for _, column := range columns {
-			db = db.Group(column)
+	groupBy := clause.GroupBy{Columns: make([]clause.Column, 0, len(columns))}
+	for _, column := range columns {
+		if column = strings.TrimSpace(column); column != "" {
+			groupBy.Columns = append(groupBy.Columns, clause.Column{Name: column, Raw: !groupColumn.MatchString(column)})
 		}
-		return db
+	}
+	if len(groupBy.Columns) == 0 {
+		return w
+	}
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(groupBy)
 	})
 	return w
 }
 
 // Having 分组后筛选 HAVING
 func (w *QueryWrapper[T]) Having(query string, args ...any) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		return db.Having(query, args...)
 	})
 	return w
@@ -477,44 +768,69 @@ func (w *QueryWrapper[T]) Having(query string, args ...any) *QueryWrapper[T] {
 
 // Distinct 去重 DISTINCT
 func (w *QueryWrapper[T]) Distinct(args ...any) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		return db.Distinct(args...)
 	})
 	return w
 }
 
+// Limit 限制 List 返回的条数，优先于 Service 的默认 LIMIT；n 为 -1 时不限制 (取消默认 LIMIT)
+// Page / SelectPage 使用分页参数，不受该设置影响
+func (w *QueryWrapper[T]) Limit(n int) *QueryWrapper[T] {
+	w.limit = &n
+	return w
+}
+
 // Select 指定查询字段
 func (w *QueryWrapper[T]) Select(columns ...string) *QueryWrapper[T] {
 	w.selects = append(w.selects, columns...)
 	return w
 }
 
+// SelectFields 按结构体字段名指定查询字段，列名在执行时取自实体的 gorm schema (column 标签或命名策略)，标签中修改列名后无需同步修改查询
+// 可与 Select 同时使用；字段不存在或不对应列 (如关联) 时返回 ErrInvalidWrapper
+//
+//	w.SelectFields("ID", "Name", "CreatedAt") // SELECT `id`,`user_name`,`created_at` (Name 的列由标签改为 user_name)
+func (w *QueryWrapper[T]) SelectFields(fields ...string) *QueryWrapper[T] {
+	w.fields = append(w.fields, fields...)
+	return w
+}
+
+// OmitFields 按结构体字段名排除查询字段 (如大文本列)，其余列正常查询；字段的解析同 SelectFields
+func (w *QueryWrapper[T]) OmitFields(fields ...string) *QueryWrapper[T] {
+	w.omits = append(w.omits, fields...)
+	return w
+}
+
 // LeftJoin 左连接
-func (w *QueryWrapper[T]) LeftJoin(table string, leftColumn string, rightColumn string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *QueryWrapper[T]) LeftJoin(table string, leftColumn, rightColumn any) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		on, args := columnPair(leftColumn, rightColumn)
+		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), on), args...)
 	})
 	return w
 }
 
 // RightJoin 右连接
-func (w *QueryWrapper[T]) RightJoin(table string, leftColumn string, rightColumn string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *QueryWrapper[T]) RightJoin(table string, leftColumn, rightColumn any) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		on, args := columnPair(leftColumn, rightColumn)
+		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), on), args...)
 	})
 	return w
 }
 
 // InnerJoin 内连接
-func (w *QueryWrapper[T]) InnerJoin(table string, leftColumn string, rightColumn string) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *QueryWrapper[T]) InnerJoin(table string, leftColumn, rightColumn any) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		on, args := columnPair(leftColumn, rightColumn)
+		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), on), args...)
 	})
 	return w
 }
 
-func (w *QueryWrapper[T]) LeftJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *QueryWrapper[T]) LeftJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -526,13 +842,13 @@ func (w *QueryWrapper[T]) LeftJoinOn(table string, leftColumn string, rightColum
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
-func (w *QueryWrapper[T]) RightJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *QueryWrapper[T]) RightJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -544,13 +860,13 @@ func (w *QueryWrapper[T]) RightJoinOn(table string, leftColumn string, rightColu
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
-func (w *QueryWrapper[T]) InnerJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *QueryWrapper[T]) InnerJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -562,18 +878,107 @@ func (w *QueryWrapper[T]) InnerJoinOn(table string, leftColumn string, rightColu
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
+	})
+	return w
+}
+
+// Label 设置调试标签，以注释形式写入该查询的 SQL，并出现在 SQL 日志、指标、性能统计与拦截器的 ctx (QueryLabel) 中
+// 用于将生产环境的慢查询追溯到构造它的代码，如 Label("order-list-endpoint")
+func (w *QueryWrapper[T]) Label(label string) *QueryWrapper[T] {
+	w.label = label
+	return w
+}
+
+// queryLabel 调试标签，wrapper 为 nil 时为空
+func (w *QueryWrapper[T]) queryLabel() string {
+	if w == nil {
+		return ""
+	}
+	return w.label
+}
+
+// Cache 缓存 List / Count / Page 的查询结果 (需先通过 SetQueryCache 配置缓存存储)
+// 结果按渲染后的 SQL 与参数缓存 ttl，通过 gomp 对实体表执行写操作后自动失效，适合变化较少的字典等数据
+func (w *QueryWrapper[T]) Cache(ttl time.Duration) *QueryWrapper[T] {
+	w.cacheTTL = ttl
+	return w
+}
+
+// queryCacheTTL 查询结果缓存有效期，wrapper 为 nil 时为 0
+func (w *QueryWrapper[T]) queryCacheTTL() time.Duration {
+	if w == nil {
+		return 0
+	}
+	return w.cacheTTL
+}
+
+// NotExistsJoin 的渲染方式 (对应配置 antiJoin)
+const (
+	AntiJoinNotExists = "notExists" // NOT EXISTS (SELECT 1 FROM 子表 WHERE ...) (默认)
+	AntiJoinLeftJoin  = "leftJoin"  // LEFT JOIN 子表 ON ... WHERE 子表关联列 IS NULL，适合 NOT EXISTS 执行计划较差的数据库版本
+)
+
+// NotExistsJoin 反连接: 只保留在 table 中没有匹配记录的行 (如没有订单的用户)，onLeft 为本表的列、onRight 为 table 中的关联列
+// builders 为子表的附加匹配条件，与 LeftJoinOn 的 builders 相同；渲染方式由配置 antiJoin 决定，两种方式结果相同
+//
+//	w.NotExistsJoin("orders o", "user.id", "o.user_id", func(on *gomp.JoinOnWrapper) {
+//		on.Eq("o.status", "paid")
+//	})
+//	// notExists: WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE user.id = o.user_id AND o.status = 'paid')
+//	// leftJoin:  LEFT JOIN orders o ON user.id = o.user_id AND o.status = 'paid' WHERE o.user_id IS NULL
+func (w *QueryWrapper[T]) NotExistsJoin(table string, onLeft, onRight any, builders ...func(*JoinOnWrapper)) *QueryWrapper[T] {
+	isOr := w.or
+	w.or = false
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		onWrapper := NewJoinOnWrapper()
+		onWrapper.EqColumn(onLeft, onRight)
+		for _, b := range builders {
+			if b != nil {
+				b(onWrapper)
+			}
+		}
+		on, args := onWrapper.Build()
+		child := resolveTableExpr(db.Statement.Context, table)
+		var cond clause.Expression
+		switch mode := getConfig().AntiJoin; mode {
+		case "", AntiJoinNotExists:
+			cond = clause.Expr{SQL: fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE %s)", child, on), Vars: args}
+		case AntiJoinLeftJoin:
+			db = db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", child, on), args...)
+			right, rightArgs := columnSQL(onRight)
+			cond = clause.Expr{SQL: right + " IS NULL", Vars: rightArgs}
+		default:
+			_ = db.AddError(fmt.Errorf("unknown antiJoin mode %q", mode))
+			return db
+		}
+		if isOr {
+			return db.Or(cond)
+		}
+		return db.Where(cond)
 	})
 	return w
 }
 
 // Apply 应用条件到 GORM DB
 func (w *QueryWrapper[T]) Apply(db *gorm.DB) *gorm.DB {
-	if len(w.selects) > 0 {
-		db = db.Select(w.selects)
+	selects := w.selects
+	if len(w.fields) > 0 || len(w.omits) > 0 {
+		fields, omits, err := fieldColumns[T](db, w.fields, w.omits)
+		if err != nil {
+			_ = db.AddError(err)
+		}
+		selects = append(slices.Clip(selects), fields...)
+		if len(omits) > 0 {
+			db = db.Omit(omits...)
+		}
+	}
+	if len(selects) > 0 {
+		db = db.Select(selects)
 	}
-	for _, scope := range w.scopes {
-		db = scope(db)
+	db = applyScopes(db, w.scopes)
+	if w.limit != nil {
+		db = db.Limit(*w.limit)
 	}
 	return db
 }
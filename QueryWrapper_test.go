@@ -0,0 +1,124 @@
+package gomp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+	"gorm.io/driver/postgres"
+)
+
+// groupOrder 分组查询使用的实体
+type groupOrder struct {
+	ID        int64 `gorm:"primaryKey"`
+	UserID    int64
+	Status    int
+	Amount    float64
+	CreatedAt time.Time
+}
+
+func TestGroupByRendersSingleClause(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		wrapper *gomp.QueryWrapper[groupOrder]
+		want    gomptest.Statement
+	}{
+		{
+			name: "columns with having",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().
+				Select("user_id", "status", "SUM(amount) AS total").
+				GroupBy("user_id", "status").
+				Having("SUM(amount) > ?", 100),
+			want: gomptest.Expect("SELECT `user_id`,`status`,SUM(amount) AS total FROM `group_orders` GROUP BY `user_id`,`status` HAVING SUM(amount) > ?", 100),
+		},
+		{
+			name: "expression with having and order",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().
+				Select("user_id", "DATE(created_at) AS day", "COUNT(*) AS n").
+				GroupBy("user_id", "DATE(created_at)").
+				Having("COUNT(*) > ?", 1).
+				OrderByDesc("user_id"),
+			want: gomptest.Expect("SELECT `user_id`,DATE(created_at) AS day,COUNT(*) AS n FROM `group_orders` GROUP BY `user_id`,DATE(created_at) HAVING COUNT(*) > ? ORDER BY user_id DESC", 1),
+		},
+		{
+			name: "repeated calls merge",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().
+				Select("user_id", "status").
+				Eq("status", 1).
+				GroupBy("user_id").
+				GroupBy("status"),
+			want: gomptest.Expect("SELECT `user_id`,`status` FROM `group_orders` WHERE status = ? GROUP BY `user_id`,`status`", 1),
+		},
+		{
+			name: "blank columns ignored",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().
+				Select("user_id").
+				GroupBy(" ", "user_id", ""),
+			want: gomptest.Expect("SELECT `user_id` FROM `group_orders` GROUP BY `user_id`"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gomptest.QuerySQL(ctx, tt.wrapper)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gomptest.AssertSQL(t, got, tt.want)
+		})
+	}
+}
+
+func TestGroupByHavingPostgres(t *testing.T) {
+	rec := gomptest.NewRecorder(postgres.New(postgres.Config{DSN: "host=localhost"}))
+	svc := gomp.NewServiceImpl[groupOrder](rec.DB())
+	_, err := svc.List(context.Background(), gomp.NewQueryWrapper[groupOrder]().
+		Select("user_id", "DATE(created_at) AS day").
+		GroupBy("user_id", "DATE(created_at)").
+		Having("COUNT(*) > ?", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomptest.AssertSQL(t, rec.Last(), gomptest.Expect(`SELECT "user_id",DATE(created_at) AS day FROM "group_orders" GROUP BY "user_id",DATE(created_at) HAVING COUNT(*) > $1`, 1))
+}
+
+func TestGroupByHavingExecutes(t *testing.T) {
+	ctx := context.Background()
+	svc := gomptest.NewService[groupOrder](t)
+	orders := []*groupOrder{
+		{UserID: 1, Status: 1, Amount: 80},
+		{UserID: 1, Status: 1, Amount: 40},
+		{UserID: 1, Status: 2, Amount: 10},
+		{UserID: 2, Status: 1, Amount: 50},
+	}
+	if err := svc.SaveBatch(ctx, orders); err != nil {
+		t.Fatal(err)
+	}
+	var totals []struct {
+		UserID int64
+		Status int
+		Total  float64
+	}
+	err := gomp.NewQueryWrapper[groupOrder]().
+		Select("user_id", "status", "SUM(amount) AS total").
+		GroupBy("user_id", "status").
+		Having("SUM(amount) > ?", 20).
+		OrderByAsc("user_id").
+		Apply(svc.GetDB().Model(&groupOrder{})).
+		Scan(&totals).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 2 || totals[0].UserID != 1 || totals[0].Status != 1 || totals[0].Total != 120 || totals[1].UserID != 2 || totals[1].Total != 50 {
+		t.Fatalf("unexpected groups: %+v", totals)
+	}
+	n, err := svc.Count(ctx, gomp.NewQueryWrapper[groupOrder]().GroupBy("user_id", "status").Having("SUM(amount) > ?", 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("count = %d, want 2", n)
+	}
+}
@@ -2,6 +2,7 @@ package gomp
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"gorm.io/gorm"
@@ -9,23 +10,45 @@ import (
 
 // UpdateWrapper 更新条件构造器
 type UpdateWrapper[T any] struct {
-	scopes      []func(*gorm.DB) *gorm.DB
+	scopes      []scope
 	values      map[string]any
 	or          bool // 下一个条件是否使用 OR 连接
 	tableName   string
-	joinClauses []string
+	joinClauses []joinClause
+	label       string // 调试标签
+	strict      bool   // 未影响任何行时返回 ErrNoRowsAffected
 }
 
 // NewUpdateWrapper 创建更新条件构造器
 func NewUpdateWrapper[T any]() *UpdateWrapper[T] {
 	return &UpdateWrapper[T]{
-		scopes:      make([]func(*gorm.DB) *gorm.DB, 0),
+		scopes:      make([]scope, 0, wrapperScopeCap),
 		values:      make(map[string]any),
 		or:          false,
-		joinClauses: make([]string, 0),
+		joinClauses: make([]joinClause, 0),
 	}
 }
 
+// Label 设置调试标签，以注释形式写入该语句的 SQL，并出现在 SQL 日志、指标与拦截器的 ctx (QueryLabel) 中
+func (w *UpdateWrapper[T]) Label(label string) *UpdateWrapper[T] {
+	w.label = label
+	return w
+}
+
+// queryLabel 调试标签，wrapper 为 nil 时为空
+func (w *UpdateWrapper[T]) queryLabel() string {
+	if w == nil {
+		return ""
+	}
+	return w.label
+}
+
+// Strict 开启严格模式，未更新任何记录时返回 ErrNoRowsAffected，用于发现条件过期导致的静默空操作
+func (w *UpdateWrapper[T]) Strict() *UpdateWrapper[T] {
+	w.strict = true
+	return w
+}
+
 // Table 指定表名 (用于设置别名等)
 func (w *UpdateWrapper[T]) Table(name string) *UpdateWrapper[T] {
 	w.tableName = name
@@ -34,14 +57,19 @@ func (w *UpdateWrapper[T]) Table(name string) *UpdateWrapper[T] {
 
 // addCondition 添加条件 (内部辅助方法)
 func (w *UpdateWrapper[T]) addCondition(query any, args ...any) {
-	isOr := w.or
+	w.scopes = append(w.scopes, scope{query: query, args: args, or: w.or})
+	w.or = false
+}
+
+// addValue 添加只有一个参数的条件，参数保存在 scope 中，不再分配参数切片 (内部辅助方法)
+func (w *UpdateWrapper[T]) addValue(query string, val any) {
+	w.scopes = append(w.scopes, scope{query: query, val: [1]any{val}, isVal: true, or: w.or})
 	w.or = false
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
-		if isOr {
-			return db.Or(query, args...)
-		}
-		return db.Where(query, args...)
-	})
+}
+
+// addScope 添加在 Apply 时执行的操作 (内部辅助方法)
+func (w *UpdateWrapper[T]) addScope(fn func(*gorm.DB) *gorm.DB) {
+	w.scopes = append(w.scopes, scope{fn: fn})
 }
 
 // Or 设置下一个条件为 OR 连接，或者添加嵌套 OR 条件
@@ -50,7 +78,7 @@ func (w *UpdateWrapper[T]) Or(conditions ...func(*UpdateWrapper[T])) *UpdateWrap
 		f := conditions[0]
 		isOr := w.or
 		w.or = false
-		w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+		w.addScope(func(db *gorm.DB) *gorm.DB {
 			subWrapper := NewUpdateWrapper[T]()
 			f(subWrapper)
 
@@ -61,6 +89,11 @@ func (w *UpdateWrapper[T]) Or(conditions ...func(*UpdateWrapper[T])) *UpdateWrap
 			}
 			return db.Or(subDB)
 		})
+		markGroup(w.scopes, true, func() ([]scope, bool) {
+			sub := NewUpdateWrapper[T]()
+			f(sub)
+			return sub.scopes, sub.or
+		})
 		return w
 	}
 	w.or = true
@@ -73,7 +106,7 @@ func (w *UpdateWrapper[T]) And(conditions ...func(*UpdateWrapper[T])) *UpdateWra
 		f := conditions[0]
 		isOr := w.or
 		w.or = false
-		w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+		w.addScope(func(db *gorm.DB) *gorm.DB {
 			subWrapper := NewUpdateWrapper[T]()
 			f(subWrapper)
 
@@ -84,8 +117,58 @@ func (w *UpdateWrapper[T]) And(conditions ...func(*UpdateWrapper[T])) *UpdateWra
 			}
 			return db.Where(subDB)
 		})
+		markGroup(w.scopes, isOr, func() ([]scope, bool) {
+			sub := NewUpdateWrapper[T]()
+			f(sub)
+			return sub.scopes, sub.or
+		})
+	}
+	w.or = false
+	return w
+}
+
+// When cond 为 true 时调用 fn 向当前 Wrapper 添加一组条件 (不加括号)，避免为每个条件单独传入 condition 参数
+//
+//	w.When(req.Keyword != "", func(w *gomp.UpdateWrapper[User]) {
+//		w.Like("name", req.Keyword).Or().Like("email", req.Keyword)
+//	})
+func (w *UpdateWrapper[T]) When(cond bool, fn func(w *UpdateWrapper[T])) *UpdateWrapper[T] {
+	if cond && fn != nil {
+		fn(w)
+	}
+	return w
+}
+
+// Func 调用 fn 向当前 Wrapper 添加一组条件 (不加括号)，便于将可复用的条件构造逻辑放在一处
+func (w *UpdateWrapper[T]) Func(fn func(w *UpdateWrapper[T])) *UpdateWrapper[T] {
+	if fn != nil {
+		fn(w)
+	}
+	return w
+}
+
+// AndWrapper 将已构造的 QueryWrapper 的条件作为嵌套 AND 条件: AND ( ... )，规则同 QueryWrapper.AndWrapper
+func (w *UpdateWrapper[T]) AndWrapper(sub *QueryWrapper[T]) *UpdateWrapper[T] {
+	isOr := w.or
+	w.or = false
+	if sub == nil || len(sub.scopes) == 0 {
+		return w
 	}
+	scopes, dangling := slices.Clone(sub.scopes), sub.or
+	w.addScope(groupScope(scopes, isOr))
+	markGroup(w.scopes, isOr, func() ([]scope, bool) { return scopes, dangling })
+	return w
+}
+
+// OrWrapper 将已构造的 sub 的条件作为嵌套 OR 条件: OR ( ... )，规则同 QueryWrapper.AndWrapper
+func (w *UpdateWrapper[T]) OrWrapper(sub *QueryWrapper[T]) *UpdateWrapper[T] {
 	w.or = false
+	if sub == nil || len(sub.scopes) == 0 {
+		return w
+	}
+	scopes, dangling := slices.Clone(sub.scopes), sub.or
+	w.addScope(groupScope(scopes, true))
+	markGroup(w.scopes, true, func() ([]scope, bool) { return scopes, dangling })
 	return w
 }
 
@@ -103,7 +186,7 @@ func (w *UpdateWrapper[T]) SetIncrBy(column string, val any, condition ...bool)
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.values[column] = gorm.Expr(fmt.Sprintf("%s + ?", column), val)
+	w.values[column] = gorm.Expr(column+" + ?", val)
 	return w
 }
 
@@ -112,166 +195,234 @@ func (w *UpdateWrapper[T]) SetDecrBy(column string, val any, condition ...bool)
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.values[column] = gorm.Expr(fmt.Sprintf("%s - ?", column), val)
+	w.values[column] = gorm.Expr(column+" - ?", val)
+	return w
+}
+
+// Where 添加由 Field 创建的类型安全条件，多个条件之间为 AND
+func (w *UpdateWrapper[T]) Where(conds ...Cond[T]) *UpdateWrapper[T] {
+	for _, c := range conds {
+		w.addCondition(c.query, c.args...)
+	}
 	return w
 }
 
 // Eq 等于 =
-func (w *UpdateWrapper[T]) Eq(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Eq(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s = ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" = ?", val)
+	} else {
+		w.addCondition(columnCond(column, "=", val))
+	}
 	return w
 }
 
 // Ne 不等于 <>
-func (w *UpdateWrapper[T]) Ne(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Ne(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <> ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" <> ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<>", val))
+	}
 	return w
 }
 
 // Gt 大于 >
-func (w *UpdateWrapper[T]) Gt(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Gt(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s > ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" > ?", val)
+	} else {
+		w.addCondition(columnCond(column, ">", val))
+	}
 	return w
 }
 
 // Ge 大于等于 >=
-func (w *UpdateWrapper[T]) Ge(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Ge(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s >= ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" >= ?", val)
+	} else {
+		w.addCondition(columnCond(column, ">=", val))
+	}
 	return w
 }
 
 // Lt 小于 <
-func (w *UpdateWrapper[T]) Lt(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Lt(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s < ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" < ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<", val))
+	}
 	return w
 }
 
 // Le 小于等于 <=
-func (w *UpdateWrapper[T]) Le(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Le(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s <= ?", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" <= ?", val)
+	} else {
+		w.addCondition(columnCond(column, "<=", val))
+	}
 	return w
 }
 
 // Like 模糊查询 LIKE '%值%'
-func (w *UpdateWrapper[T]) Like(column string, val string, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Like(column any, val string, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val+"%")
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", "%"+val+"%")
+	} else {
+		w.addCondition(columnCond(column, "LIKE", "%"+val+"%"))
+	}
 	return w
 }
 
 // LikeLeft 左模糊 LIKE '%值'
-func (w *UpdateWrapper[T]) LikeLeft(column string, val string, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) LikeLeft(column any, val string, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), "%"+val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", "%"+val)
+	} else {
+		w.addCondition(columnCond(column, "LIKE", "%"+val))
+	}
 	return w
 }
 
 // LikeRight 右模糊 LIKE '值%'
-func (w *UpdateWrapper[T]) LikeRight(column string, val string, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) LikeRight(column any, val string, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s LIKE ?", column), val+"%")
+	if name, ok := column.(string); ok {
+		w.addValue(name+" LIKE ?", val+"%")
+	} else {
+		w.addCondition(columnCond(column, "LIKE", val+"%"))
+	}
 	return w
 }
 
 // In IN 查询
-func (w *UpdateWrapper[T]) In(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) In(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IN (?)", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" IN (?)", val)
+	} else {
+		w.addCondition(columnCond(column, "IN", val))
+	}
 	return w
 }
 
 // NotIn NOT IN 查询
-func (w *UpdateWrapper[T]) NotIn(column string, val any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) NotIn(column, val any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT IN (?)", column), val)
+	if name, ok := column.(string); ok {
+		w.addValue(name+" NOT IN (?)", val)
+	} else {
+		w.addCondition(columnCond(column, "NOT IN", val))
+	}
 	return w
 }
 
 // IsNull IS NULL
-func (w *UpdateWrapper[T]) IsNull(column string, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) IsNull(column any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NULL", column))
+	if name, ok := column.(string); ok {
+		w.addCondition(name + " IS NULL")
+	} else {
+		w.addCondition(columnCond(column, "IS NULL"))
+	}
 	return w
 }
 
 // IsNotNull IS NOT NULL
-func (w *UpdateWrapper[T]) IsNotNull(column string, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) IsNotNull(column any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s IS NOT NULL", column))
+	if name, ok := column.(string); ok {
+		w.addCondition(name + " IS NOT NULL")
+	} else {
+		w.addCondition(columnCond(column, "IS NOT NULL"))
+	}
 	return w
 }
 
 // Between BETWEEN AND
-func (w *UpdateWrapper[T]) Between(column string, val1, val2 any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) Between(column any, val1, val2 any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s BETWEEN ? AND ?", column), val1, val2)
+	if name, ok := column.(string); ok {
+		w.addCondition(name+" BETWEEN ? AND ?", val1, val2)
+	} else {
+		w.addCondition(columnCond(column, "BETWEEN", val1, val2))
+	}
 	return w
 }
 
 // NotBetween NOT BETWEEN AND
-func (w *UpdateWrapper[T]) NotBetween(column string, val1, val2 any, condition ...bool) *UpdateWrapper[T] {
+func (w *UpdateWrapper[T]) NotBetween(column any, val1, val2 any, condition ...bool) *UpdateWrapper[T] {
 	if len(condition) > 0 && !condition[0] {
 		return w
 	}
-	w.addCondition(fmt.Sprintf("%s NOT BETWEEN ? AND ?", column), val1, val2)
+	if name, ok := column.(string); ok {
+		w.addCondition(name+" NOT BETWEEN ? AND ?", val1, val2)
+	} else {
+		w.addCondition(columnCond(column, "NOT BETWEEN", val1, val2))
+	}
 	return w
 }
 
 // LeftJoin 左连接
-func (w *UpdateWrapper[T]) LeftJoin(table string, leftColumn string, rightColumn string) *UpdateWrapper[T] {
-	w.joinClauses = append(w.joinClauses, fmt.Sprintf("LEFT JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *UpdateWrapper[T]) LeftJoin(table string, leftColumn, rightColumn any) *UpdateWrapper[T] {
+	w.joinClauses = append(w.joinClauses, joinClause{kind: "LEFT JOIN", table: table, left: leftColumn, right: rightColumn})
 	return w
 }
 
 // RightJoin 右连接
-func (w *UpdateWrapper[T]) RightJoin(table string, leftColumn string, rightColumn string) *UpdateWrapper[T] {
-	w.joinClauses = append(w.joinClauses, fmt.Sprintf("RIGHT JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *UpdateWrapper[T]) RightJoin(table string, leftColumn, rightColumn any) *UpdateWrapper[T] {
+	w.joinClauses = append(w.joinClauses, joinClause{kind: "RIGHT JOIN", table: table, left: leftColumn, right: rightColumn})
 	return w
 }
 
 // InnerJoin 内连接
-func (w *UpdateWrapper[T]) InnerJoin(table string, leftColumn string, rightColumn string) *UpdateWrapper[T] {
-	w.joinClauses = append(w.joinClauses, fmt.Sprintf("INNER JOIN %s ON %s = %s", table, leftColumn, rightColumn))
+func (w *UpdateWrapper[T]) InnerJoin(table string, leftColumn, rightColumn any) *UpdateWrapper[T] {
+	w.joinClauses = append(w.joinClauses, joinClause{kind: "INNER JOIN", table: table, left: leftColumn, right: rightColumn})
 	return w
 }
 
 // LeftJoinOn 左连接(自定义条件)
-func (w *UpdateWrapper[T]) LeftJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *UpdateWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *UpdateWrapper[T]) LeftJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *UpdateWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -283,14 +434,14 @@ func (w *UpdateWrapper[T]) LeftJoinOn(table string, leftColumn string, rightColu
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("LEFT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
 // RightJoinOn 右连接(自定义条件)
-func (w *UpdateWrapper[T]) RightJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *UpdateWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *UpdateWrapper[T]) RightJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *UpdateWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -302,14 +453,14 @@ func (w *UpdateWrapper[T]) RightJoinOn(table string, leftColumn string, rightCol
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("RIGHT JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
 // InnerJoinOn 内连接(自定义条件)
-func (w *UpdateWrapper[T]) InnerJoinOn(table string, leftColumn string, rightColumn string, builders ...func(*JoinOnWrapper)) *UpdateWrapper[T] {
-	w.scopes = append(w.scopes, func(db *gorm.DB) *gorm.DB {
+func (w *UpdateWrapper[T]) InnerJoinOn(table string, leftColumn, rightColumn any, builders ...func(*JoinOnWrapper)) *UpdateWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
 		onWrapper := NewJoinOnWrapper()
 		onWrapper.EqColumn(leftColumn, rightColumn)
 		for _, b := range builders {
@@ -321,36 +472,35 @@ func (w *UpdateWrapper[T]) InnerJoinOn(table string, leftColumn string, rightCol
 		if strings.TrimSpace(onClause) == "" {
 			return db
 		}
-		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", table, onClause), args...)
+		return db.Joins(fmt.Sprintf("INNER JOIN %s ON %s", resolveTableExpr(db.Statement.Context, table), onClause), args...)
 	})
 	return w
 }
 
 // Apply 应用条件到 GORM DB
 func (w *UpdateWrapper[T]) Apply(db *gorm.DB) *gorm.DB {
-	for _, scope := range w.scopes {
-		db = scope(db)
-	}
+	db = applyScopes(db, w.scopes)
+	ctx := db.Statement.Context
 
 	// 处理连接查询 (将 Joins 合并到 Table)
 	if len(w.joinClauses) > 0 {
-		fullTable := w.tableName
+		fullTable := resolveTableExpr(ctx, w.tableName)
 		if fullTable != "" {
 			sb := strings.Builder{}
 			sb.WriteString(fullTable)
 			for _, join := range w.joinClauses {
 				sb.WriteString(" ")
-				sb.WriteString(join)
+				sb.WriteString(join.render(db))
 			}
 			db = db.Table(sb.String())
 		} else {
 			// 如果没设置表名，回退到 standard Joins
 			for _, join := range w.joinClauses {
-				db = db.Joins(join)
+				db = db.Joins(join.render(db))
 			}
 		}
 	} else if w.tableName != "" {
-		db = db.Table(w.tableName)
+		db = db.Table(resolveTableExpr(ctx, w.tableName))
 	}
 
 	return db
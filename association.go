@@ -0,0 +1,172 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveOptions Save / SaveBatch 的选项，控制关联字段 (has one / has many / belongs to / many to many) 的保存
+// 默认只保存实体本身，不写入任何关联，避免 gorm 隐式插入或更新关联记录
+//
+//	// 随订单一起插入明细，已存在的明细记录不更新
+//	err := orderService.Save(ctx, order, gomp.SaveOptions{Associations: []string{"Items"}})
+//	// 完整保存全部关联，已存在的关联记录同样更新
+//	err = orderService.Save(ctx, order, gomp.SaveOptions{Associations: []string{clause.Associations}, FullSave: true})
+type SaveOptions struct {
+	Associations []string // 随实体一起保存的关联 (字段名)，clause.Associations 表示全部关联
+	FullSave     bool     // 关联记录已存在时同样更新 (gorm FullSaveAssociations)，默认只插入不存在的关联记录
+}
+
+// saveOptions 取可变参数中的选项
+func saveOptions(opts []SaveOptions) SaveOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return SaveOptions{}
+}
+
+// applySaveOptions 忽略未选择的关联，FullSave 时开启 FullSaveAssociations
+func applySaveOptions[T any](db *gorm.DB, opts SaveOptions) (*gorm.DB, error) {
+	if len(opts.Associations) == 0 {
+		return db.Omit(clause.Associations), nil
+	}
+	if opts.FullSave {
+		db = db.Session(&gorm.Session{FullSaveAssociations: true})
+	}
+	if slices.Contains(opts.Associations, clause.Associations) {
+		return db, nil
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range opts.Associations {
+		if _, ok := sch.Relationships.Relations[name]; !ok {
+			return nil, fmt.Errorf("unknown association %q of %s", name, sch.Name)
+		}
+	}
+	var omits []string
+	for name := range sch.Relationships.Relations {
+		if !slices.Contains(opts.Associations, name) {
+			omits = append(omits, name)
+		}
+	}
+	if len(omits) == 0 {
+		return db, nil
+	}
+	slices.Sort(omits)
+	return db.Omit(omits...), nil
+}
+
+// LoadRelation 为一批父实体批量加载子记录，避免逐条查询的 N+1 问题: 以父实体主键执行一次 fk IN (...) 查询，按 fk 分组后调用 setter
+// fk 为子记录中指向父实体主键的列 (列名或字段名)；每个父实体都会调用一次 setter，没有子记录时为空切片
+// 子记录通过 Service 查询，逻辑删除、多租户、全局条件与脱敏同样生效
+//
+//	err := gomp.LoadRelation(ctx, db, orders, "order_id", func(o *Order, items []*OrderItem) { o.Items = items })
+func LoadRelation[T, R any](ctx context.Context, db *gorm.DB, parents []*T, fk string, setter func(*T, []*R)) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	parentSchema, err := parseSchema[T](db)
+	if err != nil {
+		return err
+	}
+	pk := parentSchema.PrioritizedPrimaryField
+	if pk == nil {
+		return fmt.Errorf("%s has no primary key", parentSchema.Name)
+	}
+	childSchema, err := parseSchema[R](db)
+	if err != nil {
+		return err
+	}
+	fkField := childSchema.LookUpField(fk)
+	if fkField == nil || fkField.DBName == "" {
+		return fmt.Errorf("foreign key %q not found in %s", fk, childSchema.Name)
+	}
+
+	ids := make([]any, 0, len(parents))
+	seen := make(map[string]struct{}, len(parents))
+	for _, parent := range parents {
+		if parent == nil {
+			continue
+		}
+		id, zero := pk.ValueOf(ctx, reflect.ValueOf(parent))
+		if zero {
+			continue
+		}
+		key := fmt.Sprint(plainValue(id))
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	groups := make(map[string][]*R)
+	if len(ids) > 0 {
+		children, err := NewServiceImpl[R](db).List(ctx, NewQueryWrapper[R]().In(fkField.DBName, ids))
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			v, _ := fkField.ValueOf(ctx, reflect.ValueOf(child))
+			key := fmt.Sprint(plainValue(v))
+			groups[key] = append(groups[key], child)
+		}
+	}
+	for _, parent := range parents {
+		if parent == nil {
+			continue
+		}
+		id, _ := pk.ValueOf(ctx, reflect.ValueOf(parent))
+		children := groups[fmt.Sprint(plainValue(id))]
+		if children == nil {
+			children = make([]*R, 0)
+		}
+		setter(parent, children)
+	}
+	return nil
+}
+
+// preloadRelations 预加载关联 (gorm Preload)，关联实体同样追加多租户、逻辑删除与全局条件
+// 嵌套关联 (如 "Items.Product") 的每一层都单独追加对应实体的条件
+func (s *ServiceImpl[T]) preloadRelations(db *gorm.DB, relations []string) *gorm.DB {
+	scope := preloadScope(s.ignoreGlobalConditions)
+	seen := make(map[string]struct{})
+	for _, relation := range relations {
+		parts := strings.Split(relation, ".")
+		for i := range parts {
+			name := strings.Join(parts[:i+1], ".")
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			db = db.Preload(name, scope)
+		}
+	}
+	return db
+}
+
+// preloadScope 预加载查询的条件，关联实体的类型在执行时由语句的 Model 确定
+func preloadScope(ignoreGlobalConditions bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if err := db.Statement.Parse(db.Statement.Model); err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		sch := db.Statement.Schema
+		exprs, err := entityConditions(db, sch, sch.ModelType, true)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		if !ignoreGlobalConditions {
+			exprs = append(exprs, globalConditionsOf(db, sch.ModelType)...)
+		}
+		return appendWhere(db, exprs...)
+	}
+}
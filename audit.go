@@ -0,0 +1,236 @@
+package gomp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// defaultAuditTable 默认审计表名
+const defaultAuditTable = "gomp_audit_log"
+
+// 审计操作类型
+const (
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditLog 审计记录，可通过 db.Table(gomp.AuditTableName(ctx)).AutoMigrate(&gomp.AuditLog{}) 建表
+type AuditLog struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	Entity    string    `gorm:"size:64;index:idx_gomp_audit_record" json:"entity"`   // 实体名称
+	RecordID  string    `gorm:"size:64;index:idx_gomp_audit_record" json:"recordId"` // 记录主键
+	Action    string    `gorm:"size:16" json:"action"`                               // 操作类型: update / delete
+	Operator  string    `gorm:"size:64" json:"operator"`                             // 操作人
+	Changes   string    `gorm:"type:text" json:"changes"`                            // 字段变更 JSON: {"column": {"old": .., "new": ..}}
+	Method    string    `gorm:"size:32" json:"method"`                               // 触发的 Service 方法
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// FieldChange 字段变更前后的值
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditOperatorFunc 从 ctx 中获取操作人
+type AuditOperatorFunc func(ctx context.Context) string
+
+var (
+	auditMu       sync.RWMutex
+	auditEntities = make(map[reflect.Type]struct{})
+	auditOperator AuditOperatorFunc
+)
+
+// EnableAudit 为实体 T 开启审计，UpdateById / Update / RemoveById / RemoveByIds / Delete
+// 会记录变更前后的字段差异、操作人与时间到审计表 (auditTable 配置，默认 gomp_audit_log)
+func EnableAudit[T any]() {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditEntities[entityType[T]()] = struct{}{}
+}
+
+// SetAuditOperator 设置审计记录的操作人获取方式，未设置时使用 OperatorFrom(ctx)
+func SetAuditOperator(fn AuditOperatorFunc) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditOperator = fn
+}
+
+// AuditTableName 获取审计表名 (已按 tablePrefix 及表名解析器解析)
+func AuditTableName(ctx context.Context) string {
+	table := getConfig().AuditTable
+	if table == "" {
+		table = defaultAuditTable
+	}
+	return ResolveTableName(ctx, table)
+}
+
+// auditEnabled 判断实体 T 是否开启审计
+func auditEnabled[T any]() bool {
+	auditMu.RLock()
+	defer auditMu.RUnlock()
+	_, ok := auditEntities[entityType[T]()]
+	return ok
+}
+
+// auditTrail 一次写操作的审计上下文，保存变更前的记录快照
+type auditTrail[T any] struct {
+	s      *ServiceImpl[T]
+	sch    *schema.Schema
+	method string
+	action string
+	before []*T
+}
+
+// beginAudit 在写操作前查询受影响记录的快照，实体未开启审计时返回 nil
+// db 需已包含写操作的全部条件
+func (s *ServiceImpl[T]) beginAudit(db *gorm.DB, method, action string) (*auditTrail[T], error) {
+	if !auditEnabled[T]() {
+		return nil, nil
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("audit requires a primary key on %s", sch.Name)
+	}
+	qualifier := sch.Table
+	if db.Statement.Table != "" {
+		qualifier = db.Statement.Table
+	}
+	before := make([]*T, 0)
+	// 只查询实体表的列，避免联表时同名列覆盖
+	if err := db.Session(&gorm.Session{}).Set(primaryOnlyKey, true).Select(qualifier + ".*").Find(&before).Error; err != nil {
+		return nil, err
+	}
+	return &auditTrail[T]{s: s, sch: sch, method: method, action: action, before: before}, nil
+}
+
+// commit 在写操作成功后对比变更前后的记录并写入审计表
+func (a *auditTrail[T]) commit(ctx context.Context) error {
+	if a == nil || len(a.before) == 0 {
+		return nil
+	}
+	pkField := a.sch.PrioritizedPrimaryField
+	ids := make([]any, len(a.before))
+	for i, e := range a.before {
+		ids[i], _ = pkField.ValueOf(ctx, reflect.ValueOf(e))
+	}
+
+	after := make(map[string]*T, len(a.before))
+	if a.action == AuditActionUpdate {
+		cond, err := primaryKeyCondition(a.sch, ids)
+		if err != nil {
+			return err
+		}
+		current := make([]*T, 0, len(a.before))
+		if err := a.s.model(ctx).Set(primaryOnlyKey, true).Where(cond).Find(&current).Error; err != nil {
+			return err
+		}
+		for _, e := range current {
+			id, _ := pkField.ValueOf(ctx, reflect.ValueOf(e))
+			after[fmt.Sprint(id)] = e
+		}
+	}
+
+	operator := operatorName(ctx)
+	auditMu.RLock()
+	if auditOperator != nil {
+		operator = auditOperator(ctx)
+	}
+	auditMu.RUnlock()
+
+	now := time.Now()
+	logs := make([]*AuditLog, 0, len(a.before))
+	for i, old := range a.before {
+		recordID := fmt.Sprint(ids[i])
+		changes, err := diffEntity(ctx, a.sch, old, after[recordID])
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		logs = append(logs, &AuditLog{
+			Entity:    a.sch.Name,
+			RecordID:  recordID,
+			Action:    a.action,
+			Operator:  operator,
+			Changes:   string(data),
+			Method:    a.method,
+			CreatedAt: now,
+		})
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	return a.s.getDB(ctx).Table(AuditTableName(ctx)).Create(&logs).Error
+}
+
+// diffEntity 对比实体字段差异，newEntity 为 nil 时 (删除) 记录全部字段的原值
+// 敏感列 (gomp:"sensitive" 或 sensitiveColumns 配置) 的值替换为 RedactedValue，加密字段以密文记录，审计表中不保存明文
+func diffEntity[T any](ctx context.Context, sch *schema.Schema, oldEntity, newEntity *T) (map[string]FieldChange, error) {
+	changes := make(map[string]FieldChange)
+	oldValue := reflect.ValueOf(oldEntity)
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		oldVal, _ := field.ValueOf(ctx, oldValue)
+		if newEntity == nil {
+			changes[field.DBName] = FieldChange{Old: oldVal}
+			continue
+		}
+		newVal, _ := field.ValueOf(ctx, reflect.ValueOf(newEntity))
+		if !reflect.DeepEqual(oldVal, newVal) {
+			changes[field.DBName] = FieldChange{Old: oldVal, New: newVal}
+		}
+	}
+	return changes, protectChanges(sch, changes, newEntity == nil)
+}
+
+// protectChanges 将变更中敏感列的值替换为 RedactedValue、加密字段的值替换为密文
+func protectChanges(sch *schema.Schema, changes map[string]FieldChange, deleted bool) error {
+	sensitive := schemaSensitiveColumns(sch)
+	encrypted := schemaEncryptedFields(sch)
+	for column, change := range changes {
+		if isSensitiveColumn(column, sensitive) {
+			change.Old = RedactedValue
+			if !deleted {
+				change.New = RedactedValue
+			}
+			changes[column] = change
+			continue
+		}
+		f, ok := encrypted[strings.ToLower(column)]
+		if !ok {
+			continue
+		}
+		c := activeCipher.Load()
+		if c == nil {
+			return ErrCipherNotConfigured
+		}
+		var err error
+		if change.Old, err = encryptValue(*c, change.Old, f.deterministic); err != nil {
+			return err
+		}
+		if change.New, err = encryptValue(*c, change.New, f.deterministic); err != nil {
+			return err
+		}
+		changes[column] = change
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package gomp_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+)
+
+// auditedUser 含加密与敏感字段的审计实体
+type auditedUser struct {
+	ID       int64 `gorm:"primaryKey"`
+	Name     string
+	IdCard   string `gomp:"encrypt"`
+	Password string `gomp:"sensitive"`
+}
+
+func TestAuditDoesNotStorePlaintext(t *testing.T) {
+	ctx := context.Background()
+	cipher, err := gomp.NewAESCipher(bytes.Repeat([]byte{9}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomp.SetCipher(cipher)
+	t.Cleanup(func() { gomp.SetCipher(nil) })
+	gomp.EnableAudit[auditedUser]()
+
+	db := gomptest.NewDB(t, &auditedUser{})
+	if err := db.Table(gomp.AuditTableName(ctx)).AutoMigrate(&gomp.AuditLog{}); err != nil {
+		t.Fatal(err)
+	}
+	svc := gomp.NewServiceImpl[auditedUser](db)
+	user := &auditedUser{Name: "a", IdCard: "110101199001011234", Password: "old-secret"}
+	if err := svc.Save(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.UpdateById(ctx, &auditedUser{ID: user.ID, Name: "b", IdCard: "110101199001015678", Password: "new-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.RemoveById(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs []gomp.AuditLog
+	if err := db.Table(gomp.AuditTableName(ctx)).Order("id").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d audit logs, want 2", len(logs))
+	}
+	for _, log := range logs {
+		for _, plain := range []string{"110101199001011234", "110101199001015678", "old-secret", "new-secret"} {
+			if strings.Contains(log.Changes, plain) {
+				t.Fatalf("%s log contains plaintext %q: %s", log.Action, plain, log.Changes)
+			}
+		}
+		if !strings.Contains(log.Changes, gomp.RedactedValue) || !strings.Contains(log.Changes, `"id_card"`) {
+			t.Fatalf("%s log = %s", log.Action, log.Changes)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package gomp_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+	"gorm.io/gorm"
+)
+
+// benchUser 基准测试使用的实体
+type benchUser struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	Email     string
+	Age       int
+	Status    int
+	Score     float64
+	DeletedAt *time.Time
+	CreatedAt time.Time
+}
+
+// hotPaths 热点路径基准及其内存分配预算 (每次操作的分配次数)，见 README「基准测试与内存分配预算」
+var hotPaths = []struct {
+	name   string
+	budget int64
+	run    func(b *testing.B)
+}{
+	{name: "QueryWrapper/Build15", budget: 45, run: benchmarkBuild15},
+	{name: "QueryWrapper/Apply15", budget: 60, run: benchmarkApply15},
+	{name: "Service/Page", budget: 520, run: benchmarkPage},
+}
+
+func BenchmarkQueryWrapperBuild15(b *testing.B) { benchmarkBuild15(b) }
+
+func BenchmarkQueryWrapperApply15(b *testing.B) { benchmarkApply15(b) }
+
+func BenchmarkServicePage(b *testing.B) { benchmarkPage(b) }
+
+// TestAllocationBudget 热点路径每次操作的分配次数超出预算时失败，防止性能回退；-short 时跳过
+func TestAllocationBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation budget in short mode")
+	}
+	for _, hp := range hotPaths {
+		t.Run(hp.name, func(t *testing.T) {
+			r := testing.Benchmark(hp.run)
+			if r.N == 0 {
+				t.Fatal("benchmark failed")
+			}
+			if allocs := r.AllocsPerOp(); allocs > hp.budget {
+				t.Errorf("%d allocs/op, budget %d", allocs, hp.budget)
+			}
+		})
+	}
+}
+
+func benchmarkBuild15(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = buildWrapper15()
+	}
+}
+
+func benchmarkApply15(b *testing.B) {
+	b.ReportAllocs()
+	db := gomptest.NewDB(b, &benchUser{})
+	w := buildWrapper15()
+	base := db.Session(&gorm.Session{NewDB: true})
+	for b.Loop() {
+		_ = w.Apply(base.Model(&benchUser{}))
+	}
+}
+
+func benchmarkPage(b *testing.B) {
+	b.ReportAllocs()
+	svc := gomptest.NewService[benchUser](b)
+	ctx := context.Background()
+	users := make([]*benchUser, 100)
+	for i := range users {
+		users[i] = &benchUser{Name: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Age: 18 + i%50, Status: i % 3, CreatedAt: time.Now()}
+	}
+	if err := svc.SaveBatch(ctx, users); err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		w := gomp.NewQueryWrapper[benchUser]().Ge("age", 20).In("status", []int{0, 1}).OrderByDesc("id")
+		if _, err := svc.Page(ctx, gomp.NewPage[benchUser](2, 20), w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// buildWrapper15 构造包含 15 个条件的查询
+func buildWrapper15() *gomp.QueryWrapper[benchUser] {
+	return gomp.NewQueryWrapper[benchUser]().
+		Eq("name", "user1").
+		Ne("status", 2).
+		Gt("age", 18).
+		Ge("score", 60.5).
+		Lt("age", 60).
+		Le("score", 100).
+		Like("email", "example").
+		LikeRight("name", "user").
+		In("status", []int{0, 1}).
+		NotIn("id", []int64{7, 8, 9}).
+		IsNull("deleted_at").
+		IsNotNull("created_at").
+		Between("age", 20, 50).
+		NotBetween("score", 0, 10).
+		Eq("email", "user1@example.com")
+}
@@ -0,0 +1,120 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// IdFilter 主键存在性过滤器 (如布隆过滤器)，用于拦截不存在主键的 GetById 查询
+// MightContain 返回 false 表示主键一定不存在；返回错误时视为可能存在，继续查询
+type IdFilter interface {
+	Add(ctx context.Context, id any) error
+	MightContain(ctx context.Context, id any) (bool, error)
+}
+
+var (
+	idFiltersMu sync.RWMutex
+	idFilters   = make(map[reflect.Type]IdFilter)
+)
+
+// SetIdFilter 为实体 T 设置主键过滤器，过滤器判定不存在的主键 GetById 直接返回 nil，不访问缓存与数据库；filter 为 nil 时移除
+// 通过 gomp 新增的记录会自动加入过滤器，已有数据需在启动时自行加载
+func SetIdFilter[T any](filter IdFilter) {
+	idFiltersMu.Lock()
+	defer idFiltersMu.Unlock()
+	if filter == nil {
+		delete(idFilters, entityType[T]())
+		return
+	}
+	idFilters[entityType[T]()] = filter
+}
+
+// lookupIdFilter 获取实体 T 的主键过滤器
+func lookupIdFilter[T any]() IdFilter {
+	idFiltersMu.RLock()
+	defer idFiltersMu.RUnlock()
+	return idFilters[entityType[T]()]
+}
+
+// idMightExist 主键是否可能存在，未设置过滤器时总是返回 true
+func idMightExist[T any](ctx context.Context, id any) bool {
+	filter := lookupIdFilter[T]()
+	if filter == nil {
+		return true
+	}
+	ok, err := filter.MightContain(ctx, id)
+	return ok || err != nil
+}
+
+// addToIdFilter 将新增记录的主键加入过滤器，失败不影响调用结果
+func addToIdFilter[T any](ctx context.Context, ids ...any) {
+	filter := lookupIdFilter[T]()
+	if filter == nil {
+		return
+	}
+	for _, id := range ids {
+		_ = filter.Add(ctx, id)
+	}
+}
+
+// BloomFilter 进程内布隆过滤器，实现 IdFilter，并发安全
+// 主键按 fmt.Sprint 的结果计算哈希，因此 1 与 "1" 视为同一主键
+type BloomFilter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	m    uint64 // 位数
+	k    uint64 // 哈希函数个数
+}
+
+// NewBloomFilter 按预计元素个数与期望误判率创建布隆过滤器
+func NewBloomFilter(expected uint64, falsePositiveRate float64) *BloomFilter {
+	if expected == 0 {
+		expected = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(expected) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(expected) * math.Ln2))
+	k = max(k, 1)
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Add 实现 IdFilter
+func (f *BloomFilter) Add(_ context.Context, id any) error {
+	h1, h2 := bloomHash(id)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	return nil
+}
+
+// MightContain 实现 IdFilter
+func (f *BloomFilter) MightContain(_ context.Context, id any) (bool, error) {
+	h1, h2 := bloomHash(id)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// bloomHash 计算双重哈希使用的两个哈希值
+func bloomHash(id any) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = fmt.Fprint(h, id)
+	sum := h.Sum64()
+	return sum & 0xffffffff, sum>>32 | 1
+}
@@ -0,0 +1,220 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于打开状态，调用未执行直接失败
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 关闭: 正常执行并统计失败率
+	BreakerOpen                         // 打开: 调用直接返回 ErrCircuitOpen
+	BreakerHalfOpen                     // 半开: 放行少量探测调用，全部成功后关闭，任一失败重新打开
+)
+
+// String 返回状态名称
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// BreakerOptions CircuitBreaker 的配置
+type BreakerOptions struct {
+	Window        time.Duration                                                      // 统计失败率的时间窗口，默认 10s
+	MinRequests   int                                                                // 窗口内至少达到的调用数才计算失败率，默认 20
+	FailureRate   float64                                                            // 打开熔断的失败率 (0, 1]，默认 0.5
+	SlowCall      time.Duration                                                      // 耗时超过该值的调用计为失败，为 0 时不按耗时统计
+	OpenTimeout   time.Duration                                                      // 打开后进入半开的时间，默认 30s
+	HalfOpenCalls int                                                                // 半开状态放行的探测调用数，默认 1
+	IsFailure     func(err error) bool                                               // 判断错误是否计为失败，默认见 isBreakerFailure
+	Fallback      func(ctx context.Context, inv *Invocation, err error) (any, error) // 熔断打开时的降级处理，返回值需与方法结果类型一致；为 nil 时返回 ErrCircuitOpen
+	OnStateChange func(from, to BreakerState)                                        // 状态变化时调用
+}
+
+// CircuitBreaker Service 方法的熔断器，通过 Middleware 接入一个或多个 Service，在数据库故障期间快速失败，避免请求堆积拖垮应用
+type CircuitBreaker struct {
+	opts BreakerOptions
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time         // 当前统计窗口的开始时间
+	requests    int               // 窗口内的调用数
+	failures    int               // 窗口内的失败数
+	openedAt    time.Time         // 打开的时间
+	probes      int               // 半开状态已放行的探测调用数
+	successes   int               // 半开状态成功的探测调用数
+	changes     [][2]BreakerState // 待通知 OnStateChange 的状态变化
+}
+
+// NewCircuitBreaker 创建熔断器
+//
+//	breaker := gomp.NewCircuitBreaker(gomp.BreakerOptions{FailureRate: 0.3, SlowCall: 2 * time.Second,
+//		Fallback: func(ctx context.Context, inv *gomp.Invocation, err error) (any, error) {
+//			if inv.Method == "List" {
+//				return []*Product{}, nil // 降级为空列表
+//			}
+//			return nil, err
+//		}})
+//	productService := gomp.NewServiceImpl[Product](db).Use(breaker.Middleware())
+func NewCircuitBreaker(opts BreakerOptions) *CircuitBreaker {
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 20
+	}
+	if opts.FailureRate <= 0 || opts.FailureRate > 1 {
+		opts.FailureRate = 0.5
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.HalfOpenCalls <= 0 {
+		opts.HalfOpenCalls = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = isBreakerFailure
+	}
+	return &CircuitBreaker{opts: opts, windowStart: time.Now()}
+}
+
+// State 当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.unlock()
+	b.advance(time.Now())
+	return b.state
+}
+
+// Middleware 返回执行熔断的拦截器
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(ctx context.Context, inv *Invocation, next Handler) (any, error) {
+		if !b.allow() {
+			if b.opts.Fallback != nil {
+				return b.opts.Fallback(ctx, inv, ErrCircuitOpen)
+			}
+			return nil, ErrCircuitOpen
+		}
+		start := time.Now()
+		// next panic 时同样记录为失败，否则半开状态占用的探测名额不会释放
+		failed := true
+		defer func() {
+			b.record(failed)
+		}()
+		result, err := next(ctx, inv)
+		failed = b.opts.IsFailure(err) || b.opts.SlowCall > 0 && time.Since(start) > b.opts.SlowCall
+		return result, err
+	}
+}
+
+// allow 是否放行本次调用
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.unlock()
+	b.advance(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.probes >= b.opts.HalfOpenCalls {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// record 记录调用结果
+func (b *CircuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	now := time.Now()
+	b.advance(now)
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			b.transition(BreakerOpen, now)
+			return
+		}
+		if b.successes++; b.successes >= b.opts.HalfOpenCalls {
+			b.transition(BreakerClosed, now)
+		}
+	case BreakerClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.opts.MinRequests && float64(b.failures) >= b.opts.FailureRate*float64(b.requests) {
+			b.transition(BreakerOpen, now)
+		}
+	}
+}
+
+// advance 按时间推进状态: 打开超时后进入半开，关闭状态下窗口到期后重新统计
+func (b *CircuitBreaker) advance(now time.Time) {
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) >= b.opts.OpenTimeout {
+			b.transition(BreakerHalfOpen, now)
+		}
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= b.opts.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+	}
+}
+
+// transition 切换状态并重置计数
+func (b *CircuitBreaker) transition(to BreakerState, now time.Time) {
+	from := b.state
+	b.state = to
+	b.windowStart, b.requests, b.failures = now, 0, 0
+	b.probes, b.successes = 0, 0
+	if to == BreakerOpen {
+		b.openedAt = now
+	}
+	if b.opts.OnStateChange != nil && from != to {
+		b.changes = append(b.changes, [2]BreakerState{from, to})
+	}
+}
+
+// unlock 释放锁后通知状态变化，避免回调中访问熔断器时死锁
+func (b *CircuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+	for _, c := range changes {
+		b.opts.OnStateChange(c[0], c[1])
+	}
+}
+
+// breakerIgnoredErrors 由调用方或数据本身导致、不代表数据库故障的错误
+var breakerIgnoredErrors = []error{
+	ErrNotFound, ErrDuplicateKey, ErrForeignKey, ErrCheckConstraint, ErrNoRowsAffected, ErrOptimisticLock,
+	ErrInvalidWrapper, ErrInvalidParam, ErrTenantRequired, ErrShardKeyRequired, ErrLimitExceeded, context.Canceled,
+}
+
+// isBreakerFailure 默认的失败判断: 除记录不存在、约束冲突、参数错误、调用方取消等错误外的错误计为失败
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, ignored := range breakerIgnoredErrors {
+		if errors.Is(err, ignored) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,238 @@
+package gomp
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrBulkLoadUnsupported BulkLoader 无法处理本次导入 (如处于事务中、服务端未开启 local_infile)，BulkInsert 回退到分批 INSERT
+var ErrBulkLoadUnsupported = errors.New("bulk load is not supported for this connection")
+
+// BulkLoader 使用数据库的批量导入协议写入行，如 PostgreSQL 的 COPY FROM、MySQL 的 LOAD DATA LOCAL INFILE
+// 内置实现见 bulkload 包；rows 中的值已转换为驱动值 (driver.Valuer 已求值)，NULL 为 nil
+type BulkLoader interface {
+	// Load 将 rows 写入 table 的 columns 列，返回写入的行数；返回 ErrBulkLoadUnsupported 时回退到分批 INSERT
+	Load(ctx context.Context, db *gorm.DB, table string, columns []string, rows [][]any) (int64, error)
+}
+
+// BulkLoaderFunc 函数形式的 BulkLoader
+type BulkLoaderFunc func(ctx context.Context, db *gorm.DB, table string, columns []string, rows [][]any) (int64, error)
+
+// Load 实现 BulkLoader
+func (f BulkLoaderFunc) Load(ctx context.Context, db *gorm.DB, table string, columns []string, rows [][]any) (int64, error) {
+	return f(ctx, db, table, columns, rows)
+}
+
+var (
+	bulkLoaderMu sync.RWMutex
+	bulkLoaders  = make(map[string]BulkLoader) // 方言名 (Dialector.Name()) -> BulkLoader
+)
+
+// RegisterBulkLoader 为方言 (如 "postgres"、"mysql") 注册批量导入实现，loader 为 nil 时移除注册
+//
+//	gomp.RegisterBulkLoader("postgres", bulkload.Postgres())
+//	gomp.RegisterBulkLoader("mysql", bulkload.MySQL()) // 需服务端开启 local_infile
+func RegisterBulkLoader(dialect string, loader BulkLoader) {
+	bulkLoaderMu.Lock()
+	defer bulkLoaderMu.Unlock()
+	if loader == nil {
+		delete(bulkLoaders, dialect)
+		return
+	}
+	bulkLoaders[dialect] = loader
+}
+
+// lookupBulkLoader 获取方言的批量导入实现
+func lookupBulkLoader(dialect string) BulkLoader {
+	bulkLoaderMu.RLock()
+	defer bulkLoaderMu.RUnlock()
+	return bulkLoaders[dialect]
+}
+
+// BulkOptions BulkInsert 的选项
+type BulkOptions struct {
+	BatchSize int // 每次写入的行数，默认 5000
+}
+
+// BulkInsert 大批量导入实体，返回写入的行数
+// 方言注册了 BulkLoader 时使用批量导入协议 (COPY / LOAD DATA)，否则或 BulkLoader 返回 ErrBulkLoadUnsupported 时回退到 CreateInBatches
+// 批量导入协议不经过 gorm 的回调：实体包含加密或序列化字段、实体分表时总是使用分批 INSERT；
+// 数据库生成的列 (自增主键、有默认值的列) 仅在全部实体都为零值时由数据库生成，不会回填到实体
+// 主键生成、自动填充与钩子与 SaveBatch 一致，导入的实体不写入缓存
+//
+//	n, err := orderService.BulkInsert(ctx, orders, gomp.BulkOptions{BatchSize: 10000})
+func (s *ServiceImpl[T]) BulkInsert(ctx context.Context, entities []*T, opts ...BulkOptions) (int64, error) {
+	return invoke(s, ctx, "BulkInsert", nil, []any{entities, opts}, func(ctx context.Context) (int64, error) {
+		var opt BulkOptions
+		if len(opts) > 0 {
+			opt = opts[0]
+		}
+		return s.bulkInsert(ctx, "BulkInsert", entities, opt)
+	})
+}
+
+// bulkInsert 大批量导入实现
+func (s *ServiceImpl[T]) bulkInsert(ctx context.Context, method string, entities []*T, opt BulkOptions) (int64, error) {
+	if len(entities) == 0 {
+		return 0, nil
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 5000
+	}
+	db := s.table(ctx)
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.beforeInsert(ctx, db, entities...); err != nil {
+		return 0, err
+	}
+	if err := runEntityHooks(ctx, BeforeSave, method, entities...); err != nil {
+		return 0, err
+	}
+	rows, err := s.bulkWrite(ctx, db, sch, entities, opt.BatchSize)
+	if rows > 0 && !IsDryRun(ctx) {
+		// 批量导入协议不经过 gorm 回调，需显式更新查询缓存版本
+		bumpQueryCacheVersion(db, sch.Table)
+	}
+	if err != nil {
+		return rows, err
+	}
+	if pk := sch.PrioritizedPrimaryField; pk != nil {
+		for _, entity := range entities {
+			if id, zero := pk.ValueOf(ctx, reflect.ValueOf(entity)); !zero {
+				addToIdFilter[T](ctx, id)
+			}
+		}
+	}
+	return rows, s.afterEntityHooks(ctx, AfterSave, method, rows, entities...)
+}
+
+// bulkWrite 按批写入，优先使用方言的 BulkLoader
+func (s *ServiceImpl[T]) bulkWrite(ctx context.Context, db *gorm.DB, sch *schema.Schema, entities []*T, batchSize int) (int64, error) {
+	var total int64
+	// 批量导入协议不经过 gorm 回调，DryRun 模式下使用分批 INSERT 渲染语句
+	if loader := lookupBulkLoader(db.Dialector.Name()); loader != nil && bulkLoadable(sch) && !IsDryRun(ctx) {
+		table := sch.Table
+		if db.Statement.Table != "" {
+			table = db.Statement.Table
+		}
+		columns, fields := bulkColumns(ctx, sch, entities)
+		for len(entities) > 0 {
+			n := min(batchSize, len(entities))
+			rows, err := bulkRows(ctx, fields, entities[:n])
+			if err != nil {
+				return total, err
+			}
+			loaded, err := loader.Load(ctx, db, table, columns, rows)
+			if errors.Is(err, ErrBulkLoadUnsupported) {
+				break
+			}
+			total += loaded
+			if err != nil {
+				return total, err
+			}
+			entities = entities[n:]
+		}
+		if len(entities) == 0 {
+			return total, nil
+		}
+	}
+	groups, err := shardGroups(ctx, db, entities)
+	if err != nil {
+		return total, err
+	}
+	for _, group := range groups {
+		result := db.CreateInBatches(group, batchSize)
+		total += result.RowsAffected
+		if result.Error != nil {
+			return total, result.Error
+		}
+	}
+	return total, nil
+}
+
+// bulkLoadable 实体能否绕过 gorm 回调直接导入：不包含加密、序列化字段且未分表
+func bulkLoadable(sch *schema.Schema) bool {
+	if len(schemaEncryptedFields(sch)) > 0 {
+		return false
+	}
+	if _, ok := lookupShardingRule(sch); ok {
+		return false
+	}
+	for _, field := range sch.Fields {
+		if field.Serializer != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// bulkColumns 导入的列，数据库生成的列 (有默认值) 在全部实体都为零值时跳过；自动时间戳为零值时填充当前时间
+func bulkColumns[T any](ctx context.Context, sch *schema.Schema, entities []*T) ([]string, []*schema.Field) {
+	now := time.Now()
+	var (
+		columns []string
+		fields  []*schema.Field
+	)
+	for _, field := range sch.Fields {
+		if field.DBName == "" || !field.Creatable {
+			continue
+		}
+		allZero := true
+		for _, entity := range entities {
+			rv := reflect.ValueOf(entity)
+			_, zero := field.ValueOf(ctx, rv)
+			if zero && (field.AutoCreateTime > 0 || field.AutoUpdateTime > 0) {
+				_ = field.Set(ctx, rv, now)
+				zero = false
+			}
+			allZero = allZero && zero
+		}
+		if field.HasDefaultValue && allZero {
+			continue
+		}
+		columns = append(columns, field.DBName)
+		fields = append(fields, field)
+	}
+	return columns, fields
+}
+
+// bulkRows 按列取出实体的驱动值，nil 指针为 NULL
+func bulkRows[T any](ctx context.Context, fields []*schema.Field, entities []*T) ([][]any, error) {
+	rows := make([][]any, len(entities))
+	for i, entity := range entities {
+		rv := reflect.ValueOf(entity)
+		row := make([]any, len(fields))
+		for j, field := range fields {
+			v, _ := field.ValueOf(ctx, rv)
+			if valuer, ok := v.(driver.Valuer); ok {
+				if p := reflect.ValueOf(v); p.Kind() == reflect.Ptr && p.IsNil() {
+					continue
+				}
+				dv, err := valuer.Value()
+				if err != nil {
+					return nil, err
+				}
+				row[j] = dv
+				continue
+			}
+			if p := reflect.ValueOf(v); p.Kind() == reflect.Ptr {
+				if p.IsNil() {
+					continue
+				}
+				v = p.Elem().Interface()
+			}
+			row[j] = v
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
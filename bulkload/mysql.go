@@ -0,0 +1,129 @@
+package bulkload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/shelbeii/gomp"
+	"gorm.io/gorm"
+)
+
+// readerSeq LOAD DATA 读取器名称的序号，保证并发导入互不影响
+var readerSeq atomic.Uint64
+
+// MySQLLoader 使用 LOAD DATA LOCAL INFILE 导入的 BulkLoader，行数据以流的方式写入，不生成临时文件
+// 服务端需开启 local_infile；未开启时返回 gomp.ErrBulkLoadUnsupported，由 BulkInsert 回退到分批 INSERT
+type MySQLLoader struct {
+	Location *time.Location // time.Time 值写入前转换到的时区，应与 DSN 的 loc 一致，默认 UTC
+}
+
+// MySQL 创建 LOAD DATA 导入器，时间按 UTC 写入
+func MySQL() *MySQLLoader {
+	return &MySQLLoader{Location: time.UTC}
+}
+
+// Load 实现 gomp.BulkLoader
+func (l *MySQLLoader) Load(ctx context.Context, db *gorm.DB, table string, columns []string, rows [][]any) (int64, error) {
+	name := "gomp-bulk-" + strconv.FormatUint(readerSeq.Add(1), 10)
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+	go func() {
+		pw.CloseWithError(l.write(pw, rows))
+	}()
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quote(column)
+	}
+	tables := strings.Split(table, ".")
+	for i, t := range tables {
+		tables[i] = quote(t)
+	}
+	result := db.WithContext(ctx).Exec("LOAD DATA LOCAL INFILE 'Reader::" + name + "' INTO TABLE " +
+		strings.Join(tables, ".") + " CHARACTER SET utf8mb4 (" + strings.Join(quoted, ",") + ")")
+	var mysqlErr *mysql.MySQLError
+	if errors.As(result.Error, &mysqlErr) && (mysqlErr.Number == 1148 || mysqlErr.Number == 3948) {
+		// ER_NOT_ALLOWED_COMMAND / ER_CLIENT_LOCAL_FILES_DISABLED: 未开启 local_infile
+		return 0, gomp.ErrBulkLoadUnsupported
+	}
+	return result.RowsAffected, result.Error
+}
+
+// write 按 LOAD DATA 的默认格式 (制表符分隔、换行结束、反斜杠转义、\N 表示 NULL) 写出行
+func (l *MySQLLoader) write(w io.Writer, rows [][]any) error {
+	var b strings.Builder
+	for _, row := range rows {
+		b.Reset()
+		for i, v := range row {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			l.writeValue(&b, v)
+		}
+		b.WriteByte('\n')
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeValue 写出单个值
+func (l *MySQLLoader) writeValue(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString(`\N`)
+	case string:
+		escape(b, v)
+	case []byte:
+		escape(b, string(v))
+	case bool:
+		if v {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	case time.Time:
+		loc := l.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		b.WriteString(v.In(loc).Format("2006-01-02 15:04:05.999999"))
+	default:
+		escape(b, fmt.Sprint(v))
+	}
+}
+
+// escape 转义反斜杠、制表符、换行与 NUL
+func escape(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case 0:
+			b.WriteString(`\0`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// quote 以反引号引用标识符
+func quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,47 @@
+// Package bulkload 提供 gomp.BulkLoader 的内置实现：PostgreSQL COPY FROM 与 MySQL LOAD DATA LOCAL INFILE
+//
+//	gomp.RegisterBulkLoader("postgres", bulkload.Postgres())
+//	gomp.RegisterBulkLoader("mysql", bulkload.MySQL())
+package bulkload
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/shelbeii/gomp"
+	"gorm.io/gorm"
+)
+
+// Postgres 使用 COPY FROM STDIN 导入的 BulkLoader，要求连接由 pgx (gorm.io/driver/postgres) 建立
+// COPY 在独立连接上执行，db 处于事务中时返回 gomp.ErrBulkLoadUnsupported
+func Postgres() gomp.BulkLoader {
+	return gomp.BulkLoaderFunc(copyFrom)
+}
+
+// copyFrom 通过 pgx 连接执行 COPY FROM
+func copyFrom(ctx context.Context, db *gorm.DB, table string, columns []string, rows [][]any) (int64, error) {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return 0, gomp.ErrBulkLoadUnsupported
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var n int64
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(interface{ Conn() *pgx.Conn })
+		if !ok {
+			return gomp.ErrBulkLoadUnsupported
+		}
+		n, err = c.Conn().CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, pgx.CopyFromRows(rows))
+		return err
+	})
+	return n, err
+}
@@ -0,0 +1,328 @@
+package gomp
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Cache 缓存存储，gomp 的各类缓存均通过该接口读写，值为编码后的字节
+// 内置进程内的 MemoryCache 与 RedisCache，也可基于其他存储实现
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error // ttl 为 0 时不过期
+	Delete(ctx context.Context, keys ...string) error
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) // 只返回命中的 key
+	SetMulti(ctx context.Context, values map[string][]byte, ttl time.Duration) error
+}
+
+// entityCache 实体的缓存配置
+type entityCache struct {
+	cache       Cache
+	ttl         time.Duration
+	notFoundTTL time.Duration // 大于 0 时缓存 GetById 未找到的结果
+}
+
+var (
+	entityCachesMu sync.RWMutex
+	entityCaches   = make(map[reflect.Type]entityCache)
+)
+
+// EnableCache 为实体 T 开启 GetById 缓存，ttl 为缓存有效期 (0 表示不过期)，cache 为 nil 时关闭
+// 开启后 GetById 优先读取缓存，Save / SaveBatch 写入缓存，UpdateById / RemoveById / RemoveByIds / Update / Delete 使受影响的记录失效
+// 事务中的 GetById 不读写缓存，写入与失效在 Transaction 提交后进行；加密字段 (gomp:"encrypt") 以密文写入缓存
+func EnableCache[T any](cache Cache, ttl time.Duration) {
+	entityCachesMu.Lock()
+	defer entityCachesMu.Unlock()
+	if cache == nil {
+		delete(entityCaches, entityType[T]())
+		return
+	}
+	entityCaches[entityType[T]()] = entityCache{cache: cache, ttl: ttl}
+}
+
+// CacheNotFound 为实体 T 缓存 GetById 未找到的结果，ttl 内重复查询不存在的主键不再访问数据库，ttl 为 0 时关闭
+// 需在 EnableCache 之后调用；通过 gomp 新增对应记录时空结果随之失效，实体包含租户字段时不缓存空结果
+func CacheNotFound[T any](ttl time.Duration) {
+	entityCachesMu.Lock()
+	defer entityCachesMu.Unlock()
+	if c, ok := entityCaches[entityType[T]()]; ok {
+		c.notFoundTTL = ttl
+		entityCaches[entityType[T]()] = c
+	}
+}
+
+// lookupEntityCache 获取实体 T 的缓存配置
+func lookupEntityCache[T any]() (entityCache, bool) {
+	entityCachesMu.RLock()
+	defer entityCachesMu.RUnlock()
+	c, ok := entityCaches[entityType[T]()]
+	return c, ok
+}
+
+// cacheKey 缓存 key: gomp:<数据源>:<表名>:<主键>，指定 schema 时表名为 <schema>.<表名>
+func (s *ServiceImpl[T]) cacheKey(ctx context.Context, sch *schema.Schema, id any) string {
+	table := sch.Table
+	if name, ok := SchemaFrom(ctx); ok {
+		table = name + "." + table
+	}
+	return fmt.Sprintf("gomp:%s:%s:%v", s.dataSourceName(ctx), table, id)
+}
+
+// entityFlight 合并 GetById 缓存未命中时的并发加载
+var entityFlight flightGroup
+
+// loadEntity 通过缓存读取实体: 命中时直接返回，未命中时合并相同主键的并发加载并写入缓存
+// load 在记录不存在时返回 nil, nil；实体未开启缓存时直接执行 load
+func (s *ServiceImpl[T]) loadEntity(ctx context.Context, sch *schema.Schema, id any, load func() (*T, error)) (*T, error) {
+	c, ok := lookupEntityCache[T]()
+	if !ok || s.ignoreGlobalConditions || inTransaction(s.DB) {
+		// 忽略全局条件时可能读到平时不可见的记录，事务中可能读到未提交的记录，不读写缓存
+		return load()
+	}
+	key := s.cacheKey(ctx, sch, id)
+	if entity, hit := s.cacheGet(ctx, c, sch, key); hit {
+		return entity, nil
+	}
+
+	// 不同租户的加载结果不能共享，空结果也只对当前租户成立
+	flightKey := key
+	notFoundTTL := c.notFoundTTL
+	if tenantField[T](ctx, sch) != nil {
+		notFoundTTL = 0
+		tenant, err := currentTenant(ctx)
+		if err != nil {
+			return nil, err
+		}
+		flightKey += "\x00" + fmt.Sprint(tenant)
+	}
+	var leader *T
+	data, err, _ := entityFlight.do(flightKey, func() ([]byte, error) {
+		entity, err := load()
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			if notFoundTTL > 0 {
+				_ = c.cache.Set(ctx, key, []byte{}, notFoundTTL)
+			}
+			return nil, nil
+		}
+		leader = entity
+		data, err := encodeEntity(ctx, sch, entity)
+		if err != nil {
+			return nil, errFlightUnshareable
+		}
+		_ = c.cache.Set(ctx, key, data, c.ttl)
+		return data, nil
+	})
+	if leader != nil {
+		return leader, nil
+	}
+	if errors.Is(err, errFlightUnshareable) {
+		return load()
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+	entity, err := decodeEntity[T](ctx, sch, data)
+	if err != nil {
+		return load()
+	}
+	return entity, nil
+}
+
+// encodeEntity 编码缓存的实体，加密字段以密文写入缓存 (实体本身不变)，未设置 Cipher 时返回错误
+func encodeEntity[T any](ctx context.Context, sch *schema.Schema, entity *T) ([]byte, error) {
+	sealed, err := sealEntity(ctx, sch, entity)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sealed); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeEntity 解码缓存的实体并解密加密字段
+func decodeEntity[T any](ctx context.Context, sch *schema.Schema, data []byte) (*T, error) {
+	entity := new(T)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(entity); err != nil {
+		return nil, err
+	}
+	if err := openEntity(ctx, sch, entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// sealEntity 返回加密字段替换为密文的实体副本，没有加密字段时返回实体本身；未设置 Cipher 时返回错误
+func sealEntity[T any](ctx context.Context, sch *schema.Schema, entity *T) (*T, error) {
+	fields := schemaEncryptedFields(sch)
+	if len(fields) == 0 || entity == nil {
+		return entity, nil
+	}
+	c := activeCipher.Load()
+	if c == nil {
+		return nil, ErrCipherNotConfigured
+	}
+	sealed := *entity
+	if err := transformFields(ctx, reflect.ValueOf(&sealed), fields, func(_ encryptedField, s string) (string, error) {
+		return (*c).Encrypt(s, false)
+	}); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// openEntity 解密 sealEntity 加密的字段
+func openEntity[T any](ctx context.Context, sch *schema.Schema, entity *T) error {
+	fields := schemaEncryptedFields(sch)
+	if len(fields) == 0 || entity == nil {
+		return nil
+	}
+	c := activeCipher.Load()
+	if c == nil {
+		return ErrCipherNotConfigured
+	}
+	return transformFields(ctx, reflect.ValueOf(entity), fields, func(_ encryptedField, s string) (string, error) {
+		return (*c).Decrypt(s)
+	})
+}
+
+// cacheGet 从缓存读取实体，未命中、读取失败或不属于当前租户时返回 false
+// 命中缓存的空结果 (空字节) 时返回 nil, true
+func (s *ServiceImpl[T]) cacheGet(ctx context.Context, c entityCache, sch *schema.Schema, key string) (*T, bool) {
+	data, ok, err := c.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	if len(data) == 0 {
+		return nil, true
+	}
+	entity, err := decodeEntity[T](ctx, sch, data)
+	if err != nil {
+		return nil, false
+	}
+	if field := tenantField[T](ctx, sch); field != nil {
+		tenant, err := currentTenant(ctx)
+		if err != nil {
+			return nil, false
+		}
+		if v, _ := field.ValueOf(ctx, reflect.ValueOf(entity)); fmt.Sprint(v) != fmt.Sprint(tenant) {
+			return nil, false
+		}
+	}
+	return entity, true
+}
+
+// cachePut 将新增的实体写入缓存并加入主键过滤器，缓存写入失败不影响调用结果
+// 事务中的实体在 Transaction 提交后写入缓存，无法得知提交结果的事务不写入缓存
+func (s *ServiceImpl[T]) cachePut(ctx context.Context, sch *schema.Schema, entities ...*T) {
+	if sch.PrioritizedPrimaryField == nil {
+		return
+	}
+	c, ok := lookupEntityCache[T]()
+	values := make(map[string][]byte, len(entities))
+	for _, entity := range entities {
+		id, zero := sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(entity))
+		if zero {
+			continue
+		}
+		addToIdFilter[T](ctx, id)
+		if !ok {
+			continue
+		}
+		data, err := encodeEntity(ctx, sch, entity)
+		if err != nil {
+			continue
+		}
+		values[s.cacheKey(ctx, sch, id)] = data
+	}
+	if !ok || len(values) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	afterCommit(s.DB, func() {
+		if len(values) == 1 {
+			for key, value := range values {
+				_ = c.cache.Set(ctx, key, value, c.ttl)
+			}
+		} else {
+			_ = c.cache.SetMulti(ctx, values, c.ttl)
+		}
+	})
+}
+
+// cacheInvalidation 写操作成功后需要失效的缓存 key
+type cacheInvalidation struct {
+	db    *gorm.DB // 执行写操作的 DB，处于事务中时在提交后失效
+	cache Cache
+	keys  []string
+}
+
+// invalidateIds 根据主键确定需要失效的缓存，实体未开启缓存时返回 nil
+func (s *ServiceImpl[T]) invalidateIds(ctx context.Context, sch *schema.Schema, ids any) *cacheInvalidation {
+	c, ok := lookupEntityCache[T]()
+	if !ok {
+		return nil
+	}
+	values, ok := primaryKeyValues(ids)
+	if !ok {
+		values = []any{ids}
+	}
+	keys := make([]string, len(values))
+	for i, id := range values {
+		keys[i] = s.cacheKey(ctx, sch, id)
+	}
+	return &cacheInvalidation{db: s.DB, cache: c.cache, keys: keys}
+}
+
+// beginInvalidate 在条件写操作前查询受影响记录的主键，实体未开启缓存时返回 nil
+// db 需已包含写操作的全部条件
+func (s *ServiceImpl[T]) beginInvalidate(db *gorm.DB) (*cacheInvalidation, error) {
+	if _, ok := lookupEntityCache[T](); !ok {
+		return nil, nil
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("cache requires a primary key on %s", sch.Name)
+	}
+	qualifier := sch.Table
+	if db.Statement.Table != "" {
+		qualifier = db.Statement.Table
+	}
+	ids := reflect.New(reflect.SliceOf(sch.PrioritizedPrimaryField.FieldType))
+	if err := db.Session(&gorm.Session{}).Set(primaryOnlyKey, true).
+		Pluck(qualifier+"."+sch.PrioritizedPrimaryField.DBName, ids.Interface()).Error; err != nil {
+		return nil, err
+	}
+	return s.invalidateIds(db.Statement.Context, sch, ids.Elem().Interface()), nil
+}
+
+// commit 写操作成功后删除缓存，事务中的写操作在 Transaction 提交后删除
+// 无法得知提交结果的事务立即删除，提交前并发读取可能重新缓存旧值
+func (c *cacheInvalidation) commit(ctx context.Context) error {
+	if c == nil || len(c.keys) == 0 {
+		return nil
+	}
+	if inTransaction(c.db) {
+		ctx := context.WithoutCancel(ctx)
+		if afterCommit(c.db, func() { _ = c.cache.Delete(ctx, c.keys...) }) {
+			return nil
+		}
+	}
+	return c.cache.Delete(ctx, c.keys...)
+}
@@ -0,0 +1,127 @@
+package gomp
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryCache 进程内 LRU 缓存，超过容量时淘汰最久未使用的条目，过期的条目在读取时清除
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // 最近使用的在前
+	entries  map[string]*list.Element
+}
+
+// memoryCacheEntry 缓存条目
+type memoryCacheEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time // 为零值时不过期
+}
+
+// NewMemoryCache 创建进程内 LRU 缓存，capacity 为最大条目数，不大于 0 时不限制
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get 实现 Cache
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.get(key, time.Now())
+	return value, ok, nil
+}
+
+// GetMulti 实现 Cache
+func (c *MemoryCache) GetMulti(_ context.Context, keys []string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if value, ok := c.get(key, now); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// Set 实现 Cache
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+	return nil
+}
+
+// SetMulti 实现 Cache
+func (c *MemoryCache) SetMulti(_ context.Context, values map[string][]byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, value := range values {
+		c.set(key, value, ttl)
+	}
+	return nil
+}
+
+// Delete 实现 Cache
+func (c *MemoryCache) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Len 当前缓存的条目数 (含未清除的过期条目)
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get 读取条目并标记为最近使用，调用方需持有锁
+func (c *MemoryCache) get(key string, now time.Time) ([]byte, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !entry.expireAt.IsZero() && now.After(entry.expireAt) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set 写入条目，超过容量时淘汰最久未使用的条目，调用方需持有锁
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expireAt = time.Now().Add(ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.capacity > 0 {
+		for c.order.Len() > c.capacity {
+			c.remove(c.order.Back())
+		}
+	}
+}
+
+// remove 删除条目，调用方需持有锁
+func (c *MemoryCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryCacheEntry).key)
+}
@@ -0,0 +1,386 @@
+package gomp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RedisOptions Redis 连接配置
+type RedisOptions struct {
+	Addr        string        // 地址，默认 127.0.0.1:6379
+	Username    string        // ACL 用户名 (Redis 6+)，为空时只使用密码认证
+	Password    string        // 密码
+	DB          int           // 数据库编号
+	KeyPrefix   string        // key 前缀，用于多个应用共用同一 Redis
+	PoolSize    int           // 最大空闲连接数，默认 10
+	DialTimeout time.Duration // 连接超时，默认 5s
+	Timeout     time.Duration // 单条命令的读写超时，默认 3s (ctx 的截止时间更早时以 ctx 为准)
+}
+
+// RedisCache 基于 Redis 的缓存，内置精简的 RESP 客户端，无需额外依赖；同时实现 Locker
+type RedisCache struct {
+	opts   RedisOptions
+	pool   chan *redisConn
+	closed atomic.Bool
+}
+
+// redisError Redis 返回的错误应答，不影响连接复用
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn Redis 连接
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// NewRedisCache 创建 Redis 缓存，连接在首次使用时建立
+func NewRedisCache(opts RedisOptions) *RedisCache {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:6379"
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 3 * time.Second
+	}
+	return &RedisCache{opts: opts, pool: make(chan *redisConn, opts.PoolSize)}
+}
+
+// Get 实现 Cache
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.opts.KeyPrefix+key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+// GetMulti 实现 Cache
+func (c *RedisCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "MGET")
+	for _, key := range keys {
+		args = append(args, c.opts.KeyPrefix+key)
+	}
+	reply, err := c.do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok || len(items) != len(keys) {
+		return nil, fmt.Errorf("redis: unexpected MGET reply %T", reply)
+	}
+	for i, item := range items {
+		if value, ok := item.([]byte); ok {
+			values[keys[i]] = value
+		}
+	}
+	return values, nil
+}
+
+// Set 实现 Cache
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, c.setArgs(key, value, ttl)...)
+	return err
+}
+
+// SetMulti 实现 Cache，使用 pipeline 一次发送所有 SET 命令
+func (c *RedisCache) SetMulti(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+	cmds := make([][]string, 0, len(values))
+	for key, value := range values {
+		cmds = append(cmds, c.setArgs(key, value, ttl))
+	}
+	replies, err := c.pipeline(ctx, cmds)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if err, ok := reply.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete 实现 Cache
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, c.opts.KeyPrefix+key)
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// redisUnlockScript 只删除自己持有的锁
+const redisUnlockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// TryLock 实现 Locker，使用 SET NX PX 加锁，ttl 不大于 0 时为 30s
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(ctx context.Context) error, error) {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	lockKey := c.opts.KeyPrefix + "gomp:lock:" + key
+	args := append(c.setArgs("gomp:lock:"+key, []byte(hex.EncodeToString(token)), ttl), "NX")
+	reply, err := c.do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLockNotAcquired, key)
+	}
+	return func(ctx context.Context) error {
+		_, err := c.do(ctx, "EVAL", redisUnlockScript, "1", lockKey, hex.EncodeToString(token))
+		return err
+	}, nil
+}
+
+// Close 关闭空闲连接，关闭后不可再使用
+func (c *RedisCache) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	for {
+		select {
+		case rc := <-c.pool:
+			_ = rc.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// setArgs 构造 SET 命令，ttl 大于 0 时使用毫秒过期时间
+func (c *RedisCache) setArgs(key string, value []byte, ttl time.Duration) []string {
+	args := []string{"SET", c.opts.KeyPrefix + key, string(value)}
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms <= 0 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+	return args
+}
+
+// do 执行单条命令，Redis 错误应答作为 error 返回
+func (c *RedisCache) do(ctx context.Context, args ...string) (any, error) {
+	replies, err := c.pipeline(ctx, [][]string{args})
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := replies[0].(error); ok {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// pipeline 在同一连接上依次发送多条命令后读取全部应答，Redis 错误应答以 error 值出现在结果中
+func (c *RedisCache) pipeline(ctx context.Context, cmds [][]string) ([]any, error) {
+	if c.closed.Load() {
+		return nil, errors.New("redis: cache is closed")
+	}
+	rc, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(c.opts.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		_ = rc.conn.Close()
+		return nil, err
+	}
+	for _, args := range cmds {
+		rc.writeCommand(args)
+	}
+	if err := rc.w.Flush(); err != nil {
+		_ = rc.conn.Close()
+		return nil, err
+	}
+	replies := make([]any, len(cmds))
+	for i := range cmds {
+		reply, err := rc.readReply()
+		var redisErr redisError
+		if err != nil && !errors.As(err, &redisErr) {
+			_ = rc.conn.Close()
+			return nil, err
+		}
+		if err != nil {
+			replies[i] = err
+		} else {
+			replies[i] = reply
+		}
+	}
+	c.release(rc)
+	return replies, nil
+}
+
+// acquire 从连接池获取连接，没有空闲连接时新建
+func (c *RedisCache) acquire(ctx context.Context) (*redisConn, error) {
+	select {
+	case rc := <-c.pool:
+		return rc, nil
+	default:
+	}
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+
+	// 认证与选择数据库
+	var setup [][]string
+	if c.opts.Password != "" {
+		if c.opts.Username != "" {
+			setup = append(setup, []string{"AUTH", c.opts.Username, c.opts.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.opts.Password})
+		}
+	}
+	if c.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+	if len(setup) > 0 {
+		_ = conn.SetDeadline(time.Now().Add(c.opts.Timeout))
+		for _, args := range setup {
+			rc.writeCommand(args)
+		}
+		err := rc.w.Flush()
+		for range setup {
+			if err != nil {
+				break
+			}
+			_, err = rc.readReply()
+		}
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+// release 归还连接，连接池已满或已关闭时关闭连接
+func (c *RedisCache) release(rc *redisConn) {
+	if c.closed.Load() {
+		_ = rc.conn.Close()
+		return
+	}
+	select {
+	case c.pool <- rc:
+	default:
+		_ = rc.conn.Close()
+	}
+}
+
+// writeCommand 按 RESP 数组格式写入命令
+func (rc *redisConn) writeCommand(args []string) {
+	rc.w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		rc.w.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n")
+		rc.w.WriteString(arg)
+		rc.w.WriteString("\r\n")
+	}
+}
+
+// readReply 读取一条 RESP 应答: 简单字符串为 string，整数为 int64，批量字符串为 []byte (不存在时为 nil)，数组为 []any
+func (rc *redisConn) readReply() (any, error) {
+	line, err := rc.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := rc.readReply()
+			var redisErr redisError
+			if err != nil && !errors.As(err, &redisErr) {
+				return nil, err
+			}
+			if err != nil {
+				items[i] = err
+			} else {
+				items[i] = item
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// readLine 读取以 \r\n 结尾的一行 (不含换行符)
+func (rc *redisConn) readLine() (string, error) {
+	line, err := rc.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
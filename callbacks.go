@@ -0,0 +1,186 @@
+package gomp
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Statement 设置项 key
+const (
+	gompPackage  = "github.com/shelbeii/gomp"
+	managedKey   = "gomp:managed"
+	startTimeKey = "gomp:start_time"
+)
+
+// registeredConfigs 每个 GORM 配置注册 gomp 回调的 *sync.Once
+var registeredConfigs sync.Map
+
+// ensureCallbacks 为 DB 注册 gomp 回调 (每个 GORM 配置只注册一次)
+// 回调只处理通过 gomp 执行的语句，不影响直接使用 GORM 的代码；并发首次调用时均等待注册完成后返回
+func ensureCallbacks(db *gorm.DB) {
+	once, _ := registeredConfigs.LoadOrStore(db.Config, new(sync.Once))
+	once.(*sync.Once).Do(func() {
+		registerCallbacks(db)
+	})
+}
+
+// registerCallbacks 注册 gomp 回调
+func registerCallbacks(db *gorm.DB) {
+	cb := db.Callback()
+	_ = cb.Create().Before("*").Register("gomp:before_create", beforeStatement)
+	_ = cb.Create().After("*").Register("gomp:after_create", afterStatement(OperationCreate))
+	_ = cb.Query().Before("*").Register("gomp:before_query", beforeStatement)
+	_ = cb.Query().After("*").Register("gomp:after_query", afterStatement(OperationQuery))
+	_ = cb.Update().Before("*").Register("gomp:before_update", beforeStatement)
+	_ = cb.Update().After("*").Register("gomp:after_update", afterStatement(OperationUpdate))
+	_ = cb.Delete().Before("*").Register("gomp:before_delete", beforeStatement)
+	_ = cb.Delete().After("*").Register("gomp:after_delete", afterStatement(OperationDelete))
+	_ = cb.Row().Before("*").Register("gomp:before_row", beforeStatement)
+	_ = cb.Row().After("*").Register("gomp:after_row", afterStatement(OperationRow))
+	_ = cb.Raw().Before("*").Register("gomp:before_raw", beforeStatement)
+	_ = cb.Raw().After("*").Register("gomp:after_raw", afterStatement(OperationRaw))
+
+	// DryRun 模式的写语句
+	_ = cb.Create().Before("*").Register("gomp:dry_run_create", beginDryRun)
+	_ = cb.Create().After("*").Register("gomp:dry_run_create_end", endDryRun(OperationCreate))
+	_ = cb.Update().Before("*").Register("gomp:dry_run_update", beginDryRun)
+	_ = cb.Update().After("*").Register("gomp:dry_run_update_end", endDryRun(OperationUpdate))
+	_ = cb.Delete().Before("*").Register("gomp:dry_run_delete", beginDryRun)
+	_ = cb.Delete().After("*").Register("gomp:dry_run_delete_end", endDryRun(OperationDelete))
+	_ = cb.Raw().Before("*").Register("gomp:dry_run_raw", beginDryRun)
+	_ = cb.Raw().After("*").Register("gomp:dry_run_raw_end", endDryRun(OperationRaw))
+
+	// 分表
+	_ = cb.Create().Before("gorm:create").Register("gomp:shard_create", resolveShardTable)
+	_ = cb.Query().Before("gorm:query").Register("gomp:shard_query", resolveShardTable)
+	_ = cb.Update().Before("gorm:update").Register("gomp:shard_update", resolveShardTable)
+	_ = cb.Delete().Before("gorm:delete").Register("gomp:shard_delete", resolveShardTable)
+	_ = cb.Row().Before("gorm:row").Register("gomp:shard_row", resolveShardTable)
+
+	// 查询结果缓存失效
+	_ = cb.Create().After("gorm:create").Register("gomp:query_cache_create", invalidateQueryCache)
+	_ = cb.Update().After("gorm:update").Register("gomp:query_cache_update", invalidateQueryCache)
+	_ = cb.Delete().After("gorm:delete").Register("gomp:query_cache_delete", invalidateQueryCache)
+
+	// 读写分离
+	_ = cb.Query().Before("gorm:query").Register("gomp:replica_query", routeReplica)
+	_ = cb.Row().Before("gorm:row").Register("gomp:replica_row", routeReplica)
+
+	// 字段加解密
+	_ = cb.Create().Before("gorm:create").Register("gomp:encrypt_create", encryptBeforeWrite)
+	_ = cb.Create().After("gorm:create").Register("gomp:decrypt_create", decryptAfterWrite)
+	_ = cb.Update().Before("gorm:update").Register("gomp:encrypt_update", encryptBeforeWrite)
+	_ = cb.Update().After("gorm:update").Register("gomp:decrypt_update", decryptAfterWrite)
+	_ = cb.Query().Before("gorm:query").Register("gomp:encrypt_query", encryptBeforeQuery)
+	_ = cb.Query().After("gorm:query").Register("gomp:decrypt_query", decryptAfterQuery)
+	_ = cb.Delete().Before("gorm:delete").Register("gomp:encrypt_delete", encryptBeforeQuery)
+	_ = cb.Row().Before("gorm:row").Register("gomp:encrypt_row", encryptBeforeQuery)
+
+	// 预编译语句的 IN 列表补齐
+	_ = cb.Query().Before("gorm:query").Register("gomp:normalize_in_query", normalizeInLists)
+	_ = cb.Update().Before("gorm:update").Register("gomp:normalize_in_update", normalizeInLists)
+	_ = cb.Delete().Before("gorm:delete").Register("gomp:normalize_in_delete", normalizeInLists)
+	_ = cb.Row().Before("gorm:row").Register("gomp:normalize_in_row", normalizeInLists)
+
+	// 优化器提示 (早于调试标签)
+	_ = cb.Query().Before("gorm:query").Register("gomp:hint_query", hintStatement)
+	_ = cb.Row().Before("gorm:row").Register("gomp:hint_row", hintStatement)
+
+	// 调试标签
+	_ = cb.Query().Before("gorm:query").After("gomp:hint_query").Register("gomp:label_query", labelStatement)
+	_ = cb.Update().Before("gorm:update").Register("gomp:label_update", labelStatement)
+	_ = cb.Delete().Before("gorm:delete").Register("gomp:label_delete", labelStatement)
+	_ = cb.Row().Before("gorm:row").After("gomp:hint_row").Register("gomp:label_row", labelStatement)
+
+	// 开发期 SQL 检查
+	_ = cb.Query().After("gorm:query").Register("gomp:inspect_query", inspectStatement(OperationQuery))
+	_ = cb.Update().After("gorm:update").Register("gomp:inspect_update", inspectStatement(OperationUpdate))
+	_ = cb.Delete().After("gorm:delete").Register("gomp:inspect_delete", inspectStatement(OperationDelete))
+	_ = cb.Row().After("gorm:row").Register("gomp:inspect_row", inspectStatement(OperationRow))
+
+	// 影子写，插入在解密之前镜像以保持密文
+	_ = cb.Create().After("gorm:create").Before("gomp:decrypt_create").Register("gomp:shadow_create", mirrorWrite(OperationCreate))
+	_ = cb.Update().After("gorm:update").Before("gomp:decrypt_update").Register("gomp:shadow_update", mirrorWrite(OperationUpdate))
+	_ = cb.Delete().After("gorm:delete").Register("gomp:shadow_delete", mirrorWrite(OperationDelete))
+
+	// 金丝雀读，在解密之前比对以保持密文
+	_ = cb.Query().After("gorm:query").Before("gomp:decrypt_query").Register("gomp:canary_query", compareCanaryRead)
+}
+
+// callerLocation 获取调用栈中第一个不属于 gomp / GORM 的代码位置
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.HasPrefix(frame.Function, gompPackage+".") {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// isManaged 判断语句是否由 gomp 发起
+func isManaged(db *gorm.DB) bool {
+	v, ok := db.Get(managedKey)
+	return ok && v == true
+}
+
+// instanceKey 当前语句实例的设置项 key
+func instanceKey(db *gorm.DB, key string) string {
+	return fmt.Sprintf("%p", db.Statement) + key
+}
+
+func beforeStatement(db *gorm.DB) {
+	if !isManaged(db) {
+		return
+	}
+	db.Statement.Settings.Store(instanceKey(db, startTimeKey), time.Now())
+}
+
+// afterStatement 语句执行后上报指标并分发 SQL 事件
+func afterStatement(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if !isManaged(db) {
+			return
+		}
+		var duration time.Duration
+		if v, ok := db.Statement.Settings.LoadAndDelete(instanceKey(db, startTimeKey)); ok {
+			duration = time.Since(v.(time.Time))
+		}
+		if db.Statement.SQL.Len() == 0 {
+			return
+		}
+		recordMetric(db, operation, duration)
+		recordPerformance(db, operation, duration)
+		recordRequestStats(db, operation, duration)
+		if !sqlEventsEnabled() {
+			return
+		}
+		event := SQLEvent{
+			Statement: db.Statement.SQL.String(),
+			Args:      db.Statement.Vars,
+			Rows:      db.Statement.RowsAffected,
+			Duration:  duration,
+			Err:       db.Error,
+			Table:     db.Statement.Table,
+			Label:     QueryLabel(db.Statement.Context),
+		}
+		if db.Statement.Schema != nil {
+			event.Entity = db.Statement.Schema.Name
+		}
+		event.Args = redactArgs(event.Statement, event.Args, db.Statement.Schema)
+		event.SQL = db.Dialector.Explain(event.Statement, event.Args...)
+		event.Caller = callerLocation()
+		emitSQLEvent(db.Statement.Context, event)
+	}
+}
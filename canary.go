@@ -0,0 +1,183 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// CanaryOptions 实体的金丝雀读 (比对读) 配置，用于在生产环境验证新表、新索引或新库的读取结果，见 EnableCanaryRead
+type CanaryOptions struct {
+	Table          string                                      // 候选表，为空时与主表同名
+	DataSource     string                                      // 候选数据源 (RegisterDataSource)，为空时在主查询所在的连接上执行
+	Rewrite        func(sql string) string                     // 改写候选 SQL，如追加索引提示；在替换表名之后执行
+	CompareResults bool                                        // 比对完整结果 (逐字段)，默认只比对返回行数
+	SampleRate     float64                                     // 参与比对的查询比例 (0, 1]，默认全部
+	OnMismatch     func(ctx context.Context, m CanaryMismatch) // 结果不一致或候选查询失败时调用，默认通过 slog.Default() 以 WARN 级别输出
+}
+
+// CanaryMismatch 一次不一致的金丝雀读
+type CanaryMismatch struct {
+	Entity        string // 实体名称
+	SQL           string // 主查询 (带占位符)
+	CandidateSQL  string // 候选查询 (带占位符)
+	PrimaryRows   int64  // 主查询返回行数
+	CandidateRows int64  // 候选查询返回行数
+	Err           error  // 候选查询的错误，结果不一致时为 nil
+	Caller        string // 发起调用的代码位置 (file:line)
+}
+
+// CanaryStats 金丝雀读统计
+type CanaryStats struct {
+	Reads      int64 // 已比对的查询
+	Mismatches int64 // 结果不一致的查询
+	Failures   int64 // 候选查询失败的查询
+}
+
+// canaryReader 实体的金丝雀读配置与统计
+type canaryReader struct {
+	opts CanaryOptions
+
+	reads, mismatches, failures atomic.Int64
+}
+
+var (
+	canaryReadersMu sync.RWMutex
+	canaryReaders   = make(map[reflect.Type]*canaryReader)
+)
+
+// EnableCanaryRead 为实体 T 开启金丝雀读: 通过 gomp 执行的查询成功后，将同一语句 (表名替换为候选表、按 Rewrite 改写) 在候选路径上再执行一次，
+// 比对返回行数或完整结果，不一致时通知 OnMismatch。调用方始终得到主查询的结果，候选查询的错误不会返回给调用方
+// 候选查询同步执行，会增加查询耗时，可通过 SampleRate 控制比例；重复调用会替换原有配置
+//
+//	gomp.EnableCanaryRead[Order](gomp.CanaryOptions{Table: "orders_v2", CompareResults: true, SampleRate: 0.05})
+func EnableCanaryRead[T any](opts CanaryOptions) {
+	canaryReadersMu.Lock()
+	defer canaryReadersMu.Unlock()
+	canaryReaders[entityType[T]()] = &canaryReader{opts: opts}
+}
+
+// DisableCanaryRead 关闭实体 T 的金丝雀读
+func DisableCanaryRead[T any]() {
+	canaryReadersMu.Lock()
+	defer canaryReadersMu.Unlock()
+	delete(canaryReaders, entityType[T]())
+}
+
+// CanaryReadStats 实体 T 当前金丝雀读配置的统计，未开启时返回零值
+func CanaryReadStats[T any]() CanaryStats {
+	canaryReadersMu.RLock()
+	r := canaryReaders[entityType[T]()]
+	canaryReadersMu.RUnlock()
+	if r == nil {
+		return CanaryStats{}
+	}
+	return CanaryStats{
+		Reads:      r.reads.Load(),
+		Mismatches: r.mismatches.Load(),
+		Failures:   r.failures.Load(),
+	}
+}
+
+// sampled 本次查询是否参与比对
+func (r *canaryReader) sampled() bool {
+	rate := r.opts.SampleRate
+	return rate <= 0 || rate >= 1 || rand.Float64() < rate
+}
+
+// report 报告不一致
+func (r *canaryReader) report(ctx context.Context, m CanaryMismatch) {
+	if r.opts.OnMismatch != nil {
+		r.opts.OnMismatch(ctx, m)
+		return
+	}
+	attrs := []any{
+		slog.String("entity", m.Entity),
+		slog.String("sql", m.SQL),
+		slog.String("candidate", m.CandidateSQL),
+		slog.Int64("primaryRows", m.PrimaryRows),
+		slog.Int64("candidateRows", m.CandidateRows),
+		slog.String("caller", m.Caller),
+	}
+	if m.Err != nil {
+		attrs = append(attrs, slog.String("error", m.Err.Error()))
+	}
+	slog.Default().WarnContext(ctx, "gomp canary read mismatch", attrs...)
+}
+
+// compareCanaryRead 主查询成功后在候选路径上执行同一查询并比对结果，在解密之前执行以比对密文
+func compareCanaryRead(db *gorm.DB) {
+	if !isManaged(db) || db.DryRun || db.Statement.SQL.Len() == 0 || db.Statement.Schema == nil {
+		return
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return
+	}
+	canaryReadersMu.RLock()
+	r := canaryReaders[db.Statement.Schema.ModelType]
+	canaryReadersMu.RUnlock()
+	if r == nil || !r.sampled() {
+		return
+	}
+
+	ctx := db.Statement.Context
+	m := CanaryMismatch{
+		Entity:      db.Statement.Schema.Name,
+		SQL:         db.Statement.SQL.String(),
+		PrimaryRows: db.Statement.RowsAffected,
+	}
+	m.CandidateSQL = m.SQL
+	if r.opts.Table != "" {
+		m.CandidateSQL = replaceTable(db, r.opts.Table)
+	}
+	if r.opts.Rewrite != nil {
+		m.CandidateSQL = r.opts.Rewrite(m.CandidateSQL)
+	}
+
+	var conn *gorm.DB
+	if r.opts.DataSource != "" {
+		target, ok := DataSource(r.opts.DataSource)
+		if !ok {
+			r.failures.Add(1)
+			m.Err, m.Caller = fmt.Errorf("%w: %s", ErrDataSourceNotFound, r.opts.DataSource), callerLocation()
+			r.report(ctx, m)
+			return
+		}
+		conn = target
+	} else {
+		// 与主查询相同的连接 (事务或从库)
+		conn = db.Session(&gorm.Session{NewDB: true, Initialized: true})
+	}
+
+	dest := db.Statement.Dest
+	var candidate any
+	if rv := reflect.ValueOf(dest); rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		candidate = reflect.New(rv.Type().Elem()).Interface()
+	} else {
+		var rows []map[string]any
+		candidate, dest = &rows, nil
+	}
+	result := conn.WithContext(ctx).Raw(m.CandidateSQL, db.Statement.Vars...).Scan(candidate)
+	r.reads.Add(1)
+	m.CandidateRows = result.RowsAffected
+	switch {
+	case result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound):
+		r.failures.Add(1)
+		m.Err = result.Error
+	case m.CandidateRows != m.PrimaryRows:
+		r.mismatches.Add(1)
+	case r.opts.CompareResults && dest != nil && !reflect.DeepEqual(dest, candidate):
+		r.mismatches.Add(1)
+	default:
+		return
+	}
+	m.Caller = callerLocation()
+	r.report(ctx, m)
+}
@@ -0,0 +1,38 @@
+package gomp
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appendWhere 追加 AND 条件
+// 若已有条件中包含顶层 OR (如 a = 1 OR b = 2)，先将已有条件整体括起来，避免追加的条件被 OR 短路
+func appendWhere(db *gorm.DB, exprs ...clause.Expression) *gorm.DB {
+	if len(exprs) == 0 {
+		return db
+	}
+	tx := db.Where(clause.And(exprs...))
+	c, ok := tx.Statement.Clauses["WHERE"]
+	if !ok {
+		return tx
+	}
+	where, ok := c.Expression.(clause.Where)
+	if !ok || len(where.Exprs) < 2 {
+		return tx
+	}
+	existing, added := where.Exprs[:len(where.Exprs)-1], where.Exprs[len(where.Exprs)-1]
+	for _, expr := range existing {
+		if _, isOr := expr.(clause.OrConditions); isOr {
+			where.Exprs = []clause.Expression{clause.And(existing...), added}
+			c.Expression = where
+			tx.Statement.Clauses["WHERE"] = c
+			break
+		}
+	}
+	return tx
+}
+
+// currentColumn 当前表 (或别名) 限定的列
+func currentColumn(name string) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: name}
+}
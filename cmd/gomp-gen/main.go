@@ -0,0 +1,102 @@
+// gomp-gen 根据数据库表结构生成 gomp 实体、列名常量与 Service 骨架
+//
+//	go install github.com/shelbeii/gomp/cmd/gomp-gen@latest
+//	gomp-gen -driver mysql -dsn "user:pass@tcp(127.0.0.1:3306)/app?parseTime=true" \
+//	    -out ./model -prefix t_ -service-out ./service -model-import your_project/model
+//
+// 使用 -columns 时根据已有的实体结构体生成列名常量，通常写在实体文件中通过 go:generate 调用
+//
+//	//go:generate go run github.com/shelbeii/gomp/cmd/gomp-gen -columns
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shelbeii/gomp/gen"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	var (
+		driver = flag.String("driver", "mysql", "数据库类型: mysql / postgres")
+		dsn    = flag.String("dsn", "", "数据库连接串 (必填)")
+		tables = flag.String("tables", "", "需要生成的表，逗号分隔，为空时生成全部表")
+		cfg    gen.Config
+
+		columns = flag.Bool("columns", false, "根据实体结构体生成列名常量 (用于 go:generate)")
+		types   = flag.String("type", "", "-columns: 需要生成的结构体，逗号分隔，为空时生成 $GOFILE 中的全部结构体")
+		output  = flag.String("output", "", "-columns: 输出文件，默认为 <源文件>_columns.go")
+	)
+	flag.StringVar(&cfg.OutDir, "out", "model", "实体输出目录")
+	flag.StringVar(&cfg.Package, "pkg", "", "实体包名，默认取输出目录名")
+	flag.StringVar(&cfg.TablePrefix, "prefix", "", "生成结构体名时去掉的表前缀，如 t_")
+	flag.StringVar(&cfg.ServiceOutDir, "service-out", "", "Service 输出目录，为空时不生成 Service")
+	flag.StringVar(&cfg.ServicePackage, "service-pkg", "", "Service 包名，默认取输出目录名")
+	flag.StringVar(&cfg.ModelImportPath, "model-import", "", "实体包的导入路径，Service 与实体不在同一目录时必填")
+	flag.BoolVar(&cfg.Overwrite, "overwrite", false, "覆盖已存在的 Service 文件")
+	flag.Parse()
+
+	if *columns {
+		path, err := gen.GenerateColumns(gen.ColumnsConfig{
+			File:    os.Getenv("GOFILE"),
+			Types:   splitList(*types),
+			Output:  *output,
+			Package: cfg.Package,
+		})
+		if err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Println(path)
+		return
+	}
+
+	if *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cfg.Tables = splitList(*tables)
+
+	var dialector gorm.Dialector
+	switch *driver {
+	case "mysql":
+		dialector = mysql.Open(*dsn)
+	case "postgres":
+		dialector = postgres.Open(*dsn)
+	default:
+		fatalf("unsupported driver %q", *driver)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		fatalf("connect: %v", err)
+	}
+
+	files, err := gen.Generate(db, cfg)
+	for _, file := range files {
+		fmt.Println(file)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// splitList 拆分逗号分隔的参数
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "gomp-gen: "+format+"\n", args...)
+	os.Exit(1)
+}
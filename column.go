@@ -0,0 +1,148 @@
+package gomp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// columnRef 可作为列参数的列描述 (Field)
+type columnRef interface {
+	clauseColumn() clause.Column
+}
+
+// toColumn 将非字符串的列参数转换为 clause.Column，支持 clause.Column、*clause.Column 与 Field
+func toColumn(column any) (clause.Column, error) {
+	switch c := column.(type) {
+	case clause.Column:
+		return c, nil
+	case *clause.Column:
+		if c != nil {
+			return *c, nil
+		}
+	case columnRef:
+		return c.clauseColumn(), nil
+	}
+	return clause.Column{}, fmt.Errorf("%w: unsupported column type %T", ErrInvalidWrapper, column)
+}
+
+// errorExpr 构建时报告错误的表达式，用于延迟报告 Wrapper 构造阶段的参数错误
+type errorExpr struct {
+	err error
+}
+
+// Build 实现 clause.Expression
+func (e errorExpr) Build(builder clause.Builder) {
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		_ = stmt.AddError(e.err)
+	}
+}
+
+// columnCond 列参数为 clause.Column / Field 时的条件
+// 等值、比较、LIKE 与 IN 使用 gorm 的条件表达式，与字符串条件一样可被分表、加密、MockService 识别；列在执行时按方言加引号 (带表名时分别加引号)
+func columnCond(column any, op string, vals ...any) clause.Expression {
+	col, err := toColumn(column)
+	if err != nil {
+		return errorExpr{err: err}
+	}
+	switch op {
+	case "=":
+		return clause.Eq{Column: col, Value: vals[0]}
+	case "<>":
+		return clause.Neq{Column: col, Value: vals[0]}
+	case ">":
+		return clause.Gt{Column: col, Value: vals[0]}
+	case ">=":
+		return clause.Gte{Column: col, Value: vals[0]}
+	case "<":
+		return clause.Lt{Column: col, Value: vals[0]}
+	case "<=":
+		return clause.Lte{Column: col, Value: vals[0]}
+	case "LIKE":
+		return clause.Like{Column: col, Value: vals[0]}
+	case "IN":
+		return clause.IN{Column: col, Values: inValues(vals[0])}
+	case "NOT IN":
+		return clause.Not(clause.IN{Column: col, Values: inValues(vals[0])})
+	case "IS NULL", "IS NOT NULL":
+		return clause.Expr{SQL: "? " + op, Vars: []any{col}}
+	case "BETWEEN", "NOT BETWEEN":
+		return clause.Expr{SQL: "? " + op + " ? AND ?", Vars: []any{col, vals[0], vals[1]}}
+	}
+	return errorExpr{err: fmt.Errorf("%w: unsupported operator %s", ErrInvalidWrapper, op)}
+}
+
+// inValues IN 条件的值列表，切片 (除 []byte) 与数组展开为多个值
+func inValues(val any) []any {
+	rv := reflect.ValueOf(val)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{val}
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// columnSQL 列参数在 SQL 片段中的形式: 字符串原样返回，clause.Column / Field 以占位符引用，执行时加引号
+func columnSQL(column any) (string, []any) {
+	if name, ok := column.(string); ok {
+		return name, nil
+	}
+	col, err := toColumn(column)
+	if err != nil {
+		return "?", []any{errorExpr{err: err}}
+	}
+	return "?", []any{col}
+}
+
+// columnPair 两列相等的 SQL 片段 (联表 ON 条件)
+func columnPair(left, right any) (string, []any) {
+	l, args := columnSQL(left)
+	r, rightArgs := columnSQL(right)
+	return l + " = " + r, append(args, rightArgs...)
+}
+
+// columnText 列参数在无法携带参数的 SQL 片段 (如合并到 Table 的联表子句) 中的文本，clause.Column / Field 按方言加引号
+func columnText(db *gorm.DB, column any) string {
+	if name, ok := column.(string); ok {
+		return name
+	}
+	col, err := toColumn(column)
+	if err != nil {
+		_ = db.AddError(err)
+		return ""
+	}
+	return db.Statement.Quote(col)
+}
+
+// fieldColumns 按实体的 gorm schema 将 SelectFields / OmitFields 的结构体字段名解析为列名
+func fieldColumns[T any](db *gorm.DB, fields, omits []string) ([]string, []string, error) {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected, err1 := lookUpFieldColumns(sch, fields)
+	omitted, err2 := lookUpFieldColumns(sch, omits)
+	return selected, omitted, errors.Join(err1, err2)
+}
+
+// lookUpFieldColumns 结构体字段名对应的列名，不存在或不对应列的字段返回 ErrInvalidWrapper
+func lookUpFieldColumns(sch *schema.Schema, names []string) ([]string, error) {
+	columns := make([]string, 0, len(names))
+	var errs []error
+	for _, name := range names {
+		field := sch.FieldsByName[name]
+		if field == nil || field.DBName == "" {
+			errs = append(errs, fmt.Errorf("%w: %s has no column field %s", ErrInvalidWrapper, sch.Name, name))
+			continue
+		}
+		columns = append(columns, field.DBName)
+	}
+	return columns, errors.Join(errs...)
+}
@@ -0,0 +1,111 @@
+package gomp
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// bareColumn 去掉列名的表名限定与引号
+func bareColumn(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"")
+}
+
+// plainValue 解引用指针并展开 driver.Valuer，NULL 返回 nil
+func plainValue(v any) any {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		v = value
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		return string(rv.Bytes())
+	}
+	return rv.Interface()
+}
+
+// compareValues 比较两个值，类型不可比较或任一为 NULL 时返回 false
+func compareValues(a, b any) (int, bool) {
+	a, b = plainValue(a), plainValue(b)
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb), true
+		}
+		return 0, false
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isIntKind(va.Kind()) && isIntKind(vb.Kind()):
+		return cmpOrdered(va.Int(), vb.Int()), true
+	case isUintKind(va.Kind()) && isUintKind(vb.Kind()):
+		return cmpOrdered(va.Uint(), vb.Uint()), true
+	case isNumberKind(va.Kind()) && isNumberKind(vb.Kind()):
+		return cmpOrdered(toFloat(va), toFloat(vb)), true
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return strings.Compare(va.String(), vb.String()), true
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		if va.Bool() == vb.Bool() {
+			return 0, true
+		}
+		if vb.Bool() {
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return isIntKind(k) || isUintKind(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// toFloat 数值转换为 float64
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case isIntKind(v.Kind()):
+		return float64(v.Int())
+	case isUintKind(v.Kind()):
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+func cmpOrdered[V int64 | uint64 | float64](a, b V) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
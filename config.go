@@ -1,25 +1,329 @@
 package gomp
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
 )
 
-var config struct {
-	Gomp struct {
-		EnableSQLPrint    bool `yaml:"enableSqlPrint"`
-		AllowGlobalUpdate bool `yaml:"allowGlobalUpdate"`
-		AllowGlobalDelete bool `yaml:"allowGlobalDelete"`
-	} `yaml:"gomp"`
+// envPrefix 环境变量前缀
+const envPrefix = "GOMP_"
+
+// Config gomp 配置
+type Config struct {
+	EnableSQLPrint    bool       `yaml:"enableSqlPrint"`
+	AllowGlobalUpdate bool       `yaml:"allowGlobalUpdate"`
+	AllowGlobalDelete bool       `yaml:"allowGlobalDelete"`
+	ScrollSecret      string     `yaml:"scrollSecret"`
+	PageFields        PageFields `yaml:"pageFields"`
+	Env               string     `yaml:"env"` // 当前环境 (如 dev / test / prod)，用于 Migrate 的环境白名单
+
+	TablePrefix         string `yaml:"tablePrefix"`         // 表名前缀
+	IdType              string `yaml:"idType"`              // 主键生成策略: auto / input / uuid
+	LogicDeleteField    string `yaml:"logicDeleteField"`    // 逻辑删除字段 (列名)
+	LogicDeleteValue    string `yaml:"logicDeleteValue"`    // 逻辑已删除值，默认 1
+	LogicNotDeleteValue string `yaml:"logicNotDeleteValue"` // 逻辑未删除值，默认 0
+
+	SlowQueryThreshold time.Duration `yaml:"slowQueryThreshold"` // 慢查询阈值 (如 500ms)，0 表示关闭
+	SensitiveColumns   []string      `yaml:"sensitiveColumns"`   // 敏感列，日志中的绑定参数会被脱敏
+	PrettySQL          bool          `yaml:"prettySql"`          // 格式化 enableSqlPrint 输出的 SQL
+	PrepareStmt        bool          `yaml:"prepareStmt"`        // gomp 执行的语句使用预编译语句缓存
+
+	AuditTable      string          `yaml:"auditTable"`      // 审计表名，默认 gomp_audit_log
+	OperatorColumns OperatorColumns `yaml:"operatorColumns"` // 操作人列映射 (created_by / updated_by / deleted_by)
+
+	TenantColumn       string   `yaml:"tenantColumn"`       // 租户列，默认 tenant_id
+	TenantIgnoreTables []string `yaml:"tenantIgnoreTables"` // 不进行租户隔离的表
+
+	Snowflake         SnowflakeConfig `yaml:"snowflake"`         // 雪花 ID 配置 (idType 为 snowflake 或 gomp:"id:snowflake")
+	SequenceIncrement int64           `yaml:"sequenceIncrement"` // 序列步长，需与数据库序列的 INCREMENT BY 一致，大于 1 时在本地缓存号段，默认 1
+
+	AntiJoin string `yaml:"antiJoin"` // NotExistsJoin 的渲染方式: notExists (默认) / leftJoin
+}
+
+// configFile 配置文件结构
+type configFile struct {
+	Gomp Config `yaml:"gomp"`
+}
+
+var (
+	currentConfig atomic.Pointer[Config]
+	configPath    atomic.Pointer[string]
+
+	subscribersMu sync.Mutex
+	subscribers   = make(map[int]func(old, new *Config))
+	subscriberSeq int
+
+	// overridesMu 保护 overrides 及配置的读-改-写，overrides 为代码中设置的配置项 (如 SetPageFields)，
+	// 每次加载配置文件后重新应用，优先于配置文件与环境变量
+	overridesMu sync.Mutex
+	overrides   []configOverride
+)
+
+// configOverride 代码中设置的配置项
+type configOverride struct {
+	name  string
+	apply func(cfg *Config)
+}
+
+func init() {
+	currentConfig.Store(&Config{})
+}
+
+// getConfig 获取当前生效的配置 (只读，不可修改)
+func getConfig() *Config {
+	return currentConfig.Load()
+}
+
+// CurrentConfig 获取当前生效配置的副本
+func CurrentConfig() Config {
+	return *getConfig()
 }
 
 // InitConfig initializes the configuration from a YAML file.
 // filePath: absolute or relative path to the yaml configuration file.
+// GOMP_* environment variables take precedence over the file, e.g. GOMP_ENABLE_SQL_PRINT=true
+// overrides gomp.enableSqlPrint; an empty filePath loads environment variables only.
+// Settings applied in code (e.g. SetPageFields) take precedence over both and are kept.
 func InitConfig(filePath string) error {
-	data, err := os.ReadFile(filePath)
+	overridesMu.Lock()
+	cfg, err := loadConfig(filePath)
 	if err != nil {
+		overridesMu.Unlock()
 		return err
 	}
-	return yaml.Unmarshal(data, &config)
+	configPath.Store(&filePath)
+	old := currentConfig.Swap(cfg)
+	overridesMu.Unlock()
+	notifyConfig(old, cfg)
+	return nil
+}
+
+// ReloadConfig 重新加载 InitConfig 指定的配置文件及环境变量，加载失败时保留原配置；代码中设置的配置项 (如 SetPageFields) 保持不变
+func ReloadConfig() error {
+	path := ""
+	if p := configPath.Load(); p != nil {
+		path = *p
+	}
+	overridesMu.Lock()
+	cfg, err := loadConfig(path)
+	if err != nil {
+		overridesMu.Unlock()
+		return err
+	}
+	old := currentConfig.Swap(cfg)
+	overridesMu.Unlock()
+	notifyConfig(old, cfg)
+	return nil
+}
+
+// WatchConfig 定期检查配置文件的修改时间，发生变化时自动重新加载，直到 ctx 结束
+// 重新加载失败时通过 onError 回调通知 (可为 nil)，并保留原配置
+func WatchConfig(ctx context.Context, interval time.Duration, onError func(error)) error {
+	p := configPath.Load()
+	if p == nil || *p == "" {
+		return errors.New("config file is not initialized; call InitConfig first")
+	}
+	path := *p
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	lastMod := stat.ModTime()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stat, err := os.Stat(path)
+				if err != nil {
+					if onError != nil {
+						onError(err)
+					}
+					continue
+				}
+				if !stat.ModTime().After(lastMod) {
+					continue
+				}
+				lastMod = stat.ModTime()
+				if err := ReloadConfig(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// OnConfigChange 订阅配置变更，返回取消订阅函数
+// 回调在配置替换后同步执行，old/new 均为只读快照
+func OnConfigChange(fn func(old, new *Config)) (cancel func()) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscriberSeq++
+	id := subscriberSeq
+	subscribers[id] = fn
+	return func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+// loadConfig 读取配置文件并应用环境变量覆盖，最后应用代码中设置的配置项；调用方需持有 overridesMu
+func loadConfig(filePath string) (*Config, error) {
+	var file configFile
+	if filePath != "" {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(&file.Gomp).Elem(), envPrefix); err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		o.apply(&file.Gomp)
+	}
+	return &file.Gomp, nil
+}
+
+// updateConfig 以写时复制方式修改配置，并记录为名为 name 的代码配置项，重新加载配置文件后仍然生效
+// 同名配置项再次设置时替换之前的设置
+func updateConfig(name string, fn func(cfg *Config)) {
+	overridesMu.Lock()
+	o := configOverride{name: name, apply: fn}
+	if i := slices.IndexFunc(overrides, func(o configOverride) bool { return o.name == name }); i >= 0 {
+		overrides[i] = o
+	} else {
+		overrides = append(overrides, o)
+	}
+	cfg := *getConfig()
+	fn(&cfg)
+	old := currentConfig.Swap(&cfg)
+	overridesMu.Unlock()
+	notifyConfig(old, &cfg)
+}
+
+// notifyConfig 配置替换后通知订阅者 (不持有 overridesMu，订阅者可以再次修改配置)
+func notifyConfig(old, cfg *Config) {
+	subscribersMu.Lock()
+	fns := make([]func(old, new *Config), 0, len(subscribers))
+	for _, fn := range subscribers {
+		fns = append(fns, fn)
+	}
+	subscribersMu.Unlock()
+	for _, fn := range fns {
+		fn(old, cfg)
+	}
+}
+
+// applyEnv 使用环境变量覆盖配置项，变量名由 yaml 标签转换而来 (enableSqlPrint -> GOMP_ENABLE_SQL_PRINT)
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + envName(tag)
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvValue(fv, raw); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", raw, name, err)
+		}
+	}
+	return nil
+}
+
+// envName 驼峰转大写下划线
+func envName(tag string) string {
+	var sb strings.Builder
+	for i, r := range tag {
+		if unicode.IsUpper(r) && i > 0 {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
+
+// setEnvValue 按字段类型解析环境变量
+func setEnvValue(fv reflect.Value, raw string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", fv.Type())
+		}
+		parts := strings.Split(raw, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		fv.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
 }
@@ -0,0 +1,89 @@
+package gomp
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Each 以游标方式逐条读取查询结果并调用 fn，不会一次性加载全部结果，适用于导出、数据修复等大结果集的遍历
+// 结果与 List 一致 (解密、脱敏、分表扇出)，但不使用查询结果缓存与默认 LIMIT；fn 返回错误时停止遍历并返回该错误
+// 遍历期间占用一个数据库连接，fn 中不应执行耗时操作
+//
+//	err := orderService.Each(ctx, gomp.NewQueryWrapper[Order]().Eq("status", "paid"), func(o *Order) error {
+//		return enc.Encode(o)
+//	})
+func (s *ServiceImpl[T]) Each(ctx context.Context, wrapper *QueryWrapper[T], fn func(entity *T) error) error {
+	return s.exec(ctx, "Each", wrapper, []any{wrapper, fn}, func(ctx context.Context) error {
+		return s.each(ctx, s.cursorQuery(ctx, wrapper), fn)
+	})
+}
+
+// cursorQuery 游标遍历的查询，按 List 的规则追加默认选项与全局条件 (不设置默认 LIMIT)
+func (s *ServiceImpl[T]) cursorQuery(ctx context.Context, wrapper *QueryWrapper[T]) *gorm.DB {
+	db := s.model(ctx)
+	if wrapper != nil {
+		db = wrapper.Apply(db)
+	}
+	return s.prepare(s.applyQueryDefaults(db, false))
+}
+
+// each 遍历查询结果，实体分表且条件中缺少分片键时依次遍历全部分表
+func (s *ServiceImpl[T]) each(ctx context.Context, db *gorm.DB, fn func(entity *T) error) error {
+	shards, err := shardFanOut[T](db)
+	if err != nil {
+		return err
+	}
+	if shards == nil {
+		return s.scan(ctx, db, fn)
+	}
+	for _, table := range shards {
+		if err := s.scan(ctx, onShard(db, table), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan 执行查询并逐行扫描为实体
+func (s *ServiceImpl[T]) scan(ctx context.Context, db *gorm.DB, fn func(entity *T) error) error {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return err
+	}
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entity := new(T)
+		if err := db.ScanRows(rows, entity); err != nil {
+			return err
+		}
+		if err := decryptScanned(db, sch, entity); err != nil {
+			return err
+		}
+		if err := s.mask(ctx, entity); err != nil {
+			return err
+		}
+		if err := fn(entity); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// decryptScanned 解密游标扫描得到的实体，ScanRows 不经过查询回调
+func decryptScanned[T any](db *gorm.DB, sch *schema.Schema, entity *T) error {
+	if len(schemaEncryptedFields(sch)) == 0 {
+		return nil
+	}
+	tx := db.Session(&gorm.Session{}).Model(entity)
+	tx.Statement.Schema = sch
+	tx.Statement.ReflectValue = reflect.ValueOf(entity)
+	decryptAfterQuery(tx)
+	return tx.Error
+}
@@ -0,0 +1,84 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrDataSourceNotFound 数据源未注册
+var ErrDataSourceNotFound = errors.New("data source not found")
+
+var (
+	dataSourcesMu sync.RWMutex
+	dataSources   = make(map[string]*gorm.DB)
+)
+
+// RegisterDataSource 注册命名数据源，重复注册同名数据源会覆盖原有的
+// replicaDBs 为该数据源的从库，见 RegisterReplicas
+func RegisterDataSource(name string, db *gorm.DB, replicaDBs ...*gorm.DB) {
+	dataSourcesMu.Lock()
+	dataSources[name] = db
+	dataSourcesMu.Unlock()
+	if len(replicaDBs) > 0 {
+		RegisterReplicas(db, replicaDBs...)
+	}
+}
+
+// DataSource 获取已注册的数据源
+func DataSource(name string) (*gorm.DB, bool) {
+	dataSourcesMu.RLock()
+	defer dataSourcesMu.RUnlock()
+	db, ok := dataSources[name]
+	return db, ok
+}
+
+// dataSourceKey 数据源 context key
+type dataSourceKey struct{}
+
+// WithDataSource 指定本次调用使用的数据源，优先级高于 Service 的 UseDataSource
+func WithDataSource(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, dataSourceKey{}, name)
+}
+
+// DataSourceFrom 获取 ctx 中指定的数据源名称
+func DataSourceFrom(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	name, ok := ctx.Value(dataSourceKey{}).(string)
+	return name, ok
+}
+
+// UseDataSource 返回使用指定数据源的 Service 副本
+//
+//	reportService := orderService.UseDataSource("reporting")
+func (s *ServiceImpl[T]) UseDataSource(name string) *ServiceImpl[T] {
+	c := *s
+	c.dataSource = name
+	return &c
+}
+
+// dataSourceName 本次调用使用的数据源名称，ctx 优先于 Service，均未指定时为空字符串
+func (s *ServiceImpl[T]) dataSourceName(ctx context.Context) string {
+	if name, ok := DataSourceFrom(ctx); ok {
+		return name
+	}
+	return s.dataSource
+}
+
+// resolveDataSource 按 ctx / Service 指定的数据源名称选择 DB，均未指定时使用 Service 的 DB
+func (s *ServiceImpl[T]) resolveDataSource(ctx context.Context) (*gorm.DB, error) {
+	name := s.dataSourceName(ctx)
+	if name == "" {
+		return s.DB, nil
+	}
+	db, ok := DataSource(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDataSourceNotFound, name)
+	}
+	return db, nil
+}
@@ -0,0 +1,56 @@
+package gomp
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// QueryDefaults Service 的默认查询选项，查询未指定对应内容时生效，避免在每个 Wrapper 中重复相同的约定
+type QueryDefaults struct {
+	OrderBy []string // 默认排序，如 "id DESC"；作用于 List / Page / GetOne，Wrapper 指定了排序时不生效
+	Limit   int      // List 默认的最大返回条数，0 表示不限制；Wrapper 通过 Limit 指定时不生效
+	Selects []string // 总是查询的列 (如 id、version)，查询指定了列 (Wrapper 的 Select 或 GetOne 的 columns) 时自动补充
+}
+
+// WithQueryDefaults 设置该 Service 的默认查询选项；应在 Service 初始化时调用
+//
+//	orderService := gomp.NewServiceImpl[Order](db).WithQueryDefaults(gomp.QueryDefaults{
+//		OrderBy: []string{"id DESC"},
+//		Limit:   1000,
+//		Selects: []string{"id", "version"},
+//	})
+func (s *ServiceImpl[T]) WithQueryDefaults(defaults QueryDefaults) *ServiceImpl[T] {
+	s.queryDefaults = &defaults
+	return s
+}
+
+// applyQueryDefaults 为未指定排序、条数、查询列的查询补充默认选项，limit 为 false 时不设置默认 LIMIT
+// 补充 Service 的默认选项后仍未指定排序时，使用实体的默认排序 (RegisterDefaultOrder / DefaultOrderer)
+func (s *ServiceImpl[T]) applyQueryDefaults(db *gorm.DB, limit bool) *gorm.DB {
+	d := s.queryDefaults
+	if d == nil {
+		return applyDefaultOrder[T](db)
+	}
+	stmt := db.Statement
+	if len(d.Selects) > 0 && len(stmt.Selects) > 0 {
+		selects := slices.Clone(stmt.Selects)
+		for _, column := range d.Selects {
+			if !slices.Contains(selects, column) {
+				selects = append(selects, column)
+			}
+		}
+		if len(selects) > len(stmt.Selects) {
+			db = db.Select(selects)
+		}
+	}
+	if _, ok := stmt.Clauses["ORDER BY"]; !ok {
+		for _, order := range d.OrderBy {
+			db = db.Order(order)
+		}
+	}
+	if _, ok := stmt.Clauses["LIMIT"]; !ok && limit && d.Limit > 0 {
+		db = db.Limit(d.Limit)
+	}
+	return applyDefaultOrder[T](db)
+}
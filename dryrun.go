@@ -0,0 +1,105 @@
+package gomp
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// dryRunConfigKey DryRun 模式下语句执行前的 GORM 配置的设置项 key
+const dryRunConfigKey = "gomp:dry_run_config"
+
+// DryRunStatement DryRun 模式下渲染但没有执行的写语句
+type DryRunStatement struct {
+	SQL       string // 参数内联后的 SQL (敏感参数已脱敏)
+	Statement string // 带占位符的 SQL
+	Args      []any  // 绑定参数 (敏感参数已脱敏)
+	Operation string // 操作类型: create / update / delete / raw
+	Entity    string // 实体名称
+	Table     string // 表名
+	Caller    string // 发起调用的代码位置 (file:line)
+}
+
+// dryRunKey DryRun 模式的 context key，值为语句的接收函数
+type dryRunKey struct{}
+
+// DryRun 返回 DryRun 模式的 Service 副本: 写语句 (插入、更新、删除、Exec) 只渲染 SQL 并交给 capture，不在数据库上执行；查询照常执行
+// 适用于预览批处理任务或运维操作将执行的语句。写语句的影响行数为 0，因此严格模式返回 ErrNoRowsAffected、乐观锁返回 ErrOptimisticLock，
+// 自增主键不会回填；实体钩子照常执行 (可通过 IsDryRun 判断)，实体变更事件不会发布
+//
+//	preview := orderService.DryRun(func(ctx context.Context, stmt gomp.DryRunStatement) {
+//		fmt.Println(stmt.SQL)
+//	})
+//	err := preview.Update(ctx, gomp.NewUpdateWrapper[Order]().Set("status", "expired").Lt("expire_at", now))
+func (s *ServiceImpl[T]) DryRun(capture func(ctx context.Context, stmt DryRunStatement)) *ServiceImpl[T] {
+	c := *s
+	if capture == nil {
+		capture = func(context.Context, DryRunStatement) {}
+	}
+	c.dryRun = capture
+	return &c
+}
+
+// IsDryRun ctx 是否来自 DryRun 模式的 Service 方法，可在钩子与拦截器中跳过有副作用的操作
+func IsDryRun(ctx context.Context) bool {
+	_, ok := dryRunCapture(ctx)
+	return ok
+}
+
+// withDryRun Service 处于 DryRun 模式时返回标记了 DryRun 的 ctx
+func (s *ServiceImpl[T]) withDryRun(ctx context.Context) context.Context {
+	if s.dryRun == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, dryRunKey{}, s.dryRun)
+}
+
+// dryRunCapture ctx 中 DryRun 语句的接收函数
+func dryRunCapture(ctx context.Context) (func(context.Context, DryRunStatement), bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	capture, ok := ctx.Value(dryRunKey{}).(func(context.Context, DryRunStatement))
+	return capture, ok
+}
+
+// beginDryRun DryRun 模式下写语句执行前为该语句开启 GORM 的 DryRun，语句结束后由 endDryRun 恢复
+func beginDryRun(db *gorm.DB) {
+	if !isManaged(db) || db.DryRun {
+		return
+	}
+	if _, ok := dryRunCapture(db.Statement.Context); !ok {
+		return
+	}
+	config := *db.Config
+	config.DryRun = true
+	db.Statement.Settings.Store(instanceKey(db, dryRunConfigKey), db.Config)
+	db.Config = &config
+}
+
+// endDryRun 恢复语句的 GORM 配置并将渲染的语句交给接收函数
+func endDryRun(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.Statement.Settings.LoadAndDelete(instanceKey(db, dryRunConfigKey))
+		if !ok {
+			return
+		}
+		db.Config = v.(*gorm.Config)
+		capture, ok := dryRunCapture(db.Statement.Context)
+		if !ok || db.Statement.SQL.Len() == 0 {
+			return
+		}
+		stmt := DryRunStatement{
+			Statement: db.Statement.SQL.String(),
+			Operation: operation,
+			Table:     db.Statement.Table,
+			Caller:    callerLocation(),
+		}
+		if db.Statement.Schema != nil {
+			stmt.Entity = db.Statement.Schema.Name
+		}
+		stmt.Args = redactArgs(stmt.Statement, db.Statement.Vars, db.Statement.Schema)
+		stmt.SQL = db.Dialector.Explain(stmt.Statement, stmt.Args...)
+		capture(db.Statement.Context, stmt)
+	}
+}
@@ -0,0 +1,476 @@
+package gomp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrCipherNotConfigured 实体包含加密字段但未设置 Cipher
+var ErrCipherNotConfigured = errors.New("entity has encrypted fields but no cipher is configured; call gomp.SetCipher")
+
+// Cipher 字段加解密接口
+// deterministic 为 true 时相同明文必须得到相同密文，以便对加密列使用 Eq / In 条件
+type Cipher interface {
+	Encrypt(plaintext string, deterministic bool) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// activeCipher 当前使用的 Cipher
+var activeCipher atomic.Pointer[Cipher]
+
+// SetCipher 设置字段加解密使用的 Cipher，传入 nil 清除
+func SetCipher(c Cipher) {
+	if c == nil {
+		activeCipher.Store(nil)
+		return
+	}
+	activeCipher.Store(&c)
+}
+
+// AESCipher 基于 AES-GCM 的 Cipher，密文为 base64(nonce + ciphertext)
+// 加密密钥与确定性模式的 nonce 密钥由主密钥经 HKDF-SHA256 分别派生，确定性模式下 nonce 由 HMAC-SHA256(nonce 密钥, plaintext) 派生
+type AESCipher struct {
+	aead     cipher.AEAD
+	nonceKey []byte
+	legacy   cipher.AEAD // 直接使用主密钥的旧版密文，只用于解密
+}
+
+// HKDF 派生子密钥时使用的 info，区分不同用途的子密钥
+const (
+	aesEncryptionInfo = "gomp/aes-gcm/encryption"
+	aesNonceInfo      = "gomp/aes-gcm/deterministic-nonce"
+)
+
+// NewAESCipher 创建 AES-GCM Cipher，key 长度需为 16 / 24 / 32 字节
+func NewAESCipher(key []byte) (*AESCipher, error) {
+	legacy, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	encKey, err := hkdf.Key(sha256.New, key, nil, aesEncryptionInfo, len(key))
+	if err != nil {
+		return nil, err
+	}
+	nonceKey, err := hkdf.Key(sha256.New, key, nil, aesNonceInfo, sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(encKey)
+	if err != nil {
+		return nil, err
+	}
+	return &AESCipher{aead: aead, nonceKey: nonceKey, legacy: legacy}, nil
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt 实现 Cipher
+func (c *AESCipher) Encrypt(plaintext string, deterministic bool) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if deterministic {
+		mac := hmac.New(sha256.New, c.nonceKey)
+		mac.Write([]byte(plaintext))
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 实现 Cipher
+func (c *AESCipher) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	size := c.aead.NonceSize()
+	if len(data) < size {
+		return "", errors.New("ciphertext too short")
+	}
+	plaintext, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		// 兼容直接使用主密钥加密的旧版密文
+		var legacyErr error
+		if plaintext, legacyErr = c.legacy.Open(nil, data[:size], data[size:], nil); legacyErr != nil {
+			return "", err
+		}
+	}
+	return string(plaintext), nil
+}
+
+// encryptedField 加密字段
+type encryptedField struct {
+	field         *schema.Field
+	deterministic bool
+}
+
+// encryptedFieldCache 实体中标记为 gomp:"encrypt" 的字段，按 Schema 缓存
+var encryptedFieldCache sync.Map
+
+// schemaEncryptedFields 获取实体中的加密字段 (以小写列名为 key)
+// gomp:"encrypt" 为随机加密，gomp:"encrypt:deterministic" 为确定性加密
+func schemaEncryptedFields(sch *schema.Schema) map[string]encryptedField {
+	if v, ok := encryptedFieldCache.Load(sch); ok {
+		return v.(map[string]encryptedField)
+	}
+	fields := make(map[string]encryptedField)
+	for _, field := range sch.Fields {
+		mode, ok := gompTagValue(field, "encrypt")
+		if !ok || field.DBName == "" {
+			continue
+		}
+		fields[strings.ToLower(field.DBName)] = encryptedField{field: field, deterministic: strings.EqualFold(mode, "deterministic")}
+	}
+	encryptedFieldCache.Store(sch, fields)
+	return fields
+}
+
+// statementCipher 获取语句需要的加密字段与 Cipher，无需加解密时返回 nil
+// report 为 true 时，实体包含加密字段但未设置 Cipher 会使语句失败
+func statementCipher(db *gorm.DB, report bool) (map[string]encryptedField, Cipher) {
+	if !isManaged(db) || db.Statement.Schema == nil {
+		return nil, nil
+	}
+	fields := schemaEncryptedFields(db.Statement.Schema)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	c := activeCipher.Load()
+	if c == nil {
+		if report {
+			_ = db.AddError(ErrCipherNotConfigured)
+		}
+		return nil, nil
+	}
+	return fields, *c
+}
+
+// encryptBeforeWrite 写入前加密实体或列值，并加密条件中的确定性加密列
+func encryptBeforeWrite(db *gorm.DB) {
+	fields, c := statementCipher(db, true)
+	if fields == nil {
+		return
+	}
+	switch values := db.Statement.Dest.(type) {
+	case map[string]any:
+		encrypted, err := encryptMap(values, fields, c)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		db.Statement.Dest = encrypted
+	case []map[string]any:
+		// InsertWrapper 的多行插入
+		rows := make([]map[string]any, len(values))
+		for i, row := range values {
+			encrypted, err := encryptMap(row, fields, c)
+			if err != nil {
+				_ = db.AddError(err)
+				return
+			}
+			rows[i] = encrypted
+		}
+		db.Statement.Dest = rows
+	default:
+		if err := transformEntities(db, fields, func(f encryptedField, s string) (string, error) {
+			return c.Encrypt(s, f.deterministic)
+		}); err != nil {
+			_ = db.AddError(err)
+			return
+		}
+	}
+	encryptConditions(db, fields, c)
+}
+
+// encryptMap 加密列值中的加密列，返回新的 map
+func encryptMap(values map[string]any, fields map[string]encryptedField, c Cipher) (map[string]any, error) {
+	encrypted := make(map[string]any, len(values))
+	for k, v := range values {
+		encrypted[k] = v
+		if f, ok := fields[strings.ToLower(k)]; ok {
+			ev, err := encryptValue(c, v, f.deterministic)
+			if err != nil {
+				return nil, err
+			}
+			encrypted[k] = ev
+		}
+	}
+	return encrypted, nil
+}
+
+// decryptAfterWrite 写入后将实体恢复为明文
+func decryptAfterWrite(db *gorm.DB) {
+	fields, c := statementCipher(db, false)
+	if fields == nil {
+		return
+	}
+	switch db.Statement.Dest.(type) {
+	case map[string]any, []map[string]any:
+		return
+	}
+	if err := transformEntities(db, fields, func(_ encryptedField, s string) (string, error) {
+		return c.Decrypt(s)
+	}); err != nil {
+		_ = db.AddError(err)
+	}
+}
+
+// encryptBeforeQuery 查询前加密条件中的确定性加密列
+func encryptBeforeQuery(db *gorm.DB) {
+	if fields, c := statementCipher(db, true); fields != nil {
+		encryptConditions(db, fields, c)
+	}
+}
+
+// decryptAfterQuery 查询后解密结果
+func decryptAfterQuery(db *gorm.DB) {
+	fields, c := statementCipher(db, false)
+	if fields == nil || db.Error != nil {
+		return
+	}
+	if err := transformEntities(db, fields, func(_ encryptedField, s string) (string, error) {
+		return c.Decrypt(s)
+	}); err != nil {
+		_ = db.AddError(fmt.Errorf("decrypt %s: %w", db.Statement.Schema.Name, err))
+	}
+}
+
+// transformEntities 对语句目标 (实体或实体切片) 的加密字段执行转换，空字符串不做处理
+func transformEntities(db *gorm.DB, fields map[string]encryptedField, fn func(f encryptedField, s string) (string, error)) error {
+	rv := db.Statement.ReflectValue
+	modelType := db.Statement.Schema.ModelType
+	apply := func(elem reflect.Value) error {
+		for elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				return nil
+			}
+			elem = elem.Elem()
+		}
+		if elem.Type() != modelType {
+			return nil
+		}
+		return transformFields(db.Statement.Context, elem, fields, fn)
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := apply(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct, reflect.Ptr:
+		return apply(rv)
+	}
+	return nil
+}
+
+// transformFields 对单个实体的加密字段执行转换，空字符串不做处理
+// *string 字段写入新的指针，不修改原指针指向的值 (实体可能是共享指针字段的浅拷贝)
+func transformFields(ctx context.Context, elem reflect.Value, fields map[string]encryptedField, fn func(f encryptedField, s string) (string, error)) error {
+	for _, f := range fields {
+		v, zero := f.field.ValueOf(ctx, elem)
+		if zero {
+			continue
+		}
+		var out string
+		var err error
+		switch val := v.(type) {
+		case string:
+			if val == "" {
+				continue
+			}
+			out, err = fn(f, val)
+		case *string:
+			if val == nil || *val == "" {
+				continue
+			}
+			out, err = fn(f, *val)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.field.Name, err)
+		}
+		if _, isPtr := v.(*string); isPtr {
+			err = f.field.Set(ctx, elem, &out)
+		} else {
+			err = f.field.Set(ctx, elem, out)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptValue 加密字符串或字符串切片，其他类型 (如 gorm.Expr) 原样返回
+func encryptValue(c Cipher, v any, deterministic bool) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if val == "" {
+			return val, nil
+		}
+		return c.Encrypt(val, deterministic)
+	case *string:
+		if val == nil || *val == "" {
+			return val, nil
+		}
+		s, err := c.Encrypt(*val, deterministic)
+		return s, err
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			e, err := encryptValue(c, s, deterministic)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = e.(string)
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(val))
+		for i, s := range val {
+			e, err := encryptValue(c, s, deterministic)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = e
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// encryptConditions 加密 WHERE 条件中确定性加密列的参数，随机加密列无法作为条件使用
+func encryptConditions(db *gorm.DB, fields map[string]encryptedField, c Cipher) {
+	where, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+	w, ok := where.Expression.(clause.Where)
+	if !ok {
+		return
+	}
+	exprs, err := encryptExpressions(w.Exprs, fields, c)
+	if err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	where.Expression = clause.Where{Exprs: exprs}
+	db.Statement.Clauses["WHERE"] = where
+}
+
+// encryptExpressions 递归处理条件表达式，返回新的表达式切片 (不修改原条件，避免影响复用的 Wrapper)
+func encryptExpressions(exprs []clause.Expression, fields map[string]encryptedField, c Cipher) ([]clause.Expression, error) {
+	out := make([]clause.Expression, len(exprs))
+	for i, expr := range exprs {
+		var err error
+		out[i], err = encryptExpression(expr, fields, c)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func encryptExpression(expr clause.Expression, fields map[string]encryptedField, c Cipher) (clause.Expression, error) {
+	lookup := func(column any) (encryptedField, bool) {
+		name := ""
+		switch col := column.(type) {
+		case string:
+			name = col
+		case clause.Column:
+			name = col.Name
+		}
+		f, ok := fields[strings.ToLower(unquoteColumn(name))]
+		return f, ok && f.deterministic
+	}
+	switch e := expr.(type) {
+	case clause.Expr:
+		columns := placeholderColumns(e.SQL)
+		vars := make([]any, len(e.Vars))
+		copy(vars, e.Vars)
+		for i, column := range columns {
+			if i >= len(vars) {
+				break
+			}
+			if f, ok := lookup(column); ok {
+				v, err := encryptValue(c, vars[i], f.deterministic)
+				if err != nil {
+					return nil, err
+				}
+				vars[i] = v
+			}
+		}
+		e.Vars = vars
+		return e, nil
+	case clause.Eq:
+		if f, ok := lookup(e.Column); ok {
+			v, err := encryptValue(c, e.Value, f.deterministic)
+			if err != nil {
+				return nil, err
+			}
+			e.Value = v
+		}
+		return e, nil
+	case clause.Neq:
+		if f, ok := lookup(e.Column); ok {
+			v, err := encryptValue(c, e.Value, f.deterministic)
+			if err != nil {
+				return nil, err
+			}
+			e.Value = v
+		}
+		return e, nil
+	case clause.IN:
+		if f, ok := lookup(e.Column); ok {
+			v, err := encryptValue(c, e.Values, f.deterministic)
+			if err != nil {
+				return nil, err
+			}
+			e.Values = v.([]any)
+		}
+		return e, nil
+	case clause.AndConditions:
+		exprs, err := encryptExpressions(e.Exprs, fields, c)
+		e.Exprs = exprs
+		return e, err
+	case clause.OrConditions:
+		exprs, err := encryptExpressions(e.Exprs, fields, c)
+		e.Exprs = exprs
+		return e, err
+	case clause.NotConditions:
+		exprs, err := encryptExpressions(e.Exprs, fields, c)
+		e.Exprs = exprs
+		return e, err
+	case clause.Where:
+		exprs, err := encryptExpressions(e.Exprs, fields, c)
+		e.Exprs = exprs
+		return e, err
+	}
+	return expr, nil
+}
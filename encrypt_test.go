@@ -0,0 +1,121 @@
+package gomp_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+)
+
+// testKey 测试使用的主密钥
+var testKey = bytes.Repeat([]byte{0x42}, 32)
+
+func newTestCipher(t *testing.T, key []byte) *gomp.AESCipher {
+	t.Helper()
+	c, err := gomp.NewAESCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAESCipherRoundTrip(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		c := newTestCipher(t, testKey[:size])
+		for _, plaintext := range []string{"", "a", "110101199001011234", "张三 <zhang@example.com>", string(bytes.Repeat([]byte("x"), 4096))} {
+			for _, deterministic := range []bool{false, true} {
+				ciphertext, err := c.Encrypt(plaintext, deterministic)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(plaintext) >= 8 && bytes.Contains([]byte(ciphertext), []byte(plaintext)) {
+					t.Fatalf("ciphertext contains plaintext: %s", ciphertext)
+				}
+				got, err := c.Decrypt(ciphertext)
+				if err != nil {
+					t.Fatalf("key %d, deterministic %v: %v", size, deterministic, err)
+				}
+				if got != plaintext {
+					t.Fatalf("key %d, deterministic %v: got %q, want %q", size, deterministic, got, plaintext)
+				}
+			}
+		}
+	}
+}
+
+func TestAESCipherDeterminism(t *testing.T) {
+	c := newTestCipher(t, testKey)
+	a, _ := c.Encrypt("13800138000", true)
+	b, _ := c.Encrypt("13800138000", true)
+	if a != b {
+		t.Fatalf("deterministic ciphertexts differ: %s, %s", a, b)
+	}
+	if other, _ := newTestCipher(t, testKey).Encrypt("13800138000", true); other != a {
+		t.Fatal("deterministic ciphertext depends on the cipher instance")
+	}
+	if d, _ := c.Encrypt("13800138001", true); d == a {
+		t.Fatal("different plaintexts produced the same ciphertext")
+	}
+	x, _ := c.Encrypt("13800138000", false)
+	y, _ := c.Encrypt("13800138000", false)
+	if x == y || x == a {
+		t.Fatal("random ciphertexts must differ")
+	}
+}
+
+func TestAESCipherKeySeparation(t *testing.T) {
+	c := newTestCipher(t, testKey)
+	ciphertext, _ := c.Encrypt("13800138000", true)
+	data, _ := base64.StdEncoding.DecodeString(ciphertext)
+
+	// nonce 不能由主密钥直接作为 HMAC 密钥派生
+	mac := hmac.New(sha256.New, testKey)
+	mac.Write([]byte("13800138000"))
+	if bytes.Equal(data[:12], mac.Sum(nil)[:12]) {
+		t.Fatal("deterministic nonce is derived from the master key")
+	}
+	// 密文不能直接用主密钥解开
+	block, _ := aes.NewCipher(testKey)
+	aead, _ := cipher.NewGCM(block)
+	if _, err := aead.Open(nil, data[:12], data[12:], nil); err == nil {
+		t.Fatal("ciphertext is sealed with the master key")
+	}
+}
+
+func TestAESCipherRejectsTamperingAndWrongKey(t *testing.T) {
+	c := newTestCipher(t, testKey)
+	ciphertext, _ := c.Encrypt("secret", false)
+	data, _ := base64.StdEncoding.DecodeString(ciphertext)
+	data[len(data)-1] ^= 1
+	if _, err := c.Decrypt(base64.StdEncoding.EncodeToString(data)); err == nil {
+		t.Fatal("tampered ciphertext decrypted")
+	}
+	if _, err := newTestCipher(t, bytes.Repeat([]byte{0x43}, 32)).Decrypt(ciphertext); err == nil {
+		t.Fatal("ciphertext decrypted with another key")
+	}
+	for _, bad := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := c.Decrypt(bad); err == nil {
+			t.Fatalf("Decrypt(%q) succeeded", bad)
+		}
+	}
+	if _, err := gomp.NewAESCipher([]byte("short")); err == nil {
+		t.Fatal("invalid key length accepted")
+	}
+}
+
+func TestAESCipherDecryptsLegacyCiphertext(t *testing.T) {
+	// 旧版直接使用主密钥加密: base64(nonce + AES-GCM(key, plaintext))
+	block, _ := aes.NewCipher(testKey)
+	aead, _ := cipher.NewGCM(block)
+	nonce := bytes.Repeat([]byte{1}, aead.NonceSize())
+	legacy := base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte("legacy"), nil))
+	got, err := newTestCipher(t, testKey).Decrypt(legacy)
+	if err != nil || got != "legacy" {
+		t.Fatalf("Decrypt(legacy) = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,257 @@
+package gomp
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// 数据库错误分类，Service 方法返回的驱动错误经内置的 ErrorTranslator 归类为 DBError，可直接用 errors.Is 判断
+//
+//	if errors.Is(err, gomp.ErrDuplicateKey) {
+//		return ErrUsernameTaken
+//	}
+var (
+	ErrNotFound        = gorm.ErrRecordNotFound          // 记录不存在 (与 gorm.ErrRecordNotFound 相同)
+	ErrDuplicateKey    = gorm.ErrDuplicatedKey           // 唯一约束冲突: MySQL 1062、PostgreSQL 23505、SQLite 2067/1555 (与 gorm.ErrDuplicatedKey 相同)
+	ErrForeignKey      = gorm.ErrForeignKeyViolated      // 外键约束冲突: MySQL 1451/1452、PostgreSQL 23503、SQLite 787 (与 gorm.ErrForeignKeyViolated 相同)
+	ErrCheckConstraint = gorm.ErrCheckConstraintViolated // CHECK 约束冲突: MySQL 3819、PostgreSQL 23514、SQLite 275 (与 gorm.ErrCheckConstraintViolated 相同)
+	ErrLockTimeout     = errors.New("lock wait timeout") // 等待锁超时: MySQL 1205/3572、PostgreSQL 55P03、SQLite BUSY/LOCKED
+	ErrDeadlock        = errors.New("deadlock detected") // 死锁: MySQL 1213、PostgreSQL 40P01
+)
+
+// ErrNoRowsAffected 严格模式 (UpdateByIdStrict、UpdateWrapper.Strict、DeleteWrapper.Strict) 下语句没有影响任何记录
+var ErrNoRowsAffected = errors.New("no rows affected")
+
+// DBError 已分类的数据库错误，errors.Is 同时匹配分类 (Kind) 与原始的驱动错误，errors.As 仍可取得驱动的错误类型
+type DBError struct {
+	Kind       error  // 错误分类，如 ErrDuplicateKey
+	Code       string // 驱动的错误码，如 "1062"、"23505"
+	Constraint string // 冲突的约束名 (SQLite 的唯一约束为冲突的列，如 "users.email")，无法识别时为空
+	Err        error  // 原始错误
+}
+
+// Error 返回原始错误的信息
+func (e *DBError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回分类与原始错误
+func (e *DBError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// errorKinds 驱动错误码 -> 错误分类
+var errorKinds = map[string]map[string]error{
+	"mysql": {
+		"1062": ErrDuplicateKey, "1586": ErrDuplicateKey,
+		"1451": ErrForeignKey, "1452": ErrForeignKey, "1216": ErrForeignKey, "1217": ErrForeignKey,
+		"3819": ErrCheckConstraint,
+		"1205": ErrLockTimeout, "3572": ErrLockTimeout,
+		"1213": ErrDeadlock,
+	},
+	"postgres": {
+		"23505": ErrDuplicateKey,
+		"23503": ErrForeignKey,
+		"23514": ErrCheckConstraint,
+		"55P03": ErrLockTimeout,
+		"40P01": ErrDeadlock,
+	},
+	"sqlite": {
+		"2067": ErrDuplicateKey, "1555": ErrDuplicateKey,
+		"787": ErrForeignKey,
+		"275": ErrCheckConstraint,
+		"5":   ErrLockTimeout, "517": ErrLockTimeout, "6": ErrLockTimeout, "262": ErrLockTimeout,
+	},
+}
+
+// ErrorTranslator 错误转换器，Service 方法返回的错误都会经过执行语句的方言所注册的转换器
+// 内置 MySQL、PostgreSQL、SQLite 的转换器，将驱动错误归类为 DBError；可注册自定义转换器，如将约束名映射为业务错误
+type ErrorTranslator interface {
+	// Translate 返回转换后的错误，无需转换时原样返回；err 不为 nil
+	Translate(err error) error
+}
+
+// ErrorTranslatorFunc 函数形式的 ErrorTranslator
+type ErrorTranslatorFunc func(err error) error
+
+// Translate 实现 ErrorTranslator
+func (f ErrorTranslatorFunc) Translate(err error) error {
+	return f(err)
+}
+
+// ChainErrorTranslators 依次执行多个转换器，前一个的结果作为后一个的输入
+//
+//	gomp.RegisterErrorTranslator("postgres", gomp.ChainErrorTranslators(gomp.PostgresErrorTranslator(), domainErrors))
+func ChainErrorTranslators(translators ...ErrorTranslator) ErrorTranslator {
+	return ErrorTranslatorFunc(func(err error) error {
+		for _, t := range translators {
+			if err = t.Translate(err); err == nil {
+				return nil
+			}
+		}
+		return err
+	})
+}
+
+// MySQLErrorTranslator 内置的 MySQL 错误转换器
+func MySQLErrorTranslator() ErrorTranslator {
+	return codeTranslator("mysql")
+}
+
+// PostgresErrorTranslator 内置的 PostgreSQL 错误转换器
+func PostgresErrorTranslator() ErrorTranslator {
+	return codeTranslator("postgres")
+}
+
+// SQLiteErrorTranslator 内置的 SQLite 错误转换器
+func SQLiteErrorTranslator() ErrorTranslator {
+	return codeTranslator("sqlite")
+}
+
+var (
+	errorTranslatorMu sync.RWMutex
+	errorTranslators  = map[string]ErrorTranslator{ // 方言名 (Dialector.Name()) -> ErrorTranslator
+		"mysql":    MySQLErrorTranslator(),
+		"postgres": PostgresErrorTranslator(),
+		"sqlite":   SQLiteErrorTranslator(),
+	}
+)
+
+// RegisterErrorTranslator 为方言注册错误转换器，替换已有的转换器 (包括内置转换器)；translator 为 nil 时移除注册，该方言的错误不再转换
+// 需要保留内置的错误分类时，使用 ChainErrorTranslators 组合内置转换器：
+//
+//	gomp.RegisterErrorTranslator("mysql", gomp.ChainErrorTranslators(gomp.MySQLErrorTranslator(),
+//		gomp.ErrorTranslatorFunc(func(err error) error {
+//			var dbErr *gomp.DBError
+//			if errors.As(err, &dbErr) && dbErr.Constraint == "uk_user_email" {
+//				return ErrEmailTaken
+//			}
+//			return err
+//		})))
+func RegisterErrorTranslator(dialect string, translator ErrorTranslator) {
+	errorTranslatorMu.Lock()
+	defer errorTranslatorMu.Unlock()
+	if translator == nil {
+		delete(errorTranslators, dialect)
+		return
+	}
+	errorTranslators[dialect] = translator
+}
+
+// lookupErrorTranslator 获取方言的错误转换器
+func lookupErrorTranslator(dialect string) ErrorTranslator {
+	errorTranslatorMu.RLock()
+	defer errorTranslatorMu.RUnlock()
+	return errorTranslators[dialect]
+}
+
+// translateError 使用 db 方言的转换器转换错误
+func translateError(db *gorm.DB, err error) error {
+	if err == nil || db == nil || db.Dialector == nil {
+		return err
+	}
+	if translator := lookupErrorTranslator(db.Dialector.Name()); translator != nil {
+		return translator.Translate(err)
+	}
+	return err
+}
+
+// ClassifyError 将驱动错误 (MySQL、PostgreSQL、SQLite) 按内置规则归类为 DBError，无法识别或已分类的错误原样返回
+// 与方言注册的转换器无关，用于直接使用 gorm 或 database/sql 执行的语句
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	dialect, _, ok := driverErrorCode(err)
+	if !ok {
+		return err
+	}
+	return codeTranslator(dialect).Translate(err)
+}
+
+// codeTranslator 按驱动错误码归类的内置转换器，值为方言名
+type codeTranslator string
+
+// Translate 实现 ErrorTranslator，其他方言的错误原样返回
+func (t codeTranslator) Translate(err error) error {
+	var classified *DBError
+	if errors.As(err, &classified) {
+		return err
+	}
+	dialect, code, ok := driverErrorCode(err)
+	if !ok || dialect != string(t) {
+		return err
+	}
+	kind, ok := errorKinds[dialect][code]
+	if !ok {
+		return err
+	}
+	return &DBError{Kind: kind, Code: code, Constraint: constraintName(dialect, err), Err: err}
+}
+
+var (
+	// mysqlConstraint MySQL 错误信息中的约束名: for key 'users.uk_email' / CONSTRAINT `fk_order_user` / Check constraint 'chk_age'
+	mysqlConstraint = regexp.MustCompile("(?:for key|CONSTRAINT|Check constraint) [`']([^`']+)[`']")
+	// sqliteConstraint SQLite 错误信息中的约束: UNIQUE constraint failed: users.email / CHECK constraint failed: chk_age
+	sqliteConstraint = regexp.MustCompile(`constraint failed: (.+)$`)
+)
+
+// constraintName 提取冲突的约束名，PostgreSQL 取 PgError.ConstraintName，MySQL、SQLite 解析错误信息
+func constraintName(dialect string, err error) string {
+	switch dialect {
+	case "postgres":
+		for e := err; e != nil; e = errors.Unwrap(e) {
+			rv := reflect.Indirect(reflect.ValueOf(e))
+			if rv.Kind() != reflect.Struct {
+				continue
+			}
+			if f := rv.FieldByName("ConstraintName"); f.IsValid() && f.Kind() == reflect.String {
+				return f.String()
+			}
+		}
+	case "mysql":
+		if m := mysqlConstraint.FindStringSubmatch(err.Error()); m != nil {
+			// MySQL 8.0.19 起唯一键名带有表名前缀
+			name := m[1]
+			if i := strings.LastIndexByte(name, '.'); i >= 0 {
+				name = name[i+1:]
+			}
+			return name
+		}
+	case "sqlite":
+		if m := sqliteConstraint.FindStringSubmatch(err.Error()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// driverErrorCode 识别错误链中的驱动错误并返回方言与错误码，不依赖驱动包：
+// PostgreSQL (pgconn.PgError) 通过 SQLState 方法，MySQL (mysql.MySQLError) 通过 Number 字段，
+// SQLite (mattn/go-sqlite3 的 ExtendedCode 字段或 modernc 的 Code 方法) 使用扩展错误码
+func driverErrorCode(err error) (dialect, code string, ok bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(interface{ SQLState() string }); ok {
+			return "postgres", e.SQLState(), true
+		}
+		rv := reflect.Indirect(reflect.ValueOf(err))
+		if rv.Kind() == reflect.Struct {
+			if f := rv.FieldByName("Number"); f.IsValid() && f.CanUint() {
+				return "mysql", strconv.FormatUint(f.Uint(), 10), true
+			}
+			if f := rv.FieldByName("ExtendedCode"); f.IsValid() && f.CanInt() {
+				return "sqlite", strconv.FormatInt(f.Int(), 10), true
+			}
+		}
+		if e, ok := err.(interface{ Code() int }); ok {
+			return "sqlite", strconv.Itoa(e.Code()), true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,144 @@
+package gomp
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ChangeType 实体变更类型
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// ChangeEvent 实体变更事件，写操作成功且影响了记录后发布
+type ChangeEvent[T any] struct {
+	Type    ChangeType // 变更类型
+	Method  string     // 触发的 Service 方法
+	Entity  *T         // 实体快照 (Save / SaveBatch / UpdateById)，条件写入时为 nil
+	Ids     any        // 被删除的主键 (RemoveById / RemoveByIds)
+	Wrapper any        // 条件写入的 Wrapper (Insert / Update / Delete)
+	Time    time.Time  // 写操作完成的时间
+}
+
+// ChangeSink 变更事件接收端
+type ChangeSink[T any] interface {
+	Publish(ctx context.Context, event ChangeEvent[T])
+}
+
+// ChangeSinkFunc 函数形式的 ChangeSink
+type ChangeSinkFunc[T any] func(ctx context.Context, event ChangeEvent[T])
+
+// Publish 实现 ChangeSink
+func (f ChangeSinkFunc[T]) Publish(ctx context.Context, event ChangeEvent[T]) {
+	f(ctx, event)
+}
+
+// ChannelSink 将变更事件发送到 channel 的 ChangeSink
+// 发送会阻塞直到被接收或 ctx 结束，消费端较慢时应使用带缓冲的 channel
+type ChannelSink[T any] struct {
+	ch chan<- ChangeEvent[T]
+}
+
+// NewChannelSink 创建发送到 ch 的 ChangeSink
+func NewChannelSink[T any](ch chan<- ChangeEvent[T]) *ChannelSink[T] {
+	return &ChannelSink[T]{ch: ch}
+}
+
+// Publish 实现 ChangeSink
+func (s *ChannelSink[T]) Publish(ctx context.Context, event ChangeEvent[T]) {
+	select {
+	case s.ch <- event:
+	case <-ctx.Done():
+	}
+}
+
+var (
+	changeSinksMu  sync.RWMutex
+	changeSinks    = make(map[reflect.Type]map[int]any)
+	changeSinksSeq int
+)
+
+// SubscribeChanges 订阅实体 T 的变更事件，返回取消订阅函数
+// 事件在写操作成功后同步发布 (未影响任何记录时不发布)，事务中的写操作在 Transaction 提交后发布，可用于缓存、搜索索引等下游数据同步
+func SubscribeChanges[T any](sink ChangeSink[T]) (cancel func()) {
+	changeSinksMu.Lock()
+	defer changeSinksMu.Unlock()
+	typ := entityType[T]()
+	if changeSinks[typ] == nil {
+		changeSinks[typ] = make(map[int]any)
+	}
+	changeSinksSeq++
+	id := changeSinksSeq
+	changeSinks[typ][id] = sink
+	return func() {
+		changeSinksMu.Lock()
+		defer changeSinksMu.Unlock()
+		delete(changeSinks[typ], id)
+	}
+}
+
+// hasChangeSinks 判断实体 T 是否存在变更订阅
+func hasChangeSinks[T any]() bool {
+	changeSinksMu.RLock()
+	defer changeSinksMu.RUnlock()
+	return len(changeSinks[entityType[T]()]) > 0
+}
+
+// changeTypes 钩子触发点对应的变更类型
+var changeTypes = map[HookPoint]ChangeType{
+	AfterSave:   ChangeCreated,
+	AfterUpdate: ChangeUpdated,
+	AfterDelete: ChangeDeleted,
+}
+
+// publishChange 发布实体变更事件，仅在 After 触发点且写操作影响了记录 (rows > 0) 时生效
+// 事件内容在写操作完成时确定；Service 处于 Transaction 开启的事务中时在提交后发布，回滚时丢弃
+func (s *ServiceImpl[T]) publishChange(ctx context.Context, point HookPoint, rows int64, events ...*HookEvent[T]) {
+	changeType, ok := changeTypes[point]
+	if !ok || rows == 0 || IsDryRun(ctx) {
+		return
+	}
+	changeSinksMu.RLock()
+	registered := changeSinks[entityType[T]()]
+	sinks := make([]ChangeSink[T], 0, len(registered))
+	for _, sink := range registered {
+		sinks = append(sinks, sink.(ChangeSink[T]))
+	}
+	changeSinksMu.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	changes := make([]ChangeEvent[T], len(events))
+	for i, event := range events {
+		changes[i] = ChangeEvent[T]{
+			Type:    changeType,
+			Method:  event.Method,
+			Ids:     event.Ids,
+			Wrapper: event.Wrapper,
+			Time:    now,
+		}
+		if event.Entity != nil {
+			snapshot := *event.Entity
+			changes[i].Entity = &snapshot
+		}
+	}
+	publish := func() {
+		for _, change := range changes {
+			for _, sink := range sinks {
+				sink.Publish(ctx, change)
+			}
+		}
+	}
+	// 无法得知提交结果的事务 (未通过 Transaction 开启) 立即发布
+	if !afterCommit(s.DB, publish) {
+		publish()
+	}
+}
@@ -0,0 +1,280 @@
+package gomp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+// Plan 查询的执行计划
+type Plan struct {
+	SQL      string     // 被分析的查询 (参数内联)
+	Raw      string     // 数据库返回的原始计划: MySQL / PostgreSQL 为 JSON，其他数据库为逐行文本
+	Nodes    []PlanNode // 计划中访问表的节点，按计划的嵌套顺序排列
+	Filesort bool       // 排序无法利用索引，需要额外排序 (MySQL filesort、PostgreSQL Sort、SQLite TEMP B-TREE)
+}
+
+// PlanNode 执行计划中访问表的节点
+type PlanNode struct {
+	Table    string  // 表名
+	Access   string  // 访问方式: MySQL 的 access_type、PostgreSQL 的 Node Type、SQLite 的 SCAN / SEARCH
+	Index    string  // 使用的索引，未使用索引时为空
+	Rows     float64 // 预估扫描行数，数据库未提供时为 0
+	FullScan bool    // 是否全表扫描
+}
+
+// UsesIndex 计划是否使用了指定索引
+func (p Plan) UsesIndex(index string) bool {
+	for _, node := range p.Nodes {
+		if strings.EqualFold(node.Index, index) {
+			return true
+		}
+	}
+	return false
+}
+
+// FullScans 全表扫描的表
+func (p Plan) FullScans() []string {
+	var tables []string
+	for _, node := range p.Nodes {
+		if node.FullScan {
+			tables = append(tables, node.Table)
+		}
+	}
+	return tables
+}
+
+// Explain 分析 wrapper 对应查询 (与 List 相同，包含租户、逻辑删除等全局条件) 的执行计划，不执行查询本身
+// MySQL 使用 EXPLAIN FORMAT=JSON，PostgreSQL 使用 EXPLAIN (FORMAT JSON)，SQLite 使用 EXPLAIN QUERY PLAN，其他数据库使用 EXPLAIN
+// 分表实体未指定分表键时分析第一张分表
+//
+//	plan, err := userService.Explain(ctx, gomp.NewQueryWrapper[User]().Eq("email", email))
+//	if !plan.UsesIndex("idx_users_email") { ... }
+func (s *ServiceImpl[T]) Explain(ctx context.Context, wrapper *QueryWrapper[T]) (Plan, error) {
+	ctx = withServiceMethod(ctx, "Explain")
+	db := s.model(ctx)
+	if wrapper != nil {
+		db = wrapper.Apply(db)
+	}
+	db = s.prepare(s.applyQueryDefaults(db, true))
+	shards, err := shardFanOut[T](db)
+	if err != nil {
+		return Plan{}, err
+	}
+	if len(shards) > 0 {
+		db = onShard(db, shards[0])
+	}
+	tx, err := queryStatement[T](db)
+	if err != nil {
+		return Plan{}, err
+	}
+	resolveShardTable(tx)
+	callbacks.BuildQuerySQL(tx)
+	if tx.Error != nil {
+		return Plan{}, tx.Error
+	}
+	return explainStatement(ctx, tx)
+}
+
+// explainStatement 分析已构建的语句 (db.Statement.SQL) 的执行计划
+func explainStatement(ctx context.Context, db *gorm.DB) (Plan, error) {
+	stmt := db.Statement
+	query := stmt.SQL.String()
+	plan := Plan{SQL: db.Dialector.Explain(query, stmt.Vars...)}
+
+	dialect := db.Dialector.Name()
+	switch dialect {
+	case "mysql":
+		query = "EXPLAIN FORMAT=JSON " + query
+	case "postgres":
+		query = "EXPLAIN (FORMAT JSON) " + query
+	case "sqlite":
+		query = "EXPLAIN QUERY PLAN " + query
+	default:
+		query = "EXPLAIN " + query
+	}
+	rows, err := explainRows(ctx, stmt.ConnPool, query, stmt.Vars)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	switch dialect {
+	case "mysql", "postgres":
+		if len(rows) == 0 || len(rows[0]) == 0 {
+			return plan, errors.New("empty explain result")
+		}
+		plan.Raw = fmt.Sprint(rows[0][0])
+		var doc any
+		if err := json.Unmarshal([]byte(plan.Raw), &doc); err != nil {
+			return plan, fmt.Errorf("parse explain result: %w", err)
+		}
+		if dialect == "mysql" {
+			plan.Nodes = mysqlPlanNodes(doc, nil)
+			plan.Filesort = jsonContains(doc, "using_filesort", func(v any) bool { return v == true })
+		} else {
+			plan.Nodes = postgresPlanNodes(doc, nil)
+			plan.Filesort = jsonContains(doc, "Node Type", func(v any) bool { return v == "Sort" || v == "Incremental Sort" })
+		}
+	case "sqlite":
+		lines := make([]string, 0, len(rows))
+		for _, row := range rows {
+			// id, parent, notused, detail
+			detail := fmt.Sprint(row[len(row)-1])
+			lines = append(lines, detail)
+			if node, ok := sqlitePlanNode(detail); ok {
+				plan.Nodes = append(plan.Nodes, node)
+			}
+			if strings.Contains(detail, "TEMP B-TREE FOR ORDER BY") {
+				plan.Filesort = true
+			}
+		}
+		plan.Raw = strings.Join(lines, "\n")
+	default:
+		lines := make([]string, 0, len(rows))
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = fmt.Sprint(cell)
+			}
+			lines = append(lines, strings.Join(cells, "\t"))
+		}
+		plan.Raw = strings.Join(lines, "\n")
+	}
+	return plan, nil
+}
+
+// explainRows 在语句的连接上执行 EXPLAIN，返回全部行 ([]byte 转换为 string)
+func explainRows(ctx context.Context, pool gorm.ConnPool, query string, vars []any) ([][]any, error) {
+	// EXPLAIN 语句不进入预编译语句缓存
+	rows, err := basePool(pool).QueryContext(ctx, query, vars...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]any
+	for rows.Next() {
+		row := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// mysqlPlanNodes 收集 MySQL JSON 计划中的表访问节点 (包含 table_name 与 access_type 的对象)
+func mysqlPlanNodes(v any, nodes []PlanNode) []PlanNode {
+	switch v := v.(type) {
+	case map[string]any:
+		if table, ok := v["table_name"].(string); ok {
+			if access, ok := v["access_type"].(string); ok {
+				node := PlanNode{Table: table, Access: access, FullScan: access == "ALL"}
+				node.Index, _ = v["key"].(string)
+				if rows, ok := v["rows_examined_per_scan"].(float64); ok {
+					node.Rows = rows
+				} else if rows, ok := v["rows"].(float64); ok {
+					node.Rows = rows
+				}
+				nodes = append(nodes, node)
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			nodes = mysqlPlanNodes(v[key], nodes)
+		}
+	case []any:
+		for _, item := range v {
+			nodes = mysqlPlanNodes(item, nodes)
+		}
+	}
+	return nodes
+}
+
+// jsonContains JSON 文档中是否存在满足 match 的 key
+func jsonContains(v any, key string, match func(v any) bool) bool {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if k == key && match(item) || jsonContains(item, key, match) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if jsonContains(item, key, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// postgresPlanNodes 收集 PostgreSQL JSON 计划中访问表的节点 (包含 Relation Name 的节点)
+func postgresPlanNodes(v any, nodes []PlanNode) []PlanNode {
+	switch v := v.(type) {
+	case map[string]any:
+		if plan, ok := v["Plan"]; ok {
+			return postgresPlanNodes(plan, nodes)
+		}
+		if table, ok := v["Relation Name"].(string); ok {
+			node := PlanNode{Table: table}
+			node.Access, _ = v["Node Type"].(string)
+			node.Index, _ = v["Index Name"].(string)
+			node.Rows, _ = v["Plan Rows"].(float64)
+			node.FullScan = node.Access == "Seq Scan"
+			nodes = append(nodes, node)
+		}
+		if children, ok := v["Plans"].([]any); ok {
+			nodes = postgresPlanNodes(children, nodes)
+		}
+	case []any:
+		for _, item := range v {
+			nodes = postgresPlanNodes(item, nodes)
+		}
+	}
+	return nodes
+}
+
+// sqlitePlanNode 解析 SQLite EXPLAIN QUERY PLAN 的一行，如 "SEARCH users USING INDEX idx_users_age (age>?)"
+func sqlitePlanNode(detail string) (PlanNode, bool) {
+	fields := strings.Fields(detail)
+	if len(fields) < 2 || fields[0] != "SCAN" && fields[0] != "SEARCH" {
+		return PlanNode{}, false
+	}
+	node := PlanNode{Access: fields[0], Table: fields[1]}
+	if node.Table == "TABLE" && len(fields) > 2 {
+		// 3.36 之前的版本: SCAN TABLE users
+		node.Table = fields[2]
+	}
+	for i, field := range fields {
+		if field == "INDEX" && i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "(") {
+			node.Index = fields[i+1]
+			break
+		}
+		if field == "PRIMARY" && i+1 < len(fields) && fields[i+1] == "KEY" {
+			node.Index = "PRIMARY"
+			break
+		}
+	}
+	node.FullScan = node.Access == "SCAN" && node.Index == ""
+	return node, true
+}
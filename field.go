@@ -0,0 +1,150 @@
+package gomp
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Field 实体 T 中值类型为 V 的列，通过其方法创建的条件在编译期检查值的类型
+//
+//	var (
+//	    UserAge  = gomp.NewField[User, int]("age")
+//	    UserName = gomp.FieldOf[User, string]("Name") // 按结构体字段名解析列名
+//	)
+//
+//	w := gomp.NewQueryWrapper[User]().Where(UserAge.Ge(18), UserName.LikeRight("张"))
+//	w.Where(UserAge.Eq("abc")) // 编译错误
+type Field[T, V any] struct {
+	column string
+}
+
+// NewField 创建列描述
+func NewField[T, V any](column string) Field[T, V] {
+	return Field[T, V]{column: column}
+}
+
+var fieldSchemas sync.Map // 解析实体结构使用的缓存
+
+// FieldOf 按结构体字段名创建列描述，列名取自 gorm column 标签或 GORM 默认命名策略
+// 字段不存在或类型不是 V 时 panic，适合在包级变量中使用以便启动时发现错误
+func FieldOf[T, V any](name string) Field[T, V] {
+	sch, err := schema.Parse(new(T), &fieldSchemas, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("gomp: parse %s: %v", entityType[T](), err))
+	}
+	field := sch.LookUpField(name)
+	if field == nil || field.DBName == "" {
+		panic(fmt.Sprintf("gomp: %s has no column field %s", sch.Name, name))
+	}
+	if want := reflect.TypeOf((*V)(nil)).Elem(); field.FieldType != want {
+		panic(fmt.Sprintf("gomp: field %s.%s is %s, not %s", sch.Name, name, field.FieldType, want))
+	}
+	return Field[T, V]{column: field.DBName}
+}
+
+// Column 列名
+func (f Field[T, V]) Column() string {
+	return f.column
+}
+
+// Of 带表名 (或别名) 的列，可作为 Wrapper 条件、排序与联表方法的列参数，执行时按方言加引号
+//
+//	w.LeftJoin("orders o", UserID.Of("u"), OrderUserID.Of("o")).Eq(OrderStatus.Of("o"), "paid")
+func (f Field[T, V]) Of(table string) clause.Column {
+	return clause.Column{Table: table, Name: f.column}
+}
+
+// clauseColumn 实现 columnRef，作为列参数时不带表名
+func (f Field[T, V]) clauseColumn() clause.Column {
+	return clause.Column{Name: f.column}
+}
+
+// String 实现 fmt.Stringer，返回列名
+func (f Field[T, V]) String() string {
+	return f.column
+}
+
+// Cond 实体 T 的条件，由 Field 的方法创建，通过 Wrapper 的 Where 添加
+type Cond[T any] struct {
+	query string
+	args  []any
+}
+
+// Eq 等于 =
+func (f Field[T, V]) Eq(val V) Cond[T] {
+	return Cond[T]{query: f.column + " = ?", args: []any{val}}
+}
+
+// Ne 不等于 <>
+func (f Field[T, V]) Ne(val V) Cond[T] {
+	return Cond[T]{query: f.column + " <> ?", args: []any{val}}
+}
+
+// Gt 大于 >
+func (f Field[T, V]) Gt(val V) Cond[T] {
+	return Cond[T]{query: f.column + " > ?", args: []any{val}}
+}
+
+// Ge 大于等于 >=
+func (f Field[T, V]) Ge(val V) Cond[T] {
+	return Cond[T]{query: f.column + " >= ?", args: []any{val}}
+}
+
+// Lt 小于 <
+func (f Field[T, V]) Lt(val V) Cond[T] {
+	return Cond[T]{query: f.column + " < ?", args: []any{val}}
+}
+
+// Le 小于等于 <=
+func (f Field[T, V]) Le(val V) Cond[T] {
+	return Cond[T]{query: f.column + " <= ?", args: []any{val}}
+}
+
+// Like 模糊查询 LIKE '%值%'
+func (f Field[T, V]) Like(val string) Cond[T] {
+	return Cond[T]{query: f.column + " LIKE ?", args: []any{"%" + val + "%"}}
+}
+
+// LikeLeft 左模糊 LIKE '%值'
+func (f Field[T, V]) LikeLeft(val string) Cond[T] {
+	return Cond[T]{query: f.column + " LIKE ?", args: []any{"%" + val}}
+}
+
+// LikeRight 右模糊 LIKE '值%'
+func (f Field[T, V]) LikeRight(val string) Cond[T] {
+	return Cond[T]{query: f.column + " LIKE ?", args: []any{val + "%"}}
+}
+
+// In IN 查询
+func (f Field[T, V]) In(vals ...V) Cond[T] {
+	return Cond[T]{query: f.column + " IN (?)", args: []any{vals}}
+}
+
+// NotIn NOT IN 查询
+func (f Field[T, V]) NotIn(vals ...V) Cond[T] {
+	return Cond[T]{query: f.column + " NOT IN (?)", args: []any{vals}}
+}
+
+// Between BETWEEN AND
+func (f Field[T, V]) Between(val1, val2 V) Cond[T] {
+	return Cond[T]{query: f.column + " BETWEEN ? AND ?", args: []any{val1, val2}}
+}
+
+// NotBetween NOT BETWEEN AND
+func (f Field[T, V]) NotBetween(val1, val2 V) Cond[T] {
+	return Cond[T]{query: f.column + " NOT BETWEEN ? AND ?", args: []any{val1, val2}}
+}
+
+// IsNull IS NULL
+func (f Field[T, V]) IsNull() Cond[T] {
+	return Cond[T]{query: f.column + " IS NULL"}
+}
+
+// IsNotNull IS NOT NULL
+func (f Field[T, V]) IsNotNull() Cond[T] {
+	return Cond[T]{query: f.column + " IS NOT NULL"}
+}
@@ -0,0 +1,173 @@
+package gomp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// 自动填充时机 (对应标签 gomp:"fill:insert")
+const (
+	FillInsert       = "insert"        // 插入时填充
+	FillUpdate       = "update"        // 更新时填充
+	FillInsertUpdate = "insert_update" // 插入和更新时均填充
+)
+
+// FillFunc 自动填充值提供者
+type FillFunc func(ctx context.Context) any
+
+var (
+	fillMu        sync.RWMutex
+	fillProviders = make(map[string]FillFunc)
+	fillClock     = time.Now
+
+	// fillFieldCache 实体中标记了 fill 的字段，按 Schema 缓存
+	fillFieldCache sync.Map
+)
+
+// fillField 自动填充字段
+type fillField struct {
+	field *schema.Field
+	mode  string
+}
+
+// RegisterFill 注册列的自动填充值提供者
+// 未注册提供者时，operatorColumns 配置的列使用 OperatorFrom(ctx) 填充，time.Time 字段使用时钟 (默认 time.Now) 填充
+func RegisterFill(column string, fn FillFunc) {
+	fillMu.Lock()
+	defer fillMu.Unlock()
+	fillProviders[strings.ToLower(column)] = fn
+}
+
+// SetFillClock 设置时间字段的填充时钟，传入 nil 恢复 time.Now
+func SetFillClock(clock func() time.Time) {
+	fillMu.Lock()
+	defer fillMu.Unlock()
+	if clock == nil {
+		clock = time.Now
+	}
+	fillClock = clock
+}
+
+// schemaFillFields 获取实体中标记了 gomp:"fill:..." 的字段
+func schemaFillFields(sch *schema.Schema) []fillField {
+	if v, ok := fillFieldCache.Load(sch); ok {
+		return v.([]fillField)
+	}
+	fields := make([]fillField, 0)
+	for _, field := range sch.Fields {
+		if mode, ok := gompTagValue(field, "fill"); ok && field.DBName != "" {
+			fields = append(fields, fillField{field: field, mode: strings.ToLower(mode)})
+		}
+	}
+	fillFieldCache.Store(sch, fields)
+	return fields
+}
+
+// fillFields 获取实体需要自动填充的字段: 标签字段及 operatorColumns 配置的操作人字段
+func fillFields(sch *schema.Schema) []fillField {
+	tagged := schemaFillFields(sch)
+	operators := operatorFillFields(sch)
+	if len(operators) == 0 {
+		return tagged
+	}
+	fields := make([]fillField, 0, len(tagged)+len(operators))
+	fields = append(fields, tagged...)
+	for _, op := range operators {
+		exists := false
+		for _, f := range tagged {
+			if f.field == op.field {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			fields = append(fields, op)
+		}
+	}
+	return fields
+}
+
+// fillApplies 判断填充时机是否匹配当前操作
+func fillApplies(mode string, insert bool) bool {
+	if mode == FillInsertUpdate {
+		return true
+	}
+	if insert {
+		return mode == FillInsert
+	}
+	return mode == FillUpdate
+}
+
+// fillValue 获取字段的填充值
+func fillValue(ctx context.Context, field *schema.Field) (any, bool) {
+	fillMu.RLock()
+	fn, ok := fillProviders[strings.ToLower(field.DBName)]
+	clock := fillClock
+	fillMu.RUnlock()
+	if ok {
+		return fn(ctx), true
+	}
+	if isOperatorColumn(field.DBName) {
+		return OperatorFrom(ctx)
+	}
+	typ := field.FieldType
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return clock(), true
+	}
+	return nil, false
+}
+
+// fillEntity 填充实体字段: 插入时仅填充零值字段，更新时总是覆盖
+func fillEntity(ctx context.Context, sch *schema.Schema, entity any, insert bool) error {
+	rv := reflect.ValueOf(entity)
+	for _, f := range fillFields(sch) {
+		if !fillApplies(f.mode, insert) {
+			continue
+		}
+		if insert {
+			if _, zero := f.field.ValueOf(ctx, rv); !zero {
+				continue
+			}
+		}
+		val, ok := fillValue(ctx, f.field)
+		if !ok {
+			continue
+		}
+		if err := f.field.Set(ctx, rv, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillColumns 为 Wrapper 的列值补充填充字段，已显式设置的列不会被覆盖，返回新的 map
+func fillColumns(ctx context.Context, sch *schema.Schema, values map[string]any, insert bool) map[string]any {
+	fields := fillFields(sch)
+	if len(fields) == 0 {
+		return values
+	}
+	filled := make(map[string]any, len(values)+len(fields))
+	for k, v := range values {
+		filled[k] = v
+	}
+	for _, f := range fields {
+		if !fillApplies(f.mode, insert) {
+			continue
+		}
+		if _, ok := filled[f.field.DBName]; ok {
+			continue
+		}
+		if val, ok := fillValue(ctx, f.field); ok {
+			filled[f.field.DBName] = val
+		}
+	}
+	return filled
+}
@@ -0,0 +1,53 @@
+package gomp
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// errFlightPanicked 合并执行的函数发生 panic，等待中的调用方收到该错误
+	errFlightPanicked = errors.New("concurrent load panicked")
+	// errFlightUnshareable 结果无法编码共享，等待中的调用方需自行加载
+	errFlightUnshareable = errors.New("concurrent load result is not shareable")
+)
+
+// flightGroup 合并相同 key 的并发加载 (singleflight)，缓存未命中时避免热点 key 同时击穿到数据库
+// 结果以编码后的字节共享，每个调用方各自解码，互不影响
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall 进行中的加载
+type flightCall struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+// do 执行 fn，同一 key 已有进行中的加载时等待其结果；shared 表示结果来自其他调用方的加载
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) (val []byte, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &flightCall{err: errFlightPanicked}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
@@ -0,0 +1,171 @@
+package gomp
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sqlKeywords 格式化时转为大写的关键字
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true, "IN": true,
+	"IS": true, "NULL": true, "LIKE": true, "BETWEEN": true, "JOIN": true, "LEFT": true, "RIGHT": true,
+	"INNER": true, "OUTER": true, "CROSS": true, "FULL": true, "ON": true, "AS": true, "ORDER": true,
+	"GROUP": true, "BY": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "INSERT": true, "INTO": true,
+	"VALUES": true, "UPDATE": true, "SET": true, "DELETE": true, "DISTINCT": true, "ASC": true, "DESC": true,
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true, "EXISTS": true, "CASE": true,
+	"WHEN": true, "THEN": true, "ELSE": true, "END": true, "UNION": true, "ALL": true, "RETURNING": true,
+	"DEFAULT": true, "TRUE": true, "FALSE": true, "REPLACE": true, "CONFLICT": true, "DO": true,
+	"DUPLICATE": true, "KEY": true, "FOR": true, "SHARE": true,
+}
+
+// sqlClauses 格式化时另起一行的子句关键字
+var sqlClauses = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"SET": true, "VALUES": true, "RETURNING": true, "UNION": true, "JOIN": true, "LEFT": true,
+	"RIGHT": true, "INNER": true, "CROSS": true, "FULL": true, "ON": true,
+}
+
+// splitSQL 拆分 SQL 为单词 / 字符串常量 / 引号标识符 / 符号，丢弃空白
+func splitSQL(sql string) []string {
+	tokens := make([]string, 0, 64)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == c {
+					if c == '\'' && j+1 < len(sql) && sql[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				if sql[j] == '\\' && c != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(sql) {
+				j = len(sql) - 1
+			}
+			tokens = append(tokens, sql[i:j+1])
+			i = j + 1
+		case c == '_' || c == '.' || c == '$' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			j := i
+			for j < len(sql) {
+				d := sql[j]
+				if d == '_' || d == '.' || d == '$' || unicode.IsLetter(rune(d)) || unicode.IsDigit(rune(d)) {
+					j++
+					continue
+				}
+				// `u`.`name` 形式的限定标识符
+				if (d == '`' || d == '"') && j > i && sql[j-1] == '.' {
+					end := strings.IndexByte(sql[j+1:], d)
+					if end >= 0 {
+						j += end + 2
+						continue
+					}
+				}
+				break
+			}
+			tokens = append(tokens, sql[i:j])
+			i = j
+		default:
+			tokens = append(tokens, sql[i:i+1])
+			i++
+		}
+	}
+	// 合并引号标识符与后续的 .column，如 `u` . `name`
+	merged := tokens[:0]
+	for _, tok := range tokens {
+		if n := len(merged); n > 0 && (strings.HasPrefix(tok, ".") || strings.HasSuffix(merged[n-1], ".")) && tok != "," && tok != "(" {
+			merged[n-1] += tok
+			continue
+		}
+		merged = append(merged, tok)
+	}
+	return merged
+}
+
+// FormatSQL 格式化 SQL: 关键字大写，主要子句换行，顶层 AND / OR 条件缩进，便于阅读与复制执行
+func FormatSQL(sql string) string {
+	tokens := splitSQL(sql)
+	var sb strings.Builder
+	depth := 0
+	prev := ""
+	for i, tok := range tokens {
+		upper := strings.ToUpper(tok)
+		isKeyword := sqlKeywords[upper]
+		if isKeyword {
+			tok = upper
+		}
+
+		newline := false
+		indent := ""
+		if depth == 0 && isKeyword && i > 0 {
+			switch {
+			case upper == "JOIN" && (prev == "LEFT" || prev == "RIGHT" || prev == "INNER" || prev == "CROSS" || prev == "OUTER" || prev == "FULL"):
+			case upper == "OUTER" && (prev == "LEFT" || prev == "RIGHT" || prev == "FULL"):
+			case upper == "ON":
+				newline, indent = true, "  "
+			case upper == "AND" || upper == "OR":
+				if prev != "BETWEEN_VALUE" {
+					newline, indent = true, "  "
+				}
+			case sqlClauses[upper]:
+				newline = true
+			}
+		}
+
+		switch {
+		case sb.Len() == 0:
+		case newline:
+			sb.WriteString("\n")
+			sb.WriteString(indent)
+		case tok == ")" || tok == "," || prev == "(":
+		case tok == "(" && isFunctionName(tokens[i-1]):
+		default:
+			sb.WriteString(" ")
+		}
+		sb.WriteString(tok)
+
+		switch tok {
+		case "(":
+			depth++
+		case ")":
+			if depth > 0 {
+				depth--
+			}
+		}
+		// BETWEEN a AND b 中的 AND 不换行
+		if prev == "BETWEEN" {
+			prev = "BETWEEN_VALUE"
+		} else if isKeyword || tok == "(" || tok == ")" {
+			prev = upper
+		} else if prev != "BETWEEN_VALUE" {
+			prev = tok
+		} else {
+			prev = ""
+		}
+	}
+	return sb.String()
+}
+
+// sqlFunctions 紧跟括号的函数关键字
+var sqlFunctions = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+// isFunctionName 判断括号前的单词是否为函数名 (非关键字的裸标识符或聚合函数)
+func isFunctionName(tok string) bool {
+	upper := strings.ToUpper(tok)
+	if sqlFunctions[upper] {
+		return true
+	}
+	if sqlKeywords[upper] || tok == "" {
+		return false
+	}
+	c := tok[0]
+	return c == '_' || unicode.IsLetter(rune(c))
+}
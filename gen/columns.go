@@ -0,0 +1,242 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// ColumnsConfig 根据实体结构体生成列名常量的配置，通常通过 go:generate 调用
+//
+//	//go:generate go run github.com/shelbeii/gomp/cmd/gomp-gen -columns
+type ColumnsConfig struct {
+	Dir     string   // 实体所在目录，默认当前目录
+	File    string   // 未指定 Types 时，生成该文件中声明的全部结构体 (go:generate 时为 $GOFILE)
+	Types   []string // 需要生成的结构体
+	Output  string   // 输出文件，默认为 <File>_columns.go，未指定 File 时为 gomp_columns.go
+	Naming  schema.Namer
+	Package string // 包名，默认取源文件的包名
+}
+
+// GenerateColumns 解析实体结构体并生成 <实体>Col 列名常量，返回输出文件路径
+// 列名优先使用 gorm column 标签，否则按 Naming (默认 GORM 的 NamingStrategy) 转换；展开匿名嵌入与 embedded 字段
+// 忽略 gorm:"-"、同一包内结构体类型及带 foreignKey / many2many 等标签的关联字段
+func GenerateColumns(cfg ColumnsConfig) (string, error) {
+	if cfg.Dir == "" {
+		cfg.Dir = "."
+	}
+	if cfg.Naming == nil {
+		cfg.Naming = schema.NamingStrategy{}
+	}
+	if cfg.Output == "" {
+		cfg.Output = "gomp_columns.go"
+		if cfg.File != "" {
+			cfg.Output = strings.TrimSuffix(filepath.Base(cfg.File), ".go") + "_columns.go"
+		}
+	}
+	if !filepath.IsAbs(cfg.Output) {
+		cfg.Output = filepath.Join(cfg.Dir, cfg.Output)
+	}
+
+	structs, declared, pkg, err := parseStructs(cfg.Dir, cfg.File, cfg.Output)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Package == "" {
+		cfg.Package = pkg
+	}
+	types := cfg.Types
+	if len(types) == 0 {
+		if cfg.File == "" {
+			return "", errors.New("either Types or File is required")
+		}
+		types = declared
+	}
+	if len(types) == 0 {
+		return "", fmt.Errorf("no structs found in %s", cfg.File)
+	}
+
+	tables := make([]Table, 0, len(types))
+	for _, name := range types {
+		st, ok := structs[name]
+		if !ok {
+			return "", fmt.Errorf("struct %s not found in %s", name, cfg.Dir)
+		}
+		seen := make(map[string]bool)
+		table := Table{Struct: name}
+		table.Columns = structColumns(st, structs, cfg.Naming, "", "", seen, table.Columns)
+		if len(table.Columns) == 0 {
+			return "", fmt.Errorf("struct %s has no columns", name)
+		}
+		tables = append(tables, table)
+	}
+	if _, err := render(cfg.Output, columnsTemplate, map[string]any{"Package": cfg.Package, "Tables": tables}, true); err != nil {
+		return "", err
+	}
+	return cfg.Output, nil
+}
+
+// parseStructs 解析目录下的全部结构体 (不含测试文件与输出文件)，返回结构体、file 中按声明顺序的导出结构体名与包名
+func parseStructs(dir, file, output string) (map[string]*ast.StructType, []string, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	fset := token.NewFileSet()
+	structs := make(map[string]*ast.StructType)
+	var declared []string
+	var pkg string
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") ||
+			filepath.Clean(path) == filepath.Clean(output) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		pkg = f.Name.Name
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || ts.TypeParams != nil {
+					continue
+				}
+				structs[ts.Name.Name] = st
+				if name == filepath.Base(file) && ts.Name.IsExported() {
+					declared = append(declared, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return structs, declared, pkg, nil
+}
+
+// gormModelFields gorm.Model 的字段
+var gormModelFields = []string{"ID", "CreatedAt", "UpdatedAt", "DeletedAt"}
+
+// structColumns 收集结构体的列，prefix 为 embeddedPrefix，fieldPrefix 为具名 embedded 字段的字段名 (如 HomeCity)
+// 同名字段只保留先出现的
+func structColumns(st *ast.StructType, structs map[string]*ast.StructType, naming schema.Namer, prefix, fieldPrefix string, seen map[string]bool, columns []Column) []Column {
+	add := func(field, column string) {
+		field = fieldPrefix + field
+		if seen[field] {
+			return
+		}
+		seen[field] = true
+		columns = append(columns, Column{Name: column, Field: field})
+	}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			if s, err := strconv.Unquote(field.Tag.Value); err == nil {
+				tag = reflect.StructTag(s)
+			}
+		}
+		gormTag := tag.Get("gorm")
+		if gormTag == "-" || gormTag == "-:all" {
+			continue
+		}
+		settings := schema.ParseTagSetting(gormTag, ";")
+		typeName, local := fieldTypeName(field.Type, structs)
+
+		// 匿名嵌入或 embedded 标签的结构体展开
+		_, embedded := settings["EMBEDDED"]
+		if len(field.Names) == 0 || embedded {
+			embeddedPrefix, embeddedField := prefix+settings["EMBEDDEDPREFIX"], fieldPrefix
+			if len(field.Names) > 0 {
+				embeddedField += field.Names[0].Name
+			}
+			if local {
+				columns = structColumns(structs[typeName], structs, naming, embeddedPrefix, embeddedField, seen, columns)
+				continue
+			}
+			if typeName == "gorm.Model" {
+				for _, name := range gormModelFields {
+					if !seen[embeddedField+name] {
+						seen[embeddedField+name] = true
+						columns = append(columns, Column{Name: embeddedPrefix + naming.ColumnName("", name), Field: embeddedField + name})
+					}
+				}
+				continue
+			}
+			continue
+		}
+		if local || isAssociation(settings) || !isColumnType(field.Type, settings) {
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			column := settings["COLUMN"]
+			if column == "" {
+				column = naming.ColumnName("", name.Name)
+			}
+			add(name.Name, prefix+column)
+		}
+	}
+	return columns
+}
+
+// fieldTypeName 字段类型名 (去掉指针)，local 表示类型为同一包内的结构体
+func fieldTypeName(expr ast.Expr, structs map[string]*ast.StructType) (string, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		_, local := structs[t.Name]
+		return t.Name, local
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			return pkg.Name + "." + t.Sel.Name, false
+		}
+	}
+	return "", false
+}
+
+// isAssociation 是否带有关联标签
+func isAssociation(settings map[string]string) bool {
+	for _, key := range []string{"FOREIGNKEY", "REFERENCES", "MANY2MANY", "POLYMORPHIC"} {
+		if _, ok := settings[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isColumnType 字段类型是否映射为列: 切片 (除 []byte) 与 map 只在指定 serializer 时视为列
+func isColumnType(expr ast.Expr, settings map[string]string) bool {
+	if _, ok := settings["SERIALIZER"]; ok {
+		return true
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		elem, ok := t.Elt.(*ast.Ident)
+		return t.Len == nil && ok && slices.Contains([]string{"byte", "uint8"}, elem.Name)
+	case *ast.MapType, *ast.InterfaceType, *ast.FuncType, *ast.ChanType, *ast.StructType:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+// Package gen 根据数据库表结构生成 gomp 实体、列名常量与 Service 骨架，命令行工具见 cmd/gomp-gen
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gorm.io/gorm"
+)
+
+// Config 代码生成配置
+type Config struct {
+	OutDir      string   // 实体输出目录，默认 ./model
+	Package     string   // 实体包名，默认取 OutDir 的最后一级目录名
+	Tables      []string // 需要生成的表，为空时生成全部表
+	TablePrefix string   // 生成结构体名时去掉的表前缀，如 t_
+
+	ServiceOutDir   string // Service 输出目录，为空时不生成 Service
+	ServicePackage  string // Service 包名，默认取 ServiceOutDir 的最后一级目录名
+	ModelImportPath string // 实体包的导入路径，Service 与实体不在同一目录时必填
+	Overwrite       bool   // 是否覆盖已存在的 Service 文件 (实体与列名文件总是重新生成)
+}
+
+// Table 表结构
+type Table struct {
+	Name    string   // 表名
+	Struct  string   // 结构体名
+	File    string   // 文件名 (不含扩展名)
+	Columns []Column // 列
+}
+
+// Column 列结构
+type Column struct {
+	Name          string // 列名
+	Field         string // 字段名
+	GoType        string // Go 类型
+	DBType        string // 数据库类型，如 varchar(64)
+	Comment       string // 注释
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+// Generate 读取表结构并生成代码，返回写入的文件路径
+func Generate(db *gorm.DB, cfg Config) ([]string, error) {
+	cfg = cfg.normalize()
+	if cfg.ServiceOutDir != "" && cfg.ModelImportPath == "" && filepath.Clean(cfg.ServiceOutDir) != filepath.Clean(cfg.OutDir) {
+		return nil, errors.New("ModelImportPath is required when ServiceOutDir differs from OutDir")
+	}
+	tables, err := LoadTables(db, cfg.Tables, cfg.TablePrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no tables found")
+	}
+
+	var files []string
+	write := func(path string, tmpl *template.Template, data any, overwrite bool) error {
+		written, err := render(path, tmpl, data, overwrite)
+		if written {
+			files = append(files, path)
+		}
+		return err
+	}
+	for _, table := range tables {
+		data := map[string]any{"Package": cfg.Package, "Table": table}
+		if err := write(filepath.Join(cfg.OutDir, table.File+".go"), entityTemplate, data, true); err != nil {
+			return files, err
+		}
+		columns := map[string]any{"Package": cfg.Package, "Tables": []Table{table}}
+		if err := write(filepath.Join(cfg.OutDir, table.File+"_columns.go"), columnsTemplate, columns, true); err != nil {
+			return files, err
+		}
+		if cfg.ServiceOutDir == "" {
+			continue
+		}
+		data = map[string]any{"Package": cfg.ServicePackage, "Table": table, "Model": "", "ModelImport": ""}
+		if filepath.Clean(cfg.ServiceOutDir) != filepath.Clean(cfg.OutDir) {
+			data["Model"], data["ModelImport"] = cfg.Package+".", cfg.ModelImportPath
+		}
+		if err := write(filepath.Join(cfg.ServiceOutDir, table.File+"_service.go"), serviceTemplate, data, cfg.Overwrite); err != nil {
+			return files, err
+		}
+	}
+	return files, nil
+}
+
+// normalize 填充默认值
+func (cfg Config) normalize() Config {
+	if cfg.OutDir == "" {
+		cfg.OutDir = "model"
+	}
+	if cfg.Package == "" {
+		cfg.Package = packageName(cfg.OutDir)
+	}
+	if cfg.ServiceOutDir != "" && cfg.ServicePackage == "" {
+		cfg.ServicePackage = packageName(cfg.ServiceOutDir)
+	}
+	return cfg
+}
+
+// packageName 以目录名作为包名
+func packageName(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	name := strings.ToLower(filepath.Base(abs))
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// LoadTables 通过 GORM Migrator 读取表结构，names 为空时读取全部表
+func LoadTables(db *gorm.DB, names []string, tablePrefix string) ([]Table, error) {
+	migrator := db.Migrator()
+	if len(names) == 0 {
+		var err error
+		if names, err = migrator.GetTables(); err != nil {
+			return nil, err
+		}
+		slices.Sort(names)
+	}
+	tables := make([]Table, 0, len(names))
+	for _, name := range names {
+		if !migrator.HasTable(name) {
+			return nil, fmt.Errorf("table %s not found", name)
+		}
+		columnTypes, err := migrator.ColumnTypes(name)
+		if err != nil {
+			return nil, fmt.Errorf("read columns of %s: %w", name, err)
+		}
+		base := strings.TrimPrefix(name, tablePrefix)
+		table := Table{Name: name, Struct: camelCase(base), File: strings.ToLower(base)}
+		for _, ct := range columnTypes {
+			table.Columns = append(table.Columns, newColumn(ct))
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// newColumn 转换列信息
+func newColumn(ct gorm.ColumnType) Column {
+	col := Column{Name: ct.Name(), Field: camelCase(ct.Name())}
+	col.PrimaryKey, _ = ct.PrimaryKey()
+	col.AutoIncrement, _ = ct.AutoIncrement()
+	col.Comment, _ = ct.Comment()
+	col.DBType, _ = ct.ColumnType()
+	if col.DBType == "" {
+		col.DBType = ct.DatabaseTypeName()
+	}
+	nullable, _ := ct.Nullable()
+	col.GoType = goType(ct.DatabaseTypeName(), col.DBType, nullable && !col.PrimaryKey)
+	return col
+}
+
+// render 执行模板并格式化写入文件，overwrite 为 false 时跳过已存在的文件，返回是否写入
+func render(path string, tmpl *template.Template, data any, overwrite bool) (bool, error) {
+	if !overwrite {
+		if _, err := os.Stat(path); err == nil {
+			return false, nil
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return false, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return false, fmt.Errorf("format %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, src, 0o644)
+}
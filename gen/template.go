@@ -0,0 +1,188 @@
+package gen
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var funcs = template.FuncMap{
+	"oneLine": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	"tag":     fieldTag,
+}
+
+var entityTemplate = template.Must(template.New("entity").Funcs(funcs).Parse(`// Code generated by gomp-gen. DO NOT EDIT.
+
+package {{.Package}}
+{{- $t := .Table}}
+{{- $time := false}}{{range $t.Columns}}{{if or (eq .GoType "time.Time") (eq .GoType "*time.Time")}}{{$time = true}}{{end}}{{end}}
+{{if $time}}
+import "time"
+{{end}}
+// {{$t.Struct}} 对应表 {{$t.Name}}
+type {{$t.Struct}} struct {
+{{- range $t.Columns}}
+	{{.Field}} {{.GoType}} {{tag .}}{{if .Comment}} // {{oneLine .Comment}}{{end}}
+{{- end}}
+}
+
+// TableName 表名
+func ({{$t.Struct}}) TableName() string {
+	return "{{$t.Name}}"
+}
+`))
+
+var columnsTemplate = template.Must(template.New("columns").Funcs(funcs).Parse(`// Code generated by gomp-gen. DO NOT EDIT.
+
+package {{.Package}}
+{{- range $t := .Tables}}
+
+// {{$t.Struct}}Col {{if $t.Name}}表 {{$t.Name}}{{else}}{{$t.Struct}}{{end}} 的列名，用于 Wrapper 条件
+//
+//	gomp.NewQueryWrapper[{{$t.Struct}}]().Eq({{$t.Struct}}Col.{{(index $t.Columns 0).Field}}, v)
+var {{$t.Struct}}Col = struct {
+{{- range $t.Columns}}
+	{{.Field}} string
+{{- end}}
+}{
+{{- range $t.Columns}}
+	{{.Field}}: "{{.Name}}",
+{{- end}}
+}
+{{- end}}
+`))
+
+var serviceTemplate = template.Must(template.New("service").Funcs(funcs).Parse(`package {{.Package}}
+{{- $t := .Table}}{{$m := print .Model $t.Struct}}
+
+import (
+	"github.com/shelbeii/gomp"
+	"gorm.io/gorm"
+{{- if .ModelImport}}
+
+	"{{.ModelImport}}"
+{{- end}}
+)
+
+// I{{$t.Struct}}Service {{$t.Struct}} Service 接口
+type I{{$t.Struct}}Service interface {
+	gomp.IService[{{$m}}]
+	// 在此定义其他自定义业务方法
+}
+
+// {{$t.Struct}}Service {{$t.Struct}} Service 实现
+type {{$t.Struct}}Service struct {
+	*gomp.ServiceImpl[{{$m}}]
+}
+
+// New{{$t.Struct}}Service 创建 {{$t.Struct}}Service
+func New{{$t.Struct}}Service(db *gorm.DB) *{{$t.Struct}}Service {
+	return &{{$t.Struct}}Service{
+		ServiceImpl: gomp.NewServiceImpl[{{$m}}](db),
+	}
+}
+`))
+
+// fieldTag 生成字段标签
+func fieldTag(col Column) string {
+	gormTag := "column:" + col.Name
+	if col.PrimaryKey {
+		gormTag += ";primaryKey"
+		if col.AutoIncrement {
+			gormTag += ";autoIncrement"
+		}
+	}
+	if col.DBType != "" {
+		gormTag += ";type:" + col.DBType
+	}
+	return "`gorm:\"" + gormTag + "\" json:\"" + lowerCamelCase(col.Name) + "\"`"
+}
+
+// goType 按数据库类型推断 Go 类型，nullable 时使用指针
+func goType(typeName, columnType string, nullable bool) string {
+	typeName = strings.ToLower(typeName)
+	columnType = strings.ToLower(columnType)
+	unsigned := strings.Contains(columnType, "unsigned")
+	var t string
+	switch typeName {
+	case "bigint", "int8", "bigserial", "serial8":
+		t = "int64"
+	case "int", "integer", "int4", "mediumint", "serial", "serial4":
+		t = "int32"
+	case "smallint", "int2", "smallserial", "year":
+		t = "int16"
+	case "tinyint":
+		if strings.HasPrefix(columnType, "tinyint(1)") {
+			t = "bool"
+		} else {
+			t = "int8"
+		}
+	case "bool", "boolean":
+		t = "bool"
+	case "bit":
+		if columnType == "bit" || columnType == "bit(1)" {
+			t = "bool"
+		} else {
+			t = "[]byte"
+		}
+	case "float", "float4", "real":
+		t = "float32"
+	case "double", "float8", "double precision", "decimal", "numeric", "money":
+		t = "float64"
+	case "date", "datetime", "timestamp", "timestamptz", "time", "timetz",
+		"timestamp without time zone", "timestamp with time zone", "time without time zone", "time with time zone":
+		t = "time.Time"
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return "[]byte"
+	default:
+		t = "string"
+	}
+	if unsigned && strings.HasPrefix(t, "int") {
+		t = "u" + t
+	}
+	if nullable {
+		t = "*" + t
+	}
+	return t
+}
+
+// commonInitialisms 转换为驼峰时保持全大写的缩写
+var commonInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "SSH": true, "TCP": true, "TTL": true, "UDP": true,
+	"UI": true, "UID": true, "URI": true, "URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// camelCase 将 snake_case 转换为 CamelCase，如 user_id -> UserID
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	}) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	s := b.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// lowerCamelCase 将 snake_case 转换为 lowerCamelCase，用于 json 标签，如 user_id -> userId
+func lowerCamelCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == ' ' || r == '.' })
+	for i, word := range words {
+		runes := []rune(strings.ToLower(word))
+		if i > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
@@ -0,0 +1,67 @@
+package gomp
+
+import (
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	globalConditionMu sync.RWMutex
+	globalConditions  = make(map[reflect.Type][]func(db *gorm.DB) *gorm.DB) // 实体类型 -> 追加注册条件的 scope
+)
+
+// RegisterGlobalCondition 为实体 T 注册全局条件，之后对 T 的查询、更新、删除都会追加 fn 构造的条件 (多个全局条件之间为 AND)
+// 只使用 Wrapper 中的条件，排序、分组等设置会被忽略；需要跳过时使用 Service 的 IgnoreGlobalConditions
+//
+//	gomp.RegisterGlobalCondition(func(w *gomp.QueryWrapper[Order]) {
+//		w.Ne("status", "purged")
+//	})
+func RegisterGlobalCondition[T any](fn func(w *QueryWrapper[T])) {
+	if fn == nil {
+		return
+	}
+	globalConditionMu.Lock()
+	defer globalConditionMu.Unlock()
+	t := entityType[T]()
+	globalConditions[t] = append(globalConditions[t], func(db *gorm.DB) *gorm.DB {
+		w := NewQueryWrapper[T]()
+		fn(w)
+		return applyScopes(db, w.scopes)
+	})
+}
+
+// IgnoreGlobalConditions 返回不追加 RegisterGlobalCondition 注册的条件的 Service 副本，用于本次调用 (租户、逻辑删除条件不受影响)
+//
+//	all, err := orderService.IgnoreGlobalConditions().List(ctx, nil)
+func (s *ServiceImpl[T]) IgnoreGlobalConditions() *ServiceImpl[T] {
+	c := *s
+	c.ignoreGlobalConditions = true
+	return &c
+}
+
+// userGlobalConditions 实体 T 注册的全局条件，每个注册的函数对应一个 AND 组
+func (s *ServiceImpl[T]) userGlobalConditions(db *gorm.DB) []clause.Expression {
+	if s.ignoreGlobalConditions {
+		return nil
+	}
+	return globalConditionsOf(db, entityType[T]())
+}
+
+// globalConditionsOf 类型为 typ 的实体注册的全局条件
+func globalConditionsOf(db *gorm.DB, typ reflect.Type) []clause.Expression {
+	globalConditionMu.RLock()
+	fns := globalConditions[typ]
+	globalConditionMu.RUnlock()
+
+	var exprs []clause.Expression
+	for _, fn := range fns {
+		tx := fn(db.Session(&gorm.Session{NewDB: true}))
+		if where, ok := tx.Statement.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			exprs = append(exprs, clause.And(where.Exprs...))
+		}
+	}
+	return exprs
+}
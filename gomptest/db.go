@@ -0,0 +1,49 @@
+package gomptest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dbSeq 内存数据库序号，保证每次 NewDB 得到独立的库
+var dbSeq atomic.Int64
+
+// NewDB 创建内存 SQLite 数据库并自动迁移 models，测试结束时关闭
+// 每次调用得到独立的数据库，同一数据库的多个连接共享数据；失败时通过 t.Fatalf 终止测试
+//
+//	db := gomptest.NewDB(t, &User{}, &Order{})
+//	users := gomp.NewServiceImpl[User](db)
+func NewDB(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:gomptest_%d?mode=memory&cache=shared&_foreign_keys=1", dbSeq.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gomptest: open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("gomptest: open sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			t.Fatalf("gomptest: auto migrate: %v", err)
+		}
+	}
+	return db
+}
+
+// NewService 创建基于内存 SQLite 的 Service，自动迁移 T 及 models
+//
+//	users := gomptest.NewService[User](t)
+//	_ = users.Save(ctx, &User{Name: "张三"})
+func NewService[T any](t testing.TB, models ...any) *gomp.ServiceImpl[T] {
+	t.Helper()
+	return gomp.NewServiceImpl[T](NewDB(t, append([]any{new(T)}, models...)...))
+}
@@ -0,0 +1,221 @@
+package gomptest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// fixtureSchemas 夹具实体的 schema 缓存
+var fixtureSchemas sync.Map
+
+// Fixture 夹具文件中一个数据集与实体的对应关系
+type Fixture struct {
+	key       string
+	dependsOn []string
+	seed      func(ctx context.Context, db *gorm.DB, rows []map[string]any) (cleanup func(context.Context) error, err error)
+}
+
+// Table 声明夹具文件中 key 对应的数据集写入实体 T，dependsOn 为需要先写入的数据集 (如外键引用的表)
+// 每行数据的 key 可以是列名或字段名
+//
+//	gomptest.Table[User]("users")
+//	gomptest.Table[Order]("orders", "users")
+func Table[T any](key string, dependsOn ...string) Fixture {
+	return Fixture{key: key, dependsOn: dependsOn, seed: seedTable[T]}
+}
+
+// LoadFixtures 读取 YAML / JSON 夹具并按依赖顺序通过 SaveBatch 写入，测试结束时按相反顺序删除写入的记录
+// path 为文件或目录 (读取目录下全部 .yaml / .yml / .json 文件)；失败时通过 t.Fatalf 终止测试
+//
+//	# testdata/fixtures.yaml
+//	users:
+//	  - id: 1
+//	    name: 张三
+//	orders:
+//	  - id: 1
+//	    user_id: 1
+//
+//	db := gomptest.NewDB(t, &User{}, &Order{})
+//	gomptest.LoadFixtures(t, db, "testdata/fixtures.yaml", gomptest.Table[Order]("orders", "users"), gomptest.Table[User]("users"))
+func LoadFixtures(t testing.TB, db *gorm.DB, path string, fixtures ...Fixture) {
+	t.Helper()
+	LoadFixturesContext(context.Background(), t, db, path, fixtures...)
+}
+
+// LoadFixturesContext 同 LoadFixtures，ctx 用于写入 (如携带租户、操作人)
+func LoadFixturesContext(ctx context.Context, t testing.TB, db *gorm.DB, path string, fixtures ...Fixture) {
+	t.Helper()
+	data, err := readFixtures(path)
+	if err != nil {
+		t.Fatalf("gomptest: load fixtures: %v", err)
+	}
+	ordered, err := sortFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("gomptest: load fixtures: %v", err)
+	}
+	for key := range data {
+		if !slices.ContainsFunc(fixtures, func(f Fixture) bool { return f.key == key }) {
+			t.Fatalf("gomptest: load fixtures: no fixture declared for %q", key)
+		}
+	}
+
+	var cleanups []func(context.Context) error
+	t.Cleanup(func() {
+		ctx := context.WithoutCancel(ctx)
+		for _, cleanup := range slices.Backward(cleanups) {
+			if err := cleanup(ctx); err != nil {
+				t.Errorf("gomptest: clean fixtures: %v", err)
+			}
+		}
+	})
+	for _, f := range ordered {
+		rows, ok := data[f.key]
+		if !ok || len(rows) == 0 {
+			continue
+		}
+		cleanup, err := f.seed(ctx, db, rows)
+		if err != nil {
+			t.Fatalf("gomptest: seed %s: %v", f.key, err)
+		}
+		cleanups = append(cleanups, cleanup)
+	}
+}
+
+// readFixtures 读取夹具文件，目录下多个文件中的同名数据集合并
+func readFixtures(path string) (map[string][]map[string]any, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+	result := make(map[string][]map[string]any)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		// JSON 是 YAML 的子集，两种格式统一按 YAML 解析
+		var data map[string][]map[string]any
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for key, rows := range data {
+			result[key] = append(result[key], rows...)
+		}
+	}
+	return result, nil
+}
+
+// sortFixtures 按依赖关系排序，被依赖的数据集在前；依赖未声明或存在循环依赖时返回错误
+func sortFixtures(fixtures []Fixture) ([]Fixture, error) {
+	byKey := make(map[string]Fixture, len(fixtures))
+	for _, f := range fixtures {
+		if _, ok := byKey[f.key]; ok {
+			return nil, fmt.Errorf("duplicate fixture %q", f.key)
+		}
+		byKey[f.key] = f
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(fixtures))
+	ordered := make([]Fixture, 0, len(fixtures))
+	var visit func(key string, path []string) error
+	visit = func(key string, path []string) error {
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular fixture dependency: %s", strings.Join(append(path, key), " -> "))
+		}
+		f, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("fixture %q depends on undeclared %q", path[len(path)-1], key)
+		}
+		state[key] = visiting
+		for _, dep := range f.dependsOn {
+			if err := visit(dep, append(path, key)); err != nil {
+				return err
+			}
+		}
+		state[key] = visited
+		ordered = append(ordered, f)
+		return nil
+	}
+	for _, f := range fixtures {
+		if err := visit(f.key, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// seedTable 将夹具数据转换为实体 T 并通过 SaveBatch 写入，返回按主键删除已写入记录的清理函数
+func seedTable[T any](ctx context.Context, db *gorm.DB, rows []map[string]any) (func(context.Context) error, error) {
+	sch, err := schema.Parse(new(T), &fixtureSchemas, db.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]*T, len(rows))
+	for i, row := range rows {
+		entity := new(T)
+		rv := reflect.ValueOf(entity)
+		for key, value := range row {
+			field := sch.LookUpField(key)
+			if field == nil || field.DBName == "" {
+				return nil, fmt.Errorf("row %d: unknown column %q of %s", i, key, sch.Name)
+			}
+			if err := field.Set(ctx, rv, value); err != nil {
+				return nil, fmt.Errorf("row %d: set %s: %w", i, key, err)
+			}
+		}
+		entities[i] = entity
+	}
+	if err := gomp.NewServiceImpl[T](db).SaveBatch(ctx, entities); err != nil {
+		return nil, err
+	}
+
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+	ids := make([]any, 0, len(entities))
+	for _, entity := range entities {
+		if id, zero := pk.ValueOf(ctx, reflect.ValueOf(entity)); !zero {
+			ids = append(ids, id)
+		}
+	}
+	return func(ctx context.Context) error {
+		if len(ids) == 0 {
+			return nil
+		}
+		return db.Session(&gorm.Session{NewDB: true, Context: ctx}).
+			Table(gomp.ResolveTableName(ctx, sch.Table)).Delete(new(T), ids).Error
+	}, nil
+}
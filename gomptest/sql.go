@@ -0,0 +1,239 @@
+// Package gomptest 提供测试 gomp 代码的辅助工具: 断言 Wrapper / Service 方法生成的 SQL 等
+package gomptest
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Statement 一条 SQL 语句及其参数
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+// Expect 构造期望的语句，比较时忽略多余的空白
+//
+//	gomptest.Expect("SELECT * FROM `users` WHERE age >= ?", 18)
+func Expect(sql string, args ...any) Statement {
+	return Statement{SQL: sql, Args: args}
+}
+
+// String 参数内联后的 SQL，用于失败信息
+func (s Statement) String() string {
+	return logger.ExplainSQL(normalizeSQL(s.SQL), nil, `'`, s.Args...)
+}
+
+// Pattern 匹配该语句的正则表达式，可直接用于 sqlmock 的 ExpectQuery / ExpectExec
+func (s Statement) Pattern() string {
+	return "^" + regexp.QuoteMeta(normalizeSQL(s.SQL)) + "$"
+}
+
+// DriverArgs 转换为 driver.Value 的参数，可直接用于 sqlmock 的 WithArgs
+func (s Statement) DriverArgs() []driver.Value {
+	args := make([]driver.Value, len(s.Args))
+	for i, arg := range s.Args {
+		args[i] = driverValue(arg)
+	}
+	return args
+}
+
+// Equal 语句与参数是否一致，参数按 driver.Value 比较 (int 与 int64 视为相同)
+func (s Statement) Equal(other Statement) bool {
+	return normalizeSQL(s.SQL) == normalizeSQL(other.SQL) && reflect.DeepEqual(s.DriverArgs(), other.DriverArgs())
+}
+
+// Recorder 以 DryRun 模式记录生成的 SQL，不连接数据库
+type Recorder struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	stmts []Statement
+}
+
+// NewRecorder 创建 SQL 记录器，dialector 为 nil 时使用 MySQL 风格的引号与 ? 占位符
+// 需要特定数据库的方言时传入对应驱动的 Dialector (如 postgres.New(postgres.Config{DSN: "..."}))，不会建立连接
+//
+//	rec := gomptest.NewRecorder(nil)
+//	svc := gomp.NewServiceImpl[User](rec.DB())
+//	_, _ = svc.List(ctx, gomp.NewQueryWrapper[User]().Ge("age", 18))
+//	gomptest.AssertSQL(t, rec.Last(), gomptest.Expect("SELECT * FROM `users` WHERE age >= ?", 18))
+func NewRecorder(dialector gorm.Dialector) *Recorder {
+	if dialector == nil {
+		dialector = dryRunDialector{}
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		panic(err)
+	}
+	r := &Recorder{db: db}
+	cb := db.Callback()
+	_ = cb.Create().After("gorm:create").Register("gomptest:record_create", r.record)
+	_ = cb.Query().After("gorm:query").Register("gomptest:record_query", r.record)
+	_ = cb.Update().After("gorm:update").Register("gomptest:record_update", r.record)
+	_ = cb.Delete().After("gorm:delete").Register("gomptest:record_delete", r.record)
+	_ = cb.Row().After("gorm:row").Register("gomptest:record_row", r.record)
+	_ = cb.Raw().After("gorm:raw").Register("gomptest:record_raw", r.record)
+	return r
+}
+
+// record 记录语句构建完成后的 SQL 与参数
+func (r *Recorder) record(db *gorm.DB) {
+	if db.Statement.SQL.Len() == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stmts = append(r.stmts, Statement{SQL: db.Statement.SQL.String(), Args: append([]any(nil), db.Statement.Vars...)})
+}
+
+// DB 用于构造 Service 的 DryRun DB
+// DryRun 模式下查询不返回数据，依赖查询结果的后续语句 (如 Page 总数为 0 时的列表查询) 不会生成
+func (r *Recorder) DB() *gorm.DB {
+	return r.db
+}
+
+// Statements 已记录的全部语句
+func (r *Recorder) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Statement(nil), r.stmts...)
+}
+
+// Last 最后一条语句，没有记录时返回零值
+func (r *Recorder) Last() Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.stmts) == 0 {
+		return Statement{}
+	}
+	return r.stmts[len(r.stmts)-1]
+}
+
+// Reset 清空已记录的语句
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stmts = nil
+}
+
+// QuerySQL 生成 Service.List 对 wrapper 执行的查询语句，包含逻辑删除、多租户等 gomp 追加的条件
+func QuerySQL[T any](ctx context.Context, wrapper *gomp.QueryWrapper[T]) (Statement, error) {
+	return capture(func(db *gorm.DB) error {
+		_, err := gomp.NewServiceImpl[T](db).List(ctx, wrapper)
+		return err
+	})
+}
+
+// CountSQL 生成 Service.Count 对 wrapper 执行的统计语句
+func CountSQL[T any](ctx context.Context, wrapper *gomp.QueryWrapper[T]) (Statement, error) {
+	return capture(func(db *gorm.DB) error {
+		_, err := gomp.NewServiceImpl[T](db).Count(ctx, wrapper)
+		return err
+	})
+}
+
+// UpdateSQL 生成 Service.Update 对 wrapper 执行的更新语句
+func UpdateSQL[T any](ctx context.Context, wrapper *gomp.UpdateWrapper[T]) (Statement, error) {
+	return capture(func(db *gorm.DB) error {
+		return gomp.NewServiceImpl[T](db).Update(ctx, wrapper)
+	})
+}
+
+// DeleteSQL 生成 Service.Delete 对 wrapper 执行的删除语句 (开启逻辑删除时为更新语句)
+func DeleteSQL[T any](ctx context.Context, wrapper *gomp.DeleteWrapper[T]) (Statement, error) {
+	return capture(func(db *gorm.DB) error {
+		return gomp.NewServiceImpl[T](db).Delete(ctx, wrapper)
+	})
+}
+
+// capture 执行 fn 并返回最后一条语句
+func capture(fn func(db *gorm.DB) error) (Statement, error) {
+	r := NewRecorder(nil)
+	if err := fn(r.DB()); err != nil {
+		return Statement{}, err
+	}
+	return r.Last(), nil
+}
+
+// AssertSQL 断言语句与期望一致，不一致时通过 t.Errorf 报告并返回 false
+func AssertSQL(t testing.TB, got, want Statement) bool {
+	t.Helper()
+	if got.Equal(want) {
+		return true
+	}
+	t.Errorf("unexpected SQL\n got: %s\n      args %v\nwant: %s\n      args %v",
+		normalizeSQL(got.SQL), got.Args, normalizeSQL(want.SQL), want.Args)
+	return false
+}
+
+// AssertStatements 断言按顺序记录的全部语句与期望一致
+func AssertStatements(t testing.TB, got []Statement, want ...Statement) bool {
+	t.Helper()
+	ok := len(got) == len(want)
+	if !ok {
+		t.Errorf("expected %d statements, got %d", len(want), len(got))
+	}
+	for i := range min(len(got), len(want)) {
+		if !got[i].Equal(want[i]) {
+			ok = false
+			t.Errorf("statement %d mismatch\n got: %s\nwant: %s", i, got[i], want[i])
+		}
+	}
+	for _, s := range got[min(len(got), len(want)):] {
+		t.Errorf("unexpected statement: %s", s)
+	}
+	for _, s := range want[min(len(got), len(want)):] {
+		t.Errorf("missing statement: %s", s)
+	}
+	return ok
+}
+
+// normalizeSQL 合并连续空白并去掉首尾空白
+func normalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// driverValue 将参数转换为 driver.Value，无法转换时原样返回
+func driverValue(v any) driver.Value {
+	if c, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		return c
+	}
+	return v
+}
+
+// dryRunDialector 默认的 Dialector: MySQL 风格的引号与 ? 占位符，只用于构造语句
+type dryRunDialector struct{}
+
+func (dryRunDialector) Name() string                    { return "gomptest" }
+func (dryRunDialector) Migrator(*gorm.DB) gorm.Migrator { return nil }
+func (dryRunDialector) DataTypeOf(*schema.Field) string { return "" }
+func (dryRunDialector) Initialize(db *gorm.DB) error {
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	return nil
+}
+func (dryRunDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+func (dryRunDialector) BindVarTo(w clause.Writer, _ *gorm.Statement, _ any) {
+	_ = w.WriteByte('?')
+}
+func (dryRunDialector) QuoteTo(w clause.Writer, s string) {
+	_ = w.WriteByte('`')
+	_, _ = w.WriteString(strings.ReplaceAll(s, ".", "`.`"))
+	_ = w.WriteByte('`')
+}
+func (dryRunDialector) Explain(sql string, vars ...any) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
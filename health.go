@@ -0,0 +1,167 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HealthEvent 数据源健康状态变化事件
+type HealthEvent struct {
+	DataSource string   // 数据源名称，未通过 RegisterDataSource 注册的主库及其从库为空字符串
+	Primary    *gorm.DB // 主库
+	DB         *gorm.DB // 状态发生变化的库，为从库时不同于 Primary
+	Replica    bool     // 是否为从库
+	Healthy    bool     // 变化后的状态
+	Err        error    // 健康检查失败的原因，恢复时为 nil
+}
+
+var (
+	healthListenersMu sync.Mutex
+	healthListeners   = make(map[int]func(event HealthEvent))
+	healthListenerSeq int
+
+	healthCheckRunning atomic.Bool
+)
+
+// OnHealthChange 订阅数据源健康状态变化 (如告警)，返回取消订阅函数
+func OnHealthChange(fn func(event HealthEvent)) (cancel func()) {
+	healthListenersMu.Lock()
+	defer healthListenersMu.Unlock()
+	healthListenerSeq++
+	id := healthListenerSeq
+	healthListeners[id] = fn
+	return func() {
+		healthListenersMu.Lock()
+		defer healthListenersMu.Unlock()
+		delete(healthListeners, id)
+	}
+}
+
+// StartHealthCheck 定期 Ping 已注册的数据源与从库，直到 ctx 结束
+// 不可用的从库暂不参与读路由 (全部不可用时查询回退到主库)，恢复后自动重新加入；主库只通知不剔除
+// 每次 Ping 的超时时间为 timeout，为 0 时使用 interval
+func StartHealthCheck(ctx context.Context, interval, timeout time.Duration) error {
+	if interval <= 0 {
+		return errors.New("health check interval must be greater than 0")
+	}
+	if timeout <= 0 {
+		timeout = interval
+	}
+	if !healthCheckRunning.CompareAndSwap(false, true) {
+		return errors.New("health check is already running")
+	}
+
+	go func() {
+		defer healthCheckRunning.Store(false)
+		primaries := make(map[*gorm.DB]bool)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			checkHealth(ctx, timeout, primaries)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// healthTarget 一次健康检查的对象
+type healthTarget struct {
+	name    string
+	primary *gorm.DB
+	replica *replica
+}
+
+// healthTargets 收集已注册的数据源主库与从库
+func healthTargets() []healthTarget {
+	names := make(map[*gorm.DB]string)
+	targets := make([]healthTarget, 0)
+	dataSourcesMu.RLock()
+	for name, db := range dataSources {
+		names[db] = name
+		targets = append(targets, healthTarget{name: name, primary: db})
+	}
+	dataSourcesMu.RUnlock()
+
+	replicasMu.RLock()
+	defer replicasMu.RUnlock()
+	for primary, set := range replicas {
+		if _, ok := names[primary]; !ok {
+			targets = append(targets, healthTarget{primary: primary})
+		}
+		for _, rep := range set.replicas {
+			targets = append(targets, healthTarget{name: names[primary], primary: primary, replica: rep})
+		}
+	}
+	return targets
+}
+
+// checkHealth 检查所有数据源，状态变化时通知订阅者
+// primaries 记录主库上一次的状态，只在检查协程内访问
+func checkHealth(ctx context.Context, timeout time.Duration, primaries map[*gorm.DB]bool) {
+	for _, target := range healthTargets() {
+		db := target.primary
+		if target.replica != nil {
+			db = target.replica.db
+		}
+		err := pingDB(ctx, db, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		healthy := err == nil
+		if target.replica != nil {
+			if target.replica.down.Swap(!healthy) == !healthy {
+				continue
+			}
+		} else {
+			last, ok := primaries[db]
+			if !ok {
+				last = true // 首次检查视为健康，只在不可用时通知
+			}
+			primaries[db] = healthy
+			if last == healthy {
+				continue
+			}
+		}
+		notifyHealth(HealthEvent{
+			DataSource: target.name,
+			Primary:    target.primary,
+			DB:         db,
+			Replica:    target.replica != nil,
+			Healthy:    healthy,
+			Err:        err,
+		})
+	}
+}
+
+// pingDB Ping 数据库连接
+func pingDB(ctx context.Context, db *gorm.DB, timeout time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// notifyHealth 通知健康状态订阅者
+func notifyHealth(event HealthEvent) {
+	healthListenersMu.Lock()
+	fns := make([]func(event HealthEvent), 0, len(healthListeners))
+	for _, fn := range healthListeners {
+		fns = append(fns, fn)
+	}
+	healthListenersMu.Unlock()
+	for _, fn := range fns {
+		fn(event)
+	}
+}
@@ -0,0 +1,158 @@
+package gomp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// hintsKey Statement.Settings 中优化器提示的 key
+const hintsKey = "gomp:hints"
+
+// hintIdent 提示中的表名、索引名只允许标识符，名称会直接拼接到注释中
+var hintIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// Hint 优化器提示，按当前方言渲染，当前方言不支持的提示被忽略，使带提示的查询可以在不同数据库间移植
+//   - MySQL: 以优化器提示注释写在 SELECT 之后 (SELECT /*+ MAX_EXECUTION_TIME(500) */ ...)
+//   - Postgres: 以 pg_hint_plan 的提示注释写在语句开头 (/*+ IndexScan(t idx) */ SELECT ...)，未安装扩展时仅为普通注释
+type Hint struct {
+	text map[string]string // 方言 -> 提示文本
+	err  error
+}
+
+// MaxExecutionTime 语句的最长执行时间 (毫秒)，超时后由数据库中止；仅 MySQL 支持
+func MaxExecutionTime(ms int) Hint {
+	if ms <= 0 {
+		return Hint{err: fmt.Errorf("invalid max execution time %d", ms)}
+	}
+	return Hint{text: map[string]string{"mysql": "MAX_EXECUTION_TIME(" + strconv.Itoa(ms) + ")"}}
+}
+
+// StraightJoin 按 FROM / JOIN 的书写顺序联表，不由优化器调整；仅 MySQL 支持
+func StraightJoin() Hint {
+	return Hint{text: map[string]string{"mysql": "JOIN_FIXED_ORDER()"}}
+}
+
+// UseIndex 对表 table (或其别名) 使用索引 indexes 之一
+func UseIndex(table string, indexes ...string) Hint {
+	if err := checkHintIdents(table, indexes); err != nil {
+		return Hint{err: err}
+	}
+	return Hint{text: map[string]string{
+		"mysql":    "INDEX(" + table + " " + strings.Join(indexes, ", ") + ")",
+		"postgres": "IndexScan(" + table + " " + strings.Join(indexes, " ") + ")",
+	}}
+}
+
+// NoIndex 对表 table (或其别名) 不使用索引 indexes；Postgres 不区分索引，忽略该表的所有索引扫描
+func NoIndex(table string, indexes ...string) Hint {
+	if err := checkHintIdents(table, indexes); err != nil {
+		return Hint{err: err}
+	}
+	return Hint{text: map[string]string{
+		"mysql":    "NO_INDEX(" + table + " " + strings.Join(indexes, ", ") + ")",
+		"postgres": "NoIndexScan(" + table + ")",
+	}}
+}
+
+// RawHint 只在方言 dialect (mysql / postgres) 下生效的提示原文，如 RawHint("mysql", "BKA(t1)")
+func RawHint(dialect, text string) Hint {
+	if strings.Contains(text, "*/") || strings.Contains(text, "?") {
+		return Hint{err: fmt.Errorf("invalid hint %q", text)}
+	}
+	return Hint{text: map[string]string{dialect: text}}
+}
+
+// checkHintIdents 检查提示中的表名与索引名
+func checkHintIdents(table string, indexes []string) error {
+	if !hintIdent.MatchString(table) {
+		return fmt.Errorf("invalid hint table %q", table)
+	}
+	if len(indexes) == 0 {
+		return fmt.Errorf("hint for table %q requires an index", table)
+	}
+	for _, index := range indexes {
+		if !hintIdent.MatchString(index) {
+			return fmt.Errorf("invalid hint index %q", index)
+		}
+	}
+	return nil
+}
+
+// Hint 为查询添加优化器提示，按当前方言渲染，不支持的提示被忽略；多次调用时提示依次追加
+//
+//	w := gomp.NewQueryWrapper[Order]().Eq("status", "paid").
+//		Hint(gomp.MaxExecutionTime(500), gomp.UseIndex("order", "idx_status"))
+//	// MySQL:    SELECT /*+ MAX_EXECUTION_TIME(500) INDEX(order idx_status) */ * FROM `order` WHERE status = 'paid'
+//	// Postgres: /*+ IndexScan(order idx_status) */ SELECT * FROM "order" WHERE status = 'paid'
+//	// SQLite:   SELECT * FROM `order` WHERE status = 'paid'
+func (w *QueryWrapper[T]) Hint(hints ...Hint) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		dialect := db.Dialector.Name()
+		texts := make([]string, 0, len(hints))
+		for _, h := range hints {
+			if h.err != nil {
+				_ = db.AddError(h.err)
+				return db
+			}
+			if text, ok := h.text[dialect]; ok {
+				texts = append(texts, text)
+			}
+		}
+		if len(texts) == 0 {
+			return db
+		}
+		if existing, ok := db.Get(hintsKey); ok {
+			texts = append(append([]string(nil), existing.([]string)...), texts...)
+		}
+		return db.Set(hintsKey, texts)
+	})
+	return w
+}
+
+// hintComment 优化器提示注释
+type hintComment []string
+
+// Build 实现 clause.Expression
+func (c hintComment) Build(builder clause.Builder) {
+	builder.WriteString("/*+ " + strings.Join(c, " ") + " */")
+}
+
+// commentList 依次输出的多个注释
+type commentList []clause.Expression
+
+// Build 实现 clause.Expression
+func (l commentList) Build(builder clause.Builder) {
+	for i, expr := range l {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		expr.Build(builder)
+	}
+}
+
+// hintStatement 查询语句构建前写入优化器提示: MySQL 写在 SELECT 之后，Postgres 写在语句开头 (早于调试标签，pg_hint_plan 只读取开头的提示注释)
+func hintStatement(db *gorm.DB) {
+	if !isManaged(db) {
+		return
+	}
+	v, ok := db.Get(hintsKey)
+	if !ok {
+		return
+	}
+	hints := hintComment(v.([]string))
+	c := db.Statement.Clauses["SELECT"]
+	switch db.Dialector.Name() {
+	case "mysql":
+		c.AfterNameExpression = hints
+	case "postgres":
+		c.BeforeExpression = hints
+	default:
+		return
+	}
+	db.Statement.Clauses["SELECT"] = c
+}
@@ -0,0 +1,98 @@
+package gomp
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// HookPoint 生命周期钩子触发点
+type HookPoint string
+
+const (
+	BeforeSave   HookPoint = "beforeSave"   // Save / SaveBatch / Insert 执行前
+	AfterSave    HookPoint = "afterSave"    // Save / SaveBatch / Insert 执行成功后
+	BeforeUpdate HookPoint = "beforeUpdate" // UpdateById / Update 执行前
+	AfterUpdate  HookPoint = "afterUpdate"  // UpdateById / Update 执行成功后
+	BeforeDelete HookPoint = "beforeDelete" // RemoveById / RemoveByIds / Delete (含 RemoveByIdsCount / RemoveByIdsChunked / DeleteCount) 执行前
+	AfterDelete  HookPoint = "afterDelete"  // RemoveById / RemoveByIds / Delete (含 RemoveByIdsCount / RemoveByIdsChunked / DeleteCount) 执行成功后
+)
+
+// HookEvent 钩子参数，按触发方法填充 Entity / Ids / Wrapper 之一
+type HookEvent[T any] struct {
+	Method  string // 触发的 Service 方法，如 Save / Update
+	Entity  *T     // Save / SaveBatch (逐条触发) / UpdateById 的实体
+	Ids     any    // RemoveById / RemoveByIds / RemoveByIdsCount 的主键 (RemoveByIdsChunked 为每批的主键)
+	Wrapper any    // Insert / Update / Delete 的 Wrapper
+}
+
+// Hook 实体生命周期钩子，Before 钩子返回错误会中断操作，After 钩子的错误会作为方法的返回值
+type Hook[T any] func(ctx context.Context, event *HookEvent[T]) error
+
+var (
+	hooksMu sync.RWMutex
+	hooks   = make(map[reflect.Type]map[HookPoint][]any)
+)
+
+// RegisterHook 为实体 T 注册生命周期钩子，同一触发点按注册顺序执行
+// 钩子在 gomp 层执行，与 GORM 回调及实体方法无关，适合实现跨实体的业务规则
+func RegisterHook[T any](point HookPoint, hook Hook[T]) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	typ := entityType[T]()
+	if hooks[typ] == nil {
+		hooks[typ] = make(map[HookPoint][]any)
+	}
+	hooks[typ][point] = append(hooks[typ][point], hook)
+}
+
+// hasHooks 判断实体 T 在触发点是否注册了钩子
+func hasHooks[T any](point HookPoint) bool {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return len(hooks[entityType[T]()][point]) > 0
+}
+
+// runHooks 执行实体 T 在触发点注册的钩子
+func runHooks[T any](ctx context.Context, point HookPoint, event *HookEvent[T]) error {
+	hooksMu.RLock()
+	registered := hooks[entityType[T]()][point]
+	hooksMu.RUnlock()
+	for _, h := range registered {
+		if err := h.(Hook[T])(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runEntityHooks 对每个实体逐条执行钩子
+func runEntityHooks[T any](ctx context.Context, point HookPoint, method string, entities ...*T) error {
+	if !hasHooks[T](point) {
+		return nil
+	}
+	for _, entity := range entities {
+		if err := runHooks(ctx, point, &HookEvent[T]{Method: method, Entity: entity}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterHooks 写操作成功后发布实体变更事件并执行 After 钩子，rows 为写操作影响的行数
+func (s *ServiceImpl[T]) afterHooks(ctx context.Context, point HookPoint, rows int64, event *HookEvent[T]) error {
+	s.publishChange(ctx, point, rows, event)
+	return runHooks(ctx, point, event)
+}
+
+// afterEntityHooks 对每个实体发布变更事件并逐条执行 After 钩子
+func (s *ServiceImpl[T]) afterEntityHooks(ctx context.Context, point HookPoint, method string, rows int64, entities ...*T) error {
+	if hasChangeSinks[T]() {
+		events := make([]*HookEvent[T], len(entities))
+		for i, entity := range entities {
+			events[i] = &HookEvent[T]{Method: method, Entity: entity}
+		}
+		s.publishChange(ctx, point, rows, events...)
+	}
+	return runEntityHooks(ctx, point, method, entities...)
+}
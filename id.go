@@ -0,0 +1,207 @@
+package gomp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 主键生成策略 (对应配置 idType 或主键字段的 gomp:"id:<策略>" 标签)
+const (
+	IdTypeAuto      = "auto"      // 数据库自增 (默认)
+	IdTypeInput     = "input"     // 由调用方设置主键
+	IdTypeUUID      = "uuid"      // 主键为空时自动生成 UUID (主键需为 string 类型)
+	IdTypeUUIDv7    = "uuidv7"    // 主键为空时自动生成按时间有序的 UUID v7 (主键需为 string 类型)
+	IdTypeULID      = "ulid"      // 主键为空时自动生成 ULID (主键需为 string 类型)
+	IdTypeSnowflake = "snowflake" // 主键为空时自动生成雪花 ID (主键需为整数或 string 类型)
+	IdTypeSequence  = "sequence"  // 主键为空时从数据库序列分配 (Postgres / Oracle / SQL Server)
+)
+
+// ErrIdNotBackfilled 插入后实体主键未被回填，通常是驱动不支持返回自增主键
+var ErrIdNotBackfilled = errors.New("primary key was not backfilled after insert")
+
+// IdGenerator 主键生成器，通过 RegisterIdGenerator 注册后可作为 idType 使用
+type IdGenerator interface {
+	NextId(ctx context.Context) (any, error)
+}
+
+// IdGeneratorFunc 函数形式的主键生成器
+type IdGeneratorFunc func(ctx context.Context) (any, error)
+
+// NextId 实现 IdGenerator
+func (f IdGeneratorFunc) NextId(ctx context.Context) (any, error) {
+	return f(ctx)
+}
+
+var (
+	idGeneratorsMu sync.RWMutex
+	idGenerators   = map[string]IdGenerator{
+		IdTypeUUID: IdGeneratorFunc(func(context.Context) (any, error) {
+			return newUUID()
+		}),
+		IdTypeUUIDv7: IdGeneratorFunc(func(context.Context) (any, error) {
+			return newUUIDv7()
+		}),
+		IdTypeULID: IdGeneratorFunc(func(context.Context) (any, error) {
+			return newULID()
+		}),
+		IdTypeSnowflake: IdGeneratorFunc(func(ctx context.Context) (any, error) {
+			sf, err := defaultSnowflake()
+			if err != nil {
+				return nil, err
+			}
+			return sf.NextId(ctx)
+		}),
+	}
+)
+
+// RegisterIdGenerator 注册主键生成器，可覆盖内置的 uuid / uuidv7 / ulid / snowflake
+//
+//	gomp.RegisterIdGenerator("segment", gomp.IdGeneratorFunc(func(ctx context.Context) (any, error) { ... }))
+func RegisterIdGenerator(name string, generator IdGenerator) {
+	idGeneratorsMu.Lock()
+	defer idGeneratorsMu.Unlock()
+	idGenerators[name] = generator
+}
+
+// lookupIdGenerator 获取已注册的主键生成器
+func lookupIdGenerator(name string) (IdGenerator, bool) {
+	idGeneratorsMu.RLock()
+	defer idGeneratorsMu.RUnlock()
+	generator, ok := idGenerators[name]
+	return generator, ok
+}
+
+// idTypeOf 主键字段的生成策略，gomp:"id:<策略>" 标签优先于全局 idType
+func idTypeOf(field *schema.Field) string {
+	if v, ok := gompTagValue(field, "id"); ok && v != "" {
+		return v
+	}
+	return getConfig().IdType
+}
+
+// assignId 按主键字段的 gomp:"id" 标签或全局 idType 为实体主键赋值
+func assignId(ctx context.Context, db *gorm.DB, sch *schema.Schema, entity any) error {
+	field := sch.PrioritizedPrimaryField
+	if field == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(entity)
+	if _, zero := field.ValueOf(ctx, rv); !zero {
+		return nil
+	}
+
+	idType := idTypeOf(field)
+	switch idType {
+	case "", IdTypeAuto:
+		return nil
+	case IdTypeInput:
+		return fmt.Errorf("primary key %s of %s is required when idType is %q", field.Name, sch.Name, IdTypeInput)
+	}
+	var id any
+	if idType == IdTypeSequence {
+		seq, err := nextSequenceId(ctx, db, sequenceName(ctx, sch, field))
+		if err != nil {
+			return err
+		}
+		id = seq
+	} else {
+		generator, ok := lookupIdGenerator(idType)
+		if !ok {
+			return fmt.Errorf("unsupported idType %q", idType)
+		}
+		var err error
+		if id, err = generator.NextId(ctx); err != nil {
+			return err
+		}
+	}
+	// 整数 ID 写入 string 主键时转为十进制字符串
+	if field.FieldType.Kind() == reflect.String {
+		switch v := id.(type) {
+		case int64:
+			id = strconv.FormatInt(v, 10)
+		case uint64:
+			id = strconv.FormatUint(v, 10)
+		}
+	}
+	return field.Set(ctx, rv, id)
+}
+
+// checkIdsBackfilled 校验插入后实体的主键均已回填 (数据库生成的主键由驱动通过 RETURNING / LastInsertId 回填)
+// 未回填时返回 ErrIdNotBackfilled，DryRun 模式下不校验
+func checkIdsBackfilled[T any](ctx context.Context, db *gorm.DB, sch *schema.Schema, entities ...*T) error {
+	field := sch.PrioritizedPrimaryField
+	if field == nil || db.DryRun {
+		return nil
+	}
+	for i, entity := range entities {
+		if _, zero := field.ValueOf(ctx, reflect.ValueOf(entity)); zero {
+			return fmt.Errorf("%w: %s #%d", ErrIdNotBackfilled, sch.Name, i)
+		}
+	}
+	return nil
+}
+
+// newUUID 生成 UUID v4
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// newUUIDv7 生成 UUID v7: 48 位毫秒时间戳 + 随机数，按生成时间有序，适合作为索引主键
+func newUUIDv7() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putTimestamp48(b[:6], time.Now().UnixMilli())
+	b[6] = (b[6] & 0x0f) | 0x70
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// crockford ULID 使用的 Crockford Base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID 生成 ULID: 48 位毫秒时间戳 + 80 位随机数，编码为 26 位 Crockford Base32 字符串
+func newULID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	putTimestamp48(b[:6], time.Now().UnixMilli())
+
+	// 128 位按 5 位一组编码，首字符只有 3 位有效
+	var out [26]byte
+	hi := uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32 |
+		uint64(b[4])<<24 | uint64(b[5])<<16 | uint64(b[6])<<8 | uint64(b[7])
+	lo := uint64(b[8])<<56 | uint64(b[9])<<48 | uint64(b[10])<<40 | uint64(b[11])<<32 |
+		uint64(b[12])<<24 | uint64(b[13])<<16 | uint64(b[14])<<8 | uint64(b[15])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
+
+// putTimestamp48 以大端序写入 48 位毫秒时间戳
+func putTimestamp48(b []byte, ms int64) {
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
@@ -0,0 +1,163 @@
+package gomp_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// backfillUser 使用数据库自增主键的实体
+type backfillUser struct {
+	ID   int64 `gorm:"primaryKey;autoIncrement"`
+	Name string
+}
+
+// backfillDBs 各数据库的测试连接: SQLite 始终运行，MySQL / Postgres 通过
+// GOMP_TEST_MYSQL_DSN / GOMP_TEST_POSTGRES_DSN 提供 DSN，未设置时跳过
+func backfillDBs() map[string]func(t *testing.T) *gorm.DB {
+	open := func(env string, dialector func(dsn string) gorm.Dialector) func(t *testing.T) *gorm.DB {
+		return func(t *testing.T) *gorm.DB {
+			dsn := os.Getenv(env)
+			if dsn == "" {
+				t.Skipf("%s is not set", env)
+			}
+			db, err := gorm.Open(dialector(dsn), &gorm.Config{Logger: logger.Discard})
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			migrator := db.Migrator()
+			_ = migrator.DropTable(&backfillUser{})
+			if err := migrator.CreateTable(&backfillUser{}); err != nil {
+				t.Fatalf("create table: %v", err)
+			}
+			t.Cleanup(func() {
+				_ = migrator.DropTable(&backfillUser{})
+				if sqlDB, err := db.DB(); err == nil {
+					_ = sqlDB.Close()
+				}
+			})
+			return db
+		}
+	}
+	return map[string]func(t *testing.T) *gorm.DB{
+		"sqlite":   func(t *testing.T) *gorm.DB { return gomptest.NewDB(t, &backfillUser{}) },
+		"mysql":    open("GOMP_TEST_MYSQL_DSN", mysql.Open),
+		"postgres": open("GOMP_TEST_POSTGRES_DSN", postgres.Open),
+	}
+}
+
+// assertBackfilled 校验每个实体的主键都已回填且与数据库中同一行对应
+func assertBackfilled(t *testing.T, svc *gomp.ServiceImpl[backfillUser], users []*backfillUser) {
+	t.Helper()
+	ctx := context.Background()
+	seen := make(map[int64]bool, len(users))
+	for i, u := range users {
+		if u.ID == 0 {
+			t.Fatalf("user #%d: primary key not backfilled", i)
+		}
+		if seen[u.ID] {
+			t.Fatalf("user #%d: duplicate primary key %d", i, u.ID)
+		}
+		seen[u.ID] = true
+		got, err := svc.GetById(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("user #%d: get %d: %v", i, u.ID, err)
+		}
+		if got.Name != u.Name {
+			t.Fatalf("user #%d: id %d belongs to %q, want %q", i, u.ID, got.Name, u.Name)
+		}
+	}
+}
+
+func TestSaveBatchBackfillsIdsPerRow(t *testing.T) {
+	for name, open := range backfillDBs() {
+		t.Run(name, func(t *testing.T) {
+			svc := gomp.NewServiceImpl[backfillUser](open(t))
+			// 250 行按每批 100 行分 3 批写入
+			users := make([]*backfillUser, 250)
+			for i := range users {
+				users[i] = &backfillUser{Name: fmt.Sprintf("user%d", i)}
+			}
+			if err := svc.SaveBatch(context.Background(), users); err != nil {
+				t.Fatalf("save batch: %v", err)
+			}
+			assertBackfilled(t, svc, users)
+		})
+	}
+}
+
+func TestSaveBackfillsId(t *testing.T) {
+	for name, open := range backfillDBs() {
+		t.Run(name, func(t *testing.T) {
+			svc := gomp.NewServiceImpl[backfillUser](open(t))
+			users := []*backfillUser{{Name: "a"}, {Name: "b"}}
+			for _, u := range users {
+				if err := svc.Save(context.Background(), u); err != nil {
+					t.Fatalf("save: %v", err)
+				}
+			}
+			assertBackfilled(t, svc, users)
+		})
+	}
+}
+
+func TestInsertMultiRowBackfillsIdsPerRow(t *testing.T) {
+	for name, open := range backfillDBs() {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := gomp.NewServiceImpl[backfillUser](open(t))
+			w := gomp.NewInsertWrapper[backfillUser]()
+			for i := range 5 {
+				w.Set("name", fmt.Sprintf("user%d", i)).NextRow()
+			}
+			if err := svc.Insert(ctx, w); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+			users, err := svc.List(ctx, gomp.NewQueryWrapper[backfillUser]().OrderByAsc("id"))
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(users) != 5 {
+				t.Fatalf("got %d rows, want 5", len(users))
+			}
+			for i, u := range users {
+				if want := fmt.Sprintf("user%d", i); u.Name != want {
+					t.Fatalf("row #%d: got %q, want %q", i, u.Name, want)
+				}
+			}
+			assertBackfilled(t, svc, users)
+		})
+	}
+}
+
+func TestInsertReturningBackfillsIdsPerRow(t *testing.T) {
+	for name, open := range backfillDBs() {
+		t.Run(name, func(t *testing.T) {
+			if name == "mysql" {
+				t.Skip("mysql does not support RETURNING")
+			}
+			ctx := context.Background()
+			svc := gomp.NewServiceImpl[backfillUser](open(t))
+			w := gomp.NewInsertWrapper[backfillUser]()
+			for i := range 5 {
+				w.Set("name", fmt.Sprintf("user%d", i)).NextRow()
+			}
+			users, err := svc.InsertReturning(ctx, w)
+			if err != nil {
+				t.Fatalf("insert returning: %v", err)
+			}
+			if len(users) != 5 {
+				t.Fatalf("got %d rows, want 5", len(users))
+			}
+			assertBackfilled(t, svc, users)
+		})
+	}
+}
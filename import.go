@@ -0,0 +1,258 @@
+package gomp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm/schema"
+)
+
+// ImportOptions Import 的选项
+type ImportOptions[T any] struct {
+	Columns    map[string]string                       // 源列名 (CSV 表头 / JSON 键) -> 实体列名或字段名；未配置的源列按名称匹配，无法匹配的列被忽略
+	Converters map[string]func(value any) (any, error) // 实体列名 -> 值转换函数，CSV 的值为 string，JSON 的值为解码结果 (数字为 json.Number)
+	Validate   func(entity *T) error                   // 行校验钩子，返回错误时该行记为失败，不写入
+	BatchSize  int                                     // 每批写入的行数，默认 1000
+	Bulk       bool                                    // 使用 BulkInsert 写入，默认使用 SaveBatch
+	MaxErrors  int                                     // 失败行数达到 MaxErrors 时停止导入并返回 ErrImportAborted，0 表示不限制
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Total    int64         // 读取的数据行数
+	Imported int64         // 成功写入的行数
+	Errors   []ImportError // 失败的行，按行号排列
+}
+
+// ImportError 导入失败的行
+type ImportError struct {
+	Row int64 // 数据行号，从 1 开始 (不含 CSV 表头)
+	Err error
+}
+
+// Error 实现 error
+func (e ImportError) Error() string {
+	return "row " + strconv.FormatInt(e.Row, 10) + ": " + e.Err.Error()
+}
+
+// Unwrap 返回行的原始错误
+func (e ImportError) Unwrap() error {
+	return e.Err
+}
+
+// ErrImportAborted 失败行数达到 ImportOptions.MaxErrors，导入已停止
+var ErrImportAborted = errors.New("import aborted: too many row errors")
+
+// Import 从 r 读取 CSV (首行为表头) 或 JSON Lines 并按列映射写入实体，逐行报告解析、转换、校验与写入错误
+// 解析、转换或校验失败的行被跳过，写入失败时该批的所有行记为失败；读取 r 失败时返回错误，已写入的数据不会回滚
+//
+//	result, err := userService.Import(ctx, file, gomp.ExportCSV, gomp.ImportOptions[User]{
+//		Columns:  map[string]string{"姓名": "name", "手机号": "phone"},
+//		Validate: func(u *User) error { return validate.Struct(u) },
+//	})
+func (s *ServiceImpl[T]) Import(ctx context.Context, r io.Reader, format ExportFormat, opts ImportOptions[T]) (*ImportResult, error) {
+	return invoke(s, ctx, "Import", nil, []any{r, format, opts}, func(ctx context.Context) (*ImportResult, error) {
+		sch, err := parseSchema[T](s.DB)
+		if err != nil {
+			return nil, err
+		}
+		if opts.BatchSize <= 0 {
+			opts.BatchSize = 1000
+		}
+		im := &importer[T]{s: s, sch: sch, opts: opts, result: &ImportResult{}}
+		switch format {
+		case ExportCSV:
+			err = im.readCSV(ctx, r)
+		case ExportJSONLines:
+			err = im.readJSONLines(ctx, r)
+		default:
+			return nil, fmt.Errorf("unsupported import format %q", format)
+		}
+		if err == nil {
+			err = im.flush(ctx)
+		}
+		return im.result, err
+	})
+}
+
+// importer 一次导入的状态
+type importer[T any] struct {
+	s      *ServiceImpl[T]
+	sch    *schema.Schema
+	opts   ImportOptions[T]
+	result *ImportResult
+	batch  []*T
+	rows   []int64 // batch 中各实体的行号
+}
+
+// field 源列名对应的实体字段，无法匹配时返回 nil
+func (im *importer[T]) field(name string) *schema.Field {
+	if column, ok := im.opts.Columns[name]; ok {
+		name = column
+	}
+	if field := im.sch.LookUpField(name); field != nil && field.DBName != "" {
+		return field
+	}
+	return nil
+}
+
+// readCSV 逐行读取 CSV，首行为表头
+func (im *importer[T]) readCSV(ctx context.Context, r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fields := make([]*schema.Field, len(header))
+	for i, name := range header {
+		fields[i] = im.field(name)
+	}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return err
+		}
+		im.result.Total++
+		if err != nil {
+			if err := im.fail(im.result.Total, err); err != nil {
+				return err
+			}
+			continue
+		}
+		entity := new(T)
+		rv := reflect.ValueOf(entity)
+		for i, value := range record {
+			if i >= len(fields) || fields[i] == nil || value == "" {
+				continue
+			}
+			if err = im.set(ctx, rv, fields[i], value); err != nil {
+				break
+			}
+		}
+		if err := im.add(ctx, entity, err); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSONLines 逐行读取 JSON Lines，空行被跳过
+func (im *importer[T]) readJSONLines(ctx context.Context, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			im.result.Total++
+			var values map[string]any
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.UseNumber()
+			rowErr := dec.Decode(&values)
+			entity := new(T)
+			if rowErr == nil {
+				rv := reflect.ValueOf(entity)
+				for name, value := range values {
+					field := im.field(name)
+					if field == nil || value == nil {
+						continue
+					}
+					if rowErr = im.set(ctx, rv, field, value); rowErr != nil {
+						break
+					}
+				}
+			}
+			if err := im.add(ctx, entity, rowErr); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// set 转换并设置字段值
+func (im *importer[T]) set(ctx context.Context, rv reflect.Value, field *schema.Field, value any) error {
+	if convert, ok := im.opts.Converters[field.DBName]; ok {
+		v, err := convert(value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", field.DBName, err)
+		}
+		value = v
+	} else if n, ok := value.(json.Number); ok {
+		value = n.String()
+	}
+	if err := field.Set(ctx, rv, value); err != nil {
+		return fmt.Errorf("column %s: %w", field.DBName, err)
+	}
+	return nil
+}
+
+// add 校验并加入待写入批次，rowErr 不为 nil 时记为失败行
+func (im *importer[T]) add(ctx context.Context, entity *T, rowErr error) error {
+	row := im.result.Total
+	if rowErr == nil && im.opts.Validate != nil {
+		rowErr = im.opts.Validate(entity)
+	}
+	if rowErr != nil {
+		return im.fail(row, rowErr)
+	}
+	im.batch = append(im.batch, entity)
+	im.rows = append(im.rows, row)
+	if len(im.batch) >= im.opts.BatchSize {
+		return im.flush(ctx)
+	}
+	return nil
+}
+
+// fail 记录失败行，达到 MaxErrors 时返回 ErrImportAborted
+func (im *importer[T]) fail(row int64, err error) error {
+	im.result.Errors = append(im.result.Errors, ImportError{Row: row, Err: err})
+	if im.opts.MaxErrors > 0 && len(im.result.Errors) >= im.opts.MaxErrors {
+		return ErrImportAborted
+	}
+	return nil
+}
+
+// flush 写入当前批次，失败时该批的所有行记为失败
+func (im *importer[T]) flush(ctx context.Context) error {
+	if len(im.batch) == 0 {
+		return nil
+	}
+	batch, rows := im.batch, im.rows
+	im.batch, im.rows = nil, nil
+	var err error
+	if im.opts.Bulk {
+		_, err = im.s.bulkInsert(ctx, "Import", batch, BulkOptions{BatchSize: im.opts.BatchSize})
+	} else {
+		err = im.s.saveBatch(ctx, "Import", batch, SaveOptions{})
+	}
+	if err == nil {
+		im.result.Imported += int64(len(batch))
+		return nil
+	}
+	for _, row := range rows {
+		if ferr := im.fail(row, err); ferr != nil {
+			return ferr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SQL 检查规则
+const (
+	SQLRuleNoWhere  = "no_where"  // 更新 / 删除没有 WHERE 条件，或查询既没有 WHERE 也没有 LIMIT
+	SQLRuleFullScan = "full_scan" // 全表扫描
+	SQLRuleFilesort = "filesort"  // 排序无法利用索引
+	SQLRuleWrapper  = "wrapper"   // Wrapper 存在明显错误的构造，见 QueryWrapper.Validate
+)
+
+// SQLWarning SQL 检查发现的问题
+type SQLWarning struct {
+	Rule    string   // 触发的规则，如 SQLRuleFullScan
+	Message string   // 问题描述
+	SQL     string   // 参数内联后的 SQL (敏感参数已脱敏)
+	Tables  []string // 涉及的表
+	Plan    *Plan    // 执行计划，SQLRuleNoWhere 为 nil
+	Caller  string   // 发起调用的代码位置 (file:line)
+}
+
+// SQLInspector 开发期 SQL 检查配置
+type SQLInspector struct {
+	MinRows      float64                                 // 预估扫描行数不小于该值时才报告全表扫描与额外排序 (数据库未提供行数时总是报告)，0 表示全部报告
+	IgnoreTables []string                                // 不检查的表
+	OnWarning    func(ctx context.Context, w SQLWarning) // 发现问题时的回调，默认通过 slog.Default() 以 WARN 级别输出
+
+	seen sync.Map // 已检查的语句 (带占位符)
+}
+
+// activeSQLInspector 当前生效的 SQL 检查，为 nil 时关闭
+var activeSQLInspector atomic.Pointer[SQLInspector]
+
+// EnableSQLInspector 开启开发期 SQL 检查 (类似 MyBatis-Plus 的 IllegalSQLInnerInterceptor)，传入 nil 关闭
+// gomp 执行的查询、更新、删除语句成功后 EXPLAIN 一次 (相同语句只检查一次)，发现缺少 WHERE 条件、全表扫描或额外排序时报告；
+// Service 方法执行前还会检查 Wrapper (见 QueryWrapper.Validate)
+// 检查在语句执行之后进行，不会阻止语句执行；每条新语句都会额外执行 EXPLAIN，仅建议在开发、测试环境开启
+//
+//	gomp.EnableSQLInspector(&gomp.SQLInspector{MinRows: 1000})
+func EnableSQLInspector(inspector *SQLInspector) {
+	activeSQLInspector.Store(inspector)
+}
+
+// ignored 表是否不检查
+func (i *SQLInspector) ignored(table string) bool {
+	return slices.Contains(i.IgnoreTables, table)
+}
+
+// large 预估行数是否达到报告阈值
+func (i *SQLInspector) large(rows float64) bool {
+	return rows == 0 || rows >= i.MinRows
+}
+
+// warn 报告问题
+func (i *SQLInspector) warn(ctx context.Context, w SQLWarning) {
+	if i.OnWarning != nil {
+		i.OnWarning(ctx, w)
+		return
+	}
+	slog.Default().WarnContext(ctx, "gomp illegal sql",
+		slog.String("rule", w.Rule),
+		slog.String("message", w.Message),
+		slog.String("sql", w.SQL),
+		slog.String("caller", w.Caller),
+	)
+}
+
+// inspectStatement 语句执行成功后按 SQLInspector 的规则检查
+func inspectStatement(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		inspector := activeSQLInspector.Load()
+		if inspector == nil || !isManaged(db) || db.Error != nil || db.Statement.SQL.Len() == 0 {
+			return
+		}
+		stmt := db.Statement
+		query := stmt.SQL.String()
+		if _, loaded := inspector.seen.LoadOrStore(query, struct{}{}); loaded {
+			return
+		}
+		table := stmt.Table
+		if table == "" && stmt.Schema != nil {
+			table = stmt.Schema.Table
+		}
+		if inspector.ignored(table) {
+			return
+		}
+		ctx := stmt.Context
+		sql := db.Dialector.Explain(query, redactArgs(query, stmt.Vars, stmt.Schema)...)
+		caller := callerLocation()
+
+		where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where)
+		if len(where.Exprs) == 0 {
+			limit, _ := stmt.Clauses["LIMIT"].Expression.(clause.Limit)
+			if operation != OperationQuery && operation != OperationRow || limit.Limit == nil {
+				inspector.warn(ctx, SQLWarning{
+					Rule:    SQLRuleNoWhere,
+					Message: fmt.Sprintf("%s on %s without WHERE clause", operation, table),
+					SQL:     sql,
+					Tables:  []string{table},
+					Caller:  caller,
+				})
+			}
+		}
+
+		plan, err := explainStatement(ctx, db)
+		if err != nil {
+			return
+		}
+		var fullScans []string
+		var maxRows float64
+		for _, node := range plan.Nodes {
+			maxRows = max(maxRows, node.Rows)
+			if node.FullScan && !inspector.ignored(node.Table) && inspector.large(node.Rows) {
+				fullScans = append(fullScans, node.Table)
+			}
+		}
+		if len(fullScans) > 0 {
+			inspector.warn(ctx, SQLWarning{
+				Rule:    SQLRuleFullScan,
+				Message: fmt.Sprintf("full table scan on %v", fullScans),
+				SQL:     sql,
+				Tables:  fullScans,
+				Plan:    &plan,
+				Caller:  caller,
+			})
+		}
+		if plan.Filesort && inspector.large(maxRows) {
+			inspector.warn(ctx, SQLWarning{
+				Rule:    SQLRuleFilesort,
+				Message: fmt.Sprintf("sort on %s cannot use an index", table),
+				SQL:     sql,
+				Tables:  []string{table},
+				Plan:    &plan,
+				Caller:  caller,
+			})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package gomp
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// labelKey 调试标签的 context key
+type labelKey struct{}
+
+// WithQueryLabel 返回携带调试标签的 ctx，通过该 ctx 执行的 gomp 语句都带有该标签 (Wrapper 的 Label 优先)
+// 查询、更新、删除语句以注释形式写入标签 (/* label */ SELECT ...)，所有语句的 SQLEvent、SQLMetric 与 StatementStats 都带有该标签
+func WithQueryLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
+
+// QueryLabel ctx 中的调试标签，未设置时为空；可在拦截器或 Logger 中读取，用于关联链路追踪
+func QueryLabel(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	label, _ := ctx.Value(labelKey{}).(string)
+	return label
+}
+
+// labeled 带有调试标签的 Wrapper
+type labeled interface {
+	queryLabel() string
+}
+
+// withWrapperLabel Wrapper 设置了调试标签时返回携带该标签的 ctx
+func withWrapperLabel(ctx context.Context, wrapper any) context.Context {
+	if w, ok := wrapper.(labeled); ok {
+		if label := w.queryLabel(); label != "" {
+			return WithQueryLabel(ctx, label)
+		}
+	}
+	return ctx
+}
+
+// labelComment 调试标签的 SQL 注释
+type labelComment string
+
+// Build 实现 clause.Expression，字母、数字与 -_.:/ 空格之外的字符替换为 _，避免破坏注释或被当作占位符 (? / $1)
+func (c labelComment) Build(builder clause.Builder) {
+	label := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:/ ", r) {
+			return r
+		}
+		return '_'
+	}, string(c))
+	builder.WriteString("/* " + label + " */")
+}
+
+// labelStatement 查询、更新、删除语句构建前将调试标签作为注释加在 SQL 开头
+func labelStatement(db *gorm.DB) {
+	if !isManaged(db) || len(db.Statement.BuildClauses) == 0 {
+		return
+	}
+	label := QueryLabel(db.Statement.Context)
+	if label == "" {
+		return
+	}
+	name := db.Statement.BuildClauses[0]
+	c := db.Statement.Clauses[name]
+	if c.BeforeExpression != nil {
+		// 保留已有的前置注释 (如 Postgres 的优化器提示)
+		c.BeforeExpression = commentList{c.BeforeExpression, labelComment(label)}
+	} else {
+		c.BeforeExpression = labelComment(label)
+	}
+	db.Statement.Clauses[name] = c
+}
@@ -0,0 +1,178 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLimitExceeded Service 方法调用超过 Limiter 的并发或速率限制，且无法在排队时限内获得执行机会
+var ErrLimitExceeded = errors.New("service call limit exceeded")
+
+// LimitOptions Limiter 的配置，各项为 0 时不限制
+type LimitOptions struct {
+	MaxConcurrent int           // 同时执行的最大调用数
+	Rate          float64       // 每秒允许的调用数
+	Burst         int           // 速率限制允许的突发调用数，默认为 Rate 向上取整 (至少为 1)
+	MaxQueue      int           // 达到限制时最多排队等待的调用数，为 0 时直接返回 ErrLimitExceeded
+	QueueTimeout  time.Duration // 单次调用的最长排队时间，为 0 时只受 ctx 的截止时间约束
+}
+
+// LimiterStats Limiter 的运行状态
+type LimiterStats struct {
+	Active   int64 // 正在执行的调用数
+	Waiting  int64 // 正在排队的调用数
+	Rejected int64 // 累计被拒绝的调用数
+}
+
+// Limiter Service 方法的并发与速率限制器，通过 Middleware 接入一个或多个 Service，接入的 Service 共享同一份限额
+// 用于防止个别接口的突发流量占满共享的连接池
+type Limiter struct {
+	opts LimitOptions
+	sem  chan struct{} // 并发槽位，不限制并发时为 nil
+
+	active, waiting, rejected atomic.Int64
+
+	mu     sync.Mutex
+	tokens float64   // 令牌桶中的令牌，为负数时表示已被预约的未来令牌
+	last   time.Time // 上次补充令牌的时间
+}
+
+// NewLimiter 创建限制器
+//
+//	limiter := gomp.NewLimiter(gomp.LimitOptions{MaxConcurrent: 8, Rate: 200, MaxQueue: 32, QueueTimeout: 500 * time.Millisecond})
+//	reportService := gomp.NewServiceImpl[Order](db).Use(limiter.Middleware())
+func NewLimiter(opts LimitOptions) *Limiter {
+	l := &Limiter{opts: opts}
+	if opts.MaxConcurrent > 0 {
+		l.sem = make(chan struct{}, opts.MaxConcurrent)
+	}
+	if opts.Rate > 0 && l.opts.Burst <= 0 {
+		l.opts.Burst = max(1, int(math.Ceil(opts.Rate)))
+	}
+	l.tokens, l.last = float64(l.opts.Burst), time.Now()
+	return l
+}
+
+// Middleware 返回执行限制的拦截器，超过限制且无法排队时返回 ErrLimitExceeded
+func (l *Limiter) Middleware() Middleware {
+	return func(ctx context.Context, inv *Invocation, next Handler) (any, error) {
+		release, err := l.Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return next(ctx, inv)
+	}
+}
+
+// Stats 当前运行状态
+func (l *Limiter) Stats() LimiterStats {
+	return LimiterStats{Active: l.active.Load(), Waiting: l.waiting.Load(), Rejected: l.rejected.Load()}
+}
+
+// Acquire 获取一次执行机会，成功后须调用 release 归还；也可用于限制 Service 之外的数据库操作
+// 先获取速率令牌再获取并发槽位，并发槽位排队被拒绝或超时时归还令牌
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if err := l.waitRate(ctx); err != nil {
+		return nil, err
+	}
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		default:
+			if err := l.queue(ctx, func(ctx context.Context) error {
+				select {
+				case l.sem <- struct{}{}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}); err != nil {
+				// 未获得执行机会，归还已获取的速率令牌
+				l.refundRate()
+				return nil, err
+			}
+		}
+	}
+	l.active.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.active.Add(-1)
+			if l.sem != nil {
+				<-l.sem
+			}
+		})
+	}, nil
+}
+
+// waitRate 按令牌桶获取速率限额，令牌不足时预约下一个令牌并排队等待
+func (l *Limiter) waitRate(ctx context.Context) error {
+	if l.opts.Rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(float64(l.opts.Burst), l.tokens+now.Sub(l.last).Seconds()*l.opts.Rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.opts.Rate * float64(time.Second))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	err := l.queue(ctx, func(ctx context.Context) error {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// 截止前等不到令牌，不占用等待时间
+			return context.DeadlineExceeded
+		}
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		// 归还预约的令牌
+		l.refundRate()
+	}
+	return err
+}
+
+// refundRate 归还一个速率令牌，令牌数不超过 Burst
+func (l *Limiter) refundRate() {
+	if l.opts.Rate <= 0 {
+		return
+	}
+	l.mu.Lock()
+	l.tokens = min(float64(l.opts.Burst), l.tokens+1)
+	l.mu.Unlock()
+}
+
+// queue 在排队名额与时限内执行 wait
+func (l *Limiter) queue(ctx context.Context, wait func(ctx context.Context) error) error {
+	if l.waiting.Add(1) > int64(l.opts.MaxQueue) {
+		l.waiting.Add(-1)
+		l.rejected.Add(1)
+		return ErrLimitExceeded
+	}
+	defer l.waiting.Add(-1)
+	if l.opts.QueueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.opts.QueueTimeout)
+		defer cancel()
+	}
+	if err := wait(ctx); err != nil {
+		l.rejected.Add(1)
+		return fmt.Errorf("%w: %w", ErrLimitExceeded, err)
+	}
+	return nil
+}
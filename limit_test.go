@@ -0,0 +1,28 @@
+package gomp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+)
+
+func TestLimiterRefundsRateOnConcurrencyRejection(t *testing.T) {
+	ctx := context.Background()
+	limiter := gomp.NewLimiter(gomp.LimitOptions{MaxConcurrent: 1, Rate: 1, Burst: 2})
+	release, err := limiter.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 并发槽位已满，调用被拒绝，不应消耗速率令牌
+	if _, err := limiter.Acquire(ctx); !errors.Is(err, gomp.ErrLimitExceeded) {
+		t.Fatalf("err = %v, want ErrLimitExceeded", err)
+	}
+	release()
+	release, err = limiter.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
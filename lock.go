@@ -0,0 +1,151 @@
+package gomp
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrLockNotAcquired 锁已被占用
+	ErrLockNotAcquired = errors.New("lock not acquired")
+	// ErrNoLocker 未通过 SetLocker 配置锁实现
+	ErrNoLocker = errors.New("no locker configured")
+)
+
+// lockRetryInterval WithLock 等待锁时的重试间隔
+const lockRetryInterval = 50 * time.Millisecond
+
+// Locker 分布式锁实现，内置基于数据库的 DBLocker 与 RedisCache
+type Locker interface {
+	// TryLock 尝试获取锁，成功时返回释放函数，锁已被占用时返回 ErrLockNotAcquired
+	// ttl 为锁的最长持有时间，持有者异常退出时锁在 ttl 后自动释放
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(ctx context.Context) error, err error)
+}
+
+// activeLocker WithLock 使用的锁实现
+var activeLocker atomic.Pointer[Locker]
+
+// SetLocker 设置 WithLock 使用的锁实现，传入 nil 清除
+func SetLocker(l Locker) {
+	if l == nil {
+		activeLocker.Store(nil)
+		return
+	}
+	activeLocker.Store(&l)
+}
+
+// WithLock 获取 key 对应的分布式锁后执行 fn，执行完成后释放锁，用于跨实例串行化 SaveOrUpdate 之类的临界区
+// 锁被占用时等待直到 ctx 结束，超时或取消时返回 ErrLockNotAcquired
+//
+//	err := gomp.WithLock(ctx, "order:"+orderNo, 10*time.Second, func(ctx context.Context) error {
+//	    return orderService.SaveOrUpdate(ctx, order)
+//	})
+func WithLock(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	p := activeLocker.Load()
+	if p == nil {
+		return ErrNoLocker
+	}
+	locker := *p
+	for {
+		unlock, err := locker.TryLock(ctx, key, ttl)
+		if err == nil {
+			defer func() {
+				// ctx 已取消时仍需释放锁
+				_ = unlock(context.WithoutCancel(ctx))
+			}()
+			return fn(ctx)
+		}
+		if !errors.Is(err, ErrLockNotAcquired) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s: %w", ErrLockNotAcquired, key, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// DBLocker 基于数据库会话级锁的 Locker，支持 MySQL (GET_LOCK) 与 PostgreSQL (pg_try_advisory_lock)
+// 持有锁期间占用连接池中的一个连接；锁在释放或连接断开时解除，不使用 ttl。
+// 释放失败时丢弃该连接而不放回连接池，由数据库在会话断开时解除锁
+type DBLocker struct {
+	db *gorm.DB
+}
+
+// NewDBLocker 创建基于数据库的 Locker
+func NewDBLocker(db *gorm.DB) *DBLocker {
+	return &DBLocker{db: db}
+}
+
+// TryLock 实现 Locker
+func (l *DBLocker) TryLock(ctx context.Context, key string, _ time.Duration) (func(ctx context.Context) error, error) {
+	var lockSQL, unlockSQL string
+	var arg any
+	switch dialect := l.db.Dialector.Name(); dialect {
+	case "mysql":
+		lockSQL, unlockSQL, arg = "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)", mysqlLockName(key)
+	case "postgres":
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(key))
+		lockSQL, unlockSQL, arg = "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", int64(h.Sum64())
+	default:
+		return nil, fmt.Errorf("database lock is not supported for %s", dialect)
+	}
+
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var acquired sql.NullBool
+	if err := conn.QueryRowContext(ctx, lockSQL, arg).Scan(&acquired); err != nil {
+		// 无法确定是否已获取锁，丢弃连接以免带着锁回到连接池
+		discardConn(conn)
+		return nil, err
+	}
+	if !acquired.Bool {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: %s", ErrLockNotAcquired, key)
+	}
+	return func(ctx context.Context) error {
+		var released sql.NullBool
+		if err := conn.QueryRowContext(ctx, unlockSQL, arg).Scan(&released); err != nil {
+			// 释放失败时锁仍由该会话持有，且 GET_LOCK 可重入，连接回到连接池后其他调用方会直接获得该锁；
+			// 丢弃连接，由数据库在会话断开时释放锁
+			discardConn(conn)
+			return err
+		}
+		return conn.Close()
+	}, nil
+}
+
+// discardConn 关闭连接且不放回连接池，数据库在会话断开时释放会话级锁
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+	_ = conn.Close()
+}
+
+// mysqlLockName MySQL 锁名最长 64 个字符，超出时使用哈希值
+func mysqlLockName(key string) string {
+	if len(key) <= 64 {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SQLEvent 一次 SQL 执行的信息
+type SQLEvent struct {
+	SQL       string        // 绑定参数后的完整 SQL
+	Statement string        // 带占位符的 SQL
+	Args      []any         // 绑定参数
+	Rows      int64         // 影响 / 返回行数
+	Duration  time.Duration // 执行耗时
+	Err       error         // 执行错误
+	Entity    string        // 实体名称
+	Table     string        // 表名
+	Caller    string        // 发起调用的代码位置 (file:line)
+	Label     string        // 调试标签 (Wrapper.Label / WithQueryLabel)，未设置时为空
+}
+
+// Logger SQL 日志接口，可自行实现以接入项目的日志系统
+type Logger interface {
+	LogSQL(ctx context.Context, event SQLEvent)
+}
+
+// LoggerFunc 函数形式的 Logger
+type LoggerFunc func(ctx context.Context, event SQLEvent)
+
+// LogSQL 实现 Logger
+func (f LoggerFunc) LogSQL(ctx context.Context, event SQLEvent) {
+	f(ctx, event)
+}
+
+// customLogger 用户设置的 Logger
+var customLogger atomic.Pointer[Logger]
+
+// SetLogger 设置 SQL 日志，设置后所有 gomp 执行的语句都会输出到该 Logger；传入 nil 恢复默认行为
+// 未设置时，开启 enableSqlPrint 将使用 StdLogger 输出到标准输出
+func SetLogger(l Logger) {
+	if l == nil {
+		customLogger.Store(nil)
+		return
+	}
+	customLogger.Store(&l)
+}
+
+// activeLogger 获取当前生效的 Logger
+func activeLogger() Logger {
+	if l := customLogger.Load(); l != nil {
+		return *l
+	}
+	if getConfig().EnableSQLPrint {
+		return defaultStdLogger
+	}
+	return nil
+}
+
+// StdLogger 输出到标准输出的默认 Logger
+type StdLogger struct {
+	*log.Logger
+}
+
+// NewStdLogger 创建输出到标准输出的 Logger
+func NewStdLogger() *StdLogger {
+	return &StdLogger{Logger: log.New(os.Stdout, "\r\n", log.LstdFlags)}
+}
+
+var defaultStdLogger = NewStdLogger()
+
+// LogSQL 实现 Logger
+func (l *StdLogger) LogSQL(ctx context.Context, event SQLEvent) {
+	ms := float64(event.Duration.Nanoseconds()) / 1e6
+	if getConfig().PrettySQL {
+		event.SQL = "\n" + FormatSQL(event.SQL)
+	}
+	if event.Err != nil && !errors.Is(event.Err, gorm.ErrRecordNotFound) {
+		l.Printf("%s\n[%.3fms] [rows:%d] %s", event.Err, ms, event.Rows, event.SQL)
+		return
+	}
+	l.Printf("[%.3fms] [rows:%d] %s", ms, event.Rows, event.SQL)
+}
+
+// slowQueryLogger 慢查询日志
+var slowQueryLogger atomic.Pointer[Logger]
+
+// defaultSlowQueryLogger 默认慢查询日志: 通过 slog.Default() 以 WARN 级别输出
+var defaultSlowQueryLogger = NewSlogLogger(nil).WithMessage("gomp slow sql").WithLevel(slog.LevelWarn)
+
+// SetSlowQueryLogger 设置慢查询日志，传入 nil 恢复默认 (slog WARN)
+// 耗时超过 slowQueryThreshold 的语句都会输出到该 Logger，与 enableSqlPrint 无关
+func SetSlowQueryLogger(l Logger) {
+	if l == nil {
+		slowQueryLogger.Store(nil)
+		return
+	}
+	slowQueryLogger.Store(&l)
+}
+
+// activeSlowQueryLogger 获取当前生效的慢查询日志
+func activeSlowQueryLogger() Logger {
+	if l := slowQueryLogger.Load(); l != nil {
+		return *l
+	}
+	return defaultSlowQueryLogger
+}
+
+// sqlEventsEnabled 是否存在 SQL 事件的消费者，没有时跳过事件构造以减少开销
+func sqlEventsEnabled() bool {
+	return activeLogger() != nil || getConfig().SlowQueryThreshold > 0
+}
+
+// emitSQLEvent 分发 SQL 执行事件
+func emitSQLEvent(ctx context.Context, event SQLEvent) {
+	if l := activeLogger(); l != nil {
+		l.LogSQL(ctx, event)
+	}
+	if threshold := getConfig().SlowQueryThreshold; threshold > 0 && event.Duration >= threshold {
+		activeSlowQueryLogger().LogSQL(ctx, event)
+	}
+}
@@ -0,0 +1,66 @@
+package gomp
+
+import (
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 逻辑删除默认值
+const (
+	defaultLogicDeleteValue    = "1"
+	defaultLogicNotDeleteValue = "0"
+)
+
+// logicDeleteField 获取实体的逻辑删除字段，未配置 logicDeleteField 或实体不包含该字段时返回 nil
+func logicDeleteField(sch *schema.Schema) *schema.Field {
+	name := getConfig().LogicDeleteField
+	if name == "" || sch == nil {
+		return nil
+	}
+	return lookUpField(sch, name)
+}
+
+// logicDeleteValues 获取逻辑删除值与未删除值 (按字段类型转换)
+func logicDeleteValues(field *schema.Field) (deleted any, notDeleted any) {
+	cfg := getConfig()
+	deletedRaw, notDeletedRaw := cfg.LogicDeleteValue, cfg.LogicNotDeleteValue
+	if deletedRaw == "" {
+		deletedRaw = defaultLogicDeleteValue
+	}
+	if notDeletedRaw == "" {
+		notDeletedRaw = defaultLogicNotDeleteValue
+	}
+	return convertFieldValue(field, deletedRaw), convertFieldValue(field, notDeletedRaw)
+}
+
+// notDeletedCondition 未删除条件 column = notDeletedValue
+func notDeletedCondition(field *schema.Field) clause.Expression {
+	_, notDeleted := logicDeleteValues(field)
+	return clause.Eq{Column: currentColumn(field.DBName), Value: notDeleted}
+}
+
+// convertFieldValue 将配置中的字符串值转换为字段对应的类型，转换失败时原样返回
+func convertFieldValue(field *schema.Field, raw string) any {
+	typ := field.FieldType
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			return n
+		}
+	}
+	return raw
+}
@@ -0,0 +1,245 @@
+package gomp
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Masker 脱敏函数
+type Masker func(value string) string
+
+var (
+	maskersMu sync.RWMutex
+	maskers   = map[string]Masker{
+		"phone":  maskKeep(3, 4),
+		"email":  maskEmail,
+		"idcard": maskKeep(3, 4),
+	}
+
+	// maskFieldCache 实体中标记为 gomp:"mask:..." 的字段，按 Schema 缓存
+	maskFieldCache sync.Map
+)
+
+// maskField 脱敏字段
+type maskField struct {
+	field  *schema.Field
+	masker string
+}
+
+// RegisterMasker 注册脱敏规则，可覆盖内置规则 (phone / email / idcard)
+func RegisterMasker(name string, m Masker) {
+	maskersMu.Lock()
+	defer maskersMu.Unlock()
+	maskers[strings.ToLower(name)] = m
+}
+
+// RegexMasker 使用正则替换的脱敏规则，如 RegexMasker(`(\d{4})\d+(\d{4})`, "$1****$2")
+func RegexMasker(pattern, replacement string) Masker {
+	re := regexp.MustCompile(pattern)
+	return func(value string) string {
+		return re.ReplaceAllString(value, replacement)
+	}
+}
+
+// maskKeep 保留前 prefix 位与后 suffix 位，其余替换为 *
+func maskKeep(prefix, suffix int) Masker {
+	return func(value string) string {
+		runes := []rune(value)
+		if len(runes) <= prefix+suffix {
+			return strings.Repeat("*", len(runes))
+		}
+		return string(runes[:prefix]) + strings.Repeat("*", len(runes)-prefix-suffix) + string(runes[len(runes)-suffix:])
+	}
+}
+
+// maskEmail 保留邮箱用户名首字符与域名，如 a***@example.com
+func maskEmail(value string) string {
+	name, domain, ok := strings.Cut(value, "@")
+	if !ok || name == "" {
+		return maskKeep(1, 0)(value)
+	}
+	first, _ := utf8.DecodeRuneInString(name)
+	return string(first) + "***@" + domain
+}
+
+// schemaMaskFields 获取实体中的脱敏字段
+func schemaMaskFields(sch *schema.Schema) []maskField {
+	if v, ok := maskFieldCache.Load(sch); ok {
+		return v.([]maskField)
+	}
+	fields := make([]maskField, 0)
+	for _, field := range sch.Fields {
+		if name, ok := gompTagValue(field, "mask"); ok {
+			fields = append(fields, maskField{field: field, masker: strings.ToLower(name)})
+		}
+	}
+	maskFieldCache.Store(sch, fields)
+	return fields
+}
+
+// maskValue 按规则脱敏，规则不存在时整体替换为 *，避免因配置错误泄露数据
+func maskValue(name, value string) string {
+	maskersMu.RLock()
+	m, ok := maskers[name]
+	maskersMu.RUnlock()
+	if !ok {
+		return strings.Repeat("*", utf8.RuneCountInString(value))
+	}
+	return m(value)
+}
+
+// Unmasked 返回不进行结果脱敏的 Service 副本，用于本次调用读取原始数据，
+// 或按主键更新脱敏字段 (脱敏的 Service 按实体更新时忽略脱敏字段，避免把脱敏后的值写回数据库)
+//
+//	user, err := userService.Unmasked().GetById(ctx, id)
+func (s *ServiceImpl[T]) Unmasked() *ServiceImpl[T] {
+	c := *s
+	c.unmasked = true
+	return &c
+}
+
+// mask 对返回给调用方的实体进行脱敏
+func (s *ServiceImpl[T]) mask(ctx context.Context, entities ...*T) error {
+	if s.unmasked || len(entities) == 0 {
+		return nil
+	}
+	sch, err := parseSchema[T](s.DB)
+	if err != nil {
+		return err
+	}
+	fields := schemaMaskFields(sch)
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, entity := range entities {
+		if entity == nil {
+			continue
+		}
+		if err := maskEntity(ctx, fields, reflect.ValueOf(entity)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maskEntity 对单个实体 (指针或可寻址的结构体) 的脱敏字段进行脱敏
+func maskEntity(ctx context.Context, fields []maskField, rv reflect.Value) error {
+	for _, f := range fields {
+		v, zero := f.field.ValueOf(ctx, rv)
+		if zero {
+			continue
+		}
+		var err error
+		switch val := v.(type) {
+		case string:
+			err = f.field.Set(ctx, rv, maskValue(f.masker, val))
+		case *string:
+			if val != nil {
+				err = f.field.Set(ctx, rv, maskValue(f.masker, *val))
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relationTree 预加载的关联路径，如 "Items.Product" 与 "Items" 合并为 {Items: {Product: {}}}
+type relationTree map[string]relationTree
+
+// newRelationTree 由 Preload 的关联名构造关联路径树
+func newRelationTree(relations []string) relationTree {
+	tree := make(relationTree)
+	for _, relation := range relations {
+		node := tree
+		for _, name := range strings.Split(relation, ".") {
+			if node[name] == nil {
+				node[name] = make(relationTree)
+			}
+			node = node[name]
+		}
+	}
+	return tree
+}
+
+// maskRelations 对实体 rv 中预加载的关联实体脱敏，每个关联实体只处理一次
+func (s *ServiceImpl[T]) maskRelations(ctx context.Context, sch *schema.Schema, rv reflect.Value, tree relationTree) error {
+	if s.unmasked {
+		return nil
+	}
+	return maskRelationTree(ctx, sch, rv, tree)
+}
+
+// maskRelationTree 按关联路径树递归脱敏，clause.Associations 表示未单独列出的全部直接关联
+func maskRelationTree(ctx context.Context, sch *schema.Schema, rv reflect.Value, tree relationTree) error {
+	for name, sub := range tree {
+		var rels []*schema.Relationship
+		if name == clause.Associations {
+			for relName, rel := range sch.Relationships.Relations {
+				if _, listed := tree[relName]; !listed {
+					rels = append(rels, rel)
+				}
+			}
+		} else if rel := sch.Relationships.Relations[name]; rel != nil {
+			rels = append(rels, rel)
+		}
+		for _, rel := range rels {
+			fields := schemaMaskFields(rel.FieldSchema)
+			err := eachRelated(rel.Field.ReflectValueOf(ctx, rv), func(elem reflect.Value) error {
+				if err := maskEntity(ctx, fields, elem); err != nil {
+					return err
+				}
+				return maskRelationTree(ctx, rel.FieldSchema, elem, sub)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// eachRelated 遍历关联字段的值 (结构体、指针或切片)，跳过 nil 指针
+func eachRelated(v reflect.Value, fn func(elem reflect.Value) error) error {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := eachRelated(v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return fn(v)
+		}
+	case reflect.Struct:
+		if v.CanAddr() {
+			return fn(v.Addr())
+		}
+	}
+	return nil
+}
+
+// maskedColumns 按实体更新时需要忽略的脱敏列
+// 查询结果已被原地脱敏，修改其他字段后写回时不能覆盖脱敏列的真实值；Unmasked 时返回空
+func (s *ServiceImpl[T]) maskedColumns(sch *schema.Schema) []string {
+	if s.unmasked {
+		return nil
+	}
+	fields := schemaMaskFields(sch)
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.field.DBName != "" {
+			columns = append(columns, f.field.DBName)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,116 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SQL 操作类型
+const (
+	OperationCreate = "create"
+	OperationQuery  = "query"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationRow    = "row"
+	OperationRaw    = "raw"
+)
+
+// SQLMetric 一次 SQL 执行的指标数据，不包含 SQL 文本与参数，可安全地作为监控标签
+type SQLMetric struct {
+	Entity    string        // 实体名称
+	Table     string        // 表名
+	Method    string        // 发起调用的 Service 方法，如 Page / GetById
+	Operation string        // 操作类型: create / query / update / delete / row / raw
+	Duration  time.Duration // 执行耗时
+	Rows      int64         // 影响 / 返回行数
+	BatchSize int           // 批量写入的记录数，仅 create 有效
+	Err       error         // 执行错误 (不含 gorm.ErrRecordNotFound)
+	Label     string        // 调试标签 (Wrapper.Label / WithQueryLabel)，未设置时为空
+}
+
+// MetricsRecorder 指标采集接口，可对接 Prometheus / OpenTelemetry 等监控系统
+type MetricsRecorder interface {
+	RecordSQL(ctx context.Context, metric SQLMetric)
+}
+
+// MetricsRecorderFunc 函数形式的 MetricsRecorder
+type MetricsRecorderFunc func(ctx context.Context, metric SQLMetric)
+
+// RecordSQL 实现 MetricsRecorder
+func (f MetricsRecorderFunc) RecordSQL(ctx context.Context, metric SQLMetric) {
+	f(ctx, metric)
+}
+
+// metricsRecorder 用户设置的指标采集器
+var metricsRecorder atomic.Pointer[MetricsRecorder]
+
+// SetMetricsRecorder 设置指标采集器，gomp 执行的每条语句都会上报一次；传入 nil 关闭采集
+func SetMetricsRecorder(r MetricsRecorder) {
+	if r == nil {
+		metricsRecorder.Store(nil)
+		return
+	}
+	metricsRecorder.Store(&r)
+}
+
+// activeMetricsRecorder 获取当前生效的指标采集器
+func activeMetricsRecorder() MetricsRecorder {
+	if r := metricsRecorder.Load(); r != nil {
+		return *r
+	}
+	return nil
+}
+
+// recordMetric 构造并上报指标
+func recordMetric(db *gorm.DB, operation string, duration time.Duration) {
+	r := activeMetricsRecorder()
+	if r == nil {
+		return
+	}
+	metric := SQLMetric{
+		Table:     db.Statement.Table,
+		Method:    serviceMethod(db.Statement.Context),
+		Operation: operation,
+		Duration:  duration,
+		Rows:      db.Statement.RowsAffected,
+		Label:     QueryLabel(db.Statement.Context),
+	}
+	if db.Statement.Schema != nil {
+		metric.Entity = db.Statement.Schema.Name
+	}
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		metric.Err = db.Error
+	}
+	if operation == OperationCreate {
+		metric.BatchSize = 1
+		if rv := db.Statement.ReflectValue; rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			metric.BatchSize = rv.Len()
+		}
+	}
+	r.RecordSQL(db.Statement.Context, metric)
+}
+
+// serviceMethodKey 发起调用的 Service 方法名的 context key
+type serviceMethodKey struct{}
+
+// withServiceMethod 返回携带 Service 方法名的 ctx，已存在时保留最外层的方法名，如 Page 内部的查询均归属于 Page
+func withServiceMethod(ctx context.Context, method string) context.Context {
+	if _, ok := ctx.Value(serviceMethodKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, serviceMethodKey{}, method)
+}
+
+// serviceMethod ctx 中的 Service 方法名，未经过 Service 方法时为空
+func serviceMethod(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	method, _ := ctx.Value(serviceMethodKey{}).(string)
+	return method
+}
@@ -0,0 +1,91 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Invocation 一次 Service 方法调用的信息
+type Invocation struct {
+	Method  string       // 方法名，如 Save / Page / Update
+	Entity  reflect.Type // 实体类型
+	Wrapper any          // 方法的 Wrapper 参数 (QueryWrapper / UpdateWrapper 等)，没有时为 nil
+	Args    []any        // 方法参数 (不含 ctx)，按声明顺序排列
+}
+
+// Handler 执行 Service 方法，返回值为方法的结果 (无返回值的方法为 nil)
+type Handler func(ctx context.Context, inv *Invocation) (any, error)
+
+// Middleware Service 方法拦截器，调用 next 继续执行，不调用则中断 (如鉴权失败、命中缓存)
+// 返回值需与被拦截方法的结果类型一致，如 List 为 []*T、Page 为 *Page[T]
+type Middleware func(ctx context.Context, inv *Invocation, next Handler) (any, error)
+
+// Use 添加拦截器，按添加顺序由外向内执行；应在 Service 初始化时调用。
+// 在副本 (UseDataSource、DryRun、Unmasked 等) 上调用不影响原 Service 与其他副本
+func (s *ServiceImpl[T]) Use(mw ...Middleware) *ServiceImpl[T] {
+	s.middlewares = append(slices.Clip(s.middlewares), mw...)
+	return s
+}
+
+// invoke 经过拦截器链执行 Service 方法，方法返回的错误经方言的 ErrorTranslator 转换后再交给拦截器
+func invoke[T, R any](s *ServiceImpl[T], ctx context.Context, method string, wrapper any, args []any, fn func(ctx context.Context) (R, error)) (R, error) {
+	ctx = withServiceMethod(ctx, method)
+	ctx = withWrapperLabel(ctx, wrapper)
+	ctx = s.withDryRun(ctx)
+	ctx = s.withSchema(ctx)
+	inspectWrapper(ctx, wrapper)
+	call := fn
+	fn = func(ctx context.Context) (R, error) {
+		r, err := call(ctx)
+		return r, s.translateError(ctx, err)
+	}
+	if len(s.middlewares) == 0 {
+		return fn(ctx)
+	}
+	if v := reflect.ValueOf(wrapper); v.Kind() == reflect.Ptr && v.IsNil() {
+		wrapper = nil
+	}
+	inv := &Invocation{Method: method, Entity: entityType[T](), Wrapper: wrapper, Args: args}
+	handler := Handler(func(ctx context.Context, inv *Invocation) (any, error) {
+		return fn(ctx)
+	})
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		mw, next := s.middlewares[i], handler
+		handler = func(ctx context.Context, inv *Invocation) (any, error) {
+			return mw(ctx, inv, next)
+		}
+	}
+
+	var zero R
+	result, err := handler(ctx, inv)
+	if result == nil {
+		return zero, err
+	}
+	r, ok := result.(R)
+	if !ok {
+		return zero, fmt.Errorf("middleware returned %T for %s, expected %T", result, method, zero)
+	}
+	return r, err
+}
+
+// exec 经过拦截器链执行无返回值的 Service 方法
+func (s *ServiceImpl[T]) exec(ctx context.Context, method string, wrapper any, args []any, fn func(ctx context.Context) error) error {
+	_, err := invoke(s, ctx, method, wrapper, args, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// translateError 使用当前数据源方言的 ErrorTranslator 转换错误
+func (s *ServiceImpl[T]) translateError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	db, rerr := s.resolveDataSource(ctx)
+	if rerr != nil {
+		db = s.DB
+	}
+	return translateError(db, err)
+}
@@ -0,0 +1,31 @@
+package gomp_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+)
+
+func TestUseDoesNotLeakIntoCopies(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	record := func(name string) gomp.Middleware {
+		return func(ctx context.Context, inv *gomp.Invocation, next gomp.Handler) (any, error) {
+			calls = append(calls, name)
+			return next(ctx, inv)
+		}
+	}
+	// 追加 3 个拦截器后切片容量为 4，副本共享同一底层数组
+	svc := gomptest.NewService[txUser](t).Use(record("a"), record("b")).Use(record("c"))
+	first := svc.Unmasked().Use(record("first"))
+	svc.Unmasked().Use(record("second"))
+	if _, err := first.Count(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "first"}; !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,149 @@
+package gomp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrMigrationDenied Migrate 因安全选项拒绝执行 (当前环境不在白名单、DDL 包含 DROP)
+var ErrMigrationDenied = errors.New("migration denied")
+
+// MigrateOptions 迁移的安全选项
+type MigrateOptions struct {
+	AllowDrop    bool      // 允许执行包含 DROP 的 DDL (删除表、列、索引、约束)，默认拒绝
+	DryRun       bool      // 只输出将要执行的 DDL，不修改数据库
+	Output       io.Writer // DryRun 时 DDL 的输出位置，默认 os.Stdout
+	Environments []string  // 允许迁移的环境，与配置 gomp.env 比较，为空时不限制
+}
+
+// Migrate 对实体表执行 AutoMigrate，返回执行 (DryRun 时为将要执行) 的 DDL
+// 默认拒绝包含 DROP 的语句，遇到时中止迁移并返回 ErrMigrationDenied，已执行的 DDL 不会回滚 (SQLite 重建表的迁移在事务中执行)
+// DryRun 模式下表结构的读取照常进行，只跳过写操作，因此依赖前一条 DDL 结果的后续语句可能与实际执行不同
+//
+//	ddl, err := userService.Migrate(ctx, gomp.MigrateOptions{DryRun: true, Environments: []string{"dev", "test"}})
+func (s *ServiceImpl[T]) Migrate(ctx context.Context, opts MigrateOptions) ([]string, error) {
+	ctx = withServiceMethod(ctx, "Migrate")
+	if len(opts.Environments) > 0 {
+		if env := getConfig().Env; !slices.Contains(opts.Environments, env) {
+			return nil, fmt.Errorf("%w: environment %q is not in %v", ErrMigrationDenied, env, opts.Environments)
+		}
+	}
+	if opts.DryRun && opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	db := s.table(ctx)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	guard := &migrateConnPool{ConnPool: db.Statement.ConnPool, state: &migrateState{opts: opts, dialector: db.Dialector}}
+	db.Statement.ConnPool = guard
+	err := db.AutoMigrate(new(T))
+	return guard.state.statements, err
+}
+
+// migrateDrop 匹配 DDL 中的 DROP 关键字 (引号内的标识符已去除)
+var (
+	migrateDrop   = regexp.MustCompile(`(?i)\bDROP\b`)
+	migrateQuoted = regexp.MustCompile("`[^`]*`|\"[^\"]*\"|'[^']*'")
+)
+
+// migrateState 一次迁移的选项与已记录的 DDL
+type migrateState struct {
+	opts       MigrateOptions
+	dialector  gorm.Dialector
+	mu         sync.Mutex
+	statements []string
+}
+
+// exec 检查并记录写语句，返回 false 时跳过执行
+func (m *migrateState) exec(query string, args []any) (bool, error) {
+	stmt := query
+	if len(args) > 0 {
+		stmt = m.dialector.Explain(query, args...)
+	}
+	if !m.opts.AllowDrop && migrateDrop.MatchString(migrateQuoted.ReplaceAllString(query, "")) {
+		return false, fmt.Errorf("%w: %s", ErrMigrationDenied, stmt)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statements = append(m.statements, stmt)
+	if m.opts.DryRun {
+		_, _ = fmt.Fprintln(m.opts.Output, stmt+";")
+		return false, nil
+	}
+	return true, nil
+}
+
+// migrateConnPool 迁移使用的连接，拦截写语句，查询 (读取表结构) 照常执行
+type migrateConnPool struct {
+	gorm.ConnPool
+	state *migrateState
+}
+
+func (p *migrateConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	run, err := p.state.exec(query, args)
+	if err != nil || !run {
+		return driver.RowsAffected(0), err
+	}
+	return p.ConnPool.ExecContext(ctx, query, args...)
+}
+
+// BeginTx 开启事务，事务内的写语句同样被拦截
+func (p *migrateConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &migrateTx{migrateConnPool{ConnPool: tx, state: p.state}}, nil
+}
+
+// GetDBConn 返回底层 *sql.DB，供 gorm.DB.DB() 使用
+func (p *migrateConnPool) GetDBConn() (*sql.DB, error) {
+	switch pool := p.ConnPool.(type) {
+	case *sql.DB:
+		return pool, nil
+	case gorm.GetDBConnector:
+		return pool.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// migrateTx 迁移中开启的事务
+type migrateTx struct {
+	migrateConnPool
+}
+
+func (t *migrateTx) Commit() error {
+	if committer, ok := t.ConnPool.(gorm.TxCommitter); ok {
+		return committer.Commit()
+	}
+	return gorm.ErrInvalidTransaction
+}
+
+func (t *migrateTx) Rollback() error {
+	if committer, ok := t.ConnPool.(gorm.TxCommitter); ok {
+		return committer.Rollback()
+	}
+	return gorm.ErrInvalidTransaction
+}
@@ -0,0 +1,1094 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// ErrMockUnsupported MockService 无法在内存中执行的条件或操作 (如 Raw SQL、联表、分组)
+var ErrMockUnsupported = errors.New("not supported by mock service")
+
+// MockService 基于内存的 IService 实现，用于业务逻辑的单元测试，无需数据库或 sqlmock
+// Wrapper 中的比较、LIKE、IN、BETWEEN、IS NULL 条件，And / Or 嵌套及排序在内存中求值，
+// Raw SQL、联表、分组等无法求值的条件返回 ErrMockUnsupported
+// 支持主键生成、字段自动填充、自动时间戳与乐观锁；不执行拦截器、钩子、多租户、逻辑删除、全局条件、脱敏与缓存
+type MockService[T any] struct {
+	mu     sync.RWMutex
+	db     *gorm.DB // 只用于收集 Wrapper 生成的子句，不连接数据库
+	sch    *schema.Schema
+	rows   []*T
+	nextId int64 // 整数主键的自增值
+}
+
+var _ IService[struct{ ID int64 }] = (*MockService[struct{ ID int64 }])(nil)
+
+// NewMockService 创建内存 Service，rows 为初始数据 (按 Save 处理)；实体结构无法解析或初始数据主键重复时 panic
+//
+//	users := gomp.NewMockService[User](&User{Name: "张三", Age: 18})
+//	svc := NewOrderLogic(users) // 依赖 gomp.IService[User] 的业务代码
+func NewMockService[T any](rows ...*T) *MockService[T] {
+	db, err := gorm.Open(mockDialector{}, &gorm.Config{DryRun: true, Logger: logger.Discard})
+	if err != nil {
+		panic(err)
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		panic(err)
+	}
+	m := &MockService[T]{db: db, sch: sch}
+	for _, row := range rows {
+		if err := m.insert(context.Background(), row); err != nil {
+			panic(err)
+		}
+	}
+	return m
+}
+
+// Rows 当前全部记录的副本，按插入顺序排列，便于断言
+func (m *MockService[T]) Rows() []*T {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return copyRows(m.rows)
+}
+
+// GetDB 实现 IService，返回只用于构造语句的 DryRun DB，不会访问数据库
+func (m *MockService[T]) GetDB() *gorm.DB {
+	return m.db
+}
+
+// Save 内存中保存完整的实体 (包括关联字段)，opts 不生效
+func (m *MockService[T]) Save(ctx context.Context, entity *T, _ ...SaveOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.insert(ctx, entity)
+}
+
+// SaveBatch 内存中保存完整的实体 (包括关联字段)，opts 不生效
+func (m *MockService[T]) SaveBatch(ctx context.Context, entities []*T, _ ...SaveOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rows, nextId := m.rows, m.nextId
+	for _, entity := range entities {
+		if err := m.insert(ctx, entity); err != nil {
+			m.rows, m.nextId = rows, nextId
+			return err
+		}
+	}
+	return nil
+}
+
+// Upsert 按 conflictColumns 查找已有记录，存在时更新 updateColumns (为空时更新除主键、创建时间外的全部列)，否则插入
+func (m *MockService[T]) Upsert(ctx context.Context, entity *T, conflictColumns []string, updateColumns []string) error {
+	if entity == nil {
+		return errors.New("upsert entity cannot be nil")
+	}
+	if len(conflictColumns) == 0 {
+		return errors.New("upsert requires conflict columns")
+	}
+	onConflict, err := upsertClause(m.sch, nil, conflictColumns, updateColumns)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rv := reflect.ValueOf(entity)
+	i := slices.IndexFunc(m.rows, func(row *T) bool {
+		for _, column := range onConflict.Columns {
+			field := m.sch.LookUpField(column.Name)
+			a, _ := field.ValueOf(ctx, rv)
+			b, _ := field.ValueOf(ctx, reflect.ValueOf(row))
+			if fmt.Sprint(plainValue(a)) != fmt.Sprint(plainValue(b)) {
+				return false
+			}
+		}
+		return true
+	})
+	if i < 0 {
+		return m.insert(ctx, entity)
+	}
+	if err := fillEntity(ctx, m.sch, entity, false); err != nil {
+		return err
+	}
+	row := copyRow(m.rows[i])
+	dst := reflect.ValueOf(row)
+	now := time.Now()
+	for _, field := range m.sch.Fields {
+		if field.DBName == "" || field.PrimaryKey {
+			continue
+		}
+		if !slices.ContainsFunc(onConflict.DoUpdates, func(a clause.Assignment) bool { return a.Column.Name == field.DBName }) {
+			continue
+		}
+		if field.AutoUpdateTime > 0 {
+			if _, zero := field.ValueOf(ctx, rv); zero {
+				if err := field.Set(ctx, rv, now); err != nil {
+					return err
+				}
+			}
+		}
+		v, _ := field.ValueOf(ctx, rv)
+		if err := field.Set(ctx, dst, v); err != nil {
+			return err
+		}
+	}
+	m.rows[i] = row
+	return nil
+}
+
+// Replace 删除主键或唯一键与实体冲突的记录后插入
+func (m *MockService[T]) Replace(ctx context.Context, entity *T) error {
+	if entity == nil {
+		return errors.New("replace entity cannot be nil")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.replace(ctx, entity, func(*schema.Field) bool { return true })
+}
+
+// BulkInsert 按 SaveBatch 处理，返回写入的行数
+func (m *MockService[T]) BulkInsert(ctx context.Context, entities []*T, _ ...BulkOptions) (int64, error) {
+	if err := m.SaveBatch(ctx, entities); err != nil {
+		return 0, err
+	}
+	return int64(len(entities)), nil
+}
+
+func (m *MockService[T]) RemoveById(ctx context.Context, id any) error {
+	return m.RemoveByIds(ctx, []any{id})
+}
+
+func (m *MockService[T]) RemoveByIds(ctx context.Context, ids any) error {
+	_, err := m.RemoveByIdsCount(ctx, ids)
+	return err
+}
+
+func (m *MockService[T]) RemoveByIdsCount(ctx context.Context, ids any) (int64, error) {
+	values, ok := primaryKeyValues(ids)
+	if !ok {
+		values = []any{ids}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.rows)
+	m.rows = slices.DeleteFunc(m.rows, func(row *T) bool {
+		return slices.ContainsFunc(values, func(id any) bool { return m.hasId(ctx, row, id) })
+	})
+	return int64(n - len(m.rows)), nil
+}
+
+// RemoveByIdsChunked 内存中一次删除，不分批也不等待
+func (m *MockService[T]) RemoveByIdsChunked(ctx context.Context, ids any, _ int, _ ...time.Duration) (int64, error) {
+	return m.RemoveByIdsCount(ctx, ids)
+}
+
+func (m *MockService[T]) UpdateById(ctx context.Context, entity *T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateById(ctx, entity, updateOptions{})
+}
+
+func (m *MockService[T]) UpdateByIdStrict(ctx context.Context, entity *T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateById(ctx, entity, updateOptions{strict: true})
+}
+
+func (m *MockService[T]) UpdateByIdWithVersion(ctx context.Context, entity *T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateById(ctx, entity, updateOptions{version: true})
+}
+
+func (m *MockService[T]) UpdateByIdWithRetry(ctx context.Context, id any, mutate func(entity *T) error) error {
+	entity, err := m.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entity == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if err := mutate(entity); err != nil {
+		return err
+	}
+	return m.UpdateById(ctx, entity)
+}
+
+func (m *MockService[T]) GetById(ctx context.Context, id any, columns ...string) (*T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if i := m.indexOf(ctx, id); i >= 0 {
+		return m.project(ctx, m.rows[i], columns)
+	}
+	return nil, nil
+}
+
+// GetByIdWith 内存中的记录保留保存时的关联字段，relations 不生效，与 GetById 相同
+func (m *MockService[T]) GetByIdWith(ctx context.Context, id any, _ ...string) (*T, error) {
+	return m.GetById(ctx, id)
+}
+
+func (m *MockService[T]) GetOne(ctx context.Context, wrapper *QueryWrapper[T], columns ...string) (*T, error) {
+	rows, err := m.List(ctx, wrapper)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return m.project(ctx, rows[0], columns)
+}
+
+// Any 内存中没有排序开销，与 GetOne 相同
+func (m *MockService[T]) Any(ctx context.Context, wrapper *QueryWrapper[T]) (*T, error) {
+	return m.GetOne(ctx, wrapper)
+}
+
+func (m *MockService[T]) List(ctx context.Context, wrapper *QueryWrapper[T]) ([]*T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rows, err := m.query(ctx, wrapper)
+	if err != nil {
+		return nil, err
+	}
+	if wrapper != nil && wrapper.limit != nil && *wrapper.limit >= 0 {
+		rows = rows[:min(*wrapper.limit, len(rows))]
+	}
+	return copyRows(rows), nil
+}
+
+// ListParallel 内存中没有分表，与 List 相同
+func (m *MockService[T]) ListParallel(ctx context.Context, wrapper *QueryWrapper[T], _ int) ([]*T, error) {
+	return m.List(ctx, wrapper)
+}
+
+func (m *MockService[T]) Page(ctx context.Context, page *Page[T], wrapper *QueryWrapper[T]) (*Page[T], error) {
+	if page.summaryDest != nil {
+		return nil, fmt.Errorf("%w: page summary", ErrMockUnsupported)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rows, err := m.query(ctx, wrapper)
+	if err != nil {
+		return nil, err
+	}
+	page.Normalize()
+	page.Total = int64(len(rows))
+	if page.clampCurrent && page.Size > 0 && page.Current > page.Pages() {
+		page.Current = max(page.Pages(), 1)
+	}
+	if page.Size > 0 {
+		offset := min((page.Current-1)*page.Size, page.Total)
+		rows = rows[offset:min(offset+page.Size, page.Total)]
+	}
+	page.Records = copyRows(rows)
+	return page, nil
+}
+
+func (m *MockService[T]) SelectPage(ctx context.Context, current, size int64, wrapper *QueryWrapper[T]) (*Page[T], error) {
+	return m.Page(ctx, NewPage[T](current, size), wrapper)
+}
+
+// Scroll 实现 IService，游标为下一页的偏移量，不需要配置 scrollSecret
+func (m *MockService[T]) Scroll(ctx context.Context, token string, size int64, orders []ScrollOrder, wrapper *QueryWrapper[T]) (*ScrollPage[T], error) {
+	if size <= 0 {
+		return nil, errors.New("scroll size must be greater than 0")
+	}
+	var offset int
+	if token != "" {
+		var err error
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, errors.New("invalid scroll token")
+		}
+	}
+	orders, _, err := resolveScrollOrders(m.sch, orders)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]clause.OrderByColumn, len(orders))
+	for i, o := range orders {
+		columns[i] = clause.OrderByColumn{Column: clause.Column{Name: o.Column}, Desc: o.Desc}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rows, err := m.query(ctx, wrapper)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.sort(ctx, rows, columns); err != nil {
+		return nil, err
+	}
+	rows = rows[min(offset, len(rows)):]
+	page := &ScrollPage[T]{Size: size, HasMore: int64(len(rows)) > size}
+	if page.HasMore {
+		rows = rows[:size]
+		page.NextToken = strconv.Itoa(offset + len(rows))
+	}
+	page.Records = copyRows(rows)
+	return page, nil
+}
+
+func (m *MockService[T]) Count(ctx context.Context, wrapper *QueryWrapper[T], column ...string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rows, err := m.query(ctx, wrapper)
+	if err != nil {
+		return 0, err
+	}
+	if len(column) > 0 && column[0] != "" {
+		return m.countColumn(ctx, rows, column[0])
+	}
+	return m.countDistinct(ctx, rows, wrapper)
+}
+
+// countDistinct 按 wrapper 的 Distinct 统计: 单列同 COUNT(DISTINCT col)，多列按列值组合去重，未去重时为记录数
+func (m *MockService[T]) countDistinct(ctx context.Context, rows []*T, wrapper *QueryWrapper[T]) (int64, error) {
+	if wrapper == nil {
+		return int64(len(rows)), nil
+	}
+	stmt := wrapper.Apply(m.db.Session(&gorm.Session{NewDB: true}).Model(new(T))).Statement
+	switch {
+	case !stmt.Distinct || len(stmt.Selects) == 0:
+		return int64(len(rows)), nil
+	case len(stmt.Selects) == 1:
+		return m.countColumn(ctx, rows, "DISTINCT "+stmt.Selects[0])
+	}
+	fields := make([]*schema.Field, len(stmt.Selects))
+	for i, column := range stmt.Selects {
+		if fields[i] = m.sch.LookUpField(bareColumn(column)); fields[i] == nil {
+			return 0, fmt.Errorf("%w: count distinct %q", ErrMockUnsupported, column)
+		}
+	}
+	seen := make(map[string]struct{})
+	for _, row := range rows {
+		var key strings.Builder
+		for _, field := range fields {
+			v, _ := field.ValueOf(ctx, reflect.ValueOf(row))
+			fmt.Fprintf(&key, "%v\x00", plainValue(v))
+		}
+		seen[key.String()] = struct{}{}
+	}
+	return int64(len(seen)), nil
+}
+
+// countColumn 按 COUNT(column) 统计，支持 *、列名与 DISTINCT 列名，值为 NULL 的记录不计入
+func (m *MockService[T]) countColumn(ctx context.Context, rows []*T, column string) (int64, error) {
+	expr := strings.TrimSpace(column)
+	if expr == "*" || expr == "1" {
+		return int64(len(rows)), nil
+	}
+	distinct := false
+	if len(expr) > len("DISTINCT") && strings.EqualFold(expr[:len("DISTINCT")], "DISTINCT") {
+		distinct = true
+		expr = strings.Trim(expr[len("DISTINCT"):], " ()")
+	}
+	field := m.sch.LookUpField(bareColumn(expr))
+	if field == nil {
+		return 0, fmt.Errorf("%w: count %q", ErrMockUnsupported, column)
+	}
+	var n int64
+	seen := make(map[string]struct{})
+	for _, row := range rows {
+		v, _ := field.ValueOf(ctx, reflect.ValueOf(row))
+		if v = plainValue(v); v == nil {
+			continue
+		}
+		if distinct {
+			key := fmt.Sprint(v)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (m *MockService[T]) Insert(ctx context.Context, wrapper *InsertWrapper[T]) error {
+	_, err := m.insertRows(ctx, wrapper)
+	return err
+}
+
+// InsertReturning 插入各行并返回插入后的记录 (含生成的主键与自动时间)，不模拟数据库默认值与生成列
+func (m *MockService[T]) InsertReturning(ctx context.Context, wrapper *InsertWrapper[T]) ([]*T, error) {
+	if wrapper != nil && wrapper.replace {
+		return nil, errors.New("insert returning does not support replace")
+	}
+	return m.insertRows(ctx, wrapper)
+}
+
+// insertRows 插入 wrapper 中的各行，任一行失败时已插入的行被撤销
+func (m *MockService[T]) insertRows(ctx context.Context, wrapper *InsertWrapper[T]) ([]*T, error) {
+	if wrapper == nil {
+		return nil, errors.New("insert wrapper cannot be nil")
+	}
+	rows := wrapper.rows()
+	if wrapper.replace && len(rows) > 1 {
+		return nil, errors.New("replace does not support multiple rows")
+	}
+	entities := make([]*T, len(rows))
+	for i, row := range rows {
+		entities[i] = new(T)
+		if err := m.assign(ctx, entities[i], fillColumns(ctx, m.sch, row, true)); err != nil {
+			return nil, err
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if wrapper.replace {
+		return entities, m.replace(ctx, entities[0], func(field *schema.Field) bool {
+			_, ok := rows[0][field.DBName]
+			return ok
+		})
+	}
+	saved, nextId := m.rows, m.nextId
+	for _, entity := range entities {
+		if err := m.insert(ctx, entity); err != nil {
+			m.rows, m.nextId = saved, nextId
+			return nil, err
+		}
+	}
+	return entities, nil
+}
+
+func (m *MockService[T]) Delete(ctx context.Context, wrapper *DeleteWrapper[T]) error {
+	_, err := m.DeleteCount(ctx, wrapper)
+	return err
+}
+
+func (m *MockService[T]) DeleteCount(ctx context.Context, wrapper *DeleteWrapper[T]) (int64, error) {
+	var apply func(*gorm.DB) *gorm.DB
+	if wrapper != nil {
+		if len(wrapper.joinClauses) > 0 {
+			return 0, fmt.Errorf("%w: joins", ErrMockUnsupported)
+		}
+		apply = wrapper.Apply
+	}
+	where, _, err := m.clauses(apply)
+	if err != nil {
+		return 0, err
+	}
+	if len(where) == 0 && !getConfig().AllowGlobalDelete {
+		return 0, errors.New("global delete is not allowed without WHERE clause; set gomp.allowGlobalDelete=true to override")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	matched, err := m.filter(ctx, where)
+	if err != nil {
+		return 0, err
+	}
+	if wrapper != nil && wrapper.strict && len(matched) == 0 {
+		return 0, ErrNoRowsAffected
+	}
+	m.rows = slices.DeleteFunc(m.rows, func(row *T) bool { return slices.Contains(matched, row) })
+	return int64(len(matched)), nil
+}
+
+func (m *MockService[T]) Update(ctx context.Context, wrapper *UpdateWrapper[T]) error {
+	if wrapper == nil {
+		return errors.New("update wrapper cannot be nil")
+	}
+	if len(wrapper.joinClauses) > 0 {
+		return fmt.Errorf("%w: joins", ErrMockUnsupported)
+	}
+	where, _, err := m.clauses(wrapper.Apply)
+	if err != nil {
+		return err
+	}
+	if len(where) == 0 && !getConfig().AllowGlobalUpdate {
+		return errors.New("global update is not allowed without WHERE clause; set gomp.allowGlobalUpdate=true to override")
+	}
+	values := fillColumns(ctx, m.sch, wrapper.values, false)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	matched, err := m.filter(ctx, where)
+	if err != nil {
+		return err
+	}
+	if wrapper.strict && len(matched) == 0 {
+		return ErrNoRowsAffected
+	}
+	// 先在副本上修改，全部成功后再替换
+	updated := make(map[*T]*T, len(matched))
+	for _, row := range matched {
+		c := copyRow(row)
+		if err := m.assign(ctx, c, values); err != nil {
+			return err
+		}
+		updated[row] = c
+	}
+	for i, row := range m.rows {
+		if c, ok := updated[row]; ok {
+			m.rows[i] = c
+		}
+	}
+	return nil
+}
+
+// insert 写入一条记录，调用方需持有写锁
+func (m *MockService[T]) insert(ctx context.Context, entity *T) error {
+	if err := fillEntity(ctx, m.sch, entity, true); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(entity)
+	now := time.Now()
+	for _, field := range m.sch.Fields {
+		if field.AutoCreateTime > 0 || field.AutoUpdateTime > 0 {
+			if _, zero := field.ValueOf(ctx, rv); zero {
+				if err := field.Set(ctx, rv, now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if pk := m.sch.PrioritizedPrimaryField; pk != nil {
+		if idTypeOf(pk) != IdTypeSequence {
+			if err := assignId(ctx, m.db, m.sch, entity); err != nil {
+				return err
+			}
+		}
+		id, zero := pk.ValueOf(ctx, rv)
+		switch kind := pk.IndirectFieldType.Kind(); {
+		case zero && (isIntKind(kind) || isUintKind(kind)):
+			m.nextId++
+			if err := pk.Set(ctx, rv, m.nextId); err != nil {
+				return err
+			}
+		case !zero && isIntKind(kind):
+			m.nextId = max(m.nextId, reflect.Indirect(reflect.ValueOf(id)).Int())
+		case !zero && isUintKind(kind):
+			m.nextId = max(m.nextId, int64(reflect.Indirect(reflect.ValueOf(id)).Uint()))
+		}
+		id, _ = pk.ValueOf(ctx, rv)
+		if m.indexOf(ctx, id) >= 0 {
+			return fmt.Errorf("%w: %s %v", gorm.ErrDuplicatedKey, m.sch.Name, id)
+		}
+	}
+	m.rows = append(m.rows, copyRow(entity))
+	return nil
+}
+
+// replace 删除主键或唯一键与 entity 冲突的记录后插入，插入失败时恢复被删除的记录；compare 返回列是否参与比较，调用方需持有写锁
+func (m *MockService[T]) replace(ctx context.Context, entity *T, compare func(field *schema.Field) bool) error {
+	rv := reflect.ValueOf(entity)
+	var keys [][]clause.Expression
+	for _, fields := range uniqueKeys(m.sch) {
+		exprs, ok := keyCondition(fields, func(field *schema.Field) (any, bool) {
+			v, zero := field.ValueOf(ctx, rv)
+			return v, compare(field) && !(zero && field.PrimaryKey)
+		})
+		if ok {
+			keys = append(keys, exprs)
+		}
+	}
+	rows, nextId := m.rows, m.nextId
+	m.rows = slices.DeleteFunc(slices.Clone(m.rows), func(row *T) bool {
+		return slices.ContainsFunc(keys, func(exprs []clause.Expression) bool {
+			for _, expr := range exprs {
+				eq := expr.(clause.Eq)
+				v, _ := m.sch.LookUpField(eq.Column.(clause.Column).Name).ValueOf(ctx, reflect.ValueOf(row))
+				if fmt.Sprint(plainValue(v)) != fmt.Sprint(plainValue(eq.Value)) {
+					return false
+				}
+			}
+			return true
+		})
+	})
+	if err := m.insert(ctx, entity); err != nil {
+		m.rows, m.nextId = rows, nextId
+		return err
+	}
+	return nil
+}
+
+// updateById 按主键更新实体的非零值字段，调用方需持有写锁
+func (m *MockService[T]) updateById(ctx context.Context, entity *T, opts updateOptions) error {
+	pk := m.sch.PrioritizedPrimaryField
+	if pk == nil {
+		return fmt.Errorf("%s has no primary key", m.sch.Name)
+	}
+	if err := fillEntity(ctx, m.sch, entity, false); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(entity)
+	id, _ := pk.ValueOf(ctx, rv)
+	i := m.indexOf(ctx, id)
+
+	begin := beginOptimisticLock
+	if opts.version {
+		begin = beginVersionLock
+	}
+	lock, err := begin(ctx, m.sch, entity)
+	if err != nil {
+		return err
+	}
+	if lock != nil {
+		var stored any
+		if i >= 0 {
+			stored, _ = lock.field.ValueOf(ctx, reflect.ValueOf(m.rows[i]))
+		}
+		if i < 0 || fmt.Sprint(plainValue(stored)) != fmt.Sprint(plainValue(lock.current)) {
+			_ = lock.field.Set(ctx, rv, lock.current)
+			return ErrOptimisticLock
+		}
+	}
+	if i < 0 {
+		if opts.strict {
+			return ErrNoRowsAffected
+		}
+		return nil
+	}
+
+	row := copyRow(m.rows[i])
+	dst := reflect.ValueOf(row)
+	now := time.Now()
+	for _, field := range m.sch.Fields {
+		if field.DBName == "" || field.PrimaryKey {
+			continue
+		}
+		v, zero := field.ValueOf(ctx, rv)
+		if field.AutoUpdateTime > 0 && zero {
+			if err := field.Set(ctx, rv, now); err != nil {
+				return err
+			}
+			v, zero = field.ValueOf(ctx, rv)
+		}
+		if zero {
+			continue
+		}
+		if err := field.Set(ctx, dst, v); err != nil {
+			return err
+		}
+	}
+	m.rows[i] = row
+	return nil
+}
+
+// assign 按列名为实体赋值，支持 UpdateWrapper.Incr / Decr 生成的 "列 + ?" / "列 - ?" 表达式
+func (m *MockService[T]) assign(ctx context.Context, entity *T, values map[string]any) error {
+	rv := reflect.ValueOf(entity)
+	for column, val := range values {
+		field := m.sch.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return fmt.Errorf("unknown column %s of %s", column, m.sch.Name)
+		}
+		if expr, ok := val.(clause.Expr); ok {
+			matches := mockArithmetic.FindStringSubmatch(expr.SQL)
+			if matches == nil || len(expr.Vars) != 1 || bareColumn(matches[1]) != field.DBName {
+				return fmt.Errorf("%w: expression %q", ErrMockUnsupported, expr.SQL)
+			}
+			current, _ := field.ValueOf(ctx, rv)
+			result, err := mockAdd(current, expr.Vars[0], matches[2] == "-")
+			if err != nil {
+				return err
+			}
+			val = result
+		}
+		if err := field.Set(ctx, rv, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexOf 按主键查找记录下标，调用方需持有锁
+func (m *MockService[T]) indexOf(ctx context.Context, id any) int {
+	return slices.IndexFunc(m.rows, func(row *T) bool { return m.hasId(ctx, row, id) })
+}
+
+// hasId 记录的主键是否等于 id
+func (m *MockService[T]) hasId(ctx context.Context, row *T, id any) bool {
+	pk := m.sch.PrioritizedPrimaryField
+	if pk == nil {
+		return false
+	}
+	v, _ := pk.ValueOf(ctx, reflect.ValueOf(row))
+	return fmt.Sprint(plainValue(v)) == fmt.Sprint(plainValue(id))
+}
+
+// project 返回只包含 columns 对应字段的副本 (其余字段为零值)，columns 为空时返回完整副本
+func (m *MockService[T]) project(ctx context.Context, row *T, columns []string) (*T, error) {
+	if len(columns) == 0 {
+		return copyRow(row), nil
+	}
+	result := new(T)
+	src, dst := reflect.ValueOf(row), reflect.ValueOf(result)
+	for _, column := range columns {
+		field := m.sch.LookUpField(bareColumn(column))
+		if field == nil {
+			return nil, fmt.Errorf("%w: select %q", ErrMockUnsupported, column)
+		}
+		v, _ := field.ValueOf(ctx, src)
+		if err := field.Set(ctx, dst, v); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// query 按 QueryWrapper 过滤并排序记录 (未指定排序时使用实体的默认排序)，调用方需持有锁
+func (m *MockService[T]) query(ctx context.Context, wrapper *QueryWrapper[T]) ([]*T, error) {
+	var apply func(*gorm.DB) *gorm.DB
+	if wrapper != nil {
+		apply = wrapper.Apply
+	}
+	where, orders, err := m.clauses(apply)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		for _, item := range entityDefaultOrder[T]() {
+			orders = append(orders, clause.OrderByColumn{Column: clause.Column{Name: item.Column}, Desc: item.Desc})
+		}
+	}
+	rows, err := m.filter(ctx, where)
+	if err != nil {
+		return nil, err
+	}
+	return rows, m.sort(ctx, rows, orders)
+}
+
+// clauses 将 Wrapper 应用到 DryRun 语句上，取出 WHERE 条件与排序
+func (m *MockService[T]) clauses(apply func(*gorm.DB) *gorm.DB) ([]clause.Expression, []clause.OrderByColumn, error) {
+	db := m.db.Session(&gorm.Session{NewDB: true}).Model(new(T))
+	if apply != nil {
+		db = apply(db)
+	}
+	if db.Error != nil {
+		return nil, nil, db.Error
+	}
+	stmt := db.Statement
+	if len(stmt.Joins) > 0 {
+		return nil, nil, fmt.Errorf("%w: joins", ErrMockUnsupported)
+	}
+	for _, name := range []string{"GROUP BY", "FROM"} {
+		if _, ok := stmt.Clauses[name]; ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrMockUnsupported, name)
+		}
+	}
+	var where []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if w, ok := c.Expression.(clause.Where); ok {
+			where = w.Exprs
+		}
+	}
+	var orders []clause.OrderByColumn
+	if c, ok := stmt.Clauses["ORDER BY"]; ok {
+		if o, ok := c.Expression.(clause.OrderBy); ok {
+			for _, column := range o.Columns {
+				if !column.Column.Raw {
+					orders = append(orders, column)
+					continue
+				}
+				// Order("age DESC, id") 形式的原始排序
+				for _, part := range strings.Split(column.Column.Name, ",") {
+					fields := strings.Fields(part)
+					if len(fields) == 0 || len(fields) > 2 || len(fields) == 2 && !strings.EqualFold(fields[1], "DESC") && !strings.EqualFold(fields[1], "ASC") {
+						return nil, nil, fmt.Errorf("%w: order by %q", ErrMockUnsupported, column.Column.Name)
+					}
+					desc := len(fields) == 2 && strings.EqualFold(fields[1], "DESC")
+					orders = append(orders, clause.OrderByColumn{Column: clause.Column{Name: fields[0]}, Desc: desc})
+				}
+			}
+		}
+	}
+	return where, orders, nil
+}
+
+// filter 返回满足条件的记录，调用方需持有锁
+func (m *MockService[T]) filter(ctx context.Context, where []clause.Expression) ([]*T, error) {
+	if len(where) == 0 {
+		return slices.Clone(m.rows), nil
+	}
+	// 与 clause.Where 的构建一致: 首个条件为单个 OR 条件时与第一个非 OR 条件交换位置
+	where = slices.Clone(where)
+	for i, expr := range where {
+		if v, ok := expr.(clause.OrConditions); !ok || len(v.Exprs) > 1 {
+			where[0], where[i] = where[i], where[0]
+			break
+		}
+	}
+	rows := make([]*T, 0)
+	for _, row := range m.rows {
+		ok, err := m.evalList(ctx, where, false, reflect.ValueOf(row))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// evalList 按 SQL 优先级 (AND 高于 OR) 求值条件列表，or 为 true 时列表以 OR 连接
+func (m *MockService[T]) evalList(ctx context.Context, exprs []clause.Expression, or bool, row reflect.Value) (bool, error) {
+	result, group := false, true
+	for i, expr := range exprs {
+		if v, ok := expr.(clause.OrConditions); i > 0 && (or || ok && len(v.Exprs) == 1) {
+			result, group = result || group, true
+		}
+		ok, err := m.eval(ctx, expr, row)
+		if err != nil {
+			return false, err
+		}
+		group = group && ok
+	}
+	return result || group, nil
+}
+
+// eval 求值单个条件
+func (m *MockService[T]) eval(ctx context.Context, expr clause.Expression, row reflect.Value) (bool, error) {
+	switch e := expr.(type) {
+	case clause.AndConditions:
+		return m.evalList(ctx, e.Exprs, false, row)
+	case clause.OrConditions:
+		return m.evalList(ctx, e.Exprs, true, row)
+	case clause.Eq:
+		return m.compare(ctx, row, e.Column, "=", []any{e.Value})
+	case clause.Neq:
+		return m.compare(ctx, row, e.Column, "<>", []any{e.Value})
+	case clause.Gt:
+		return m.compare(ctx, row, e.Column, ">", []any{e.Value})
+	case clause.Gte:
+		return m.compare(ctx, row, e.Column, ">=", []any{e.Value})
+	case clause.Lt:
+		return m.compare(ctx, row, e.Column, "<", []any{e.Value})
+	case clause.Lte:
+		return m.compare(ctx, row, e.Column, "<=", []any{e.Value})
+	case clause.IN:
+		return m.compare(ctx, row, e.Column, "IN", []any{e.Values})
+	case clause.Like:
+		return m.compare(ctx, row, e.Column, "LIKE", []any{e.Value})
+	case clause.NotConditions:
+		if len(e.Exprs) == 1 {
+			if in, ok := e.Exprs[0].(clause.IN); ok {
+				return m.compare(ctx, row, in.Column, "NOT IN", []any{in.Values})
+			}
+		}
+	case clause.Expr:
+		if strings.HasPrefix(e.SQL, "? ") && len(e.Vars) > 0 {
+			// 列参数为 clause.Column 的条件 (? IS NULL、? BETWEEN ? AND ?)
+			if col, ok := e.Vars[0].(clause.Column); ok {
+				e = clause.Expr{SQL: col.Name + e.SQL[1:], Vars: e.Vars[1:]}
+			}
+		}
+		matches := mockPredicate.FindStringSubmatch(e.SQL)
+		if matches == nil {
+			return false, fmt.Errorf("%w: condition %q", ErrMockUnsupported, e.SQL)
+		}
+		op := strings.ToUpper(strings.Join(strings.Fields(matches[2]), " "))
+		want := map[string]string{"IN": "(?)", "NOT IN": "(?)", "BETWEEN": "? AND ?", "NOT BETWEEN": "? AND ?", "IS NULL": "", "IS NOT NULL": ""}
+		placeholder, ok := want[op]
+		if !ok {
+			placeholder = "?"
+		}
+		if !strings.EqualFold(strings.Join(strings.Fields(matches[3]), " "), placeholder) ||
+			len(e.Vars) != strings.Count(placeholder, "?") {
+			return false, fmt.Errorf("%w: condition %q", ErrMockUnsupported, e.SQL)
+		}
+		return m.compare(ctx, row, matches[1], op, e.Vars)
+	}
+	return false, fmt.Errorf("%w: condition %T", ErrMockUnsupported, expr)
+}
+
+// compare 对记录的列求值比较运算
+func (m *MockService[T]) compare(ctx context.Context, row reflect.Value, column any, op string, args []any) (bool, error) {
+	var name string
+	switch c := column.(type) {
+	case string:
+		name = c
+	case clause.Column:
+		name = c.Name
+		if c.Name == clause.PrimaryKey && m.sch.PrioritizedPrimaryField != nil {
+			name = m.sch.PrioritizedPrimaryField.DBName
+		}
+	default:
+		return false, fmt.Errorf("%w: column %T", ErrMockUnsupported, column)
+	}
+	field := m.sch.LookUpField(bareColumn(name))
+	if field == nil || field.DBName == "" {
+		return false, fmt.Errorf("%w: unknown column %s", ErrMockUnsupported, name)
+	}
+	raw, _ := field.ValueOf(ctx, row)
+	value := plainValue(raw)
+
+	switch op {
+	case "IS NULL":
+		return value == nil, nil
+	case "IS NOT NULL":
+		return value != nil, nil
+	case "IN", "NOT IN":
+		list := reflect.ValueOf(args[0])
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			list = reflect.ValueOf(args)
+		}
+		found := false
+		for i := 0; i < list.Len() && !found; i++ {
+			c, ok := compareValues(value, list.Index(i).Interface())
+			found = ok && c == 0
+		}
+		return value != nil && found == (op == "IN"), nil
+	case "LIKE", "NOT LIKE":
+		if value == nil {
+			return false, nil
+		}
+		matched := mockLikePattern(fmt.Sprint(plainValue(args[0]))).MatchString(fmt.Sprint(value))
+		return matched == (op == "LIKE"), nil
+	case "BETWEEN", "NOT BETWEEN":
+		lo, ok1 := compareValues(value, args[0])
+		hi, ok2 := compareValues(value, args[1])
+		if !ok1 || !ok2 {
+			return false, nil
+		}
+		return (lo >= 0 && hi <= 0) == (op == "BETWEEN"), nil
+	}
+	c, ok := compareValues(value, args[0])
+	if !ok {
+		return false, nil
+	}
+	switch op {
+	case "=":
+		return c == 0, nil
+	case "<>", "!=":
+		return c != 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	}
+	return false, fmt.Errorf("%w: operator %s", ErrMockUnsupported, op)
+}
+
+// sort 按排序列稳定排序，NULL 排在最前 (降序时最后)
+func (m *MockService[T]) sort(ctx context.Context, rows []*T, orders []clause.OrderByColumn) error {
+	fields := make([]*schema.Field, len(orders))
+	for i, o := range orders {
+		fields[i] = m.sch.LookUpField(bareColumn(o.Column.Name))
+		if fields[i] == nil || fields[i].DBName == "" {
+			return fmt.Errorf("%w: order by %s", ErrMockUnsupported, o.Column.Name)
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b *T) int {
+		for i, field := range fields {
+			va, _ := field.ValueOf(ctx, reflect.ValueOf(a))
+			vb, _ := field.ValueOf(ctx, reflect.ValueOf(b))
+			x, y := plainValue(va), plainValue(vb)
+			var c int
+			switch {
+			case x == nil && y == nil:
+			case x == nil:
+				c = -1
+			case y == nil:
+				c = 1
+			default:
+				c, _ = compareValues(x, y)
+			}
+			if orders[i].Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return nil
+}
+
+var (
+	// mockPredicate Wrapper 生成的单个条件: 列 运算符 占位符
+	mockPredicate = regexp.MustCompile("(?is)^\\s*([\\w.`\"]+)\\s+(=|<>|!=|>=|<=|>|<|NOT\\s+LIKE|LIKE|NOT\\s+IN|IN|IS\\s+NOT\\s+NULL|IS\\s+NULL|NOT\\s+BETWEEN|BETWEEN)\\s*(.*?)\\s*$")
+	// mockArithmetic UpdateWrapper.Incr / Decr 生成的表达式
+	mockArithmetic = regexp.MustCompile("^\\s*([\\w.`\"]+)\\s*([+-])\\s*\\?\\s*$")
+)
+
+// mockLikePattern 将 LIKE 模式转换为正则表达式 (区分大小写)
+func mockLikePattern(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// mockAdd 计算 current + delta (sub 为 true 时为减法)，结果类型与 current 一致
+func mockAdd(current, delta any, sub bool) (any, error) {
+	cv, dv := reflect.ValueOf(plainValue(current)), reflect.ValueOf(plainValue(delta))
+	if !cv.IsValid() || !dv.IsValid() || !isNumberKind(cv.Kind()) || !isNumberKind(dv.Kind()) {
+		return nil, fmt.Errorf("%w: arithmetic on %T and %T", ErrMockUnsupported, current, delta)
+	}
+	sign := 1.0
+	if sub {
+		sign = -1
+	}
+	switch {
+	case isIntKind(cv.Kind()) && isIntKind(dv.Kind()):
+		return cv.Int() + int64(sign)*dv.Int(), nil
+	case isUintKind(cv.Kind()) && !sub && (isIntKind(dv.Kind()) || isUintKind(dv.Kind())):
+		return cv.Uint() + uint64(toFloat(dv)), nil
+	case isUintKind(cv.Kind()) && (isIntKind(dv.Kind()) || isUintKind(dv.Kind())):
+		return cv.Uint() - uint64(toFloat(dv)), nil
+	}
+	return toFloat(cv) + sign*toFloat(dv), nil
+}
+
+// copyRow 复制记录，避免调用方修改内存中的数据
+func copyRow[T any](row *T) *T {
+	c := *row
+	return &c
+}
+
+// copyRows 复制记录列表
+func copyRows[T any](rows []*T) []*T {
+	result := make([]*T, len(rows))
+	for i, row := range rows {
+		result[i] = copyRow(row)
+	}
+	return result
+}
+
+// mockDialector MockService 使用的 Dialector，只用于构造语句，不连接数据库
+type mockDialector struct{}
+
+func (mockDialector) Name() string                    { return "mock" }
+func (mockDialector) Initialize(*gorm.DB) error       { return nil }
+func (mockDialector) Migrator(*gorm.DB) gorm.Migrator { return nil }
+func (mockDialector) DataTypeOf(*schema.Field) string { return "" }
+func (mockDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+func (mockDialector) BindVarTo(w clause.Writer, _ *gorm.Statement, _ any) {
+	_ = w.WriteByte('?')
+}
+func (mockDialector) QuoteTo(w clause.Writer, s string) {
+	_, _ = w.WriteString(s)
+}
+func (mockDialector) Explain(sql string, vars ...any) string {
+	return logger.ExplainSQL(sql, nil, `'`, vars...)
+}
@@ -0,0 +1,83 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// OperatorColumns 操作人列映射，实体包含对应列时自动填充当前操作人
+type OperatorColumns struct {
+	CreatedBy string `yaml:"createdBy"` // 创建人列，插入时填充
+	UpdatedBy string `yaml:"updatedBy"` // 更新人列，插入和更新时填充
+	DeletedBy string `yaml:"deletedBy"` // 删除人列，逻辑删除时填充
+}
+
+// operatorKey 操作人 context key
+type operatorKey struct{}
+
+// WithOperator 将当前操作人 (用户 ID 等) 写入 ctx，用于自动填充、审计与逻辑删除
+func WithOperator(ctx context.Context, id any) context.Context {
+	return context.WithValue(ctx, operatorKey{}, id)
+}
+
+// OperatorFrom 获取 ctx 中的操作人
+func OperatorFrom(ctx context.Context) (any, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	id := ctx.Value(operatorKey{})
+	return id, id != nil
+}
+
+// operatorName 操作人的字符串形式，未设置时为空字符串
+func operatorName(ctx context.Context) string {
+	if id, ok := OperatorFrom(ctx); ok {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
+
+// isOperatorColumn 判断列是否为配置的操作人列
+func isOperatorColumn(column string) bool {
+	cols := getConfig().OperatorColumns
+	for _, c := range []string{cols.CreatedBy, cols.UpdatedBy, cols.DeletedBy} {
+		if c != "" && strings.EqualFold(c, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// operatorFillFields 按 operatorColumns 配置获取实体中需要填充操作人的字段
+func operatorFillFields(sch *schema.Schema) []fillField {
+	cols := getConfig().OperatorColumns
+	fields := make([]fillField, 0, 2)
+	if cols.CreatedBy != "" {
+		if field := lookUpField(sch, cols.CreatedBy); field != nil {
+			fields = append(fields, fillField{field: field, mode: FillInsert})
+		}
+	}
+	if cols.UpdatedBy != "" {
+		if field := lookUpField(sch, cols.UpdatedBy); field != nil {
+			fields = append(fields, fillField{field: field, mode: FillInsertUpdate})
+		}
+	}
+	return fields
+}
+
+// logicDeleteColumns 逻辑删除时更新的列: 删除标记及删除人 (配置了 deletedBy 且 ctx 中存在操作人时)
+func logicDeleteColumns(ctx context.Context, sch *schema.Schema, field *schema.Field) map[string]any {
+	deleted, _ := logicDeleteValues(field)
+	columns := map[string]any{field.DBName: deleted}
+	if column := getConfig().OperatorColumns.DeletedBy; column != "" {
+		if f := lookUpField(sch, column); f != nil {
+			if id, ok := OperatorFrom(ctx); ok {
+				columns[f.DBName] = id
+			}
+		}
+	}
+	return columns
+}
@@ -0,0 +1,69 @@
+package gomp
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// OrderItem 排序项
+type OrderItem struct {
+	Column string // 排序列或表达式
+	Desc   bool   // 是否降序
+}
+
+// DefaultOrderer 由实体实现，List / Page / GetOne 未指定排序时按 DefaultOrder 排序，避免分页结果顺序不确定
+//
+//	func (Order) DefaultOrder() []gomp.OrderItem {
+//		return []gomp.OrderItem{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}
+//	}
+type DefaultOrderer interface {
+	DefaultOrder() []OrderItem
+}
+
+var (
+	defaultOrderMu sync.RWMutex
+	defaultOrders  = make(map[reflect.Type][]OrderItem)
+)
+
+// RegisterDefaultOrder 为实体 T 注册默认排序，优先于实体实现的 DefaultOrderer；不传 items 时移除注册
+func RegisterDefaultOrder[T any](items ...OrderItem) {
+	defaultOrderMu.Lock()
+	defer defaultOrderMu.Unlock()
+	if len(items) == 0 {
+		delete(defaultOrders, entityType[T]())
+		return
+	}
+	defaultOrders[entityType[T]()] = slices.Clone(items)
+}
+
+// entityDefaultOrder 实体 T 的默认排序，未注册也未实现 DefaultOrderer 时返回 nil
+func entityDefaultOrder[T any]() []OrderItem {
+	defaultOrderMu.RLock()
+	items, ok := defaultOrders[entityType[T]()]
+	defaultOrderMu.RUnlock()
+	if ok {
+		return items
+	}
+	if orderer, ok := any(new(T)).(DefaultOrderer); ok {
+		return orderer.DefaultOrder()
+	}
+	return nil
+}
+
+// applyDefaultOrder 查询未指定排序时追加实体 T 的默认排序
+func applyDefaultOrder[T any](db *gorm.DB) *gorm.DB {
+	if _, ok := db.Statement.Clauses["ORDER BY"]; ok {
+		return db
+	}
+	for _, item := range entityDefaultOrder[T]() {
+		if item.Desc {
+			db = db.Order(item.Column + " DESC")
+		} else {
+			db = db.Order(item.Column + " ASC")
+		}
+	}
+	return db
+}
@@ -1,31 +1,129 @@
 package gomp
 
+import "math"
+
 // Page 分页对象
 type Page[T any] struct {
-	Current int64 `json:"current"` // 当前页
-	Size    int64 `json:"size"`    // 每页显示条数
-	Total   int64 `json:"total"`   // 总数
-	Records []*T  `json:"records"` // 查询数据列表
+	Current int64 `json:"current"`           // 当前页
+	Size    int64 `json:"size"`              // 每页显示条数
+	Total   int64 `json:"total"`             // 总数
+	Records []*T  `json:"records"`           // 查询数据列表
+	Summary any   `json:"summary,omitempty"` // 汇总数据 (需通过 WithSummary 开启)
+
+	summaryDest        any
+	summaryExpressions []string
+	countColumn        string
+	countDistinct      bool
+	clampCurrent       bool
+	deepPageOffset     int
 }
 
 // NewPage 创建分页对象
 func NewPage[T any](current, size int64) *Page[T] {
-	return &Page[T]{
+	p := &Page[T]{
 		Current: current,
 		Size:    size,
 		Records: make([]*T, 0),
 	}
+	return p.Normalize()
+}
+
+// Normalize 规范化分页参数: Current < 1 时置为 1，Size < 0 时置为 0 (不分页)
+func (p *Page[T]) Normalize() *Page[T] {
+	if p.Current < 1 {
+		p.Current = 1
+	}
+	if p.Size < 0 {
+		p.Size = 0
+	}
+	return p
+}
+
+// ClampCurrent 开启后，统计总数后若 Current 超出最后一页，自动调整为最后一页
+func (p *Page[T]) ClampCurrent(enabled bool) *Page[T] {
+	p.clampCurrent = enabled
+	return p
+}
+
+// OptimizeDeepPage 开启深分页优化: 当偏移量 >= minOffset 时，先通过 LIMIT/OFFSET 只查询主键 (可走覆盖索引)，
+// 再使用 IN 回表查询完整记录，显著降低 MySQL 深分页的延迟
+// 回表查询只按主键获取实体完整字段，适用于单表查询场景
+func (p *Page[T]) OptimizeDeepPage(minOffset int) *Page[T] {
+	p.deepPageOffset = minOffset
+	return p
+}
+
+// Pages 总页数
+func (p *Page[T]) Pages() int64 {
+	if p.Size <= 0 {
+		if p.Total > 0 {
+			return 1
+		}
+		return 0
+	}
+	return (p.Total + p.Size - 1) / p.Size
+}
+
+// WithSummary 开启汇总查询，在分页的同时对完整筛选结果集进行聚合
+// dest 为接收汇总结果的结构体指针，expressions 为聚合表达式，例如 "SUM(amount) AS total_amount"
+func (p *Page[T]) WithSummary(dest any, expressions ...string) *Page[T] {
+	p.summaryDest = dest
+	p.summaryExpressions = expressions
+	return p
+}
+
+// CountColumn 指定统计总数时使用的列或表达式，默认 COUNT(*)
+func (p *Page[T]) CountColumn(column string) *Page[T] {
+	p.countColumn = column
+	p.countDistinct = false
+	return p
+}
+
+// CountDistinct 统计总数时使用 COUNT(DISTINCT column)，用于联表查询时避免行数膨胀
+// 不传 column 时默认使用主键 (自动带上表名或别名限定)
+func (p *Page[T]) CountDistinct(column ...string) *Page[T] {
+	p.countColumn = ""
+	if len(column) > 0 {
+		p.countColumn = column[0]
+	}
+	p.countDistinct = true
+	return p
 }
 
 // Offset 计算偏移量
 func (p *Page[T]) Offset() int {
-	if p.Current > 0 {
-		return int((p.Current - 1) * p.Size)
+	if p.Current <= 1 || p.Size <= 0 {
+		return 0
+	}
+	// 防止 (Current-1)*Size 溢出产生负数偏移量
+	if p.Current-1 > int64(math.MaxInt)/p.Size {
+		return math.MaxInt
 	}
-	return 0
+	return int((p.Current - 1) * p.Size)
 }
 
 // Limit 获取每页数量
 func (p *Page[T]) Limit() int {
+	if p.Size > int64(math.MaxInt) {
+		return math.MaxInt
+	}
 	return int(p.Size)
 }
+
+// ConvertPage 转换分页记录类型 (如实体转 DTO)，保留 Current/Size/Total/Summary
+func ConvertPage[T any, D any](p *Page[T], fn func(*T) *D) *Page[D] {
+	if p == nil {
+		return nil
+	}
+	records := make([]*D, 0, len(p.Records))
+	for _, r := range p.Records {
+		records = append(records, fn(r))
+	}
+	return &Page[D]{
+		Current: p.Current,
+		Size:    p.Size,
+		Total:   p.Total,
+		Records: records,
+		Summary: p.Summary,
+	}
+}
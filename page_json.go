@@ -0,0 +1,116 @@
+package gomp
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PageFields Page 序列化时使用的 JSON 字段名
+// 为空时使用默认字段名，设置为 "-" 时不输出该字段
+type PageFields struct {
+	Current string `yaml:"current"` // 默认 current
+	Size    string `yaml:"size"`    // 默认 size
+	Total   string `yaml:"total"`   // 默认 total
+	Records string `yaml:"records"` // 默认 records
+	Summary string `yaml:"summary"` // 默认 summary
+}
+
+// SetPageFields 设置 Page 的 JSON 字段名，用于适配前端已有的分页协议
+// 例如 PageFields{Current: "pageNum", Size: "pageSize", Records: "list"}
+// 优先于配置文件中的 pageFields，ReloadConfig / WatchConfig 重新加载后仍然生效
+func SetPageFields(fields PageFields) {
+	updateConfig("pageFields", func(cfg *Config) {
+		cfg.PageFields = fields
+	})
+}
+
+// pageFieldName 获取字段名，未配置时返回默认值
+func pageFieldName(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
+
+// MarshalJSON 按 PageFields 配置序列化分页对象
+func (p Page[T]) MarshalJSON() ([]byte, error) {
+	f := getConfig().PageFields
+	records := p.Records
+	if records == nil {
+		records = make([]*T, 0)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	write := func(name string, val any) error {
+		if name == "-" {
+			return nil
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+		return nil
+	}
+
+	if err := write(pageFieldName(f.Current, "current"), p.Current); err != nil {
+		return nil, err
+	}
+	if err := write(pageFieldName(f.Size, "size"), p.Size); err != nil {
+		return nil, err
+	}
+	if err := write(pageFieldName(f.Total, "total"), p.Total); err != nil {
+		return nil, err
+	}
+	if err := write(pageFieldName(f.Records, "records"), records); err != nil {
+		return nil, err
+	}
+	if p.Summary != nil {
+		if err := write(pageFieldName(f.Summary, "summary"), p.Summary); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON 按 PageFields 配置反序列化分页对象，便于直接绑定请求参数
+func (p *Page[T]) UnmarshalJSON(data []byte) error {
+	f := getConfig().PageFields
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	read := func(name string, dest any) error {
+		if v, ok := raw[name]; ok && name != "-" {
+			return json.Unmarshal(v, dest)
+		}
+		return nil
+	}
+
+	if err := read(pageFieldName(f.Current, "current"), &p.Current); err != nil {
+		return err
+	}
+	if err := read(pageFieldName(f.Size, "size"), &p.Size); err != nil {
+		return err
+	}
+	if err := read(pageFieldName(f.Total, "total"), &p.Total); err != nil {
+		return err
+	}
+	if err := read(pageFieldName(f.Records, "records"), &p.Records); err != nil {
+		return err
+	}
+	if p.Records == nil {
+		p.Records = make([]*T, 0)
+	}
+	return nil
+}
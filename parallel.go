@@ -0,0 +1,263 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ListParallel 与 List 相同，但实体分表且条件中缺少分片键时以最多 parallelism 个并发查询全部分表 (List 依次查询)，适用于跨分表的后台查询
+// 合并后按排序列重新排序，LIMIT / OFFSET 作用于合并后的结果；排序列必须是实体的列
+// parallelism <= 0 时不限制并发数；任一分表查询失败时取消其余查询并返回该错误
+// Service 基于事务创建时，事务只有一个连接，不能被多个查询并发使用，各分表依次查询
+// 未分表的实体按主键区间扇出查询见 ListRanges
+//
+//	orders, err := orderService.ListParallel(ctx, gomp.NewQueryWrapper[Order]().
+//		Eq("status", "refunding").OrderByDesc("created_at").Limit(100), 8)
+func (s *ServiceImpl[T]) ListParallel(ctx context.Context, wrapper *QueryWrapper[T], parallelism int) ([]*T, error) {
+	return invoke(s, ctx, "ListParallel", wrapper, []any{wrapper, parallelism}, func(ctx context.Context) ([]*T, error) {
+		db := s.model(ctx)
+		if wrapper != nil {
+			db = wrapper.Apply(db)
+		}
+		db = s.prepare(s.applyQueryDefaults(db, true))
+		entities, err := cachedQuery(ctx, s, db, wrapper.queryCacheTTL(), "ListParallel", nil, func() ([]*T, error) {
+			shards, err := shardFanOut[T](db)
+			if err != nil {
+				return nil, err
+			}
+			if shards == nil {
+				var entities []*T
+				err = db.Find(&entities).Error
+				return entities, err
+			}
+			parts := make([]func(tx *gorm.DB) *gorm.DB, len(shards))
+			for i, table := range shards {
+				parts[i] = func(tx *gorm.DB) *gorm.DB { return onShard(tx, table) }
+			}
+			return s.fanOut(ctx, db, parts, parallelism)
+		})
+		if err != nil {
+			return entities, err
+		}
+		if entities == nil {
+			entities = make([]*T, 0)
+		}
+		return entities, s.mask(ctx, entities...)
+	})
+}
+
+// ListRanges 将满足条件的数据按主键区间 (id BETWEEN Start AND End) 拆分为多个查询，以最多 parallelism 个并发执行，适用于大表的后台查询
+// 区间通常由 SplitRanges 生成；实体分表且条件中缺少分片键时，每个区间查询全部分表
+// 合并、排序、LIMIT / OFFSET 与并发规则同 ListParallel；区间之间重叠时重叠部分的记录会重复返回
+//
+//	ranges, err := orderService.SplitRanges(ctx, w, 50000)
+//	orders, err := orderService.ListRanges(ctx, w.OrderByDesc("created_at").Limit(100), ranges, 8)
+func (s *ServiceImpl[T]) ListRanges(ctx context.Context, wrapper *QueryWrapper[T], ranges []KeyRange, parallelism int) ([]*T, error) {
+	return invoke(s, ctx, "ListRanges", wrapper, []any{wrapper, ranges, parallelism}, func(ctx context.Context) ([]*T, error) {
+		db := s.model(ctx)
+		if wrapper != nil {
+			db = wrapper.Apply(db)
+		}
+		db = s.prepare(s.applyQueryDefaults(db, true))
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return nil, err
+		}
+		pk := sch.PrioritizedPrimaryField
+		if pk == nil || !rangeKeyKind(pk) {
+			return nil, fmt.Errorf("range queries require an integer primary key on %s", sch.Name)
+		}
+		if len(ranges) == 0 {
+			return make([]*T, 0), nil
+		}
+		entities, err := cachedQuery(ctx, s, db, wrapper.queryCacheTTL(), "ListRanges", []any{ranges}, func() ([]*T, error) {
+			shards, err := shardFanOut[T](db)
+			if err != nil {
+				return nil, err
+			}
+			if shards == nil {
+				shards = []string{""}
+			}
+			parts := make([]func(tx *gorm.DB) *gorm.DB, 0, len(shards)*len(ranges))
+			for _, table := range shards {
+				for _, r := range ranges {
+					parts = append(parts, func(tx *gorm.DB) *gorm.DB {
+						if table != "" {
+							tx = onShard(tx, table)
+						}
+						return tx.Where(clause.And(
+							clause.Gte{Column: currentColumn(pk.DBName), Value: r.Start},
+							clause.Lte{Column: currentColumn(pk.DBName), Value: r.End},
+						))
+					})
+				}
+			}
+			return s.fanOut(ctx, db, parts, parallelism)
+		})
+		if err != nil {
+			return entities, err
+		}
+		if entities == nil {
+			entities = make([]*T, 0)
+		}
+		return entities, s.mask(ctx, entities...)
+	})
+}
+
+// fanOut 并发执行各部分 (分表或主键区间) 的查询并合并结果，part 在语句副本上限定该部分
+func (s *ServiceImpl[T]) fanOut(ctx context.Context, db *gorm.DB, parts []func(tx *gorm.DB) *gorm.DB, parallelism int) ([]*T, error) {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := mergeOrders(sch, db.Statement)
+	if err != nil {
+		return nil, err
+	}
+	// 每个部分最多需要返回 offset + limit 行，合并排序后再截取
+	var limit clause.Limit
+	if c, ok := db.Statement.Clauses["LIMIT"]; ok {
+		limit, _ = c.Expression.(clause.Limit)
+	}
+	var perShard clause.Limit
+	if limit.Limit != nil && *limit.Limit >= 0 {
+		n := limit.Offset + *limit.Limit
+		perShard.Limit = &n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if parallelism <= 0 || parallelism > len(parts) {
+		parallelism = len(parts)
+	}
+	if inTransaction(db) {
+		// 事务连接上的并发语句会使驱动出错 (如 MySQL 的 commands out of sync)
+		parallelism = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, parallelism)
+		results  = make([][]*T, len(parts))
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			tx := part(db.WithContext(ctx))
+			if perShard.Limit != nil {
+				tx.Statement.Clauses["LIMIT"] = clause.Clause{Name: "LIMIT", Expression: perShard}
+			} else {
+				delete(tx.Statement.Clauses, "LIMIT")
+			}
+			if err := tx.Find(&results[i]).Error; err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	entities := slices.Concat(results...)
+	if len(orders) > 0 {
+		sortEntities(ctx, entities, orders)
+	}
+	if limit.Offset > 0 {
+		entities = entities[min(limit.Offset, len(entities)):]
+	}
+	if limit.Limit != nil && *limit.Limit >= 0 && *limit.Limit < len(entities) {
+		entities = entities[:*limit.Limit]
+	}
+	return entities, nil
+}
+
+// entityOrder 内存排序的排序列
+type entityOrder struct {
+	field *schema.Field
+	desc  bool
+}
+
+// mergeOrders 解析语句的 ORDER BY 为实体字段，用于合并多张分表的结果
+func mergeOrders(sch *schema.Schema, stmt *gorm.Statement) ([]entityOrder, error) {
+	c, ok := stmt.Clauses["ORDER BY"]
+	if !ok {
+		return nil, nil
+	}
+	orderBy, _ := c.Expression.(clause.OrderBy)
+	var orders []entityOrder
+	add := func(name string, desc bool) error {
+		field := sch.LookUpField(bareColumn(name))
+		if field == nil || field.DBName == "" {
+			return fmt.Errorf("cannot merge shard results ordered by %q: not a column of %s", name, sch.Name)
+		}
+		orders = append(orders, entityOrder{field: field, desc: desc})
+		return nil
+	}
+	for _, column := range orderBy.Columns {
+		if !column.Column.Raw {
+			if err := add(column.Column.Name, column.Desc); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// Order("age DESC, id") 形式的原始排序
+		for _, part := range strings.Split(column.Column.Name, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 || len(fields) > 2 || len(fields) == 2 && !strings.EqualFold(fields[1], "DESC") && !strings.EqualFold(fields[1], "ASC") {
+				return nil, fmt.Errorf("cannot merge shard results ordered by %q", column.Column.Name)
+			}
+			if err := add(fields[0], len(fields) == 2 && strings.EqualFold(fields[1], "DESC")); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return orders, nil
+}
+
+// sortEntities 按排序列稳定排序，NULL 排在最前 (降序时最后)
+func sortEntities[T any](ctx context.Context, entities []*T, orders []entityOrder) {
+	slices.SortStableFunc(entities, func(a, b *T) int {
+		for _, o := range orders {
+			va, _ := o.field.ValueOf(ctx, reflect.ValueOf(a))
+			vb, _ := o.field.ValueOf(ctx, reflect.ValueOf(b))
+			x, y := plainValue(va), plainValue(vb)
+			var c int
+			switch {
+			case x == nil && y == nil:
+			case x == nil:
+				c = -1
+			case y == nil:
+				c = 1
+			default:
+				c, _ = compareValues(x, y)
+			}
+			if o.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
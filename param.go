@@ -0,0 +1,294 @@
+package gomp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidParam 外部传入的查询参数 (排序、筛选) 不合法，错误信息说明具体原因，可直接作为 400 响应返回
+var ErrInvalidParam = errors.New("invalid query parameter")
+
+// OrderFromParam 按外部传入的排序参数追加排序，如 "-created_at,+name" (- 降序，+ 或无前缀升序)
+// whitelist 为参数中允许的名称 -> 实际排序的列 (列为空时使用名称本身)，不在白名单中的名称返回 ErrInvalidParam，
+// 任一名称不合法时不追加任何排序；参数为空时不做处理。URL 查询参数中的 + 会被解码为空格，同样按升序处理
+//
+//	sortable := map[string]string{"created_at": "created_at", "name": "username"}
+//	if err := wrapper.OrderFromParam(r.URL.Query().Get("sort"), sortable); err != nil {
+//		http.Error(w, err.Error(), http.StatusBadRequest)
+//		return
+//	}
+func (w *QueryWrapper[T]) OrderFromParam(param string, whitelist map[string]string) error {
+	var orders []string
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(param, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		desc := false
+		switch item[0] {
+		case '-':
+			desc, item = true, item[1:]
+		case '+':
+			item = item[1:]
+		}
+		column, ok := whitelist[item]
+		if !ok {
+			return fmt.Errorf("%w: unknown sort field %q", ErrInvalidParam, item)
+		}
+		if column == "" {
+			column = item
+		}
+		if _, ok := seen[column]; ok {
+			continue
+		}
+		seen[column] = struct{}{}
+		if desc {
+			orders = append(orders, column+" DESC")
+		} else {
+			orders = append(orders, column+" ASC")
+		}
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		for _, order := range orders {
+			db = db.Order(order)
+		}
+		return db
+	})
+	return nil
+}
+
+// Filter 外部调用方 (HTTP / JSON 请求) 传入的一个筛选条件，多个条件以 AND 连接
+//
+//	[{"field": "status", "op": "in", "value": [1, 2]}, {"field": "name", "op": "like", "value": "tom"}]
+type Filter struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value any    `json:"value,omitempty"`
+}
+
+// 筛选运算符
+const (
+	FilterEq      = "eq"      // = value
+	FilterNe      = "ne"      // <> value
+	FilterGt      = "gt"      // > value
+	FilterGe      = "ge"      // >= value
+	FilterLt      = "lt"      // < value
+	FilterLe      = "le"      // <= value
+	FilterLike    = "like"    // LIKE '%value%'
+	FilterIn      = "in"      // IN (values)
+	FilterNotIn   = "notin"   // NOT IN (values)
+	FilterNull    = "null"    // IS NULL，忽略 value
+	FilterNotNull = "notnull" // IS NOT NULL，忽略 value
+	FilterBetween = "between" // BETWEEN values[0] AND values[1]
+)
+
+// FilterField 允许外部筛选的字段
+type FilterField struct {
+	Column string   // 实际的列，为空时使用参数中的名称
+	Ops    []string // 允许的运算符，为空时只允许 eq
+}
+
+// FilterPolicy 实体允许外部调用方使用的筛选字段与运算符，见 SetFilterPolicy
+type FilterPolicy struct {
+	Fields     map[string]FilterField // 参数中的名称 -> 字段
+	MaxIn      int                    // in / notin 的最大元素数，默认 100
+	MaxFilters int                    // 最多的条件数，默认 20
+}
+
+var (
+	filterPoliciesMu sync.RWMutex
+	filterPolicies   = make(map[reflect.Type]FilterPolicy)
+)
+
+// SetFilterPolicy 设置实体 T 的外部筛选策略，policy 为 nil 时移除；未设置策略的实体不接受任何外部筛选条件
+//
+//	gomp.SetFilterPolicy[Order](&gomp.FilterPolicy{
+//		Fields: map[string]gomp.FilterField{
+//			"status":  {Ops: []string{gomp.FilterEq, gomp.FilterIn}},
+//			"created": {Column: "created_at", Ops: []string{gomp.FilterGe, gomp.FilterLt, gomp.FilterBetween}},
+//		},
+//		MaxIn: 50,
+//	})
+func SetFilterPolicy[T any](policy *FilterPolicy) {
+	filterPoliciesMu.Lock()
+	defer filterPoliciesMu.Unlock()
+	if policy == nil {
+		delete(filterPolicies, entityType[T]())
+		return
+	}
+	filterPolicies[entityType[T]()] = *policy
+}
+
+// lookupFilterPolicy 获取实体 T 的外部筛选策略
+func lookupFilterPolicy[T any]() (FilterPolicy, bool) {
+	filterPoliciesMu.RLock()
+	defer filterPoliciesMu.RUnlock()
+	policy, ok := filterPolicies[entityType[T]()]
+	return policy, ok
+}
+
+// FiltersFromJSON 解析 JSON 数组形式的筛选条件 (见 Filter) 并按 Filters 追加
+func (w *QueryWrapper[T]) FiltersFromJSON(data []byte) error {
+	var filters []Filter
+	if err := json.Unmarshal(data, &filters); err != nil {
+		return fmt.Errorf("%w: malformed filters: %v", ErrInvalidParam, err)
+	}
+	return w.Filters(filters)
+}
+
+// Filters 按实体的筛选策略 (SetFilterPolicy) 检查外部传入的筛选条件并以 AND 追加，
+// 字段不在策略中、运算符不允许、值的形式不对或超过数量限制时返回 ErrInvalidParam，此时不追加任何条件
+//
+//	if err := wrapper.FiltersFromJSON(body); err != nil {
+//		http.Error(w, err.Error(), http.StatusBadRequest) // invalid query parameter: operator "like" is not allowed on field "status"
+//		return
+//	}
+func (w *QueryWrapper[T]) Filters(filters []Filter) error {
+	if len(filters) == 0 {
+		return nil
+	}
+	policy, ok := lookupFilterPolicy[T]()
+	if !ok {
+		return fmt.Errorf("%w: %s does not accept filters", ErrInvalidParam, entityType[T]().Name())
+	}
+	maxFilters := policy.MaxFilters
+	if maxFilters <= 0 {
+		maxFilters = 20
+	}
+	if len(filters) > maxFilters {
+		return fmt.Errorf("%w: too many filters (%d, max %d)", ErrInvalidParam, len(filters), maxFilters)
+	}
+	conds := make([]func(*QueryWrapper[T]), 0, len(filters))
+	for _, f := range filters {
+		cond, err := filterCondition[T](policy, f)
+		if err != nil {
+			return err
+		}
+		conds = append(conds, cond)
+	}
+	for _, cond := range conds {
+		cond(w)
+	}
+	return nil
+}
+
+// filterCondition 检查一个筛选条件并返回追加该条件的函数
+func filterCondition[T any](policy FilterPolicy, f Filter) (func(*QueryWrapper[T]), error) {
+	field, ok := policy.Fields[f.Field]
+	if !ok {
+		return nil, fmt.Errorf("%w: field %q is not filterable", ErrInvalidParam, f.Field)
+	}
+	column := field.Column
+	if column == "" {
+		column = f.Field
+	}
+	op := strings.ToLower(f.Op)
+	if op == "" {
+		op = FilterEq
+	}
+	ops := field.Ops
+	if len(ops) == 0 {
+		ops = []string{FilterEq}
+	}
+	if !slices.Contains(ops, op) {
+		return nil, fmt.Errorf("%w: operator %q is not allowed on field %q", ErrInvalidParam, f.Op, f.Field)
+	}
+	values, isList := filterList(f.Value)
+	switch op {
+	case FilterNull:
+		return func(w *QueryWrapper[T]) { w.IsNull(column) }, nil
+	case FilterNotNull:
+		return func(w *QueryWrapper[T]) { w.IsNotNull(column) }, nil
+	case FilterIn, FilterNotIn:
+		maxIn := policy.MaxIn
+		if maxIn <= 0 {
+			maxIn = 100
+		}
+		if !isList || len(values) == 0 {
+			return nil, fmt.Errorf("%w: %s on field %q requires a non-empty list", ErrInvalidParam, op, f.Field)
+		}
+		if len(values) > maxIn {
+			return nil, fmt.Errorf("%w: too many values for %s on field %q (%d, max %d)", ErrInvalidParam, op, f.Field, len(values), maxIn)
+		}
+		if err := checkFilterValues(f.Field, values...); err != nil {
+			return nil, err
+		}
+		if op == FilterIn {
+			return func(w *QueryWrapper[T]) { w.In(column, values) }, nil
+		}
+		return func(w *QueryWrapper[T]) { w.NotIn(column, values) }, nil
+	case FilterBetween:
+		if !isList || len(values) != 2 {
+			return nil, fmt.Errorf("%w: between on field %q requires 2 values", ErrInvalidParam, f.Field)
+		}
+		if err := checkFilterValues(f.Field, values...); err != nil {
+			return nil, err
+		}
+		return func(w *QueryWrapper[T]) { w.Between(column, values[0], values[1]) }, nil
+	}
+	if err := checkFilterValues(f.Field, f.Value); err != nil {
+		return nil, err
+	}
+	value := f.Value
+	switch op {
+	case FilterEq:
+		return func(w *QueryWrapper[T]) { w.Eq(column, value) }, nil
+	case FilterNe:
+		return func(w *QueryWrapper[T]) { w.Ne(column, value) }, nil
+	case FilterGt:
+		return func(w *QueryWrapper[T]) { w.Gt(column, value) }, nil
+	case FilterGe:
+		return func(w *QueryWrapper[T]) { w.Ge(column, value) }, nil
+	case FilterLt:
+		return func(w *QueryWrapper[T]) { w.Lt(column, value) }, nil
+	case FilterLe:
+		return func(w *QueryWrapper[T]) { w.Le(column, value) }, nil
+	case FilterLike:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: like on field %q requires a string", ErrInvalidParam, f.Field)
+		}
+		return func(w *QueryWrapper[T]) { w.Like(column, s) }, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %q on field %q", ErrInvalidParam, f.Op, f.Field)
+}
+
+// filterList 列表形式的筛选值，Go 代码构造的 []int 等切片同样支持
+func filterList(value any) ([]any, bool) {
+	if values, ok := value.([]any); ok {
+		return values, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
+
+// checkFilterValues 筛选值必须是标量 (字符串、数字、布尔)
+func checkFilterValues(field string, values ...any) error {
+	for _, v := range values {
+		switch v.(type) {
+		case string, float64, bool, json.Number,
+			int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		default:
+			return fmt.Errorf("%w: unsupported value %v for field %q", ErrInvalidParam, v, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package gomp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrPartitionUnsupported 当前数据库不支持的分区操作 (只支持 PostgreSQL 声明式分区与 MySQL RANGE COLUMNS 分区)
+var ErrPartitionUnsupported = errors.New("partitioning is not supported for this database")
+
+// PartitionInterval 分区的时间跨度
+type PartitionInterval int
+
+const (
+	PartitionMonthly PartitionInterval = iota // 按月分区，分区名后缀 200601
+	PartitionDaily                            // 按天分区，分区名后缀 20060102
+)
+
+// PartitionPolicy 实体表按时间分区的策略，见 SetPartitionPolicy
+// 分区表需预先按分区列建好: PostgreSQL 为 PARTITION BY RANGE (列)，MySQL 为 PARTITION BY RANGE COLUMNS(列) 且不含 MAXVALUE 分区
+type PartitionPolicy struct {
+	Interval PartitionInterval // 分区跨度，默认按月
+	Ahead    int               // 除当前分区外提前创建的分区数，默认 2
+	Retain   int               // 除当前分区外保留的历史分区数，更早的分区被删除 (数据一并删除)；为 0 时不删除
+	Location *time.Location    // 分区边界的时区，应与分区列的取值一致，默认 time.Local
+}
+
+// partitionEntry 已注册的分区策略
+type partitionEntry struct {
+	policy PartitionPolicy
+	name   string                                                 // 实体名称
+	table  func(ctx context.Context, db *gorm.DB) (string, error) // 解析实体表名
+}
+
+var (
+	partitionPoliciesMu sync.RWMutex
+	partitionPolicies   = make(map[reflect.Type]partitionEntry)
+)
+
+// SetPartitionPolicy 设置实体 T 的分区策略，由 MaintainPartitions / StartPartitionMaintenance 维护分区；policy 为 nil 时移除
+//
+//	gomp.SetPartitionPolicy[AccessLog](&gomp.PartitionPolicy{Interval: gomp.PartitionDaily, Ahead: 7, Retain: 90})
+func SetPartitionPolicy[T any](policy *PartitionPolicy) {
+	partitionPoliciesMu.Lock()
+	defer partitionPoliciesMu.Unlock()
+	if policy == nil {
+		delete(partitionPolicies, entityType[T]())
+		return
+	}
+	p := *policy
+	if p.Ahead <= 0 {
+		p.Ahead = 2
+	}
+	if p.Location == nil {
+		p.Location = time.Local
+	}
+	partitionPolicies[entityType[T]()] = partitionEntry{
+		policy: p,
+		name:   entityType[T]().Name(),
+		table: func(ctx context.Context, db *gorm.DB) (string, error) {
+			sch, err := parseSchema[T](db)
+			if err != nil {
+				return "", err
+			}
+			return ResolveTableName(ctx, sch.Table), nil
+		},
+	}
+}
+
+// lookupPartitionPolicy 获取实体 T 的分区策略
+func lookupPartitionPolicy[T any]() (partitionEntry, error) {
+	partitionPoliciesMu.RLock()
+	defer partitionPoliciesMu.RUnlock()
+	entry, ok := partitionPolicies[entityType[T]()]
+	if !ok {
+		return partitionEntry{}, fmt.Errorf("no partition policy for %s", entityType[T]().Name())
+	}
+	return entry, nil
+}
+
+// layout 分区名后缀的时间格式
+func (p PartitionPolicy) layout() string {
+	if p.Interval == PartitionDaily {
+		return "20060102"
+	}
+	return "200601"
+}
+
+// start t 所在分区的起始时间
+func (p PartitionPolicy) start(t time.Time) time.Time {
+	t = t.In(p.Location)
+	if p.Interval == PartitionDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, p.Location)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, p.Location)
+}
+
+// next 起始时间为 start 的分区之后 n 个分区的起始时间
+func (p PartitionPolicy) next(start time.Time, n int) time.Time {
+	if p.Interval == PartitionDaily {
+		return start.AddDate(0, 0, n)
+	}
+	return start.AddDate(0, n, 0)
+}
+
+// partitionName 分区名: PostgreSQL 为分区表名 <表名>_p<后缀> (不含 schema，与父表在同一 schema)，MySQL 为分区名 p<后缀>
+func (p PartitionPolicy) partitionName(dialect, table string, start time.Time) string {
+	suffix := "p" + start.Format(p.layout())
+	if dialect == "postgres" {
+		_, base := splitSchema(table)
+		return base + "_" + suffix
+	}
+	return suffix
+}
+
+// parsePartition 由分区名解析分区起始时间，不是按策略命名的分区 (如默认分区) 返回 false
+func (p PartitionPolicy) parsePartition(dialect, table, name string) (time.Time, bool) {
+	prefix := "p"
+	if dialect == "postgres" {
+		_, base := splitSchema(table)
+		prefix = base + "_p"
+	}
+	suffix, ok := strings.CutPrefix(name, prefix)
+	if !ok || len(suffix) != len(p.layout()) {
+		return time.Time{}, false
+	}
+	start, err := time.ParseInLocation(p.layout(), suffix, p.Location)
+	return start, err == nil
+}
+
+// PartitionName 实体 T 在时间 t 所在分区的名称 (PostgreSQL 为分区表名，MySQL 为分区名)，可用于 QueryWrapper.Partition
+func PartitionName[T any](ctx context.Context, db *gorm.DB, t time.Time) (string, error) {
+	entry, err := lookupPartitionPolicy[T]()
+	if err != nil {
+		return "", err
+	}
+	table, err := entry.table(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	return entry.policy.partitionName(db.Dialector.Name(), table, entry.policy.start(t)), nil
+}
+
+// CreatePartition 创建实体 T 在时间 t 所在的分区，分区已存在时不做处理
+// MySQL 只能在最后一个分区之后追加，t 早于已有分区时返回错误
+func CreatePartition[T any](ctx context.Context, db *gorm.DB, t time.Time) error {
+	entry, err := lookupPartitionPolicy[T]()
+	if err != nil {
+		return err
+	}
+	table, err := entry.table(ctx, db)
+	if err != nil {
+		return err
+	}
+	existing, err := listPartitions(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	start := entry.policy.start(t)
+	if slices.Contains(existing, entry.policy.partitionName(db.Dialector.Name(), table, start)) {
+		return nil
+	}
+	return createPartition(ctx, db, entry.policy, table, start)
+}
+
+// AttachPartition 将已建好并导入数据的表 source 挂载为实体 T 在时间 t 所在的分区 (仅 PostgreSQL)
+// 适用于先离线加载数据再上线的场景，source 的结构须与分区表一致
+func AttachPartition[T any](ctx context.Context, db *gorm.DB, source string, t time.Time) error {
+	if db.Dialector.Name() != "postgres" {
+		return fmt.Errorf("%w: attach partition requires postgres", ErrPartitionUnsupported)
+	}
+	entry, err := lookupPartitionPolicy[T]()
+	if err != nil {
+		return err
+	}
+	table, err := entry.table(ctx, db)
+	if err != nil {
+		return err
+	}
+	start := entry.policy.start(t)
+	from, to := partitionBound(start), partitionBound(entry.policy.next(start, 1))
+	stmt := db.Statement
+	return db.WithContext(ctx).Exec(fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')",
+		stmt.Quote(table), stmt.Quote(source), from, to)).Error
+}
+
+// DropPartition 删除实体 T 在时间 t 所在的分区及其数据，分区不存在时不做处理
+func DropPartition[T any](ctx context.Context, db *gorm.DB, t time.Time) error {
+	entry, err := lookupPartitionPolicy[T]()
+	if err != nil {
+		return err
+	}
+	table, err := entry.table(ctx, db)
+	if err != nil {
+		return err
+	}
+	existing, err := listPartitions(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	name := entry.policy.partitionName(db.Dialector.Name(), table, entry.policy.start(t))
+	if !slices.Contains(existing, name) {
+		return nil
+	}
+	return dropPartition(ctx, db, table, name)
+}
+
+// MaintainPartitions 按已注册的分区策略维护所有实体的分区: 创建当前及之后 Ahead 个分区，删除 Retain 之前的历史分区
+// 返回各实体遇到的错误 (errors.Join)，一个实体失败不影响其他实体
+func MaintainPartitions(ctx context.Context, db *gorm.DB) error {
+	partitionPoliciesMu.RLock()
+	entries := make([]partitionEntry, 0, len(partitionPolicies))
+	for _, entry := range partitionPolicies {
+		entries = append(entries, entry)
+	}
+	partitionPoliciesMu.RUnlock()
+	slices.SortFunc(entries, func(a, b partitionEntry) int { return cmp.Compare(a.name, b.name) })
+
+	var errs []error
+	for _, entry := range entries {
+		if err := maintainPartitions(ctx, db, entry, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("maintain partitions of %s: %w", entry.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartPartitionMaintenance 立即并每隔 interval 执行一次 MaintainPartitions，直到 ctx 结束
+// onError 接收每次维护的错误，为 nil 时通过 slog.Default() 以 WARN 级别输出
+//
+//	err := gomp.StartPartitionMaintenance(ctx, db, 6*time.Hour, nil)
+func StartPartitionMaintenance(ctx context.Context, db *gorm.DB, interval time.Duration, onError func(err error)) error {
+	if interval <= 0 {
+		return errors.New("partition maintenance interval must be greater than 0")
+	}
+	if onError == nil {
+		onError = func(err error) {
+			slog.Default().WarnContext(ctx, "gomp partition maintenance failed", slog.String("error", err.Error()))
+		}
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := MaintainPartitions(ctx, db); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// maintainPartitions 维护一个实体的分区
+func maintainPartitions(ctx context.Context, db *gorm.DB, entry partitionEntry, now time.Time) error {
+	table, err := entry.table(ctx, db)
+	if err != nil {
+		return err
+	}
+	existing, err := listPartitions(ctx, db, table)
+	if err != nil {
+		return err
+	}
+	dialect := db.Dialector.Name()
+	p := entry.policy
+	current := p.start(now)
+
+	// MySQL 只能在最后一个分区之后追加
+	var latest time.Time
+	for _, name := range existing {
+		if start, ok := p.parsePartition(dialect, table, name); ok && start.After(latest) {
+			latest = start
+		}
+	}
+	for i := 0; i <= p.Ahead; i++ {
+		start := p.next(current, i)
+		if slices.Contains(existing, p.partitionName(dialect, table, start)) || dialect == "mysql" && !start.After(latest) {
+			continue
+		}
+		if err := createPartition(ctx, db, p, table, start); err != nil {
+			return err
+		}
+	}
+
+	if p.Retain <= 0 {
+		return nil
+	}
+	cutoff := p.next(current, -p.Retain)
+	for _, name := range existing {
+		if start, ok := p.parsePartition(dialect, table, name); ok && start.Before(cutoff) {
+			if err := dropPartition(ctx, db, table, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// partitionBound 分区边界的字面量
+func partitionBound(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// listPartitions 列出分区表的分区名
+func listPartitions(ctx context.Context, db *gorm.DB, table string) ([]string, error) {
+	var names []string
+	var err error
+	switch db.Dialector.Name() {
+	case "postgres":
+		err = db.WithContext(ctx).Raw(`SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = ?::regclass ORDER BY c.relname`, db.Statement.Quote(table)).Scan(&names).Error
+	case "mysql":
+		schema, name := splitSchema(table)
+		err = db.WithContext(ctx).Raw(`SELECT PARTITION_NAME FROM information_schema.PARTITIONS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL
+ORDER BY PARTITION_ORDINAL_POSITION`, schema, name).Scan(&names).Error
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPartitionUnsupported, db.Dialector.Name())
+	}
+	return names, err
+}
+
+// createPartition 创建起始时间为 start 的分区
+func createPartition(ctx context.Context, db *gorm.DB, p PartitionPolicy, table string, start time.Time) error {
+	dialect := db.Dialector.Name()
+	name := p.partitionName(dialect, table, start)
+	from, to := partitionBound(start), partitionBound(p.next(start, 1))
+	stmt := db.Statement
+	var sql string
+	switch dialect {
+	case "postgres":
+		sql = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			stmt.Quote(qualifyPartition(table, name)), stmt.Quote(table), from, to)
+	case "mysql":
+		sql = fmt.Sprintf("ALTER TABLE %s ADD PARTITION (PARTITION %s VALUES LESS THAN ('%s'))", stmt.Quote(table), stmt.Quote(name), to)
+	default:
+		return fmt.Errorf("%w: %s", ErrPartitionUnsupported, dialect)
+	}
+	return db.WithContext(ctx).Exec(sql).Error
+}
+
+// dropPartition 删除分区及其数据
+func dropPartition(ctx context.Context, db *gorm.DB, table, name string) error {
+	stmt := db.Statement
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.WithContext(ctx).Exec("DROP TABLE IF EXISTS " + stmt.Quote(qualifyPartition(table, name))).Error
+	case "mysql":
+		return db.WithContext(ctx).Exec(fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", stmt.Quote(table), stmt.Quote(name))).Error
+	}
+	return fmt.Errorf("%w: %s", ErrPartitionUnsupported, db.Dialector.Name())
+}
+
+// splitSchema 拆分 schema.table，没有 schema 时 schema 为空
+func splitSchema(table string) (schema, name string) {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return schema, name
+	}
+	return "", table
+}
+
+// qualifyPartition PostgreSQL 分区表与父表在同一 schema
+func qualifyPartition(table, partition string) string {
+	if schema, _ := splitSchema(table); schema != "" {
+		return schema + "." + partition
+	}
+	return partition
+}
+
+// partitionIdent 分区名只允许标识符
+var partitionIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Partition 将查询限定在指定分区，用于维护任务只扫描个别分区: MySQL 生成 FROM t PARTITION (p1, p2)，
+// PostgreSQL 直接查询分区表 (只能指定一个分区)，其他数据库返回 ErrPartitionUnsupported。分区名可通过 PartitionName 获取
+//
+//	name, _ := gomp.PartitionName[AccessLog](ctx, db, time.Now().AddDate(0, -1, 0))
+//	count, err := logService.Count(ctx, gomp.NewQueryWrapper[AccessLog]().Partition(name).Eq("status", 500))
+func (w *QueryWrapper[T]) Partition(names ...string) *QueryWrapper[T] {
+	w.addScope(func(db *gorm.DB) *gorm.DB {
+		for _, name := range names {
+			if !partitionIdent.MatchString(name) {
+				_ = db.AddError(fmt.Errorf("invalid partition name %q", name))
+				return db
+			}
+		}
+		if len(names) == 0 {
+			return db
+		}
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		table := ResolveTableName(db.Statement.Context, sch.Table)
+		switch dialect := db.Dialector.Name(); dialect {
+		case "mysql":
+			quoted := make([]string, len(names))
+			for i, name := range names {
+				quoted[i] = db.Statement.Quote(name)
+			}
+			return db.Table(db.Statement.Quote(table) + " PARTITION (" + strings.Join(quoted, ",") + ")")
+		case "postgres":
+			if len(names) > 1 {
+				_ = db.AddError(fmt.Errorf("%w: postgres queries a single partition table", ErrPartitionUnsupported))
+				return db
+			}
+			return db.Table(qualifyPartition(table, names[0]))
+		default:
+			_ = db.AddError(fmt.Errorf("%w: %s", ErrPartitionUnsupported, dialect))
+			return db
+		}
+	})
+	return w
+}
@@ -0,0 +1,201 @@
+package gomp
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatementStats 一类语句 (带占位符的 SQL 相同) 的执行统计
+type StatementStats struct {
+	Statement    string        `json:"statement"`    // 带占位符的 SQL
+	Entity       string        `json:"entity"`       // 实体名称
+	Table        string        `json:"table"`        // 表名
+	Operation    string        `json:"operation"`    // 操作类型: create / query / update / delete / row / raw
+	Label        string        `json:"label"`        // 调试标签 (Wrapper.Label / WithQueryLabel)，标签不同的语句分别统计
+	Count        int64         `json:"count"`        // 执行次数
+	Errors       int64         `json:"errors"`       // 执行失败次数 (不含 gorm.ErrRecordNotFound)
+	Total        time.Duration `json:"total"`        // 累计耗时
+	Min          time.Duration `json:"min"`          // 最短耗时
+	Max          time.Duration `json:"max"`          // 最长耗时
+	Rows         int64         `json:"rows"`         // 累计返回 / 影响行数
+	RowsExamined float64       `json:"rowsExamined"` // 单次执行的预估扫描行数 (EXPLAIN 估算，各表预估行数之和)，未开启或无法获取时为 0
+	SlowestSQL   string        `json:"slowestSql"`   // 最慢一次执行的 SQL (参数内联，敏感参数已脱敏)
+	SlowestAt    time.Time     `json:"slowestAt"`    // 最慢一次执行的时间
+	Caller       string        `json:"caller"`       // 最慢一次执行的调用位置 (file:line)
+}
+
+// Avg 平均耗时
+func (s StatementStats) Avg() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// AvgRows 平均返回 / 影响行数
+func (s StatementStats) AvgRows() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Rows) / float64(s.Count)
+}
+
+// PerformanceOptions 性能分析配置
+type PerformanceOptions struct {
+	MaxStatements    int  // 最多统计的语句种类，达到后不再统计新语句，默认 1000
+	EstimateExamined bool // 每类查询 / 更新 / 删除语句首次执行后通过 EXPLAIN 估算扫描行数
+}
+
+// perfCollector 进程内的语句统计
+type perfCollector struct {
+	opts  PerformanceOptions
+	mu    sync.Mutex
+	stats map[string]*StatementStats
+}
+
+// activePerf 当前生效的性能分析，为 nil 时关闭
+var activePerf atomic.Pointer[perfCollector]
+
+// EnablePerformanceStats 开启 P6Spy 式的性能分析: 按语句统计 gomp 执行的 SQL 的次数、耗时、行数等，重复调用会清空已有统计
+// 统计保存在进程内存中，通过 PerformanceSnapshot / TopSlowStatements / PerformanceHandler 读取
+func EnablePerformanceStats(opts PerformanceOptions) {
+	if opts.MaxStatements <= 0 {
+		opts.MaxStatements = 1000
+	}
+	activePerf.Store(&perfCollector{opts: opts, stats: make(map[string]*StatementStats)})
+}
+
+// DisablePerformanceStats 关闭性能分析并丢弃已有统计
+func DisablePerformanceStats() {
+	activePerf.Store(nil)
+}
+
+// ResetPerformanceStats 清空已有统计，配置不变
+func ResetPerformanceStats() {
+	if c := activePerf.Load(); c != nil {
+		c.mu.Lock()
+		c.stats = make(map[string]*StatementStats)
+		c.mu.Unlock()
+	}
+}
+
+// PerformanceSnapshot 当前全部语句统计的副本，按累计耗时降序排列；未开启时返回 nil
+func PerformanceSnapshot() []StatementStats {
+	c := activePerf.Load()
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	result := make([]StatementStats, 0, len(c.stats))
+	for _, s := range c.stats {
+		result = append(result, *s)
+	}
+	c.mu.Unlock()
+	slices.SortFunc(result, func(a, b StatementStats) int { return cmp.Compare(b.Total, a.Total) })
+	return result
+}
+
+// TopSlowStatements 按最长耗时降序排列的前 n 类语句
+func TopSlowStatements(n int) []StatementStats {
+	stats := PerformanceSnapshot()
+	slices.SortFunc(stats, func(a, b StatementStats) int { return cmp.Compare(b.Max, a.Max) })
+	return stats[:min(max(n, 0), len(stats))]
+}
+
+// PerformanceHandler 以 JSON 输出语句统计的 HTTP Handler，用于诊断接口
+// 查询参数 n 限制返回数量 (默认 20)，sort=max 按最长耗时排序 (默认按累计耗时)
+//
+//	mux.Handle("/debug/gomp/sql", gomp.PerformanceHandler())
+func PerformanceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 20
+		if v, err := strconv.Atoi(r.URL.Query().Get("n")); err == nil && v > 0 {
+			n = v
+		}
+		var stats []StatementStats
+		if r.URL.Query().Get("sort") == "max" {
+			stats = TopSlowStatements(n)
+		} else {
+			stats = PerformanceSnapshot()
+			stats = stats[:min(n, len(stats))]
+		}
+		if stats == nil {
+			stats = make([]StatementStats, 0)
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(stats)
+	})
+}
+
+// recordPerformance 语句执行后累计统计
+func recordPerformance(db *gorm.DB, operation string, duration time.Duration) {
+	c := activePerf.Load()
+	if c == nil {
+		return
+	}
+	stmt := db.Statement
+	query := stmt.SQL.String()
+	failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+
+	c.mu.Lock()
+	s, ok := c.stats[query]
+	if !ok {
+		if len(c.stats) >= c.opts.MaxStatements {
+			c.mu.Unlock()
+			return
+		}
+		s = &StatementStats{Statement: query, Table: stmt.Table, Operation: operation, Label: QueryLabel(stmt.Context), Min: duration}
+		if stmt.Schema != nil {
+			s.Entity = stmt.Schema.Name
+			if s.Table == "" {
+				s.Table = stmt.Schema.Table
+			}
+		}
+		c.stats[query] = s
+	}
+	s.Count++
+	s.Total += duration
+	s.Rows += stmt.RowsAffected
+	s.Min = min(s.Min, duration)
+	if failed {
+		s.Errors++
+	}
+	slowest := !ok || duration > s.Max
+	if slowest {
+		s.Max = duration
+		s.SlowestAt = time.Now()
+	}
+	c.mu.Unlock()
+
+	// 格式化 SQL 与获取调用位置开销较大，只在刷新最慢记录时进行
+	if slowest {
+		sql := db.Dialector.Explain(query, redactArgs(query, stmt.Vars, stmt.Schema)...)
+		caller := callerLocation()
+		c.mu.Lock()
+		if s.Max == duration {
+			s.SlowestSQL, s.Caller = sql, caller
+		}
+		c.mu.Unlock()
+	}
+
+	if !ok && c.opts.EstimateExamined && !failed && operation != OperationCreate && operation != OperationRaw {
+		if plan, err := explainStatement(stmt.Context, db); err == nil {
+			var examined float64
+			for _, node := range plan.Nodes {
+				examined += node.Rows
+			}
+			c.mu.Lock()
+			s.RowsExamined = examined
+			c.mu.Unlock()
+		}
+	}
+}
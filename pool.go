@@ -0,0 +1,136 @@
+package gomp
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PoolStats 一个数据库连接池的状态 (sql.DBStats)
+type PoolStats struct {
+	DataSource         string        `json:"dataSource"`         // 数据源名称，未通过 RegisterDataSource 注册的主库及其从库为空字符串
+	Replica            bool          `json:"replica"`            // 是否为从库
+	MaxOpenConnections int           `json:"maxOpenConnections"` // 最大连接数，0 为不限制
+	OpenConnections    int           `json:"openConnections"`    // 已建立的连接数
+	InUse              int           `json:"inUse"`              // 使用中的连接数
+	Idle               int           `json:"idle"`               // 空闲连接数
+	WaitCount          int64         `json:"waitCount"`          // 累计等待连接的次数
+	WaitDuration       time.Duration `json:"waitDuration"`       // 累计等待连接的时间
+	MaxIdleClosed      int64         `json:"maxIdleClosed"`      // 因超过 MaxIdleConns 关闭的连接数
+	MaxIdleTimeClosed  int64         `json:"maxIdleTimeClosed"`  // 因超过 ConnMaxIdleTime 关闭的连接数
+	MaxLifetimeClosed  int64         `json:"maxLifetimeClosed"`  // 因超过 ConnMaxLifetime 关闭的连接数
+	Utilization        float64       `json:"utilization"`        // InUse / MaxOpenConnections，不限制连接数时为 0
+	NewWaits           int64         `json:"newWaits"`           // 距上次采样新增的等待次数，StartPoolStats 之外为 0
+	NewWaitDuration    time.Duration `json:"newWaitDuration"`    // 距上次采样新增的等待时间，StartPoolStats 之外为 0
+	DB                 *gorm.DB      `json:"-"`                  // 连接池所属的库
+}
+
+// PoolStatsSnapshot 读取已注册的数据源与从库的连接池状态，按数据源名称排序，主库在前
+func PoolStatsSnapshot() []PoolStats {
+	stats := make([]PoolStats, 0)
+	for _, target := range healthTargets() {
+		db := target.primary
+		if target.replica != nil {
+			db = target.replica.db
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			continue
+		}
+		stats = append(stats, newPoolStats(target.name, target.replica != nil, db, sqlDB.Stats()))
+	}
+	slices.SortStableFunc(stats, func(a, b PoolStats) int {
+		if c := cmp.Compare(a.DataSource, b.DataSource); c != 0 {
+			return c
+		}
+		if a.Replica != b.Replica {
+			if a.Replica {
+				return 1
+			}
+			return -1
+		}
+		return 0
+	})
+	return stats
+}
+
+// newPoolStats 由 sql.DBStats 构造 PoolStats
+func newPoolStats(name string, replica bool, db *gorm.DB, s sql.DBStats) PoolStats {
+	stats := PoolStats{
+		DataSource:         name,
+		Replica:            replica,
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+		DB:                 db,
+	}
+	if s.MaxOpenConnections > 0 {
+		stats.Utilization = float64(s.InUse) / float64(s.MaxOpenConnections)
+	}
+	return stats
+}
+
+// StartPoolStats 每隔 interval 采样一次连接池状态并交给 fn (如上报指标、在 Utilization 或 NewWaits 过高时告警)，直到 ctx 结束
+// 采样在独立的协程中执行，NewWaits / NewWaitDuration 为距上次采样的增量
+//
+//	_ = gomp.StartPoolStats(ctx, 15*time.Second, func(stats []gomp.PoolStats) {
+//		for _, s := range stats {
+//			poolInUse.WithLabelValues(s.DataSource).Set(float64(s.InUse))
+//			if s.NewWaits > 0 {
+//				log.Printf("pool %q waited %d times (%s)", s.DataSource, s.NewWaits, s.NewWaitDuration)
+//			}
+//		}
+//	})
+func StartPoolStats(ctx context.Context, interval time.Duration, fn func(stats []PoolStats)) error {
+	if interval <= 0 {
+		return errors.New("pool stats interval must be greater than 0")
+	}
+	if fn == nil {
+		return errors.New("pool stats callback is required")
+	}
+	go func() {
+		last := make(map[*gorm.DB]PoolStats)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			stats := PoolStatsSnapshot()
+			for i, s := range stats {
+				if prev, ok := last[s.DB]; ok {
+					stats[i].NewWaits = s.WaitCount - prev.WaitCount
+					stats[i].NewWaitDuration = s.WaitDuration - prev.WaitDuration
+				}
+				last[s.DB] = s
+			}
+			fn(stats)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// PoolStatsHandler 以 JSON 输出连接池状态的 HTTP Handler，用于诊断接口
+//
+//	mux.Handle("/debug/gomp/pool", gomp.PoolStatsHandler())
+func PoolStatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(PoolStatsSnapshot())
+	})
+}
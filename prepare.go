@@ -0,0 +1,115 @@
+package gomp
+
+import (
+	"reflect"
+	"regexp"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithPrepareStmt 设置该 Service 执行的语句是否使用预编译语句缓存，优先于全局配置 prepareStmt；应在 Service 初始化时调用
+// 预编译语句按 SQL 文本缓存在连接池上，开启后 IN 列表的参数个数会补齐到 2 的幂 (重复最后一个值)，避免列表长度不同的查询各自预编译
+func (s *ServiceImpl[T]) WithPrepareStmt(enabled bool) *ServiceImpl[T] {
+	s.prepareStmt = &enabled
+	return s
+}
+
+// prepareStmtEnabled 是否使用预编译语句
+func (s *ServiceImpl[T]) prepareStmtEnabled() bool {
+	if s.prepareStmt != nil {
+		return *s.prepareStmt
+	}
+	return getConfig().PrepareStmt
+}
+
+// usesPreparedStmt 语句是否通过预编译语句缓存执行
+func usesPreparedStmt(db *gorm.DB) bool {
+	switch db.Statement.ConnPool.(type) {
+	case *gorm.PreparedStmtDB, *gorm.PreparedStmtTX:
+		return true
+	}
+	return false
+}
+
+// basePool 去掉预编译语句缓存的包装，返回底层连接池
+func basePool(pool gorm.ConnPool) gorm.ConnPool {
+	switch p := pool.(type) {
+	case *gorm.PreparedStmtDB:
+		return p.ConnPool
+	case *gorm.PreparedStmtTX:
+		return p.Tx
+	}
+	return pool
+}
+
+// inPlaceholder 单个占位符的 IN 条件，如 Wrapper.In 生成的 "status IN (?)"
+var inPlaceholder = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?\s*\)`)
+
+// normalizeInLists 使用预编译语句时将 IN 列表的参数个数补齐到 2 的幂，使不同长度的列表复用少量预编译语句
+func normalizeInLists(db *gorm.DB) {
+	if !isManaged(db) || !usesPreparedStmt(db) {
+		return
+	}
+	c, ok := db.Statement.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+	where, ok := c.Expression.(clause.Where)
+	if !ok {
+		return
+	}
+	where.Exprs = normalizeInExprs(where.Exprs)
+	c.Expression = where
+	db.Statement.Clauses["WHERE"] = c
+}
+
+// normalizeInExprs 补齐条件 (含嵌套的 AND / OR) 中的 IN 列表
+func normalizeInExprs(exprs []clause.Expression) []clause.Expression {
+	result := make([]clause.Expression, len(exprs))
+	for i, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.AndConditions:
+			e.Exprs = normalizeInExprs(e.Exprs)
+			expr = e
+		case clause.OrConditions:
+			e.Exprs = normalizeInExprs(e.Exprs)
+			expr = e
+		case clause.Expr:
+			if len(e.Vars) == 1 && inPlaceholder.MatchString(e.SQL) {
+				e.Vars = []any{padInList(e.Vars[0])}
+				expr = e
+			}
+		case clause.IN:
+			if len(e.Values) > 1 {
+				e.Values = padInList(e.Values).([]any)
+				expr = e
+			}
+		}
+		result[i] = expr
+	}
+	return result
+}
+
+// padInList 将切片补齐到 2 的幂，补齐的元素重复最后一个值；非切片 ([]byte 除外) 或长度不大于 1 时原样返回
+func padInList(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 || rv.Len() <= 1 {
+		return v
+	}
+	n := rv.Len()
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	if size == n {
+		return v
+	}
+	padded := reflect.MakeSlice(rv.Type(), size, size)
+	reflect.Copy(padded, rv)
+	last := rv.Index(n - 1)
+	for i := n; i < size; i++ {
+		padded.Index(i).Set(last)
+	}
+	return padded.Interface()
+}
@@ -0,0 +1,279 @@
+package gomp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// activeQueryCache 查询结果缓存存储，为 nil 时 QueryWrapper.Cache 不生效
+	activeQueryCache atomic.Pointer[Cache]
+	// queryFlight 合并缓存未命中时相同查询的并发加载
+	queryFlight flightGroup
+)
+
+// SetQueryCache 设置查询结果缓存 (QueryWrapper.Cache) 使用的存储，传入 nil 关闭
+func SetQueryCache(c Cache) {
+	if c == nil {
+		activeQueryCache.Store(nil)
+		return
+	}
+	activeQueryCache.Store(&c)
+}
+
+// queryCacheStore 获取查询结果缓存存储
+func queryCacheStore() Cache {
+	if c := activeQueryCache.Load(); c != nil {
+		return *c
+	}
+	return nil
+}
+
+// pageCacheEntry 分页查询缓存的内容
+type pageCacheEntry[T any] struct {
+	Current int64
+	Total   int64
+	Records []*T
+}
+
+// cachedQuery 按渲染后的 SQL 缓存查询结果，ttl 不大于 0、未配置缓存存储或处于事务中时直接执行 load
+// key 由方法名、数据源、带参数的 SQL、额外参数 (如分页) 及表的缓存版本组成，表发生写操作后版本变化，旧结果自然失效
+// 事务中的查询可能读到未提交的数据，不读写缓存；结果中实体的加密字段以密文写入缓存
+func cachedQuery[T, R any](ctx context.Context, s *ServiceImpl[T], db *gorm.DB, ttl time.Duration, method string, params []any, load func() (R, error)) (R, error) {
+	store := queryCacheStore()
+	if ttl <= 0 || store == nil || db.Error != nil || inTransaction(db) {
+		return load()
+	}
+	sql, table, err := renderQuery[T](db)
+	if err != nil {
+		return load()
+	}
+	version, err := queryCacheVersion(ctx, store, table)
+	if err != nil {
+		return load()
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%s\x00%v", method, s.dataSourceName(ctx), sql, params))
+	key := "gomp:qc:" + table + ":" + version + ":" + hex.EncodeToString(sum[:])
+
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return load()
+	}
+	if data, ok, err := store.Get(ctx, key); err == nil && ok {
+		var result R
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&result); err == nil {
+			if result, err = openResult[T](ctx, sch, result); err == nil {
+				return result, nil
+			}
+		}
+	}
+
+	// 合并相同查询的并发加载，结果以编码后的字节共享
+	var leader R
+	led := false
+	data, err, _ := queryFlight.do(key, func() ([]byte, error) {
+		result, err := load()
+		if err != nil {
+			return nil, err
+		}
+		leader, led = result, true
+		sealed, err := sealResult[T](ctx, sch, result)
+		if err != nil {
+			return nil, errFlightUnshareable
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(sealed); err != nil {
+			return nil, errFlightUnshareable
+		}
+		_ = store.Set(ctx, key, buf.Bytes(), ttl)
+		return buf.Bytes(), nil
+	})
+	if led {
+		return leader, nil
+	}
+	if errors.Is(err, errFlightUnshareable) {
+		return load()
+	}
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+	var result R
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&result); err != nil {
+		return load()
+	}
+	if result, err = openResult[T](ctx, sch, result); err != nil {
+		return load()
+	}
+	return result, nil
+}
+
+// sealResult 将查询结果中实体的加密字段替换为密文 (在副本上进行)，与实体缓存一致，缓存中不保存明文
+func sealResult[T, R any](ctx context.Context, sch *schema.Schema, result R) (R, error) {
+	if len(schemaEncryptedFields(sch)) == 0 {
+		return result, nil
+	}
+	seal := func(records []*T) ([]*T, error) {
+		sealed := make([]*T, len(records))
+		for i, entity := range records {
+			var err error
+			if sealed[i], err = sealEntity(ctx, sch, entity); err != nil {
+				return nil, err
+			}
+		}
+		return sealed, nil
+	}
+	switch r := any(result).(type) {
+	case []*T:
+		records, err := seal(r)
+		if err != nil {
+			return result, err
+		}
+		return any(records).(R), nil
+	case pageCacheEntry[T]:
+		records, err := seal(r.Records)
+		if err != nil {
+			return result, err
+		}
+		r.Records = records
+		return any(r).(R), nil
+	}
+	return result, nil
+}
+
+// openResult 解密 sealResult 加密的字段
+func openResult[T, R any](ctx context.Context, sch *schema.Schema, result R) (R, error) {
+	var records []*T
+	switch r := any(result).(type) {
+	case []*T:
+		records = r
+	case pageCacheEntry[T]:
+		records = r.Records
+	}
+	for _, entity := range records {
+		if err := openEntity(ctx, sch, entity); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// renderQuery 在语句副本上生成查询 SQL (参数内联)，不执行也不触发回调，返回 SQL 与实体表名
+func renderQuery[T any](db *gorm.DB) (string, string, error) {
+	tx, err := queryStatement[T](db)
+	if err != nil {
+		return "", "", err
+	}
+	callbacks.BuildQuerySQL(tx)
+	if tx.Error != nil {
+		return "", "", tx.Error
+	}
+	stmt := tx.Statement
+	return tx.Dialector.Explain(stmt.SQL.String(), stmt.Vars...), stmt.Schema.Table, nil
+}
+
+// queryStatement 创建用于生成查询 SQL 的语句副本，已解析实体 schema
+func queryStatement[T any](db *gorm.DB) (*gorm.DB, error) {
+	tx := db.Session(&gorm.Session{Context: db.Statement.Context})
+	stmt := tx.Statement
+	dest := new([]*T)
+	if stmt.Model == nil {
+		stmt.Model = new(T)
+	}
+	stmt.Dest = dest
+	stmt.ReflectValue = reflect.ValueOf(dest).Elem()
+	// 子句列表通常由查询回调在执行时设置，这里不执行回调，需手动指定
+	stmt.BuildClauses = tx.Callback().Query().Clauses
+	if err := stmt.Parse(stmt.Model); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// queryCacheVersionKey 表的缓存版本 key
+func queryCacheVersionKey(table string) string {
+	return "gomp:qc:ver:" + table
+}
+
+// queryCacheVersion 获取表的缓存版本，不存在 (如被淘汰) 时生成新版本，避免读取到失效前的结果
+func queryCacheVersion(ctx context.Context, store Cache, table string) (string, error) {
+	key := queryCacheVersionKey(table)
+	data, ok, err := store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(data), nil
+	}
+	version := newQueryCacheVersion()
+	return version, store.Set(ctx, key, []byte(version), 0)
+}
+
+// newQueryCacheVersion 生成新的缓存版本
+func newQueryCacheVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatUint(rand.Uint64()&0xffff, 36)
+}
+
+// invalidateQueryCache 通过 gomp 执行的写操作成功后更新实体表的缓存版本
+func invalidateQueryCache(db *gorm.DB) {
+	if db.Error != nil || !isManaged(db) || db.Statement.Schema == nil {
+		return
+	}
+	bumpQueryCacheVersion(db, db.Statement.Schema.Table)
+}
+
+// bumpQueryCacheVersion 更新表的缓存版本，事务中的写操作在 Transaction 提交后更新
+// 无法得知提交结果的事务立即更新，提交前并发的查询可能缓存旧结果直到过期
+func bumpQueryCacheVersion(db *gorm.DB, table string) {
+	store := queryCacheStore()
+	if store == nil {
+		return
+	}
+	ctx := context.WithoutCancel(db.Statement.Context)
+	bump := func() {
+		_ = store.Set(ctx, queryCacheVersionKey(table), []byte(newQueryCacheVersion()), 0)
+	}
+	if !afterCommit(db, bump) {
+		bump()
+	}
+}
+
+// cachedPage 分页查询，wrapper 开启缓存时缓存总数与当页记录 (开启汇总时不缓存)
+func (s *ServiceImpl[T]) cachedPage(ctx context.Context, page *Page[T], wrapper *QueryWrapper[T]) (*Page[T], error) {
+	ttl := wrapper.queryCacheTTL()
+	if ttl <= 0 || page.summaryDest != nil {
+		return s.page(ctx, page, wrapper)
+	}
+	page.Normalize()
+	db := s.prepare(s.applyQueryDefaults(wrapper.Apply(s.model(ctx)), false))
+	params := []any{page.Current, page.Size, page.countColumn, page.countDistinct, page.clampCurrent, page.deepPageOffset}
+	entry, err := cachedQuery(ctx, s, db, ttl, "Page", params, func() (pageCacheEntry[T], error) {
+		p, err := s.page(ctx, page, wrapper)
+		if err != nil {
+			return pageCacheEntry[T]{}, err
+		}
+		return pageCacheEntry[T]{Current: p.Current, Total: p.Total, Records: p.Records}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if entry.Records == nil {
+		entry.Records = make([]*T, 0)
+	}
+	page.Current, page.Total, page.Records = entry.Current, entry.Total, entry.Records
+	return page, nil
+}
@@ -0,0 +1,78 @@
+package gomp_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+)
+
+// secretUser 含加密字段的实体
+type secretUser struct {
+	ID     int64 `gorm:"primaryKey"`
+	Name   string
+	IdCard string `gomp:"encrypt"`
+}
+
+// recordingCache 记录全部写入值的 Cache
+type recordingCache struct {
+	*gomp.MemoryCache
+	mu     sync.Mutex
+	values [][]byte
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	c.values = append(c.values, value)
+	c.mu.Unlock()
+	return c.MemoryCache.Set(ctx, key, value, ttl)
+}
+
+func TestQueryCacheStoresCiphertext(t *testing.T) {
+	ctx := context.Background()
+	cipher, err := gomp.NewAESCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gomp.SetCipher(cipher)
+	t.Cleanup(func() { gomp.SetCipher(nil) })
+	store := &recordingCache{MemoryCache: gomp.NewMemoryCache(100)}
+	gomp.SetQueryCache(store)
+	t.Cleanup(func() { gomp.SetQueryCache(nil) })
+
+	svc := gomptest.NewService[secretUser](t)
+	if err := svc.Save(ctx, &secretUser{Name: "a", IdCard: "110101199001011234"}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		users, err := svc.List(ctx, gomp.NewQueryWrapper[secretUser]().Cache(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 || users[0].IdCard != "110101199001011234" {
+			t.Fatalf("list = %+v", users)
+		}
+		page, err := svc.Page(ctx, gomp.NewPage[secretUser](1, 10), gomp.NewQueryWrapper[secretUser]().Cache(time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Records) != 1 || page.Records[0].IdCard != "110101199001011234" {
+			t.Fatalf("page = %+v", page.Records)
+		}
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.values) < 2 {
+		t.Fatalf("expected cached results, got %d writes", len(store.values))
+	}
+	for _, v := range store.values {
+		if bytes.Contains(v, []byte("110101199001011234")) {
+			t.Fatal("encrypted field cached as plaintext")
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package gomp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// KeyRange 主键区间 [Start, End] (闭区间)
+type KeyRange struct {
+	Start int64
+	End   int64
+}
+
+// RangeOptions 主键区间拆分的选项
+type RangeOptions struct {
+	Size    int64 // 每个区间覆盖的主键跨度，默认 10000
+	Workers int   // 并发处理的区间数，默认 4
+}
+
+// SplitRanges 按主键将满足条件的数据拆分为跨度不超过 size 的区间 (id BETWEEN Start AND End)，size <= 0 时为 10000
+// 每个区间从上一区间之后满足条件的最小主键开始，跳过没有数据的主键段，区间数不超过记录数 (适用于雪花 ID 等稀疏主键)；
+// 只支持整数主键，无数据时返回空切片
+//
+//	ranges, err := orderService.SplitRanges(ctx, gomp.NewQueryWrapper[Order]().Eq("status", "expired"), 5000)
+func (s *ServiceImpl[T]) SplitRanges(ctx context.Context, wrapper *QueryWrapper[T], size int64) ([]KeyRange, error) {
+	return invoke(s, ctx, "SplitRanges", wrapper, []any{wrapper, size}, func(ctx context.Context) ([]KeyRange, error) {
+		splitter, err := s.newRangeSplitter(ctx, wrapper, size)
+		if err != nil {
+			return nil, err
+		}
+		ranges := make([]KeyRange, 0)
+		for {
+			r, ok, err := splitter.next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return ranges, nil
+			}
+			ranges = append(ranges, r)
+		}
+	})
+}
+
+// ProcessRanges 将满足条件的数据按主键拆分为区间 (见 SplitRanges)，由 Workers 个协程并发调用 fn 处理
+// 适用于全表维护任务 (归档、回填、批量修复)：每个区间的语句只扫描一小段主键，避免长时间运行的单个游标与锁堆积
+// 区间在处理过程中逐个查询生成，不预先生成全部区间；fn 中应使用区间构造自己的条件
+// 任一区间返回错误时不再处理后续区间，等待已开始的区间结束后返回该错误
+//
+//	err := orderService.ProcessRanges(ctx, expired, gomp.RangeOptions{Size: 5000, Workers: 4}, func(ctx context.Context, r gomp.KeyRange) error {
+//		return orderService.Update(ctx, gomp.NewUpdateWrapper[Order]().Set("archived", true).
+//			Eq("status", "expired").Between("id", r.Start, r.End))
+//	})
+func (s *ServiceImpl[T]) ProcessRanges(ctx context.Context, wrapper *QueryWrapper[T], opts RangeOptions, fn func(ctx context.Context, r KeyRange) error) error {
+	return s.exec(ctx, "ProcessRanges", wrapper, []any{wrapper, opts, fn}, func(ctx context.Context) error {
+		splitter, err := s.newRangeSplitter(ctx, wrapper, opts.Size)
+		if err != nil {
+			return err
+		}
+		workers := opts.Workers
+		if workers <= 0 {
+			workers = 4
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var (
+			wg       sync.WaitGroup
+			errOnce  sync.Once
+			firstErr error
+			next     = make(chan KeyRange)
+		)
+		fail := func(err error) {
+			errOnce.Do(func() {
+				firstErr = err
+				cancel()
+			})
+		}
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for r := range next {
+					if err := fn(ctx, r); err != nil {
+						fail(err)
+					}
+				}
+			}()
+		}
+	dispatch:
+		for {
+			r, ok, err := splitter.next()
+			if err != nil {
+				fail(err)
+				break
+			}
+			if !ok {
+				break
+			}
+			select {
+			case next <- r:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(next)
+		wg.Wait()
+		if firstErr != nil {
+			return firstErr
+		}
+		return ctx.Err()
+	})
+}
+
+// rangeSplitter 按主键逐个生成区间: 每个区间从上一区间之后满足条件的最小主键开始，跨度不超过 size
+type rangeSplitter struct {
+	db     *gorm.DB // 查询 MIN / MAX 主键的语句，已包含 wrapper 与 gomp 追加的条件
+	pk     *schema.Field
+	shards []string // 分表时为全部分表，否则为 [""]
+	size   int64
+	start  sql.NullInt64 // 下一区间的起点，无效时已结束
+	hi     int64         // 满足条件的最大主键
+}
+
+// newRangeSplitter 查询满足条件的主键的最小值与最大值 (分表时为全部分表的最小值与最大值)
+func (s *ServiceImpl[T]) newRangeSplitter(ctx context.Context, wrapper *QueryWrapper[T], size int64) (*rangeSplitter, error) {
+	if size <= 0 {
+		size = 10000
+	}
+	db := s.model(ctx)
+	if wrapper != nil {
+		db = wrapper.Apply(db)
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil || !rangeKeyKind(pk) {
+		return nil, fmt.Errorf("range splitting requires an integer primary key on %s", sch.Name)
+	}
+	db = s.prepare(db)
+	delete(db.Statement.Clauses, "ORDER BY")
+	delete(db.Statement.Clauses, "LIMIT")
+	db = db.Select("MIN(" + pk.DBName + "), MAX(" + pk.DBName + ")")
+	shards, err := shardFanOut[T](db)
+	if err != nil {
+		return nil, err
+	}
+	if shards == nil {
+		shards = []string{""}
+	}
+	r := &rangeSplitter{db: db, pk: pk, shards: shards, size: size}
+	lo, hi, err := r.bounds(nil)
+	if err != nil {
+		return nil, err
+	}
+	r.start, r.hi = lo, hi.Int64
+	return r, nil
+}
+
+// bounds 查询主键大于 after (为 nil 时不限制) 的最小值与最大值
+func (r *rangeSplitter) bounds(after *int64) (sql.NullInt64, sql.NullInt64, error) {
+	var lo, hi sql.NullInt64
+	for _, table := range r.shards {
+		tx := r.db.Session(&gorm.Session{})
+		if table != "" {
+			tx = onShard(tx, table)
+		}
+		if after != nil {
+			tx = tx.Where(clause.Gt{Column: currentColumn(r.pk.DBName), Value: *after})
+		}
+		var minID, maxID sql.NullInt64
+		if err := tx.Row().Scan(&minID, &maxID); err != nil {
+			return lo, hi, err
+		}
+		if !minID.Valid {
+			continue
+		}
+		if !lo.Valid || minID.Int64 < lo.Int64 {
+			lo = minID
+		}
+		if !hi.Valid || maxID.Int64 > hi.Int64 {
+			hi = maxID
+		}
+	}
+	return lo, hi, nil
+}
+
+// next 生成下一个区间，没有更多区间时返回 false
+func (r *rangeSplitter) next() (KeyRange, bool, error) {
+	if !r.start.Valid {
+		return KeyRange{}, false, nil
+	}
+	start := r.start.Int64
+	end := start + r.size - 1
+	if end >= r.hi || end < start {
+		r.start.Valid = false
+		return KeyRange{Start: start, End: r.hi}, true, nil
+	}
+	lo, _, err := r.bounds(&end)
+	if err != nil {
+		return KeyRange{}, false, err
+	}
+	r.start = lo
+	return KeyRange{Start: start, End: end}, true, nil
+}
+
+// rangeKeyKind 主键是否为整数
+func rangeKeyKind(field *schema.Field) bool {
+	switch field.IndirectFieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package gomp_test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+)
+
+// rangeOrder 主键稀疏 (如雪花 ID) 的实体
+type rangeOrder struct {
+	ID     int64 `gorm:"primaryKey;autoIncrement:false"`
+	Status int
+}
+
+// seedSparse 写入主键跨度约 1e18 的记录
+func seedSparse(t *testing.T) *gomp.ServiceImpl[rangeOrder] {
+	t.Helper()
+	svc := gomptest.NewService[rangeOrder](t)
+	orders := []*rangeOrder{
+		{ID: 1, Status: 1},
+		{ID: 5, Status: 2},
+		{ID: 1_000_000_000_000_003, Status: 1},
+		{ID: 1_000_000_000_000_020, Status: 1},
+		{ID: 1_000_000_000_000_000_000, Status: 1},
+	}
+	if err := svc.SaveBatch(context.Background(), orders); err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestSplitRangesSkipsEmptyKeySpans(t *testing.T) {
+	ctx := context.Background()
+	svc := seedSparse(t)
+	ranges, err := svc.SplitRanges(ctx, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gomp.KeyRange{
+		{Start: 1, End: 10},
+		{Start: 1_000_000_000_000_003, End: 1_000_000_000_000_012},
+		{Start: 1_000_000_000_000_020, End: 1_000_000_000_000_029},
+		{Start: 1_000_000_000_000_000_000, End: 1_000_000_000_000_000_000},
+	}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Fatalf("ranges = %v, want %v", ranges, want)
+	}
+
+	ranges, err = svc.SplitRanges(ctx, gomp.NewQueryWrapper[rangeOrder]().Eq("status", 2), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gomp.KeyRange{{Start: 5, End: 5}}; !reflect.DeepEqual(ranges, want) {
+		t.Fatalf("ranges = %v, want %v", ranges, want)
+	}
+
+	ranges, err = svc.SplitRanges(ctx, gomp.NewQueryWrapper[rangeOrder]().Eq("status", 3), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 0 {
+		t.Fatalf("ranges = %v, want none", ranges)
+	}
+}
+
+func TestProcessRangesCoversSparseKeys(t *testing.T) {
+	ctx := context.Background()
+	svc := seedSparse(t)
+	var (
+		mu  sync.Mutex
+		ids []int64
+	)
+	err := svc.ProcessRanges(ctx, gomp.NewQueryWrapper[rangeOrder]().Eq("status", 1), gomp.RangeOptions{Size: 10, Workers: 2}, func(ctx context.Context, r gomp.KeyRange) error {
+		orders, err := svc.List(ctx, gomp.NewQueryWrapper[rangeOrder]().Eq("status", 1).Between("id", r.Start, r.End))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, o := range orders {
+			ids = append(ids, o.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(ids)
+	want := []int64{1, 1_000_000_000_000_003, 1_000_000_000_000_020, 1_000_000_000_000_000_000}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("processed %v, want %v", ids, want)
+	}
+}
+
+func TestListRangesMergesAndSorts(t *testing.T) {
+	ctx := context.Background()
+	svc := seedSparse(t)
+	w := gomp.NewQueryWrapper[rangeOrder]().Eq("status", 1)
+	ranges, err := svc.SplitRanges(ctx, w, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := svc.ListRanges(ctx, gomp.NewQueryWrapper[rangeOrder]().Eq("status", 1).OrderByDesc("id").Limit(3), ranges, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+	if want := []int64{1_000_000_000_000_000_000, 1_000_000_000_000_020, 1_000_000_000_000_003}; !slices.Equal(ids, want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+
+	// 只查询给定的区间
+	orders, err = svc.ListRanges(ctx, nil, []gomp.KeyRange{{Start: 2, End: 1_000_000_000_000_010}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 || orders[0].ID+orders[1].ID != 1_000_000_000_000_008 {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+}
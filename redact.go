@@ -0,0 +1,284 @@
+package gomp
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gorm.io/gorm/schema"
+)
+
+// RedactedValue 敏感参数在日志中的替换值
+const RedactedValue = "******"
+
+// sensitiveFields 实体中标记为 gomp:"sensitive" 的列，按 Schema 缓存
+var sensitiveFields sync.Map
+
+// schemaSensitiveColumns 获取实体中标记为敏感的列
+func schemaSensitiveColumns(sch *schema.Schema) map[string]struct{} {
+	if v, ok := sensitiveFields.Load(sch); ok {
+		return v.(map[string]struct{})
+	}
+	columns := make(map[string]struct{})
+	for _, field := range sch.Fields {
+		if field.DBName != "" && hasGompTag(field, "sensitive") {
+			columns[strings.ToLower(field.DBName)] = struct{}{}
+		}
+	}
+	sensitiveFields.Store(sch, columns)
+	return columns
+}
+
+// hasGompTag 判断字段的 gomp 标签是否包含指定选项，如 `gomp:"sensitive;encrypt"`
+func hasGompTag(field *schema.Field, option string) bool {
+	_, ok := gompTagValue(field, option)
+	return ok
+}
+
+// gompTagValue 获取字段 gomp 标签中的选项值，如 `gomp:"fill:insert"` 中 fill 的值为 insert
+func gompTagValue(field *schema.Field, option string) (string, bool) {
+	for _, part := range strings.Split(field.Tag.Get("gomp"), ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if strings.EqualFold(name, option) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// isSensitiveColumn 判断列是否为敏感列 (实体标签或 sensitiveColumns 配置)
+func isSensitiveColumn(column string, tagged map[string]struct{}) bool {
+	column = strings.ToLower(column)
+	if _, ok := tagged[column]; ok {
+		return true
+	}
+	for _, c := range getConfig().SensitiveColumns {
+		if strings.EqualFold(c, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactArgs 将敏感列对应的绑定参数替换为 RedactedValue，返回新的参数切片
+// 通过解析带占位符的 SQL 推断每个占位符 (? 或 Postgres 的 $n) 对应的列: INSERT 按列顺序对应，其余按占位符前的列名 (col = ? / col IN (?) 等)
+func redactArgs(statement string, args []any, sch *schema.Schema) []any {
+	var tagged map[string]struct{}
+	if sch != nil {
+		tagged = schemaSensitiveColumns(sch)
+	}
+	if len(tagged) == 0 && len(getConfig().SensitiveColumns) == 0 {
+		return args
+	}
+
+	columns := placeholderColumns(statement)
+	var redacted []any
+	for i, column := range columns {
+		if i >= len(args) {
+			break
+		}
+		if column != "" && isSensitiveColumn(column, tagged) {
+			if redacted == nil {
+				redacted = make([]any, len(args))
+				copy(redacted, args)
+			}
+			redacted[i] = RedactedValue
+		}
+	}
+	if redacted == nil {
+		return args
+	}
+	return redacted
+}
+
+// sqlToken SQL 词法单元
+type sqlToken struct {
+	text  string
+	ident bool
+}
+
+// tokenizeSQL 简单的 SQL 分词: 标识符 (含引号与表限定)、占位符、符号，字符串常量会被跳过
+func tokenizeSQL(sql string) []sqlToken {
+	tokens := make([]sqlToken, 0, 64)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			// 跳过字符串常量
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == '\'' {
+					if j+1 < len(sql) && sql[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: "''"})
+			i = j + 1
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			// Postgres 占位符 $n
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: sql[i:j]})
+			i = j
+		case c == '`' || c == '"' || c == '_' || c == '.' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			j := i
+			for j < len(sql) {
+				d := sql[j]
+				if d == '`' || d == '"' {
+					end := strings.IndexByte(sql[j+1:], d)
+					if end < 0 {
+						j = len(sql)
+						break
+					}
+					j += end + 2
+					continue
+				}
+				if d == '_' || d == '.' || d == '$' || unicode.IsLetter(rune(d)) || unicode.IsDigit(rune(d)) {
+					j++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, sqlToken{text: sql[i:j], ident: true})
+			i = j
+		case c == '<' || c == '>' || c == '!':
+			if i+1 < len(sql) && (sql[i+1] == '=' || sql[i+1] == '>') {
+				tokens = append(tokens, sqlToken{text: sql[i : i+2]})
+				i += 2
+			} else {
+				tokens = append(tokens, sqlToken{text: sql[i : i+1]})
+				i++
+			}
+		default:
+			tokens = append(tokens, sqlToken{text: sql[i : i+1]})
+			i++
+		}
+	}
+	return tokens
+}
+
+// placeholderKeywords 推断占位符对应列时需要跳过的关键字
+var placeholderKeywords = map[string]bool{
+	"IN": true, "NOT": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "AND": true, "IS": true,
+}
+
+// placeholderIndex 占位符对应的参数下标: ? 按出现顺序 (next)，$n 为 n-1；不是占位符时返回 -1
+func placeholderIndex(text string, next int) int {
+	if text == "?" {
+		return next
+	}
+	if len(text) > 1 && text[0] == '$' {
+		if n, err := strconv.Atoi(text[1:]); err == nil && n > 0 {
+			return n - 1
+		}
+	}
+	return -1
+}
+
+// placeholderColumns 推断 SQL 中每个参数对应的列名 (按参数下标，无法推断时为空字符串)
+func placeholderColumns(statement string) []string {
+	tokens := tokenizeSQL(statement)
+	columns := make([]string, 0, 16)
+
+	// INSERT INTO t (a, b) VALUES (?, ?), (?, ?)
+	var insertColumns []string
+	inValues, groupIndex, depth, next := false, 0, 0, 0
+	if len(tokens) > 0 && strings.EqualFold(tokens[0].text, "INSERT") {
+		for i := 0; i < len(tokens); i++ {
+			if tokens[i].text == "(" {
+				for j := i + 1; j < len(tokens) && tokens[j].text != ")"; j++ {
+					if tokens[j].ident {
+						insertColumns = append(insertColumns, unquoteColumn(tokens[j].text))
+					}
+				}
+				break
+			}
+		}
+	}
+
+	for i, tok := range tokens {
+		if insertColumns != nil {
+			if tok.ident && strings.EqualFold(tok.text, "VALUES") {
+				inValues = true
+				continue
+			}
+			if inValues {
+				switch tok.text {
+				case "(":
+					depth++
+					if depth == 1 {
+						groupIndex = 0
+					}
+				case ")":
+					depth--
+					if depth == 0 && i+1 < len(tokens) && tokens[i+1].text != "," {
+						inValues = false
+					}
+				case ",":
+					if depth == 1 {
+						groupIndex++
+					}
+				}
+			}
+		}
+		idx := placeholderIndex(tok.text, next)
+		if idx < 0 {
+			continue
+		}
+		next++
+		column := ""
+		if inValues && depth == 1 && groupIndex < len(insertColumns) {
+			column = insertColumns[groupIndex]
+		} else {
+			column = precedingColumn(tokens[:i])
+		}
+		for len(columns) <= idx {
+			columns = append(columns, "")
+		}
+		// 同一个 $n 可能出现多次，取第一个能推断出的列
+		if columns[idx] == "" {
+			columns[idx] = column
+		}
+	}
+	return columns
+}
+
+// precedingColumn 向前查找占位符对应的列名，如 col = ? / col IN (?, ?) / col BETWEEN ? AND ?
+func precedingColumn(tokens []sqlToken) string {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		tok := tokens[i]
+		if tok.ident {
+			if placeholderKeywords[strings.ToUpper(tok.text)] {
+				continue
+			}
+			return unquoteColumn(tok.text)
+		}
+		switch tok.text {
+		case "?", ",", "(", "=", "<>", "!=", "<", ">", "<=", ">=":
+			continue
+		}
+		if placeholderIndex(tok.text, 0) >= 0 {
+			continue
+		}
+		return ""
+	}
+	return ""
+}
+
+// unquoteColumn 去除引号与表限定，`u`.`name` -> name
+func unquoteColumn(column string) string {
+	column = strings.ReplaceAll(strings.ReplaceAll(column, "`", ""), `"`, "")
+	if idx := strings.LastIndex(column, "."); idx >= 0 {
+		column = column[idx+1:]
+	}
+	return column
+}
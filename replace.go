@@ -0,0 +1,163 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Replace 插入实体，主键或唯一键与已有记录冲突时先删除已有记录 (物理删除)，适合缓存表、快照表的整行刷新
+// MySQL 生成 REPLACE INTO，其他数据库在事务中先删除冲突记录再插入；主键生成、自动填充、钩子与缓存与 Save 一致
+// 启用多租户时只删除当前租户的冲突记录 (MySQL 同样改为先删除再插入)，与其他租户的记录冲突时插入失败
+//
+//	err := snapshotService.Replace(ctx, &Snapshot{Key: "home", Payload: payload})
+func (s *ServiceImpl[T]) Replace(ctx context.Context, entity *T) error {
+	return s.exec(ctx, "Replace", nil, []any{entity}, func(ctx context.Context) error {
+		if entity == nil {
+			return errors.New("replace entity cannot be nil")
+		}
+		db := s.table(ctx)
+		if err := s.beforeInsert(ctx, db, entity); err != nil {
+			return err
+		}
+		if err := runEntityHooks(ctx, BeforeSave, "Replace", entity); err != nil {
+			return err
+		}
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return err
+		}
+		rv := reflect.ValueOf(entity)
+		cond := replaceCondition(sch, func(field *schema.Field) (any, bool) {
+			v, zero := field.ValueOf(ctx, rv)
+			return v, !(zero && field.PrimaryKey)
+		})
+		if err := s.replace(ctx, db, cond, func(tx *gorm.DB) error {
+			return tx.Create(entity).Error
+		}); err != nil {
+			return err
+		}
+		if err := s.afterInsert(ctx, db, entity); err != nil {
+			return err
+		}
+		return s.afterEntityHooks(ctx, AfterSave, "Replace", 1, entity)
+	})
+}
+
+// replace 执行替换插入：MySQL 使用 REPLACE INTO，其他数据库及启用多租户时在事务中先物理删除满足 cond 的当前租户记录再插入
+// cond 为主键或唯一键冲突的条件，为 nil 时直接插入；被删除记录的缓存在成功后失效
+func (s *ServiceImpl[T]) replace(ctx context.Context, db *gorm.DB, cond clause.Expression, create func(tx *gorm.DB) error) error {
+	var invalidation *cacheInvalidation
+	if cond != nil {
+		var err error
+		if invalidation, err = s.beginInvalidate(s.prepareUnscoped(s.model(ctx).Where(cond))); err != nil {
+			return err
+		}
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return err
+	}
+	// REPLACE INTO 会删除任意租户的冲突记录，启用多租户时改为按租户条件删除后再插入
+	if db.Dialector.Name() == "mysql" && tenantField[T](ctx, sch) == nil {
+		if err := create(db.Clauses(replaceInto{})); err != nil {
+			return err
+		}
+		return invalidation.commit(ctx)
+	}
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if cond != nil {
+			if err := s.prepareUnscoped(tx.Where(cond)).Delete(new(T)).Error; err != nil {
+				return err
+			}
+		}
+		return create(tx)
+	}); err != nil {
+		return err
+	}
+	return invalidation.commit(ctx)
+}
+
+// uniqueKeys 实体的主键与唯一键 (unique 标签与唯一索引)，每个键为一组列
+func uniqueKeys(sch *schema.Schema) [][]*schema.Field {
+	var keys [][]*schema.Field
+	if len(sch.PrimaryFields) > 0 {
+		keys = append(keys, sch.PrimaryFields)
+	}
+	for _, field := range sch.Fields {
+		if field.Unique && field.DBName != "" && !field.PrimaryKey {
+			keys = append(keys, []*schema.Field{field})
+		}
+	}
+	for _, index := range sch.ParseIndexes() {
+		if index.Class != "UNIQUE" {
+			continue
+		}
+		fields := make([]*schema.Field, 0, len(index.Fields))
+		for _, option := range index.Fields {
+			if option.Field != nil {
+				fields = append(fields, option.Field)
+			}
+		}
+		keys = append(keys, fields)
+	}
+	return keys
+}
+
+// replaceCondition 按主键与唯一键构造冲突条件 (各键之间为 OR)，value 返回列的值及是否参与比较
+// 任一列不参与比较或值为 NULL 的键被跳过 (NULL 不会产生唯一键冲突)，全部键被跳过时返回 nil
+func replaceCondition(sch *schema.Schema, value func(field *schema.Field) (any, bool)) clause.Expression {
+	var groups []clause.Expression
+	for _, fields := range uniqueKeys(sch) {
+		if exprs, ok := keyCondition(fields, value); ok {
+			groups = append(groups, clause.And(exprs...))
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return clause.Or(groups...)
+}
+
+// keyCondition 一个键的等值条件，有列不参与比较或值为 NULL 时返回 false
+func keyCondition(fields []*schema.Field, value func(field *schema.Field) (any, bool)) ([]clause.Expression, bool) {
+	if len(fields) == 0 {
+		return nil, false
+	}
+	exprs := make([]clause.Expression, 0, len(fields))
+	for _, field := range fields {
+		v, ok := value(field)
+		if !ok || v == nil {
+			return nil, false
+		}
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, false
+		}
+		exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: v})
+	}
+	return exprs, true
+}
+
+// replaceInto 替换 INSERT 子句，生成 MySQL 的 REPLACE INTO
+type replaceInto struct{}
+
+// Name 实现 clause.Interface
+func (replaceInto) Name() string {
+	return "INSERT"
+}
+
+// Build 实现 clause.Expression
+func (replaceInto) Build(builder clause.Builder) {
+	builder.WriteString("REPLACE INTO ")
+	builder.WriteQuoted(clause.Table{Name: clause.CurrentTable})
+}
+
+// MergeClause 实现 clause.Interface，清空子句名以免输出 INSERT 前缀
+func (r replaceInto) MergeClause(c *clause.Clause) {
+	c.Name = ""
+	c.Expression = r
+}
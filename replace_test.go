@@ -0,0 +1,68 @@
+package gomp_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// mysqlNamed 以 mysql 为名称的 SQLite 方言，SQLite 同样支持 REPLACE INTO，用于覆盖 MySQL 的替换路径
+type mysqlNamed struct {
+	gorm.Dialector
+}
+
+func (mysqlNamed) Name() string { return "mysql" }
+
+func TestReplaceDoesNotDeleteOtherTenants(t *testing.T) {
+	withTenant(t)
+	for _, name := range []string{"sqlite", "mysql"} {
+		t.Run(name, func(t *testing.T) {
+			var dialector gorm.Dialector = sqlite.Open(fmt.Sprintf("file:replace_%s?mode=memory&cache=shared", name))
+			if name == "mysql" {
+				dialector = mysqlNamed{dialector}
+			}
+			db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sqlDB, err := db.DB(); err == nil {
+				t.Cleanup(func() { _ = sqlDB.Close() })
+			}
+			if err := db.AutoMigrate(&tenantCoupon{}); err != nil {
+				t.Fatal(err)
+			}
+			svc := gomp.NewServiceImpl[tenantCoupon](db)
+			tenant1 := context.WithValue(context.Background(), tenantKey{}, int64(1))
+			tenant2 := context.WithValue(context.Background(), tenantKey{}, int64(2))
+			if err := svc.Save(tenant1, &tenantCoupon{Code: "a", Name: "tenant1"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.Replace(tenant2, &tenantCoupon{Code: "a", Name: "tenant2"}); err == nil {
+				t.Fatal("replace conflicting with another tenant succeeded")
+			}
+			got, err := svc.GetOne(gomp.WithoutTenant(context.Background()), gomp.NewQueryWrapper[tenantCoupon]().Eq("code", "a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil || got.TenantID != 1 || got.Name != "tenant1" {
+				t.Fatalf("row of tenant 1 = %+v", got)
+			}
+
+			if err := svc.Replace(tenant1, &tenantCoupon{Code: "a", Name: "replaced"}); err != nil {
+				t.Fatal(err)
+			}
+			got, err = svc.GetOne(tenant1, gomp.NewQueryWrapper[tenantCoupon]().Eq("code", "a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil || got.Name != "replaced" {
+				t.Fatalf("replace of tenant 1 = %+v", got)
+			}
+		})
+	}
+}
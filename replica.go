@@ -0,0 +1,104 @@
+package gomp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Statement 设置项 key
+const (
+	replicaSetKey  = "gomp:replica_set"  // 主库对应的 *replicaSet
+	primaryOnlyKey = "gomp:primary_only" // 为 true 时查询不路由到从库 (如审计快照)
+)
+
+// replicaSet 主库对应的从库，按轮询方式选择
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// replica 从库及其健康状态
+type replica struct {
+	db   *gorm.DB
+	down atomic.Bool // 健康检查失败，暂不参与路由
+}
+
+var (
+	replicasMu sync.RWMutex
+	replicas   = make(map[*gorm.DB]*replicaSet)
+)
+
+// RegisterReplicas 为主库注册从库，通过 gomp 执行的查询会轮询路由到从库，写操作与事务内的语句始终使用主库
+// primary 需与创建 Service 或注册数据源时使用的 *gorm.DB 为同一实例；重复注册会覆盖原有的从库，不传从库时取消读写分离
+func RegisterReplicas(primary *gorm.DB, replicaDBs ...*gorm.DB) {
+	replicasMu.Lock()
+	defer replicasMu.Unlock()
+	if len(replicaDBs) == 0 {
+		delete(replicas, primary)
+		return
+	}
+	set := &replicaSet{replicas: make([]*replica, len(replicaDBs))}
+	for i, db := range replicaDBs {
+		set.replicas[i] = &replica{db: db}
+	}
+	replicas[primary] = set
+}
+
+// forcePrimaryKey 强制主库 context key
+type forcePrimaryKey struct{}
+
+// ForcePrimary 本次调用的查询强制使用主库，用于写后立即读取等需要强一致的场景
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey{}, true)
+}
+
+// isForcePrimary 判断 ctx 是否要求使用主库
+func isForcePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(forcePrimaryKey{}).(bool)
+	return v
+}
+
+// lookupReplicas 获取主库注册的从库
+func lookupReplicas(primary *gorm.DB) (*replicaSet, bool) {
+	replicasMu.RLock()
+	defer replicasMu.RUnlock()
+	set, ok := replicas[primary]
+	return set, ok
+}
+
+// pick 轮询选择健康的从库，全部不可用时返回 nil (回退到主库)
+func (r *replicaSet) pick() *gorm.DB {
+	n := r.next.Add(1) - 1
+	size := uint64(len(r.replicas))
+	for i := uint64(0); i < size; i++ {
+		if rep := r.replicas[(n+i)%size]; !rep.down.Load() {
+			return rep.db
+		}
+	}
+	return nil
+}
+
+// routeReplica 查询前将连接切换到从库
+func routeReplica(db *gorm.DB) {
+	if db.Error != nil || !isManaged(db) || isForcePrimary(db.Statement.Context) {
+		return
+	}
+	if v, ok := db.Get(primaryOnlyKey); ok && v.(bool) {
+		return
+	}
+	// 事务内的语句需使用事务连接
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if v, ok := db.Get(replicaSetKey); ok {
+		if rep := v.(*replicaSet).pick(); rep != nil {
+			db.Statement.ConnPool = rep.ConnPool
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxRequestStatements 单个请求内按语句分别计数的最大语句种类，超过后只计入总数
+const maxRequestStatements = 200
+
+// RequestStats 一次请求内通过 gomp 执行的 SQL 统计，用于发现 N+1 等问题
+type RequestStats struct {
+	Queries    int64            `json:"queries"`    // 执行的语句数
+	Errors     int64            `json:"errors"`     // 执行失败的语句数 (不含 gorm.ErrRecordNotFound)
+	Duration   time.Duration    `json:"duration"`   // 语句的累计耗时
+	Rows       int64            `json:"rows"`       // 累计返回 / 影响行数
+	Operations map[string]int64 `json:"operations"` // 操作类型 (create / query / update / delete / row / raw) -> 语句数
+	Statements map[string]int64 `json:"statements"` // 带占位符的 SQL -> 执行次数
+}
+
+// MostRepeated 执行次数最多的语句及其次数，没有语句时返回空字符串；同一语句执行多次通常意味着 N+1 查询
+func (s RequestStats) MostRepeated() (string, int64) {
+	var (
+		statement string
+		count     int64
+	)
+	for sql, n := range s.Statements {
+		if n > count || n == count && sql < statement {
+			statement, count = sql, n
+		}
+	}
+	return statement, count
+}
+
+// requestStatsKey 请求统计的 context key
+type requestStatsKey struct{}
+
+// requestCollector 请求内的语句统计
+type requestCollector struct {
+	mu    sync.Mutex
+	stats RequestStats
+}
+
+// WithRequestStats 返回开启请求统计的 ctx，通过该 ctx (及其派生的 ctx) 执行的 gomp 语句都会计入统计，在请求结束时用 RequestStatsFrom 读取
+// 已开启统计的 ctx 原样返回，统计计入外层
+//
+//	func statsMiddleware(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			ctx := gomp.WithRequestStats(r.Context())
+//			next.ServeHTTP(w, r.WithContext(ctx))
+//			stats, _ := gomp.RequestStatsFrom(ctx)
+//			if stats.Queries > 20 {
+//				sql, n := stats.MostRepeated()
+//				log.Printf("%s ran %d queries in %s, %q x%d", r.URL.Path, stats.Queries, stats.Duration, sql, n)
+//			}
+//		})
+//	}
+func WithRequestStats(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(requestStatsKey{}).(*requestCollector); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, requestStatsKey{}, &requestCollector{stats: RequestStats{
+		Operations: make(map[string]int64),
+		Statements: make(map[string]int64),
+	}})
+}
+
+// RequestStatsFrom 读取 ctx 的请求统计 (副本)，ctx 未开启统计时返回 false
+func RequestStatsFrom(ctx context.Context) (RequestStats, bool) {
+	if ctx == nil {
+		return RequestStats{}, false
+	}
+	c, ok := ctx.Value(requestStatsKey{}).(*requestCollector)
+	if !ok {
+		return RequestStats{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Operations = maps.Clone(c.stats.Operations)
+	stats.Statements = maps.Clone(c.stats.Statements)
+	return stats, true
+}
+
+// recordRequestStats 将语句计入 ctx 的请求统计
+func recordRequestStats(db *gorm.DB, operation string, duration time.Duration) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		return
+	}
+	c, ok := ctx.Value(requestStatsKey{}).(*requestCollector)
+	if !ok {
+		return
+	}
+	query := db.Statement.SQL.String()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Queries++
+	c.stats.Duration += duration
+	c.stats.Rows += db.Statement.RowsAffected
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		c.stats.Errors++
+	}
+	c.stats.Operations[operation]++
+	if _, ok := c.stats.Statements[query]; ok || len(c.stats.Statements) < maxRequestStatements {
+		c.stats.Statements[query]++
+	}
+}
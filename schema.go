@@ -0,0 +1,71 @@
+package gomp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// parseSchema 解析实体 T 对应的 GORM Schema
+func parseSchema[T any](db *gorm.DB) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// entityType 获取实体 T 的反射类型
+func entityType[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// lookUpField 根据列名查找字段，兼容 "t.col" 形式的表限定列名
+func lookUpField(sch *schema.Schema, column string) *schema.Field {
+	if idx := strings.LastIndex(column, "."); idx >= 0 {
+		column = column[idx+1:]
+	}
+	return sch.LookUpField(strings.Trim(column, "`\""))
+}
+
+// qualifiedPrimaryKey 获取带表名 (或别名) 限定的主键列，如 "o.id"
+func qualifiedPrimaryKey(db *gorm.DB, sch *schema.Schema) (string, error) {
+	if sch.PrioritizedPrimaryField == nil {
+		return "", fmt.Errorf("%s has no primary key", sch.Name)
+	}
+	qualifier := sch.Table
+	// Table("orders o") / Table("orders AS o") 时 GORM 会将别名解析到 Statement.Table
+	if db.Statement != nil && db.Statement.Table != "" {
+		qualifier = db.Statement.Table
+	}
+	return qualifier + "." + sch.PrioritizedPrimaryField.DBName, nil
+}
+
+// primaryKeyCondition 构造主键条件，ids 为切片时使用 IN，否则使用 =
+func primaryKeyCondition(sch *schema.Schema, ids any) (clause.Expression, error) {
+	if sch.PrioritizedPrimaryField == nil {
+		return nil, fmt.Errorf("%s has no primary key", sch.Name)
+	}
+	column := currentColumn(sch.PrioritizedPrimaryField.DBName)
+	if values, ok := primaryKeyValues(ids); ok {
+		return clause.IN{Column: column, Values: values}, nil
+	}
+	return clause.Eq{Column: column, Value: ids}, nil
+}
+
+// primaryKeyValues 将切片形式的主键展开，ids 为单个主键时返回 false
+func primaryKeyValues(ids any) ([]any, bool) {
+	rv := reflect.ValueOf(ids)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
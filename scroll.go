@@ -0,0 +1,227 @@
+package gomp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidScrollToken 滚动分页游标无效 (被篡改、过期的排序规则或格式错误)
+var ErrInvalidScrollToken = errors.New("invalid scroll token")
+
+// ScrollOrder 滚动分页排序字段
+type ScrollOrder struct {
+	Column string // 排序列，需为实体字段对应的列名 (不带表名、引号，不接受结构体字段名与表达式)
+	Desc   bool   // 是否降序
+}
+
+// ScrollPage 滚动分页结果
+type ScrollPage[T any] struct {
+	Size      int64  `json:"size"`      // 每页显示条数
+	Records   []*T   `json:"records"`   // 查询数据列表
+	NextToken string `json:"nextToken"` // 下一页游标，为空表示没有更多数据
+	HasMore   bool   `json:"hasMore"`   // 是否还有更多数据
+}
+
+// scrollPayload 游标内容: 排序规则签名 + 最后一条记录的排序值
+type scrollPayload struct {
+	Key    string            `json:"k"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// Scroll 基于游标的滚动分页 (Keyset Pagination)
+// token 为上一页返回的 NextToken，首次查询传空字符串。
+// orders 为排序规则，若未包含主键会自动追加主键升序以保证顺序稳定；wrapper 中不应再指定排序。
+// 游标使用 gomp.scrollSecret 进行 HMAC 签名，客户端无法伪造或篡改。
+func (s *ServiceImpl[T]) Scroll(ctx context.Context, token string, size int64, orders []ScrollOrder, wrapper *QueryWrapper[T]) (*ScrollPage[T], error) {
+	return invoke(s, ctx, "Scroll", wrapper, []any{token, size, orders, wrapper}, func(ctx context.Context) (*ScrollPage[T], error) {
+		page, err := s.scroll(ctx, token, size, orders, wrapper)
+		if err != nil {
+			return nil, err
+		}
+		return page, s.mask(ctx, page.Records...)
+	})
+}
+
+// scroll 滚动分页实现
+func (s *ServiceImpl[T]) scroll(ctx context.Context, token string, size int64, orders []ScrollOrder, wrapper *QueryWrapper[T]) (*ScrollPage[T], error) {
+	if getConfig().ScrollSecret == "" {
+		return nil, errors.New("scroll secret is not configured; set gomp.scrollSecret")
+	}
+	if size <= 0 {
+		return nil, errors.New("scroll size must be greater than 0")
+	}
+
+	db := s.model(ctx)
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	orders, fields, err := resolveScrollOrders(sch, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	if wrapper != nil {
+		db = wrapper.Apply(db)
+	}
+	db = s.prepare(db)
+	if token != "" {
+		values, err := decodeScrollToken(token, orders, fields)
+		if err != nil {
+			return nil, err
+		}
+		db = appendWhere(db, buildScrollCondition(fields, orders, values))
+	}
+	// 排序与 Keyset 条件均使用解析出的列名，不拼接调用方传入的字符串
+	for i, o := range orders {
+		db = db.Order(clause.OrderByColumn{Column: currentColumn(fields[i].DBName), Desc: o.Desc})
+	}
+
+	entities := make([]*T, 0)
+	// 多查一条用于判断是否还有下一页
+	if err := db.Limit(int(size + 1)).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	page := &ScrollPage[T]{Size: size, Records: entities}
+	if int64(len(entities)) > size {
+		page.Records = entities[:size]
+		page.HasMore = true
+		page.NextToken, err = encodeScrollToken(ctx, orders, fields, page.Records[size-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// resolveScrollOrders 校验排序列并在缺失时追加主键，排序列必须与实体字段的列名完全一致
+func resolveScrollOrders(sch *schema.Schema, orders []ScrollOrder) ([]ScrollOrder, []*schema.Field, error) {
+	resolved := make([]ScrollOrder, 0, len(orders)+1)
+	fields := make([]*schema.Field, 0, len(orders)+1)
+	hasPrimary := false
+	for _, o := range orders {
+		field := sch.FieldsByDBName[o.Column]
+		if field == nil {
+			return nil, nil, fmt.Errorf("scroll order column %q not found in %s", o.Column, sch.Name)
+		}
+		if field == sch.PrioritizedPrimaryField {
+			hasPrimary = true
+		}
+		resolved = append(resolved, o)
+		fields = append(fields, field)
+	}
+	if !hasPrimary {
+		if sch.PrioritizedPrimaryField == nil {
+			return nil, nil, fmt.Errorf("scroll requires a primary key on %s", sch.Name)
+		}
+		resolved = append(resolved, ScrollOrder{Column: sch.PrioritizedPrimaryField.DBName})
+		fields = append(fields, sch.PrioritizedPrimaryField)
+	}
+	return resolved, fields, nil
+}
+
+// scrollKey 排序规则摘要，用于防止游标在不同排序规则间混用
+func scrollKey(orders []ScrollOrder) string {
+	parts := make([]string, len(orders))
+	for i, o := range orders {
+		if o.Desc {
+			parts[i] = o.Column + ":desc"
+		} else {
+			parts[i] = o.Column + ":asc"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// signScroll 计算游标签名
+func signScroll(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(getConfig().ScrollSecret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encodeScrollToken 将最后一条记录的排序值编码为签名游标
+func encodeScrollToken[T any](ctx context.Context, orders []ScrollOrder, fields []*schema.Field, last *T) (string, error) {
+	rv := reflect.ValueOf(last)
+	values := make([]json.RawMessage, len(fields))
+	for i, field := range fields {
+		val, _ := field.ValueOf(ctx, rv)
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		values[i] = raw
+	}
+	payload, err := json.Marshal(scrollPayload{Key: scrollKey(orders), Values: values})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(signScroll(payload)), nil
+}
+
+// decodeScrollToken 校验签名并按字段类型还原排序值
+func decodeScrollToken(token string, orders []ScrollOrder, fields []*schema.Field) ([]any, error) {
+	enc := base64.RawURLEncoding
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidScrollToken
+	}
+	payload, err := enc.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidScrollToken
+	}
+	signature, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(signature, signScroll(payload)) {
+		return nil, ErrInvalidScrollToken
+	}
+
+	var p scrollPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, ErrInvalidScrollToken
+	}
+	if p.Key != scrollKey(orders) || len(p.Values) != len(fields) {
+		return nil, ErrInvalidScrollToken
+	}
+
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		ptr := reflect.New(field.FieldType)
+		if err := json.Unmarshal(p.Values[i], ptr.Interface()); err != nil {
+			return nil, ErrInvalidScrollToken
+		}
+		values[i] = ptr.Elem().Interface()
+	}
+	return values, nil
+}
+
+// buildScrollCondition 构造 Keyset 条件
+// (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...
+func buildScrollCondition(fields []*schema.Field, orders []ScrollOrder, values []any) clause.Expression {
+	branches := make([]clause.Expression, len(orders))
+	for i := range orders {
+		exprs := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			exprs = append(exprs, clause.Eq{Column: currentColumn(fields[j].DBName), Value: values[j]})
+		}
+		column := currentColumn(fields[i].DBName)
+		if orders[i].Desc {
+			exprs = append(exprs, clause.Lt{Column: column, Value: values[i]})
+		} else {
+			exprs = append(exprs, clause.Gt{Column: column, Value: values[i]})
+		}
+		branches[i] = clause.And(exprs...)
+	}
+	return clause.Or(branches...)
+}
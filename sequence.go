@@ -0,0 +1,85 @@
+package gomp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// sequenceNamePattern 合法的序列名 (可带 schema 限定)，序列名会直接拼接到 SQL 中
+var sequenceNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// sequenceKey 序列缓存 key，按数据库连接池与序列名区分
+type sequenceKey struct {
+	pool gorm.ConnPool
+	name string
+}
+
+// sequenceRange 本地缓存的序列号段 [next, end)
+type sequenceRange struct {
+	mu   sync.Mutex
+	next int64
+	end  int64
+}
+
+// sequenceRanges 已分配的序列号段
+var sequenceRanges sync.Map
+
+// sequenceName 主键对应的序列名: gomp:"sequence:<name>" 标签，默认为 Postgres serial 的命名 <表名>_<主键列>_seq
+func sequenceName(ctx context.Context, sch *schema.Schema, field *schema.Field) string {
+	if name, ok := gompTagValue(field, "sequence"); ok && name != "" {
+		return name
+	}
+	return ResolveTableName(ctx, sch.Table) + "_" + field.DBName + "_seq"
+}
+
+// nextSequenceId 从序列分配主键
+// sequenceIncrement 大于 1 时每次 nextval 在本地分配一整段 ID，需与数据库序列的 INCREMENT BY 一致
+func nextSequenceId(ctx context.Context, db *gorm.DB, name string) (int64, error) {
+	if !sequenceNamePattern.MatchString(name) {
+		return 0, fmt.Errorf("invalid sequence name %q", name)
+	}
+	increment := getConfig().SequenceIncrement
+	if increment <= 0 {
+		increment = 1
+	}
+	v, _ := sequenceRanges.LoadOrStore(sequenceKey{pool: basePool(db.Config.ConnPool), name: name}, &sequenceRange{})
+	r := v.(*sequenceRange)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= r.end {
+		start, err := fetchSequence(ctx, db, name)
+		if err != nil {
+			return 0, err
+		}
+		r.next, r.end = start, start+increment
+	}
+	id := r.next
+	r.next++
+	return id, nil
+}
+
+// fetchSequence 执行 nextval 获取序列的下一个值
+func fetchSequence(ctx context.Context, db *gorm.DB, name string) (int64, error) {
+	var query string
+	switch dialect := db.Dialector.Name(); dialect {
+	case "postgres":
+		query = fmt.Sprintf("SELECT nextval('%s')", name)
+	case "oracle":
+		query = fmt.Sprintf("SELECT %s.NEXTVAL FROM DUAL", name)
+	case "sqlserver":
+		query = fmt.Sprintf("SELECT NEXT VALUE FOR %s", name)
+	default:
+		return 0, fmt.Errorf("idType %q is not supported by %s", IdTypeSequence, dialect)
+	}
+	var value int64
+	// 使用新会话，避免带上实体的条件，也不经过读写分离
+	if err := db.Session(&gorm.Session{NewDB: true, Context: ctx}).Raw(query).Scan(&value).Error; err != nil {
+		return 0, err
+	}
+	return value, nil
+}
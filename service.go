@@ -3,32 +3,62 @@ package gomp
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 // IService 定义类似 MyBatis-Plus 的通用 Service 接口
 type IService[T any] interface {
-	Save(ctx context.Context, entity *T) error
-	SaveBatch(ctx context.Context, entities []*T) error
+	Save(ctx context.Context, entity *T, opts ...SaveOptions) error
+	SaveBatch(ctx context.Context, entities []*T, opts ...SaveOptions) error
+	BulkInsert(ctx context.Context, entities []*T, opts ...BulkOptions) (int64, error)
+	Replace(ctx context.Context, entity *T) error
+	Upsert(ctx context.Context, entity *T, conflictColumns []string, updateColumns []string) error
 	RemoveById(ctx context.Context, id any) error
 	RemoveByIds(ctx context.Context, ids any) error
+	RemoveByIdsCount(ctx context.Context, ids any) (int64, error)
+	RemoveByIdsChunked(ctx context.Context, ids any, chunkSize int, pause ...time.Duration) (int64, error)
 	UpdateById(ctx context.Context, entity *T) error
-	GetById(ctx context.Context, id any) (*T, error)
-	GetOne(ctx context.Context, wrapper *QueryWrapper[T]) (*T, error)
+	UpdateByIdStrict(ctx context.Context, entity *T) error
+	UpdateByIdWithVersion(ctx context.Context, entity *T) error
+	UpdateByIdWithRetry(ctx context.Context, id any, mutate func(entity *T) error) error
+	GetById(ctx context.Context, id any, columns ...string) (*T, error)
+	GetByIdWith(ctx context.Context, id any, relations ...string) (*T, error)
+	GetOne(ctx context.Context, wrapper *QueryWrapper[T], columns ...string) (*T, error)
+	Any(ctx context.Context, wrapper *QueryWrapper[T]) (*T, error)
 	List(ctx context.Context, wrapper *QueryWrapper[T]) ([]*T, error)
+	ListParallel(ctx context.Context, wrapper *QueryWrapper[T], parallelism int) ([]*T, error)
 	Page(ctx context.Context, page *Page[T], wrapper *QueryWrapper[T]) (*Page[T], error)
 	SelectPage(ctx context.Context, current, size int64, wrapper *QueryWrapper[T]) (*Page[T], error)
-	Count(ctx context.Context, wrapper *QueryWrapper[T]) (int64, error)
+	Scroll(ctx context.Context, token string, size int64, orders []ScrollOrder, wrapper *QueryWrapper[T]) (*ScrollPage[T], error)
+	Count(ctx context.Context, wrapper *QueryWrapper[T], column ...string) (int64, error)
 	Insert(ctx context.Context, wrapper *InsertWrapper[T]) error
+	InsertReturning(ctx context.Context, wrapper *InsertWrapper[T]) ([]*T, error)
 	Delete(ctx context.Context, wrapper *DeleteWrapper[T]) error
+	DeleteCount(ctx context.Context, wrapper *DeleteWrapper[T]) (int64, error)
 	Update(ctx context.Context, wrapper *UpdateWrapper[T]) error
 	GetDB() *gorm.DB
 }
 
 // ServiceImpl 通用 Service 实现
 type ServiceImpl[T any] struct {
-	DB *gorm.DB
+	DB                     *gorm.DB
+	middlewares            []Middleware
+	optimisticLockRetries  int
+	unmasked               bool
+	dataSource             string
+	prepareStmt            *bool // 为 nil 时使用全局配置 prepareStmt
+	queryDefaults          *QueryDefaults
+	ignoreGlobalConditions bool
+	dryRun                 func(ctx context.Context, stmt DryRunStatement) // 不为 nil 时为 DryRun 模式
+	schema                 string                                          // 表所在的 schema (命名空间)，为空时使用连接的默认 schema
 }
 
 func NewServiceImpl[T any](db *gorm.DB) *ServiceImpl[T] {
@@ -40,84 +70,608 @@ func (s *ServiceImpl[T]) GetDB() *gorm.DB {
 }
 
 func (s *ServiceImpl[T]) getDB(ctx context.Context) *gorm.DB {
-	if config.Gomp.EnableSQLPrint {
-		return s.DB.WithContext(ctx).Debug()
+	db, err := s.resolveDataSource(ctx)
+	if err != nil {
+		db = s.DB.WithContext(ctx)
+		_ = db.AddError(err)
+		return db
+	}
+	ensureCallbacks(db)
+	tx := db.WithContext(ctx).Set(managedKey, true)
+	if schema, ok := SchemaFrom(ctx); ok && !schemaName.MatchString(schema) {
+		_ = tx.AddError(fmt.Errorf("invalid schema %q", schema))
+	}
+	if set, ok := lookupReplicas(db); ok {
+		tx = tx.Set(replicaSetKey, set)
 	}
-	return s.DB.WithContext(ctx)
+	if s.prepareStmtEnabled() {
+		tx = tx.Session(&gorm.Session{PrepareStmt: true})
+	}
+	return tx
+}
+
+// table 获取按全局配置解析表名后的 DB
+func (s *ServiceImpl[T]) table(ctx context.Context) *gorm.DB {
+	return resolveTable[T](s.getDB(ctx))
+}
+
+// model 获取绑定实体模型并解析表名后的 DB
+func (s *ServiceImpl[T]) model(ctx context.Context) *gorm.DB {
+	return s.table(ctx).Model(new(T))
 }
 
-func (s *ServiceImpl[T]) Save(ctx context.Context, entity *T) error {
-	return s.getDB(ctx).Create(entity).Error
+// prepare 在执行查询/更新/删除前追加全局条件 (如租户、逻辑删除)
+func (s *ServiceImpl[T]) prepare(db *gorm.DB) *gorm.DB {
+	return s.applyGlobalConditions(db, true)
 }
 
-func (s *ServiceImpl[T]) SaveBatch(ctx context.Context, entities []*T) error {
-	return s.getDB(ctx).CreateInBatches(entities, 100).Error
+// prepareUnscoped 物理删除前追加全局条件，不包含逻辑删除条件
+func (s *ServiceImpl[T]) prepareUnscoped(db *gorm.DB) *gorm.DB {
+	return s.applyGlobalConditions(db, false)
+}
+
+// applyGlobalConditions 追加全局条件
+func (s *ServiceImpl[T]) applyGlobalConditions(db *gorm.DB, logicDelete bool) *gorm.DB {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	exprs, err := entityConditions(db, sch, entityType[T](), logicDelete)
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	return appendWhere(db, append(exprs, s.userGlobalConditions(db)...)...)
+}
+
+// entityConditions 类型为 typ 的实体的租户条件与逻辑删除条件 (logicDelete 为 true 时)
+func entityConditions(db *gorm.DB, sch *schema.Schema, typ reflect.Type, logicDelete bool) ([]clause.Expression, error) {
+	exprs := make([]clause.Expression, 0)
+	tenant, err := tenantConditionOf(db.Statement.Context, sch, typ)
+	if err != nil {
+		return nil, err
+	}
+	if tenant != nil {
+		exprs = append(exprs, tenant)
+	}
+	if field := logicDeleteField(sch); field != nil && logicDelete {
+		exprs = append(exprs, notDeletedCondition(field))
+	}
+	return exprs, nil
+}
+
+// beforeInsert 插入前处理 (按 idType 生成主键、自动填充等)
+func (s *ServiceImpl[T]) beforeInsert(ctx context.Context, db *gorm.DB, entities ...*T) error {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		if err := assignId(ctx, db, sch, entity); err != nil {
+			return err
+		}
+		if err := fillEntity(ctx, sch, entity, true); err != nil {
+			return err
+		}
+		if err := fillTenant(ctx, sch, entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterInsert 插入后处理 (校验主键已回填、写入缓存)
+func (s *ServiceImpl[T]) afterInsert(ctx context.Context, db *gorm.DB, entities ...*T) error {
+	if IsDryRun(ctx) {
+		// 没有写入数据库，主键未回填，也不能写入缓存
+		return nil
+	}
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return err
+	}
+	if err := checkIdsBackfilled(ctx, db, sch, entities...); err != nil {
+		return err
+	}
+	s.cachePut(ctx, sch, entities...)
+	return nil
+}
+
+// beforeUpdate 更新前处理 (自动填充等)
+func (s *ServiceImpl[T]) beforeUpdate(ctx context.Context, db *gorm.DB, entity *T) error {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return err
+	}
+	return fillEntity(ctx, sch, entity, false)
+}
+
+// Save 插入实体，默认不保存关联字段，见 SaveOptions
+func (s *ServiceImpl[T]) Save(ctx context.Context, entity *T, opts ...SaveOptions) error {
+	return s.exec(ctx, "Save", nil, []any{entity, opts}, func(ctx context.Context) error {
+		db := s.table(ctx)
+		if err := s.beforeInsert(ctx, db, entity); err != nil {
+			return err
+		}
+		if err := runEntityHooks(ctx, BeforeSave, "Save", entity); err != nil {
+			return err
+		}
+		tx, err := applySaveOptions[T](db, saveOptions(opts))
+		if err != nil {
+			return err
+		}
+		result := tx.Create(entity)
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := s.afterInsert(ctx, db, entity); err != nil {
+			return err
+		}
+		return s.afterEntityHooks(ctx, AfterSave, "Save", result.RowsAffected, entity)
+	})
+}
+
+// SaveBatch 批量插入实体，默认不保存关联字段，见 SaveOptions
+func (s *ServiceImpl[T]) SaveBatch(ctx context.Context, entities []*T, opts ...SaveOptions) error {
+	return s.exec(ctx, "SaveBatch", nil, []any{entities, opts}, func(ctx context.Context) error {
+		return s.saveBatch(ctx, "SaveBatch", entities, saveOptions(opts))
+	})
+}
+
+// saveBatch 批量保存实现
+func (s *ServiceImpl[T]) saveBatch(ctx context.Context, method string, entities []*T, opts SaveOptions) error {
+	db := s.table(ctx)
+	if err := s.beforeInsert(ctx, db, entities...); err != nil {
+		return err
+	}
+	if err := runEntityHooks(ctx, BeforeSave, method, entities...); err != nil {
+		return err
+	}
+	groups, err := shardGroups(ctx, db, entities)
+	if err != nil {
+		return err
+	}
+	tx, err := applySaveOptions[T](db, opts)
+	if err != nil {
+		return err
+	}
+	var rows int64
+	for _, group := range groups {
+		result := tx.CreateInBatches(group, 100)
+		if result.Error != nil {
+			return result.Error
+		}
+		rows += result.RowsAffected
+	}
+	if err := s.afterInsert(ctx, db, entities...); err != nil {
+		return err
+	}
+	return s.afterEntityHooks(ctx, AfterSave, method, rows, entities...)
 }
 
 func (s *ServiceImpl[T]) RemoveById(ctx context.Context, id any) error {
-	var entity T
-	return s.getDB(ctx).Delete(&entity, id).Error
+	return s.exec(ctx, "RemoveById", nil, []any{id}, func(ctx context.Context) error {
+		_, err := s.removeByPrimaryKey(ctx, "RemoveById", id)
+		return err
+	})
 }
 
 func (s *ServiceImpl[T]) RemoveByIds(ctx context.Context, ids any) error {
-	var entity T
-	return s.getDB(ctx).Delete(&entity, ids).Error
+	return s.exec(ctx, "RemoveByIds", nil, []any{ids}, func(ctx context.Context) error {
+		_, err := s.removeByPrimaryKey(ctx, "RemoveByIds", ids)
+		return err
+	})
+}
+
+// RemoveByIdsCount 根据ID批量删除并返回删除的行数 (逻辑删除时为标记删除的行数)
+func (s *ServiceImpl[T]) RemoveByIdsCount(ctx context.Context, ids any) (int64, error) {
+	return invoke(s, ctx, "RemoveByIdsCount", nil, []any{ids}, func(ctx context.Context) (int64, error) {
+		return s.removeByPrimaryKey(ctx, "RemoveByIdsCount", ids)
+	})
+}
+
+// RemoveByIdsChunked 根据ID分批删除，每批最多 chunkSize 个主键 (默认 1000)，返回删除的总行数
+// 用于大批量清理，避免超长的 IN 列表、长事务与主从延迟；pause 为批次之间的等待时间
+// 每批单独执行 (各自触发删除钩子)，出错时返回出错前已删除的行数，已删除的批次不会回滚
+//
+//	n, err := logService.RemoveByIdsChunked(ctx, expiredIds, 500, 100*time.Millisecond)
+func (s *ServiceImpl[T]) RemoveByIdsChunked(ctx context.Context, ids any, chunkSize int, pause ...time.Duration) (int64, error) {
+	return invoke(s, ctx, "RemoveByIdsChunked", nil, []any{ids, chunkSize, pause}, func(ctx context.Context) (int64, error) {
+		values, ok := primaryKeyValues(ids)
+		if !ok {
+			return s.removeByPrimaryKey(ctx, "RemoveByIdsChunked", ids)
+		}
+		if chunkSize <= 0 {
+			chunkSize = 1000
+		}
+		var total int64
+		for start := 0; start < len(values); start += chunkSize {
+			if start > 0 && len(pause) > 0 && pause[0] > 0 {
+				select {
+				case <-ctx.Done():
+					return total, ctx.Err()
+				case <-time.After(pause[0]):
+				}
+			}
+			rows, err := s.removeByPrimaryKey(ctx, "RemoveByIdsChunked", values[start:min(start+chunkSize, len(values))])
+			total += rows
+			if err != nil {
+				return total, err
+			}
+		}
+		return total, nil
+	})
+}
+
+// removeByPrimaryKey 根据主键删除并执行删除钩子，返回删除的行数
+func (s *ServiceImpl[T]) removeByPrimaryKey(ctx context.Context, method string, ids any) (int64, error) {
+	event := &HookEvent[T]{Method: method, Ids: ids}
+	if err := runHooks(ctx, BeforeDelete, event); err != nil {
+		return 0, err
+	}
+	rows, err := s.deleteByPrimaryKey(ctx, method, ids)
+	if err != nil {
+		return 0, err
+	}
+	return rows, s.afterHooks(ctx, AfterDelete, rows, event)
+}
+
+// deleteByPrimaryKey 根据主键删除，配置了逻辑删除字段时执行逻辑删除
+func (s *ServiceImpl[T]) deleteByPrimaryKey(ctx context.Context, method string, ids any) (int64, error) {
+	db := s.model(ctx)
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return 0, err
+	}
+	cond, err := primaryKeyCondition(sch, ids)
+	if err != nil {
+		return 0, err
+	}
+	audit, err := s.beginAudit(s.prepare(db.Where(cond)), method, AuditActionDelete)
+	if err != nil {
+		return 0, err
+	}
+	var result *gorm.DB
+	if field := logicDeleteField(sch); field != nil {
+		result = s.prepare(s.model(ctx).Where(cond)).Updates(logicDeleteColumns(ctx, sch, field))
+	} else {
+		var entity T
+		result = s.prepareUnscoped(s.table(ctx)).Delete(&entity, ids)
+	}
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if err := s.invalidateIds(ctx, sch, ids).commit(ctx); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, audit.commit(ctx)
 }
 
 func (s *ServiceImpl[T]) UpdateById(ctx context.Context, entity *T) error {
-	return s.getDB(ctx).Updates(entity).Error
+	return s.exec(ctx, "UpdateById", nil, []any{entity}, func(ctx context.Context) error {
+		return s.updateById(ctx, "UpdateById", entity, updateOptions{})
+	})
 }
 
-func (s *ServiceImpl[T]) GetById(ctx context.Context, id any) (*T, error) {
-	var entity T
-	err := s.getDB(ctx).First(&entity, id).Error
+// UpdateByIdStrict 与 UpdateById 相同，但没有更新任何记录 (主键不存在或已被删除) 时返回 ErrNoRowsAffected，用于发现过期主键导致的静默空操作
+// MySQL 默认返回实际发生变化的行数，值未变化时也会返回 ErrNoRowsAffected，需要在 DSN 中设置 clientFoundRows=true
+func (s *ServiceImpl[T]) UpdateByIdStrict(ctx context.Context, entity *T) error {
+	return s.exec(ctx, "UpdateByIdStrict", nil, []any{entity}, func(ctx context.Context) error {
+		return s.updateById(ctx, "UpdateByIdStrict", entity, updateOptions{strict: true})
+	})
+}
+
+// UpdateByIdWithVersion 根据主键更新，总是追加 version = 实体当前版本号 条件并将版本号加一，未更新任何记录时返回 ErrOptimisticLock
+// 版本字段为 gomp:"version" 标签的字段，没有标签时使用 version 列；与 UpdateById 的乐观锁不同，版本号为零值时同样校验
+//
+//	order, _ := orderService.GetById(ctx, id) // order.Version == 3
+//	order.Status = "shipped"
+//	err := orderService.UpdateByIdWithVersion(ctx, order) // ... WHERE id = ? AND version = 3，成功后 order.Version == 4
+func (s *ServiceImpl[T]) UpdateByIdWithVersion(ctx context.Context, entity *T) error {
+	return s.exec(ctx, "UpdateByIdWithVersion", nil, []any{entity}, func(ctx context.Context) error {
+		return s.updateById(ctx, "UpdateByIdWithVersion", entity, updateOptions{version: true})
+	})
+}
+
+// updateOptions 根据主键更新的选项
+type updateOptions struct {
+	strict  bool // 未更新任何记录时返回 ErrNoRowsAffected
+	version bool // 总是追加版本号条件 (见 UpdateByIdWithVersion)
+}
+
+// updateById 根据主键更新并执行填充、钩子
+func (s *ServiceImpl[T]) updateById(ctx context.Context, method string, entity *T, opts updateOptions) error {
+	if err := s.beforeUpdate(ctx, s.table(ctx), entity); err != nil {
+		return err
+	}
+	if err := runEntityHooks(ctx, BeforeUpdate, method, entity); err != nil {
+		return err
+	}
+	rows, err := s.updateEntity(ctx, method, entity, opts)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+		return err
+	}
+	return s.afterEntityHooks(ctx, AfterUpdate, method, rows, entity)
+}
+
+// updateEntity 根据主键更新实现，实体包含版本字段时使用乐观锁，返回更新的行数
+func (s *ServiceImpl[T]) updateEntity(ctx context.Context, method string, entity *T, opts updateOptions) (int64, error) {
+	db := s.model(ctx)
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return 0, err
+	}
+	var audit *auditTrail[T]
+	if auditEnabled[T]() {
+		if sch.PrioritizedPrimaryField == nil {
+			return 0, fmt.Errorf("%s has no primary key", sch.Name)
+		}
+		id, _ := sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(entity))
+		cond, err := primaryKeyCondition(sch, id)
+		if err != nil {
+			return 0, err
+		}
+		if audit, err = s.beginAudit(s.prepare(db.Where(cond)), method, AuditActionUpdate); err != nil {
+			return 0, err
 		}
-		return nil, err
 	}
-	return &entity, nil
+
+	udb := s.prepare(s.table(ctx))
+	begin := beginOptimisticLock
+	if opts.version {
+		begin = beginVersionLock
+	}
+	lock, err := begin(ctx, sch, entity)
+	if err != nil {
+		return 0, err
+	}
+	if lock != nil {
+		udb = udb.Where(lock.condition())
+	}
+	if columns := s.maskedColumns(sch); len(columns) > 0 {
+		udb = udb.Omit(columns...)
+	}
+	result := udb.Updates(entity)
+	if err := lock.finish(ctx, result); err != nil {
+		return 0, err
+	}
+	if opts.strict && result.RowsAffected == 0 {
+		return 0, ErrNoRowsAffected
+	}
+	if sch.PrioritizedPrimaryField != nil {
+		id, _ := sch.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(entity))
+		if err := s.invalidateIds(ctx, sch, id).commit(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return result.RowsAffected, audit.commit(ctx)
+}
+
+// GetById 根据主键查询，columns 不为空时只查询这些列 (其余字段为零值)，此时不读取、不写入实体缓存
+//
+//	user, err := userService.GetById(ctx, id, "id", "status")
+func (s *ServiceImpl[T]) GetById(ctx context.Context, id any, columns ...string) (*T, error) {
+	return invoke(s, ctx, "GetById", nil, []any{id, columns}, func(ctx context.Context) (*T, error) {
+		db := s.model(ctx)
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return nil, err
+		}
+		if !idMightExist[T](ctx, id) {
+			return nil, nil
+		}
+		load := func() (*T, error) {
+			var entity T
+			tx := s.prepare(db)
+			if len(columns) > 0 {
+				tx = tx.Select(columns)
+			}
+			if err := tx.First(&entity, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, nil
+				}
+				return nil, err
+			}
+			return &entity, nil
+		}
+		var entity *T
+		if len(columns) > 0 {
+			// 部分列的结果不能进入实体缓存
+			entity, err = load()
+		} else {
+			entity, err = s.loadEntity(ctx, sch, id, load)
+		}
+		if err != nil || entity == nil {
+			return nil, err
+		}
+		if err := s.mask(ctx, entity); err != nil {
+			return nil, err
+		}
+		return entity, nil
+	})
+}
+
+// GetByIdWith 根据主键查询并预加载关联 (gorm Preload)，relations 为关联的字段名，嵌套关联用 . 连接，clause.Associations 加载全部直接关联
+// 关联实体与 LoadRelation 一样经过多租户、逻辑删除、全局条件与脱敏；关联的结果不进入实体缓存，每次都查询数据库；不存在时返回 nil
+//
+//	order, err := orderService.GetByIdWith(ctx, id, "User", "Items.Product")
+func (s *ServiceImpl[T]) GetByIdWith(ctx context.Context, id any, relations ...string) (*T, error) {
+	return invoke(s, ctx, "GetByIdWith", nil, []any{id, relations}, func(ctx context.Context) (*T, error) {
+		if !idMightExist[T](ctx, id) {
+			return nil, nil
+		}
+		var entity T
+		db := s.model(ctx)
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return nil, err
+		}
+		tx := s.preloadRelations(s.prepare(db), relations)
+		if err := tx.First(&entity, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if err := s.mask(ctx, &entity); err != nil {
+			return nil, err
+		}
+		if err := s.maskRelations(ctx, sch, reflect.ValueOf(&entity), newRelationTree(relations)); err != nil {
+			return nil, err
+		}
+		return &entity, nil
+	})
+}
+
+// GetOne 查询满足条件的一条记录，columns 不为空时只查询这些列，优先于 wrapper 的 Select
+//
+//	order, err := orderService.GetOne(ctx, gomp.NewQueryWrapper[Order]().Eq("order_no", no), "id", "status")
+func (s *ServiceImpl[T]) GetOne(ctx context.Context, wrapper *QueryWrapper[T], columns ...string) (*T, error) {
+	return invoke(s, ctx, "GetOne", wrapper, []any{wrapper, columns}, func(ctx context.Context) (*T, error) {
+		db := s.model(ctx)
+		if wrapper != nil {
+			db = wrapper.Apply(db)
+		}
+		if len(columns) > 0 {
+			db = db.Select(columns)
+		}
+		db = s.prepare(s.applyQueryDefaults(db, false))
+		return s.take(ctx, db)
+	})
+}
+
+// Any 查询满足条件的任意一条记录，不存在时返回 nil；与 GetOne 不同，不附加任何排序 (包括 wrapper 的排序与默认排序)
+// 适用于大表上只关心是否存在或取一条样本的场景，避免排序带来的额外开销；返回哪一条由数据库决定
+//
+//	order, err := orderService.Any(ctx, gomp.NewQueryWrapper[Order]().Eq("status", "pending"))
+func (s *ServiceImpl[T]) Any(ctx context.Context, wrapper *QueryWrapper[T]) (*T, error) {
+	return invoke(s, ctx, "Any", wrapper, []any{wrapper}, func(ctx context.Context) (*T, error) {
+		db := s.model(ctx)
+		if wrapper != nil {
+			db = wrapper.Apply(db)
+		}
+		db = s.prepare(s.applyQueryDefaults(db, false))
+		delete(db.Statement.Clauses, "ORDER BY")
+		return s.take(ctx, db)
+	})
 }
 
-func (s *ServiceImpl[T]) GetOne(ctx context.Context, wrapper *QueryWrapper[T]) (*T, error) {
+// take 使用 Take 查询一条记录 (不附加主键排序)，分表时依次查询各分表，不存在时返回 nil
+func (s *ServiceImpl[T]) take(ctx context.Context, db *gorm.DB) (*T, error) {
 	var entity T
-	db := s.getDB(ctx)
-	if wrapper != nil {
-		db = wrapper.Apply(db)
+	shards, err := shardFanOut[T](db)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range shards {
+		if err := onShard(db, table).Take(&entity).Error; err == nil {
+			return &entity, s.mask(ctx, &entity)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	if shards != nil {
+		return nil, nil
 	}
 	//err := db.First(&entity).Error
 	// 使用 Take 替代 First，避免自动添加 ORDER BY id，提高性能
-	err := db.Take(&entity).Error
+	err = db.Take(&entity).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	if err := s.mask(ctx, &entity); err != nil {
+		return nil, err
+	}
 	return &entity, nil
 }
 
 func (s *ServiceImpl[T]) List(ctx context.Context, wrapper *QueryWrapper[T]) ([]*T, error) {
-	var entities []*T
-	db := s.getDB(ctx)
-	if wrapper != nil {
-		db = wrapper.Apply(db)
-	}
-	err := db.Find(&entities).Error
-	return entities, err
+	return invoke(s, ctx, "List", wrapper, []any{wrapper}, func(ctx context.Context) ([]*T, error) {
+		db := s.model(ctx)
+		if wrapper != nil {
+			db = wrapper.Apply(db)
+		}
+		db = s.prepare(s.applyQueryDefaults(db, true))
+		entities, err := cachedQuery(ctx, s, db, wrapper.queryCacheTTL(), "List", nil, func() ([]*T, error) {
+			var entities []*T
+			shards, err := shardFanOut[T](db)
+			if err != nil {
+				return nil, err
+			}
+			if shards != nil {
+				entities = make([]*T, 0)
+				for _, table := range shards {
+					var part []*T
+					if err := onShard(db, table).Find(&part).Error; err != nil {
+						return nil, err
+					}
+					entities = append(entities, part...)
+				}
+				return entities, nil
+			}
+			err = db.Find(&entities).Error
+			return entities, err
+		})
+		if err != nil {
+			return entities, err
+		}
+		if entities == nil {
+			entities = make([]*T, 0)
+		}
+		return entities, s.mask(ctx, entities...)
+	})
 }
 
 func (s *ServiceImpl[T]) Page(ctx context.Context, page *Page[T], wrapper *QueryWrapper[T]) (*Page[T], error) {
+	return invoke(s, ctx, "Page", wrapper, []any{page, wrapper}, func(ctx context.Context) (*Page[T], error) {
+		return s.maskedPage(ctx, page, wrapper)
+	})
+}
+
+// maskedPage 分页查询并对结果脱敏
+func (s *ServiceImpl[T]) maskedPage(ctx context.Context, page *Page[T], wrapper *QueryWrapper[T]) (*Page[T], error) {
+	page, err := s.cachedPage(ctx, page, wrapper)
+	if err != nil {
+		return nil, err
+	}
+	return page, s.mask(ctx, page.Records...)
+}
+
+// page 分页查询实现
+func (s *ServiceImpl[T]) page(ctx context.Context, page *Page[T], wrapper *QueryWrapper[T]) (*Page[T], error) {
 	var entities []*T
-	db := s.getDB(ctx).Model(new(T))
+	page.Normalize()
+	db := s.model(ctx)
 	if wrapper != nil {
 		db = wrapper.Apply(db)
 	}
+	db = s.prepare(s.applyQueryDefaults(db, false))
 
 	var total int64
 	// 使用 Session 拷贝进行 Count，避免污染后续查询状态
-	if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+	countDB := db.Session(&gorm.Session{})
+	if page.countDistinct {
+		column := page.countColumn
+		if column == "" {
+			sch, err := parseSchema[T](db)
+			if err != nil {
+				return nil, err
+			}
+			if column, err = qualifiedPrimaryKey(db, sch); err != nil {
+				return nil, err
+			}
+		}
+		countDB = countDB.Distinct(column)
+	} else if page.countColumn != "" {
+		countDB = countDB.Select(page.countColumn)
+	} else {
+		countDB = countStatement(countDB)
+	}
+	if err := countDB.Count(&total).Error; err != nil {
 		return nil, err
 	}
 	page.Total = total
@@ -127,6 +681,31 @@ func (s *ServiceImpl[T]) Page(ctx context.Context, page *Page[T], wrapper *Query
 		return page, nil
 	}
 
+	if page.clampCurrent && page.Size > 0 {
+		if pages := page.Pages(); page.Current > pages {
+			page.Current = pages
+		}
+	}
+
+	if page.summaryDest != nil && len(page.summaryExpressions) > 0 {
+		sdb := db.Session(&gorm.Session{}).Select(page.summaryExpressions)
+		// 聚合查询不需要排序，部分数据库 (如 PostgreSQL) 会因此报错
+		delete(sdb.Statement.Clauses, "ORDER BY")
+		if err := sdb.Scan(page.summaryDest).Error; err != nil {
+			return nil, err
+		}
+		page.Summary = page.summaryDest
+	}
+
+	if page.deepPageOffset > 0 && page.Size > 0 && page.Offset() >= page.deepPageOffset {
+		records, err := s.findPageByIds(ctx, db, page)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = records
+		return page, nil
+	}
+
 	if page.Size > 0 {
 		db = db.Offset(page.Offset()).Limit(page.Limit())
 	}
@@ -138,58 +717,433 @@ func (s *ServiceImpl[T]) Page(ctx context.Context, page *Page[T], wrapper *Query
 	return page, nil
 }
 
+// findPageByIds 深分页优化: 先分页查询主键，再根据主键回表查询完整记录
+func (s *ServiceImpl[T]) findPageByIds(ctx context.Context, db *gorm.DB, page *Page[T]) ([]*T, error) {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	pkColumn, err := qualifiedPrimaryKey(db, sch)
+	if err != nil {
+		return nil, err
+	}
+	pkField := sch.PrioritizedPrimaryField
+
+	ids := reflect.New(reflect.SliceOf(pkField.FieldType))
+	if err := db.Select(pkColumn).Offset(page.Offset()).Limit(page.Limit()).Pluck(pkColumn, ids.Interface()).Error; err != nil {
+		return nil, err
+	}
+	if ids.Elem().Len() == 0 {
+		return make([]*T, 0), nil
+	}
+
+	var entities []*T
+	if err := s.model(ctx).Where(fmt.Sprintf("%s IN ?", pkField.DBName), ids.Elem().Interface()).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	// IN 查询不保证顺序，按第一步查询出的主键顺序重新排列
+	byId := make(map[string]*T, len(entities))
+	for _, e := range entities {
+		val, _ := pkField.ValueOf(ctx, reflect.ValueOf(e))
+		byId[fmt.Sprint(val)] = e
+	}
+	records := make([]*T, 0, ids.Elem().Len())
+	for i := 0; i < ids.Elem().Len(); i++ {
+		if e, ok := byId[fmt.Sprint(ids.Elem().Index(i).Interface())]; ok {
+			records = append(records, e)
+		}
+	}
+	return records, nil
+}
+
 func (s *ServiceImpl[T]) SelectPage(ctx context.Context, current, size int64, wrapper *QueryWrapper[T]) (*Page[T], error) {
-	page := NewPage[T](current, size)
-	return s.Page(ctx, page, wrapper)
+	return invoke(s, ctx, "SelectPage", wrapper, []any{current, size, wrapper}, func(ctx context.Context) (*Page[T], error) {
+		return s.maskedPage(ctx, NewPage[T](current, size), wrapper)
+	})
 }
 
-func (s *ServiceImpl[T]) Count(ctx context.Context, wrapper *QueryWrapper[T]) (int64, error) {
-	var total int64
-	db := s.getDB(ctx).Model(new(T))
-	if wrapper != nil {
-		db = wrapper.Apply(db)
+// Count 统计满足条件的记录数，默认 COUNT(*)；column 指定统计的列或表达式，生成 COUNT(column)，优先于 wrapper 的 Select 与 Distinct
+// wrapper 的 Select 只决定 List 的返回列，不影响统计 (Select 本身为 COUNT 表达式时除外)；Distinct 统计去重后的行数:
+// 单个普通列生成 COUNT(DISTINCT col) (NULL 不计入)，多列或表达式包装为子查询 SELECT COUNT(*) FROM (SELECT DISTINCT a, b ...)；
+// 带 GroupBy 时统计分组数，同样包装为子查询。分表扇出查询时结果为各分表统计之和，去重不会跨分表
+//
+//	buyers, err := orderService.Count(ctx, gomp.NewQueryWrapper[Order]().Eq("status", 1), "DISTINCT user_id")
+//	pairs, err := orderService.Count(ctx, gomp.NewQueryWrapper[Order]().Distinct("user_id", "shop_id"))
+func (s *ServiceImpl[T]) Count(ctx context.Context, wrapper *QueryWrapper[T], column ...string) (int64, error) {
+	return invoke(s, ctx, "Count", wrapper, []any{wrapper, column}, func(ctx context.Context) (int64, error) {
+		db := s.model(ctx)
+		if wrapper != nil {
+			db = wrapper.Apply(db)
+		}
+		var params []any
+		if len(column) > 0 && column[0] != "" {
+			db = db.Select("COUNT(" + column[0] + ")")
+			db.Statement.Distinct = false
+			params = []any{column[0]}
+		}
+		db = s.prepare(db)
+		return cachedQuery(ctx, s, db, wrapper.queryCacheTTL(), "Count", params, func() (int64, error) {
+			var total int64
+			shards, err := shardFanOut[T](db)
+			if err != nil {
+				return 0, err
+			}
+			for _, table := range shards {
+				var n int64
+				if err := countStatement(onShard(db, table)).Count(&n).Error; err != nil {
+					return 0, err
+				}
+				total += n
+			}
+			if shards != nil {
+				return total, nil
+			}
+			err = countStatement(db).Count(&total).Error
+			return total, err
+		})
+	})
+}
+
+// countStatement 按 Count 的规则整理统计语句: 忽略非 COUNT 的 Select；Distinct 多列、表达式或带 GROUP BY 时包装为子查询
+func countStatement(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+	selects := stmt.Selects
+	if len(selects) > 0 && strings.HasPrefix(strings.ToLower(strings.TrimSpace(selects[0])), "count(") {
+		return db
+	}
+	_, grouped := stmt.Clauses["GROUP BY"]
+	if !grouped && !stmt.Distinct {
+		if len(selects) == 0 {
+			return db
+		}
+		return db.Session(&gorm.Session{}).Select("*")
+	}
+	if !grouped && (len(selects) == 0 || len(selects) == 1 && groupColumn.MatchString(strings.TrimSpace(selects[0]))) {
+		// gorm 生成 COUNT(DISTINCT(col))；未指定列时 DISTINCT * 与 COUNT(*) 相同
+		return db
+	}
+	sub := db.Session(&gorm.Session{}).Limit(-1)
+	delete(sub.Statement.Clauses, "LIMIT")
+	delete(sub.Statement.Clauses, "ORDER BY")
+	if len(selects) == 0 && !stmt.Distinct {
+		// 分组查询不能 SELECT *
+		sub = sub.Select("1")
 	}
-	err := db.Count(&total).Error
-	return total, err
+	return db.Session(&gorm.Session{NewDB: true}).Table("(?) AS gomp_count", sub)
 }
 
 func (s *ServiceImpl[T]) Insert(ctx context.Context, wrapper *InsertWrapper[T]) error {
-	if wrapper == nil {
-		return errors.New("insert wrapper cannot be nil")
+	return s.exec(ctx, "Insert", wrapper, []any{wrapper}, func(ctx context.Context) error {
+		if wrapper == nil {
+			return errors.New("insert wrapper cannot be nil")
+		}
+		event := &HookEvent[T]{Method: "Insert", Wrapper: wrapper}
+		if err := runHooks(ctx, BeforeSave, event); err != nil {
+			return err
+		}
+		db := s.model(ctx)
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return err
+		}
+		values, err := insertValues[T](ctx, sch, wrapper)
+		if err != nil {
+			return err
+		}
+		switch {
+		case wrapper.replace:
+			cond := replaceCondition(sch, func(field *schema.Field) (any, bool) {
+				v, ok := values[0][field.DBName]
+				return v, ok
+			})
+			err = s.replace(ctx, db, cond, func(tx *gorm.DB) error {
+				return tx.Create(values[0]).Error
+			})
+		case len(values) == 1:
+			err = db.Create(values[0]).Error
+		case slices.Contains(db.Callback().Create().Clauses, "RETURNING"):
+			// 支持 RETURNING 的数据库上 gorm 无法将回填的列写回多行 map，在同一事务中逐行插入
+			err = db.Transaction(func(tx *gorm.DB) error {
+				for _, row := range values {
+					if err := tx.Create(row).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		default:
+			err = db.Create(values).Error
+		}
+		if err != nil {
+			return err
+		}
+		if pk := sch.PrioritizedPrimaryField; pk != nil {
+			// 清除该主键缓存的空结果
+			ids := make([]any, 0, len(values))
+			for _, row := range values {
+				if id, ok := row[pk.DBName]; ok {
+					addToIdFilter[T](ctx, id)
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) > 0 {
+				if err := s.invalidateIds(ctx, sch, ids).commit(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return s.afterHooks(ctx, AfterSave, int64(len(values)), event)
+	})
+}
+
+// InsertReturning 插入 wrapper 中的各行 (NextRow 分隔)，通过 INSERT ... RETURNING 在一次往返中返回插入后的完整记录，包括数据库默认值、自增主键与生成列
+// 需要数据库支持 RETURNING (PostgreSQL、SQLite 3.35+)，其他数据库返回 ErrReturningUnsupported；各行需设置相同的列，值不能是 SQL 表达式
+//
+//	tags, err := tagService.InsertReturning(ctx, gomp.NewInsertWrapper[Tag]().
+//		Set("name", "go").NextRow().
+//		Set("name", "sql"))
+//	// INSERT INTO "tag" ("name","created_at") VALUES ($1,$2),($3,$4) RETURNING *
+func (s *ServiceImpl[T]) InsertReturning(ctx context.Context, wrapper *InsertWrapper[T]) ([]*T, error) {
+	return invoke(s, ctx, "InsertReturning", wrapper, []any{wrapper}, func(ctx context.Context) ([]*T, error) {
+		if wrapper == nil {
+			return nil, errors.New("insert wrapper cannot be nil")
+		}
+		if wrapper.replace {
+			return nil, errors.New("insert returning does not support replace")
+		}
+		event := &HookEvent[T]{Method: "InsertReturning", Wrapper: wrapper}
+		if err := runHooks(ctx, BeforeSave, event); err != nil {
+			return nil, err
+		}
+		db := s.model(ctx)
+		if !slices.Contains(db.Callback().Create().Clauses, "RETURNING") {
+			return nil, ErrReturningUnsupported
+		}
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return nil, err
+		}
+		values, err := insertValues[T](ctx, sch, wrapper)
+		if err != nil {
+			return nil, err
+		}
+		entities, columns, err := insertEntities[T](ctx, sch, values)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Select(columns).Clauses(clause.Returning{}).Create(&entities).Error; err != nil {
+			return nil, err
+		}
+		if pk := sch.PrioritizedPrimaryField; pk != nil {
+			ids := make([]any, 0, len(entities))
+			for _, entity := range entities {
+				if id, zero := pk.ValueOf(ctx, reflect.ValueOf(entity)); !zero {
+					addToIdFilter[T](ctx, id)
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) > 0 {
+				if err := s.invalidateIds(ctx, sch, ids).commit(ctx); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return entities, s.afterHooks(ctx, AfterSave, int64(len(entities)), event)
+	})
+}
+
+// insertValues InsertWrapper 中各行填充字段与租户列后的值
+func insertValues[T any](ctx context.Context, sch *schema.Schema, wrapper *InsertWrapper[T]) ([]map[string]any, error) {
+	rows := wrapper.rows()
+	if wrapper.replace && len(rows) > 1 {
+		return nil, errors.New("replace does not support multiple rows")
 	}
-	return s.getDB(ctx).Model(new(T)).Create(wrapper.values).Error
+	values := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		var err error
+		if values[i], err = fillTenantColumn[T](ctx, sch, fillColumns(ctx, sch, row, true)); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// insertEntities 将各行的列值转换为实体，返回实体与插入的列 (各行设置的列与自动时间列)
+func insertEntities[T any](ctx context.Context, sch *schema.Schema, values []map[string]any) ([]*T, []string, error) {
+	var columns []string
+	entities := make([]*T, len(values))
+	for i, row := range values {
+		entities[i] = new(T)
+		rv := reflect.ValueOf(entities[i])
+		names := make([]string, 0, len(row))
+		for column, val := range row {
+			field := lookUpField(sch, column)
+			if field == nil || field.DBName == "" {
+				return nil, nil, fmt.Errorf("unknown column %s of %s", column, sch.Name)
+			}
+			if _, ok := val.(clause.Expression); ok {
+				return nil, nil, fmt.Errorf("insert returning does not support expression value of %s", column)
+			}
+			if err := field.Set(ctx, rv, val); err != nil {
+				return nil, nil, err
+			}
+			names = append(names, field.DBName)
+		}
+		slices.Sort(names)
+		if i == 0 {
+			columns = names
+		} else if !slices.Equal(names, columns) {
+			return nil, nil, fmt.Errorf("insert rows must set the same columns: %v and %v", columns, names)
+		}
+	}
+	for _, field := range sch.Fields {
+		if (field.AutoCreateTime > 0 || field.AutoUpdateTime > 0) && field.DBName != "" && !slices.Contains(columns, field.DBName) {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return entities, columns, nil
 }
 
 func (s *ServiceImpl[T]) Delete(ctx context.Context, wrapper *DeleteWrapper[T]) error {
-	db := s.getDB(ctx)
+	return s.exec(ctx, "Delete", wrapper, []any{wrapper}, func(ctx context.Context) error {
+		_, err := s.deleteWithHooks(ctx, "Delete", wrapper)
+		return err
+	})
+}
+
+// DeleteCount 条件删除并返回删除的行数 (逻辑删除时为标记删除的行数)，便于清理任务记录或校验每次删除的数量
+func (s *ServiceImpl[T]) DeleteCount(ctx context.Context, wrapper *DeleteWrapper[T]) (int64, error) {
+	return invoke(s, ctx, "DeleteCount", wrapper, []any{wrapper}, func(ctx context.Context) (int64, error) {
+		return s.deleteWithHooks(ctx, "DeleteCount", wrapper)
+	})
+}
+
+// deleteWithHooks 条件删除并执行删除钩子，返回删除的行数
+func (s *ServiceImpl[T]) deleteWithHooks(ctx context.Context, method string, wrapper *DeleteWrapper[T]) (int64, error) {
+	event := &HookEvent[T]{Method: method, Wrapper: wrapper}
+	if err := runHooks(ctx, BeforeDelete, event); err != nil {
+		return 0, err
+	}
+	rows, err := s.delete(ctx, method, wrapper)
+	if err != nil {
+		return 0, err
+	}
+	if wrapper != nil && wrapper.strict && rows == 0 {
+		return 0, ErrNoRowsAffected
+	}
+	return rows, s.afterHooks(ctx, AfterDelete, rows, event)
+}
+
+// delete 条件删除实现，返回删除的行数
+func (s *ServiceImpl[T]) delete(ctx context.Context, method string, wrapper *DeleteWrapper[T]) (int64, error) {
+	db := s.model(ctx)
 	useSoftDelete := true
 	if wrapper != nil {
 		useSoftDelete = wrapper.useSoftDelete
 		db = wrapper.Apply(db)
 	}
-	if !config.Gomp.AllowGlobalDelete {
+	if !getConfig().AllowGlobalDelete {
 		if db.Statement == nil || db.Statement.Clauses == nil || db.Statement.Clauses["WHERE"].Expression == nil {
-			return errors.New("global delete is not allowed without WHERE clause; set gomp.allowGlobalDelete=true to override")
+			return 0, errors.New("global delete is not allowed without WHERE clause; set gomp.allowGlobalDelete=true to override")
 		}
 	}
 	if !useSoftDelete {
-		db = db.Unscoped()
+		db = s.prepareUnscoped(db.Unscoped())
+	} else {
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return 0, err
+		}
+		if field := logicDeleteField(sch); field != nil {
+			db = s.prepare(db)
+			audit, err := s.beginAudit(db, method, AuditActionDelete)
+			if err != nil {
+				return 0, err
+			}
+			invalidation, err := s.beginInvalidate(db)
+			if err != nil {
+				return 0, err
+			}
+			result := db.Updates(logicDeleteColumns(ctx, sch, field))
+			if result.Error != nil {
+				return 0, result.Error
+			}
+			if err := invalidation.commit(ctx); err != nil {
+				return 0, err
+			}
+			return result.RowsAffected, audit.commit(ctx)
+		}
+		db = s.prepareUnscoped(db)
+	}
+	audit, err := s.beginAudit(db, method, AuditActionDelete)
+	if err != nil {
+		return 0, err
 	}
-	return db.Delete(new(T)).Error
+	invalidation, err := s.beginInvalidate(db)
+	if err != nil {
+		return 0, err
+	}
+	result := db.Delete(new(T))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if err := invalidation.commit(ctx); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, audit.commit(ctx)
 }
 
 func (s *ServiceImpl[T]) Update(ctx context.Context, wrapper *UpdateWrapper[T]) error {
-	if wrapper == nil {
-		return errors.New("update wrapper cannot be nil")
-	}
-	db := s.getDB(ctx)
+	return s.exec(ctx, "Update", wrapper, []any{wrapper}, func(ctx context.Context) error {
+		if wrapper == nil {
+			return errors.New("update wrapper cannot be nil")
+		}
+		event := &HookEvent[T]{Method: "Update", Wrapper: wrapper}
+		if err := runHooks(ctx, BeforeUpdate, event); err != nil {
+			return err
+		}
+		rows, err := s.update(ctx, wrapper)
+		if err != nil {
+			return err
+		}
+		return s.afterHooks(ctx, AfterUpdate, rows, event)
+	})
+}
+
+// update 条件更新实现，返回更新的行数
+func (s *ServiceImpl[T]) update(ctx context.Context, wrapper *UpdateWrapper[T]) (int64, error) {
+	db := s.model(ctx)
 	db = wrapper.Apply(db)
-	if !config.Gomp.AllowGlobalUpdate {
+	if !getConfig().AllowGlobalUpdate {
 		if db.Statement == nil || db.Statement.Clauses == nil || db.Statement.Clauses["WHERE"].Expression == nil {
-			return errors.New("global update is not allowed without WHERE clause; set gomp.allowGlobalUpdate=true to override")
+			return 0, errors.New("global update is not allowed without WHERE clause; set gomp.allowGlobalUpdate=true to override")
 		}
 	}
-	return db.Model(new(T)).Updates(wrapper.values).Error
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return 0, err
+	}
+	db = s.prepare(db)
+	audit, err := s.beginAudit(db, "Update", AuditActionUpdate)
+	if err != nil {
+		return 0, err
+	}
+	invalidation, err := s.beginInvalidate(db)
+	if err != nil {
+		return 0, err
+	}
+	result := db.Updates(fillColumns(ctx, sch, wrapper.values, false))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if wrapper.strict && result.RowsAffected == 0 {
+		return 0, ErrNoRowsAffected
+	}
+	if err := invalidation.commit(ctx); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected, audit.commit(ctx)
 }
 
 // SelectPage 快捷分页查询
@@ -197,24 +1151,49 @@ func SelectPage[T any](ctx context.Context, db *gorm.DB, current, size int64, wr
 	return NewServiceImpl[T](db).SelectPage(ctx, current, size, wrapper)
 }
 
+// SelectScroll 快捷滚动分页查询
+func SelectScroll[T any](ctx context.Context, db *gorm.DB, token string, size int64, orders []ScrollOrder, wrapper *QueryWrapper[T]) (*ScrollPage[T], error) {
+	return NewServiceImpl[T](db).Scroll(ctx, token, size, orders, wrapper)
+}
+
 // SelectList 快捷列表查询
 func SelectList[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T]) ([]*T, error) {
 	return NewServiceImpl[T](db).List(ctx, wrapper)
 }
 
+// ListParallel 快捷并发扇出列表查询
+func ListParallel[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T], parallelism int) ([]*T, error) {
+	return NewServiceImpl[T](db).ListParallel(ctx, wrapper, parallelism)
+}
+
 // SelectOne 快捷单条查询
-func SelectOne[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T]) (*T, error) {
-	return NewServiceImpl[T](db).GetOne(ctx, wrapper)
+func SelectOne[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T], columns ...string) (*T, error) {
+	return NewServiceImpl[T](db).GetOne(ctx, wrapper, columns...)
 }
 
 // Save 快捷保存
-func Save[T any](ctx context.Context, db *gorm.DB, entity *T) error {
-	return NewServiceImpl[T](db).Save(ctx, entity)
+func Save[T any](ctx context.Context, db *gorm.DB, entity *T, opts ...SaveOptions) error {
+	return NewServiceImpl[T](db).Save(ctx, entity, opts...)
 }
 
 // SaveBatch 快捷批量保存
-func SaveBatch[T any](ctx context.Context, db *gorm.DB, entities []*T) error {
-	return NewServiceImpl[T](db).SaveBatch(ctx, entities)
+func SaveBatch[T any](ctx context.Context, db *gorm.DB, entities []*T, opts ...SaveOptions) error {
+	return NewServiceImpl[T](db).SaveBatch(ctx, entities, opts...)
+}
+
+// BulkInsert 快捷大批量导入
+func BulkInsert[T any](ctx context.Context, db *gorm.DB, entities []*T, opts ...BulkOptions) (int64, error) {
+	return NewServiceImpl[T](db).BulkInsert(ctx, entities, opts...)
+}
+
+// Upsert 快捷按唯一键插入或更新
+func Upsert[T any](ctx context.Context, db *gorm.DB, entity *T, conflictColumns []string, updateColumns []string) error {
+	return NewServiceImpl[T](db).Upsert(ctx, entity, conflictColumns, updateColumns)
+}
+
+// Replace 快捷替换插入
+func Replace[T any](ctx context.Context, db *gorm.DB, entity *T) error {
+	return NewServiceImpl[T](db).Replace(ctx, entity)
 }
 
 // RemoveById 快捷根据ID删除
@@ -227,19 +1206,49 @@ func RemoveByIds[T any](ctx context.Context, db *gorm.DB, ids any) error {
 	return NewServiceImpl[T](db).RemoveByIds(ctx, ids)
 }
 
+// RemoveByIdsCount 快捷根据ID批量删除并返回删除的行数
+func RemoveByIdsCount[T any](ctx context.Context, db *gorm.DB, ids any) (int64, error) {
+	return NewServiceImpl[T](db).RemoveByIdsCount(ctx, ids)
+}
+
+// RemoveByIdsChunked 快捷根据ID分批删除
+func RemoveByIdsChunked[T any](ctx context.Context, db *gorm.DB, ids any, chunkSize int, pause ...time.Duration) (int64, error) {
+	return NewServiceImpl[T](db).RemoveByIdsChunked(ctx, ids, chunkSize, pause...)
+}
+
 // UpdateById 快捷根据ID更新
 func UpdateById[T any](ctx context.Context, db *gorm.DB, entity *T) error {
 	return NewServiceImpl[T](db).UpdateById(ctx, entity)
 }
 
+// UpdateByIdStrict 快捷严格根据ID更新
+func UpdateByIdStrict[T any](ctx context.Context, db *gorm.DB, entity *T) error {
+	return NewServiceImpl[T](db).UpdateByIdStrict(ctx, entity)
+}
+
+// UpdateByIdWithVersion 快捷按版本号条件根据ID更新
+func UpdateByIdWithVersion[T any](ctx context.Context, db *gorm.DB, entity *T) error {
+	return NewServiceImpl[T](db).UpdateByIdWithVersion(ctx, entity)
+}
+
 // GetById 快捷根据ID查询
-func GetById[T any](ctx context.Context, db *gorm.DB, id any) (*T, error) {
-	return NewServiceImpl[T](db).GetById(ctx, id)
+func GetById[T any](ctx context.Context, db *gorm.DB, id any, columns ...string) (*T, error) {
+	return NewServiceImpl[T](db).GetById(ctx, id, columns...)
+}
+
+// GetByIdWith 快捷根据ID查询并预加载关联
+func GetByIdWith[T any](ctx context.Context, db *gorm.DB, id any, relations ...string) (*T, error) {
+	return NewServiceImpl[T](db).GetByIdWith(ctx, id, relations...)
 }
 
 // GetOne 快捷查询单条
-func GetOne[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T]) (*T, error) {
-	return NewServiceImpl[T](db).GetOne(ctx, wrapper)
+func GetOne[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T], columns ...string) (*T, error) {
+	return NewServiceImpl[T](db).GetOne(ctx, wrapper, columns...)
+}
+
+// Any 快捷查询任意一条
+func Any[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T]) (*T, error) {
+	return NewServiceImpl[T](db).Any(ctx, wrapper)
 }
 
 // List 快捷列表查询
@@ -248,8 +1257,8 @@ func List[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T]) ([]
 }
 
 // Count 快捷统计
-func Count[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T]) (int64, error) {
-	return NewServiceImpl[T](db).Count(ctx, wrapper)
+func Count[T any](ctx context.Context, db *gorm.DB, wrapper *QueryWrapper[T], column ...string) (int64, error) {
+	return NewServiceImpl[T](db).Count(ctx, wrapper, column...)
 }
 
 // Insert 快捷插入
@@ -257,11 +1266,21 @@ func Insert[T any](ctx context.Context, db *gorm.DB, wrapper *InsertWrapper[T])
 	return NewServiceImpl[T](db).Insert(ctx, wrapper)
 }
 
+// InsertReturning 快捷插入并返回插入后的完整记录
+func InsertReturning[T any](ctx context.Context, db *gorm.DB, wrapper *InsertWrapper[T]) ([]*T, error) {
+	return NewServiceImpl[T](db).InsertReturning(ctx, wrapper)
+}
+
 // Delete 快捷删除
 func Delete[T any](ctx context.Context, db *gorm.DB, wrapper *DeleteWrapper[T]) error {
 	return NewServiceImpl[T](db).Delete(ctx, wrapper)
 }
 
+// DeleteCount 快捷条件删除并返回删除的行数
+func DeleteCount[T any](ctx context.Context, db *gorm.DB, wrapper *DeleteWrapper[T]) (int64, error) {
+	return NewServiceImpl[T](db).DeleteCount(ctx, wrapper)
+}
+
 // Update 快捷更新
 func Update[T any](ctx context.Context, db *gorm.DB, wrapper *UpdateWrapper[T]) error {
 	return NewServiceImpl[T](db).Update(ctx, wrapper)
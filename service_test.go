@@ -0,0 +1,104 @@
+package gomp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+)
+
+func TestCountSQL(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		wrapper *gomp.QueryWrapper[groupOrder]
+		want    gomptest.Statement
+	}{
+		{
+			name: "plain",
+			want: gomptest.Expect("SELECT count(*) FROM `group_orders`"),
+		},
+		{
+			name:    "distinct single column",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().Distinct("user_id").OrderByAsc("user_id"),
+			want:    gomptest.Expect("SELECT COUNT(DISTINCT(`user_id`)) FROM `group_orders`"),
+		},
+		{
+			name:    "distinct multiple columns",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().Distinct("user_id", "status").Eq("status", 1),
+			want:    gomptest.Expect("SELECT count(*) FROM (SELECT DISTINCT `user_id`,`status` FROM `group_orders` WHERE status = ?) AS gomp_count", 1),
+		},
+		{
+			name:    "distinct with select",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().Distinct().Select("user_id", "status"),
+			want:    gomptest.Expect("SELECT count(*) FROM (SELECT DISTINCT `user_id`,`status` FROM `group_orders`) AS gomp_count"),
+		},
+		{
+			name:    "select ignored",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().Select("user_id", "status").Eq("status", 1),
+			want:    gomptest.Expect("SELECT count(*) FROM `group_orders` WHERE status = ?", 1),
+		},
+		{
+			name:    "group by",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().GroupBy("user_id").OrderByAsc("user_id").Limit(3),
+			want:    gomptest.Expect("SELECT count(*) FROM (SELECT 1 FROM `group_orders` GROUP BY `user_id`) AS gomp_count"),
+		},
+		{
+			name:    "group by with select",
+			wrapper: gomp.NewQueryWrapper[groupOrder]().Select("user_id", "SUM(amount) AS total").GroupBy("user_id").Having("SUM(amount) > ?", 100),
+			want:    gomptest.Expect("SELECT count(*) FROM (SELECT `user_id`,SUM(amount) AS total FROM `group_orders` GROUP BY `user_id` HAVING SUM(amount) > ?) AS gomp_count", 100),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gomptest.CountSQL(ctx, tt.wrapper)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gomptest.AssertSQL(t, got, tt.want)
+		})
+	}
+}
+
+func TestCountDistinctSelectGroupBy(t *testing.T) {
+	ctx := context.Background()
+	svc := gomptest.NewService[groupOrder](t)
+	orders := []*groupOrder{
+		{UserID: 1, Status: 1, Amount: 80},
+		{UserID: 1, Status: 1, Amount: 40},
+		{UserID: 1, Status: 2, Amount: 10},
+		{UserID: 2, Status: 1, Amount: 50},
+		{UserID: 3, Status: 2, Amount: 30},
+	}
+	if err := svc.SaveBatch(ctx, orders); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		wrapper *gomp.QueryWrapper[groupOrder]
+		column  []string
+		want    int64
+	}{
+		{name: "plain", want: 5},
+		{name: "distinct single column", wrapper: gomp.NewQueryWrapper[groupOrder]().Distinct("user_id"), want: 3},
+		{name: "distinct multiple columns", wrapper: gomp.NewQueryWrapper[groupOrder]().Distinct("user_id", "status"), want: 4},
+		{name: "distinct with select", wrapper: gomp.NewQueryWrapper[groupOrder]().Distinct().Select("status"), want: 2},
+		{name: "select ignored", wrapper: gomp.NewQueryWrapper[groupOrder]().Select("user_id").Eq("status", 1), want: 3},
+		{name: "count column", wrapper: gomp.NewQueryWrapper[groupOrder]().Eq("status", 1), column: []string{"DISTINCT user_id"}, want: 2},
+		{name: "group by", wrapper: gomp.NewQueryWrapper[groupOrder]().GroupBy("user_id", "status"), want: 4},
+		{name: "group by with having", wrapper: gomp.NewQueryWrapper[groupOrder]().GroupBy("user_id").Having("SUM(amount) > ?", 40), want: 2},
+		{name: "group by with limit", wrapper: gomp.NewQueryWrapper[groupOrder]().GroupBy("user_id").Limit(1), want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := svc.Count(ctx, tt.wrapper, tt.column...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want {
+				t.Fatalf("count = %d, want %d", n, tt.want)
+			}
+		})
+	}
+}
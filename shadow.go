@@ -0,0 +1,245 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrShadowMismatch 影子写入的影响行数与主写入不一致
+var ErrShadowMismatch = errors.New("shadow write diverged from primary")
+
+// ShadowOptions 实体的影子写 (双写) 配置，用于不停机的表结构或存储迁移，见 EnableShadowWrite
+type ShadowOptions struct {
+	Table      string                               // 影子表，为空时与主表同名 (此时必须指定 DataSource)
+	DataSource string                               // 影子所在的数据源 (RegisterDataSource)，为空时写入主写入所在的库
+	Async      bool                                 // 异步写入影子，不阻塞主写入；按主写入的顺序依次执行
+	QueueSize  int                                  // 异步队列长度，默认 1000，队列已满时丢弃并计入 Dropped
+	OnError    func(ctx context.Context, err error) // 影子写入失败或影响行数不一致 (ErrShadowMismatch) 时调用
+}
+
+// ShadowStats 影子写统计
+type ShadowStats struct {
+	Writes     int64 // 已执行的影子写入
+	Failures   int64 // 执行失败的影子写入
+	Mismatches int64 // 影响行数与主写入不一致的影子写入
+	Dropped    int64 // 异步队列已满而丢弃的影子写入
+}
+
+// shadowWriter 实体的影子写入器
+type shadowWriter struct {
+	opts  ShadowOptions
+	queue chan shadowWrite
+	done  chan struct{}
+
+	writes, failures, mismatches, dropped atomic.Int64
+}
+
+// shadowWrite 一次待执行的影子写入
+type shadowWrite struct {
+	ctx     context.Context
+	db      *gorm.DB // 执行影子写入的 DB
+	sql     string
+	vars    []any
+	primary int64 // 主写入的影响行数
+}
+
+var (
+	shadowWritersMu sync.RWMutex
+	shadowWriters   = make(map[reflect.Type]*shadowWriter)
+)
+
+// EnableShadowWrite 为实体 T 开启影子写: 通过 gomp 执行的插入、更新、删除成功后，将同一语句 (表名替换为影子表) 在影子表或影子数据源上再执行一次
+// 同步模式且影子在主写入所在的库时，影子写入在主写入的连接 (包括事务) 上执行，随事务一起提交或回滚；
+// 其他情况在独立的连接上执行，主事务回滚不会撤销已执行的影子写入。影子写入的失败与影响行数不一致只计入统计并通知 OnError，不影响主写入的结果
+// 重复调用会替换原有配置
+//
+//	err := gomp.EnableShadowWrite[Order](gomp.ShadowOptions{Table: "orders_v2", Async: true,
+//		OnError: func(ctx context.Context, err error) { log.Printf("shadow: %v", err) }})
+func EnableShadowWrite[T any](opts ShadowOptions) error {
+	if opts.Table == "" && opts.DataSource == "" {
+		return errors.New("shadow write requires a table or a data source")
+	}
+	w := &shadowWriter{opts: opts}
+	if opts.Async {
+		size := opts.QueueSize
+		if size <= 0 {
+			size = 1000
+		}
+		w.queue = make(chan shadowWrite, size)
+		w.done = make(chan struct{})
+		go w.run()
+	}
+	shadowWritersMu.Lock()
+	previous := shadowWriters[entityType[T]()]
+	shadowWriters[entityType[T]()] = w
+	shadowWritersMu.Unlock()
+	previous.close()
+	return nil
+}
+
+// DisableShadowWrite 关闭实体 T 的影子写，异步模式下等待队列中的影子写入执行完毕
+func DisableShadowWrite[T any]() {
+	shadowWritersMu.Lock()
+	w := shadowWriters[entityType[T]()]
+	delete(shadowWriters, entityType[T]())
+	shadowWritersMu.Unlock()
+	w.close()
+}
+
+// ShadowWriteStats 实体 T 当前影子写配置的统计，未开启时返回零值
+func ShadowWriteStats[T any]() ShadowStats {
+	shadowWritersMu.RLock()
+	w := shadowWriters[entityType[T]()]
+	shadowWritersMu.RUnlock()
+	if w == nil {
+		return ShadowStats{}
+	}
+	return ShadowStats{
+		Writes:     w.writes.Load(),
+		Failures:   w.failures.Load(),
+		Mismatches: w.mismatches.Load(),
+		Dropped:    w.dropped.Load(),
+	}
+}
+
+// close 停止异步写入并等待队列执行完毕
+func (w *shadowWriter) close() {
+	if w == nil || w.queue == nil {
+		return
+	}
+	close(w.queue)
+	<-w.done
+}
+
+// run 依次执行异步队列中的影子写入
+func (w *shadowWriter) run() {
+	defer close(w.done)
+	for write := range w.queue {
+		w.exec(write)
+	}
+}
+
+// submit 同步执行或放入异步队列
+func (w *shadowWriter) submit(write shadowWrite) {
+	if w.queue == nil {
+		w.exec(write)
+		return
+	}
+	write.ctx = context.WithoutCancel(write.ctx)
+	defer func() {
+		// 并发关闭时队列可能已关闭
+		if recover() != nil {
+			w.dropped.Add(1)
+		}
+	}()
+	select {
+	case w.queue <- write:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// exec 执行影子写入并比较影响行数
+func (w *shadowWriter) exec(write shadowWrite) {
+	result := write.db.WithContext(write.ctx).Exec(write.sql, write.vars...)
+	w.writes.Add(1)
+	var err error
+	switch {
+	case result.Error != nil:
+		w.failures.Add(1)
+		err = fmt.Errorf("shadow write failed: %w", result.Error)
+	case result.RowsAffected != write.primary:
+		w.mismatches.Add(1)
+		err = fmt.Errorf("%w: primary affected %d rows, shadow affected %d rows: %s", ErrShadowMismatch, write.primary, result.RowsAffected, write.sql)
+	}
+	if err != nil && w.opts.OnError != nil {
+		w.opts.OnError(write.ctx, err)
+	}
+}
+
+// lookupShadowWriter 获取语句实体的影子写入器
+func lookupShadowWriter(db *gorm.DB) *shadowWriter {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	shadowWritersMu.RLock()
+	defer shadowWritersMu.RUnlock()
+	return shadowWriters[db.Statement.Schema.ModelType]
+}
+
+// mirrorWrite 主写入成功后镜像到影子表，插入语句按已回填主键的实体重新生成 (包含数据库生成的主键)
+func mirrorWrite(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if !isManaged(db) || db.Error != nil || db.DryRun || db.Statement.SQL.Len() == 0 {
+			return
+		}
+		w := lookupShadowWriter(db)
+		if w == nil {
+			return
+		}
+		table := w.opts.Table
+		if table == "" {
+			table = db.Statement.Table
+		}
+		write := shadowWrite{ctx: db.Statement.Context, primary: db.Statement.RowsAffected}
+		if operation == OperationCreate {
+			// 加密字段此时仍为密文
+			tx := db.Session(&gorm.Session{NewDB: true, DryRun: true, SkipHooks: true}).
+				Table(table).Omit(clause.Associations).Create(db.Statement.Dest)
+			if tx.Error != nil {
+				w.failures.Add(1)
+				if w.opts.OnError != nil {
+					w.opts.OnError(db.Statement.Context, fmt.Errorf("shadow write failed: %w", tx.Error))
+				}
+				return
+			}
+			write.sql, write.vars = tx.Statement.SQL.String(), tx.Statement.Vars
+			if _, ok := tx.Statement.Clauses["RETURNING"]; ok {
+				// 主键已回填，影子写入不需要返回值
+				write.sql = write.sql[:strings.LastIndex(write.sql, " RETURNING ")]
+			}
+		} else {
+			write.sql, write.vars = replaceTable(db, table), db.Statement.Vars
+		}
+
+		if w.opts.DataSource != "" {
+			target, ok := DataSource(w.opts.DataSource)
+			if !ok {
+				w.failures.Add(1)
+				if w.opts.OnError != nil {
+					w.opts.OnError(db.Statement.Context, fmt.Errorf("%w: %s", ErrDataSourceNotFound, w.opts.DataSource))
+				}
+				return
+			}
+			write.db = target
+		} else {
+			// 同步时使用主写入的连接，事务中随事务提交或回滚
+			write.db = db.Session(&gorm.Session{NewDB: true, Initialized: true})
+			if w.queue != nil {
+				write.db.Statement.ConnPool = db.Config.ConnPool
+			}
+		}
+		w.submit(write)
+	}
+}
+
+// replaceTable 将语句中的主表名替换为 table
+func replaceTable(db *gorm.DB, table string) string {
+	sql := db.Statement.SQL.String()
+	primary := db.Statement.Table
+	if table == primary || primary == "" {
+		return sql
+	}
+	sql = strings.ReplaceAll(sql, db.Statement.Quote(primary), db.Statement.Quote(table))
+	// Wrapper 条件中未加引号的 table.column
+	return regexp.MustCompile(`\b`+regexp.QuoteMeta(primary)+`\.`).ReplaceAllString(sql, table+".")
+}
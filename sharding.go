@@ -0,0 +1,367 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrShardKeyRequired 分片实体的语句缺少分片键，无法确定物理表
+var ErrShardKeyRequired = errors.New("sharding key is required to resolve the physical table")
+
+// shardResolvedKey 已指定物理表 (扇出查询) 的设置项 key
+const shardResolvedKey = "gomp:shard_resolved"
+
+// ShardingStrategy 分表策略，根据分片键的值解析物理表名
+type ShardingStrategy interface {
+	// Shard 返回分片键值对应的物理表名，table 为逻辑表名
+	Shard(ctx context.Context, table string, value any) (string, error)
+	// Shards 返回全部物理表名，用于条件中缺少分片键时的扇出查询
+	Shards(ctx context.Context, table string) ([]string, error)
+}
+
+// shardingRule 实体的分表规则
+type shardingRule struct {
+	column   string
+	strategy ShardingStrategy
+}
+
+var (
+	shardingMu    sync.RWMutex
+	shardingRules = make(map[reflect.Type]shardingRule)
+)
+
+// RegisterSharding 为实体 T 注册分表规则，column 为分片键列
+// 注册后 gomp 执行的语句会在执行时根据条件 (column = ?) 或实体中的分片键解析物理表；
+// List / Count / GetOne 缺少分片键时扇出查询全部分表，其他操作缺少分片键时返回 ErrShardKeyRequired
+func RegisterSharding[T any](column string, strategy ShardingStrategy) {
+	shardingMu.Lock()
+	defer shardingMu.Unlock()
+	shardingRules[entityType[T]()] = shardingRule{column: column, strategy: strategy}
+}
+
+// lookupShardingRule 获取实体的分表规则
+func lookupShardingRule(sch *schema.Schema) (shardingRule, bool) {
+	if sch == nil {
+		return shardingRule{}, false
+	}
+	shardingMu.RLock()
+	defer shardingMu.RUnlock()
+	rule, ok := shardingRules[sch.ModelType]
+	return rule, ok
+}
+
+// HashSharding 按分片键哈希取模分表: table_0 ... table_{n-1}
+type HashSharding struct {
+	Count int
+}
+
+// NewHashSharding 创建哈希分表策略
+func NewHashSharding(count int) *HashSharding {
+	return &HashSharding{Count: count}
+}
+
+// Shard 实现 ShardingStrategy，整数按值取模，其他类型按 FNV 哈希取模
+func (h *HashSharding) Shard(_ context.Context, table string, value any) (string, error) {
+	if h.Count <= 0 {
+		return "", errors.New("hash sharding count must be greater than 0")
+	}
+	var index uint64
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := rv.Int()
+		if n < 0 {
+			n = -n
+		}
+		index = uint64(n) % uint64(h.Count)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		index = rv.Uint() % uint64(h.Count)
+	default:
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(fmt.Sprint(value)))
+		index = uint64(hash.Sum32()) % uint64(h.Count)
+	}
+	return fmt.Sprintf("%s_%d", table, index), nil
+}
+
+// Shards 实现 ShardingStrategy
+func (h *HashSharding) Shards(_ context.Context, table string) ([]string, error) {
+	tables := make([]string, h.Count)
+	for i := range tables {
+		tables[i] = fmt.Sprintf("%s_%d", table, i)
+	}
+	return tables, nil
+}
+
+// MonthSharding 按时间分片键的月份分表: table_202601
+type MonthSharding struct {
+	Start time.Time // 第一张分表的月份，扇出查询范围为 Start 到当前月份
+}
+
+// NewMonthSharding 创建按月分表策略
+func NewMonthSharding(start time.Time) *MonthSharding {
+	return &MonthSharding{Start: start}
+}
+
+// Shard 实现 ShardingStrategy
+func (m *MonthSharding) Shard(_ context.Context, table string, value any) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", ErrShardKeyRequired
+		}
+		t = *v
+	default:
+		return "", fmt.Errorf("month sharding requires a time.Time value, got %T", value)
+	}
+	return table + "_" + t.Format("200601"), nil
+}
+
+// Shards 实现 ShardingStrategy
+func (m *MonthSharding) Shards(_ context.Context, table string) ([]string, error) {
+	start := time.Date(m.Start.Year(), m.Start.Month(), 1, 0, 0, 0, 0, m.Start.Location())
+	end := time.Now().In(m.Start.Location())
+	tables := make([]string, 0)
+	for t := start; !t.After(end); t = t.AddDate(0, 1, 0) {
+		tables = append(tables, table+"_"+t.Format("200601"))
+	}
+	return tables, nil
+}
+
+// TenantSharding 按租户分表: table_{tenantId}
+type TenantSharding struct {
+	Tenants func(ctx context.Context) ([]any, error) // 全部租户，用于扇出查询
+}
+
+// NewTenantSharding 创建按租户分表策略，配合多租户使用时分片键会从租户条件中获取
+func NewTenantSharding(tenants func(ctx context.Context) ([]any, error)) *TenantSharding {
+	return &TenantSharding{Tenants: tenants}
+}
+
+// Shard 实现 ShardingStrategy
+func (t *TenantSharding) Shard(_ context.Context, table string, value any) (string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if !rv.IsValid() {
+		return "", ErrShardKeyRequired
+	}
+	return fmt.Sprintf("%s_%v", table, rv.Interface()), nil
+}
+
+// Shards 实现 ShardingStrategy
+func (t *TenantSharding) Shards(ctx context.Context, table string) ([]string, error) {
+	if t.Tenants == nil {
+		return nil, ErrShardKeyRequired
+	}
+	tenants, err := t.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, len(tenants))
+	for i, tenant := range tenants {
+		tables[i] = fmt.Sprintf("%s_%v", table, tenant)
+	}
+	return tables, nil
+}
+
+// resolveShardTable 语句执行前根据分片键将逻辑表替换为物理表
+func resolveShardTable(db *gorm.DB) {
+	if !isManaged(db) || db.Statement.Schema == nil {
+		return
+	}
+	if resolved, ok := db.Get(shardResolvedKey); ok && resolved == true {
+		return
+	}
+	rule, ok := lookupShardingRule(db.Statement.Schema)
+	if !ok {
+		return
+	}
+	ctx := db.Statement.Context
+	logical := ResolveTableName(ctx, db.Statement.Schema.Table)
+	if db.Statement.Table != logical {
+		// 已显式指定表名或别名
+		return
+	}
+
+	values := make([]any, 0, 1)
+	if value, ok := shardKeyFromWhere(db.Statement, rule.column); ok {
+		values = append(values, value)
+	} else if values, ok = shardKeyFromDest(db, rule.column); !ok {
+		_ = db.AddError(fmt.Errorf("%w: %s.%s", ErrShardKeyRequired, db.Statement.Schema.Name, rule.column))
+		return
+	}
+	physical := ""
+	for _, value := range values {
+		table, err := rule.strategy.Shard(ctx, logical, value)
+		if err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		if physical != "" && table != physical {
+			_ = db.AddError(fmt.Errorf("batch write of %s spans multiple shards (%s, %s)", db.Statement.Schema.Name, physical, table))
+			return
+		}
+		physical = table
+	}
+	db.Statement.Table = physical
+	if db.Statement.TableExpr != nil {
+		db.Statement.TableExpr = &clause.Expr{SQL: db.Statement.Quote(physical)}
+	}
+}
+
+// shardKeyFromWhere 从 WHERE 条件 (AND 连接的 column = ?) 中获取分片键的值
+func shardKeyFromWhere(stmt *gorm.Statement, column string) (any, bool) {
+	where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok {
+		return nil, false
+	}
+	return shardKeyFromExprs(where.Exprs, column)
+}
+
+func shardKeyFromExprs(exprs []clause.Expression, column string) (any, bool) {
+	for _, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.Eq:
+			name := ""
+			switch c := e.Column.(type) {
+			case string:
+				name = c
+			case clause.Column:
+				name = c.Name
+			}
+			if strings.EqualFold(unquoteColumn(name), column) {
+				return e.Value, true
+			}
+		case clause.Expr:
+			tokens := tokenizeSQL(e.SQL)
+			if len(tokens) == 3 && len(e.Vars) == 1 && tokens[0].ident && tokens[1].text == "=" && tokens[2].text == "?" &&
+				strings.EqualFold(unquoteColumn(tokens[0].text), column) {
+				return e.Vars[0], true
+			}
+		case clause.AndConditions:
+			if v, ok := shardKeyFromExprs(e.Exprs, column); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// shardKeyFromDest 从写入的实体或列值中获取分片键的值，批量写入时返回每个实体的分片键
+func shardKeyFromDest(db *gorm.DB, column string) ([]any, bool) {
+	if values, ok := db.Statement.Dest.(map[string]any); ok {
+		for k, v := range values {
+			if strings.EqualFold(k, column) {
+				return []any{v}, true
+			}
+		}
+		return nil, false
+	}
+	field := lookUpField(db.Statement.Schema, column)
+	if field == nil {
+		return nil, false
+	}
+	rv := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	if !rv.IsValid() {
+		return nil, false
+	}
+	ctx := db.Statement.Context
+	switch rv.Kind() {
+	case reflect.Struct:
+		if rv.Type() != db.Statement.Schema.ModelType {
+			return nil, false
+		}
+		v, zero := field.ValueOf(ctx, rv)
+		return []any{v}, !zero
+	case reflect.Slice, reflect.Array:
+		values := make([]any, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() != reflect.Struct || elem.Type() != db.Statement.Schema.ModelType {
+				return nil, false
+			}
+			v, zero := field.ValueOf(ctx, elem)
+			if zero {
+				return nil, false
+			}
+			values = append(values, v)
+		}
+		return values, len(values) > 0
+	}
+	return nil, false
+}
+
+// shardFanOut 实体已分表且条件中缺少分片键时，返回需要扇出查询的全部物理表
+func shardFanOut[T any](db *gorm.DB) ([]string, error) {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	rule, ok := lookupShardingRule(sch)
+	if !ok {
+		return nil, nil
+	}
+	ctx := db.Statement.Context
+	logical := ResolveTableName(ctx, sch.Table)
+	if db.Statement.Table != "" && db.Statement.Table != logical {
+		return nil, nil
+	}
+	if _, ok := shardKeyFromWhere(db.Statement, rule.column); ok {
+		return nil, nil
+	}
+	return rule.strategy.Shards(ctx, logical)
+}
+
+// onShard 在指定物理表上执行的 DB 副本
+func onShard(db *gorm.DB, table string) *gorm.DB {
+	return db.Session(&gorm.Session{}).Table(table).Set(shardResolvedKey, true)
+}
+
+// shardGroups 按分表对实体分组 (保持原有顺序)，实体未分表时返回单个分组
+func shardGroups[T any](ctx context.Context, db *gorm.DB, entities []*T) ([][]*T, error) {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+	rule, ok := lookupShardingRule(sch)
+	if !ok || len(entities) == 0 {
+		return [][]*T{entities}, nil
+	}
+	field := lookUpField(sch, rule.column)
+	if field == nil {
+		return nil, fmt.Errorf("sharding column %q not found in %s", rule.column, sch.Name)
+	}
+	logical := ResolveTableName(ctx, sch.Table)
+	index := make(map[string]int)
+	groups := make([][]*T, 0)
+	for _, entity := range entities {
+		v, zero := field.ValueOf(ctx, reflect.ValueOf(entity))
+		if zero {
+			return nil, fmt.Errorf("%w: %s.%s", ErrShardKeyRequired, sch.Name, rule.column)
+		}
+		table, err := rule.strategy.Shard(ctx, logical, v)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[table]
+		if !ok {
+			i = len(groups)
+			index[table] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], entity)
+	}
+	return groups, nil
+}
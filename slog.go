@@ -0,0 +1,113 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SlogLogger 基于 log/slog 的结构化 SQL 日志
+type SlogLogger struct {
+	logger       *slog.Logger
+	message      string
+	level        slog.Level
+	errorLevel   slog.Level
+	attrs        []slog.Attr
+	contextAttrs func(ctx context.Context) []slog.Attr
+}
+
+// NewSlogLogger 创建结构化 SQL 日志，logger 为 nil 时使用 slog.Default()
+// 默认正常语句使用 Debug 级别，执行出错使用 Error 级别
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{
+		logger:     logger,
+		message:    "gomp sql",
+		level:      slog.LevelDebug,
+		errorLevel: slog.LevelError,
+	}
+}
+
+// WithMessage 设置日志消息
+func (l *SlogLogger) WithMessage(msg string) *SlogLogger {
+	l.message = msg
+	return l
+}
+
+// WithLevel 设置正常语句的日志级别
+func (l *SlogLogger) WithLevel(level slog.Level) *SlogLogger {
+	l.level = level
+	return l
+}
+
+// WithErrorLevel 设置执行出错时的日志级别
+func (l *SlogLogger) WithErrorLevel(level slog.Level) *SlogLogger {
+	l.errorLevel = level
+	return l
+}
+
+// WithAttrs 添加固定属性 (如服务名)
+func (l *SlogLogger) WithAttrs(attrs ...slog.Attr) *SlogLogger {
+	l.attrs = append(l.attrs, attrs...)
+	return l
+}
+
+// WithContextAttrs 设置从 ctx 中提取属性的函数 (如 trace id、用户 id)
+func (l *SlogLogger) WithContextAttrs(fn func(ctx context.Context) []slog.Attr) *SlogLogger {
+	l.contextAttrs = fn
+	return l
+}
+
+// LogSQL 实现 Logger
+func (l *SlogLogger) LogSQL(ctx context.Context, event SQLEvent) {
+	level := l.level
+	hasErr := event.Err != nil && !errors.Is(event.Err, gorm.ErrRecordNotFound)
+	if hasErr {
+		level = l.errorLevel
+	}
+	logger := l.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 10+len(l.attrs))
+	attrs = append(attrs,
+		slog.String("sql", event.SQL),
+		slog.String("statement", NormalizeSQL(event.Statement)),
+		slog.Any("args", event.Args),
+		slog.Duration("duration", event.Duration),
+		slog.Int64("rows", event.Rows),
+		slog.String("entity", event.Entity),
+		slog.String("table", event.Table),
+		slog.String("caller", event.Caller),
+	)
+	if event.Label != "" {
+		attrs = append(attrs, slog.String("label", event.Label))
+	}
+	if hasErr {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+	attrs = append(attrs, l.attrs...)
+	if l.contextAttrs != nil {
+		attrs = append(attrs, l.contextAttrs(ctx)...)
+	}
+	logger.LogAttrs(ctx, level, l.message, attrs...)
+}
+
+var (
+	spaceRegexp       = regexp.MustCompile(`\s+`)
+	placeholderRegexp = regexp.MustCompile(`\(\s*\?(\s*,\s*\?)+\s*\)`)
+)
+
+// NormalizeSQL 规范化带占位符的 SQL: 合并空白字符，并将 IN (?,?,?) 折叠为 IN (?)
+// 便于按语句模板聚合日志与统计
+func NormalizeSQL(statement string) string {
+	statement = spaceRegexp.ReplaceAllString(strings.TrimSpace(statement), " ")
+	return placeholderRegexp.ReplaceAllString(statement, "(?)")
+}
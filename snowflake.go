@@ -0,0 +1,145 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClockMovedBackwards 系统时钟回拨超过允许范围，雪花 ID 无法保证唯一
+var ErrClockMovedBackwards = errors.New("clock moved backwards")
+
+// 雪花 ID 结构: 1 位符号 + 41 位毫秒时间戳 + 5 位数据中心 + 5 位机器 + 12 位序列号
+const (
+	snowflakeWorkerBits     = 5
+	snowflakeDatacenterBits = 5
+	snowflakeSequenceBits   = 12
+
+	MaxSnowflakeWorkerId     = -1 ^ (-1 << snowflakeWorkerBits)
+	MaxSnowflakeDatacenterId = -1 ^ (-1 << snowflakeDatacenterBits)
+	snowflakeSequenceMask    = -1 ^ (-1 << snowflakeSequenceBits)
+
+	snowflakeWorkerShift     = snowflakeSequenceBits
+	snowflakeDatacenterShift = snowflakeSequenceBits + snowflakeWorkerBits
+	snowflakeTimestampShift  = snowflakeSequenceBits + snowflakeWorkerBits + snowflakeDatacenterBits
+)
+
+// SnowflakeEpoch 雪花 ID 时间戳起点 (2020-01-01 UTC)
+var SnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// defaultMaxClockBackward 默认允许等待的时钟回拨
+const defaultMaxClockBackward = 10 * time.Millisecond
+
+// SnowflakeConfig 雪花 ID 配置
+type SnowflakeConfig struct {
+	DatacenterId     int64         `yaml:"datacenterId"`     // 数据中心 ID (0-31)
+	WorkerId         int64         `yaml:"workerId"`         // 机器 ID (0-31)，同一数据中心内需唯一
+	MaxClockBackward time.Duration `yaml:"maxClockBackward"` // 时钟回拨在此范围内时等待追平，超过则报错，默认 10ms
+}
+
+// Snowflake 雪花 ID 生成器，并发安全
+type Snowflake struct {
+	mu               sync.Mutex
+	datacenterId     int64
+	workerId         int64
+	maxClockBackward time.Duration
+	lastTimestamp    int64
+	sequence         int64
+}
+
+// NewSnowflake 创建雪花 ID 生成器
+func NewSnowflake(datacenterId, workerId int64) (*Snowflake, error) {
+	if datacenterId < 0 || datacenterId > MaxSnowflakeDatacenterId {
+		return nil, fmt.Errorf("snowflake datacenterId must be between 0 and %d", MaxSnowflakeDatacenterId)
+	}
+	if workerId < 0 || workerId > MaxSnowflakeWorkerId {
+		return nil, fmt.Errorf("snowflake workerId must be between 0 and %d", MaxSnowflakeWorkerId)
+	}
+	return &Snowflake{datacenterId: datacenterId, workerId: workerId, maxClockBackward: defaultMaxClockBackward}, nil
+}
+
+// WithMaxClockBackward 设置允许等待的时钟回拨，超过时 NextId 返回 ErrClockMovedBackwards
+func (s *Snowflake) WithMaxClockBackward(d time.Duration) *Snowflake {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxClockBackward = d
+	return s
+}
+
+// NextId 实现 IdGenerator
+func (s *Snowflake) NextId(_ context.Context) (any, error) {
+	return s.Generate()
+}
+
+// Generate 生成下一个 ID
+func (s *Snowflake) Generate() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := snowflakeNow()
+	if now < s.lastTimestamp {
+		backward := time.Duration(s.lastTimestamp-now) * time.Millisecond
+		if backward > s.maxClockBackward {
+			return 0, fmt.Errorf("%w by %s", ErrClockMovedBackwards, backward)
+		}
+		time.Sleep(backward)
+		if now = snowflakeNow(); now < s.lastTimestamp {
+			return 0, fmt.Errorf("%w by %s", ErrClockMovedBackwards, time.Duration(s.lastTimestamp-now)*time.Millisecond)
+		}
+	}
+	if now == s.lastTimestamp {
+		s.sequence = (s.sequence + 1) & snowflakeSequenceMask
+		if s.sequence == 0 {
+			// 当前毫秒序列号用尽，等待下一毫秒
+			for now <= s.lastTimestamp {
+				now = snowflakeNow()
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.lastTimestamp = now
+
+	return now<<snowflakeTimestampShift |
+		s.datacenterId<<snowflakeDatacenterShift |
+		s.workerId<<snowflakeWorkerShift |
+		s.sequence, nil
+}
+
+// snowflakeNow 当前时间相对 SnowflakeEpoch 的毫秒数
+func snowflakeNow() int64 {
+	return time.Since(SnowflakeEpoch).Milliseconds()
+}
+
+var (
+	snowflakeMu     sync.Mutex
+	snowflakeShared *Snowflake
+	snowflakeConfig SnowflakeConfig
+)
+
+// defaultSnowflake 按当前 snowflake 配置获取共享的生成器，配置变化时重新创建
+func defaultSnowflake() (*Snowflake, error) {
+	cfg := getConfig().Snowflake
+	snowflakeMu.Lock()
+	defer snowflakeMu.Unlock()
+	if snowflakeShared != nil && snowflakeConfig == cfg {
+		return snowflakeShared, nil
+	}
+	sf, err := NewSnowflake(cfg.DatacenterId, cfg.WorkerId)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxClockBackward > 0 {
+		sf.maxClockBackward = cfg.MaxClockBackward
+	}
+	if prev := snowflakeShared; prev != nil {
+		// 沿用上一个生成器的时间戳与序列号，避免切换配置后在同一毫秒内产生重复 ID
+		prev.mu.Lock()
+		sf.lastTimestamp, sf.sequence = prev.lastTimestamp, prev.sequence
+		prev.mu.Unlock()
+	}
+	snowflakeShared, snowflakeConfig = sf, cfg
+	return sf, nil
+}
@@ -0,0 +1,134 @@
+package gomp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// TableNameResolver 表名解析器，每条语句执行前对实体表及 Wrapper 中的表 (Table / Join) 进行解析
+// 可用于统一添加前缀、按环境添加后缀等
+type TableNameResolver interface {
+	ResolveTableName(ctx context.Context, table string) string
+}
+
+// TableNameResolverFunc 函数形式的表名解析器
+type TableNameResolverFunc func(ctx context.Context, table string) string
+
+// ResolveTableName 实现 TableNameResolver
+func (f TableNameResolverFunc) ResolveTableName(ctx context.Context, table string) string {
+	return f(ctx, table)
+}
+
+var (
+	tableResolversMu sync.RWMutex
+	tableResolvers   []TableNameResolver
+)
+
+// RegisterTableNameResolver 注册全局表名解析器，按注册顺序依次执行 (在 tablePrefix 之后)
+func RegisterTableNameResolver(resolvers ...TableNameResolver) {
+	tableResolversMu.Lock()
+	defer tableResolversMu.Unlock()
+	tableResolvers = append(tableResolvers, resolvers...)
+}
+
+// ResolveTableName 按 tablePrefix 及已注册的解析器解析表名，可用于手写 SQL 时保持表名一致
+func ResolveTableName(ctx context.Context, table string) string {
+	if prefix := getConfig().TablePrefix; prefix != "" && !strings.HasPrefix(table, prefix) {
+		table = prefix + table
+	}
+	tableResolversMu.RLock()
+	resolvers := tableResolvers
+	tableResolversMu.RUnlock()
+	for _, r := range resolvers {
+		table = r.ResolveTableName(ctx, table)
+	}
+	if schema, ok := SchemaFrom(ctx); ok && !strings.Contains(table, ".") {
+		table = schema + "." + table
+	}
+	return table
+}
+
+// resolveTableExpr 解析 "users u" / "users AS u" 形式的表达式中的表名，保留别名部分
+// 子查询 (以括号开头) 不做处理
+func resolveTableExpr(ctx context.Context, expr string) string {
+	trimmed := strings.TrimSpace(expr)
+	if trimmed == "" || strings.HasPrefix(trimmed, "(") {
+		return expr
+	}
+	name, rest, _ := strings.Cut(trimmed, " ")
+	quote := ""
+	if len(name) > 1 && (name[0] == '`' || name[0] == '"') && name[len(name)-1] == name[0] {
+		quote = name[:1]
+		name = name[1 : len(name)-1]
+	}
+	resolved := quote + ResolveTableName(ctx, name) + quote
+	if quote != "" {
+		// schema.table 分别加引号
+		resolved = strings.ReplaceAll(resolved, ".", quote+"."+quote)
+	}
+	if rest == "" {
+		return resolved
+	}
+	return resolved + " " + rest
+}
+
+// resolveTable 解析实体对应的物理表名，Wrapper 中通过 Table() 显式指定的表名优先
+func resolveTable[T any](db *gorm.DB) *gorm.DB {
+	sch, err := parseSchema[T](db)
+	if err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	table := ResolveTableName(db.Statement.Context, sch.Table)
+	if table == sch.Table {
+		return db
+	}
+	return db.Table(table)
+}
+
+// schemaKey schema 的 context key
+type schemaKey struct{}
+
+// schemaName schema 名称只允许标识符，名称会拼接到表名中
+var schemaName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// WithSchema 返回指定 schema 的 ctx，本次调用的实体表与 Wrapper 中的表 (Table / Join) 均限定在该 schema 下，优先于 Service 的 InSchema
+// 适用于按 schema 隔离租户的部署 (如 PostgreSQL 每个租户一个 schema)；已包含 schema 的表名 (schema.table) 不受影响
+//
+//	ctx = gomp.WithSchema(ctx, "tenant_"+tenantID)
+func WithSchema(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, schemaKey{}, schema)
+}
+
+// SchemaFrom 获取 ctx 中指定的 schema
+func SchemaFrom(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	schema, ok := ctx.Value(schemaKey{}).(string)
+	return schema, ok && schema != ""
+}
+
+// InSchema 返回使用指定 schema 的 Service 副本，ctx 中通过 WithSchema 指定的 schema 优先；schema 名称不合法时方法返回错误
+//
+//	tenantService := orderService.InSchema("tenant_42") // SELECT * FROM "tenant_42"."orders" ...
+func (s *ServiceImpl[T]) InSchema(schema string) *ServiceImpl[T] {
+	c := *s
+	c.schema = schema
+	return &c
+}
+
+// withSchema Service 指定了 schema 且 ctx 中未指定时返回带有该 schema 的 ctx
+func (s *ServiceImpl[T]) withSchema(ctx context.Context) context.Context {
+	if s.schema == "" {
+		return ctx
+	}
+	if _, ok := SchemaFrom(ctx); ok {
+		return ctx
+	}
+	return WithSchema(ctx, s.schema)
+}
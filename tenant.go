@@ -0,0 +1,161 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// defaultTenantColumn 默认租户列
+const defaultTenantColumn = "tenant_id"
+
+// ErrTenantRequired 已启用多租户但 ctx 中没有租户 ID
+var ErrTenantRequired = errors.New("tenant id is required; use gomp.WithoutTenant to bypass tenant isolation explicitly")
+
+// ErrTenantConflict 写入与其他租户的记录在唯一键上冲突，或无法将冲突处理限定在当前租户
+var ErrTenantConflict = errors.New("write conflicts with a row of another tenant")
+
+// TenantProvider 从 ctx 中获取当前租户 ID
+type TenantProvider interface {
+	TenantID(ctx context.Context) (any, bool)
+}
+
+// TenantProviderFunc 函数形式的 TenantProvider
+type TenantProviderFunc func(ctx context.Context) (any, bool)
+
+// TenantID 实现 TenantProvider
+func (f TenantProviderFunc) TenantID(ctx context.Context) (any, bool) {
+	return f(ctx)
+}
+
+var (
+	tenantProvider atomic.Pointer[TenantProvider]
+
+	tenantIgnoreMu sync.RWMutex
+	tenantIgnored  = make(map[reflect.Type]struct{})
+)
+
+// SetTenantProvider 设置租户 ID 提供者以启用多租户，传入 nil 关闭
+// 启用后，包含租户列 (tenantColumn 配置，默认 tenant_id) 的实体在查询、更新、删除时自动追加 tenant_id = ? 条件，插入时自动填充租户 ID
+func SetTenantProvider(p TenantProvider) {
+	if p == nil {
+		tenantProvider.Store(nil)
+		return
+	}
+	tenantProvider.Store(&p)
+}
+
+// IgnoreTenant 将实体 T 加入多租户忽略列表 (也可通过 tenantIgnoreTables 配置表名)
+func IgnoreTenant[T any]() {
+	tenantIgnoreMu.Lock()
+	defer tenantIgnoreMu.Unlock()
+	tenantIgnored[entityType[T]()] = struct{}{}
+}
+
+// tenantSkipKey 跳过租户隔离的 context key
+type tenantSkipKey struct{}
+
+// WithoutTenant 返回跳过租户隔离的 ctx，用于平台管理等跨租户操作
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantSkipKey{}, true)
+}
+
+// tenantField 获取实体的租户字段，未启用多租户、实体被忽略或不包含租户列时返回 nil
+func tenantField[T any](ctx context.Context, sch *schema.Schema) *schema.Field {
+	return tenantFieldOf(ctx, sch, entityType[T]())
+}
+
+// tenantFieldOf 获取类型为 typ 的实体的租户字段，用于运行时才确定类型的实体 (如预加载的关联)
+func tenantFieldOf(ctx context.Context, sch *schema.Schema, typ reflect.Type) *schema.Field {
+	if tenantProvider.Load() == nil || sch == nil {
+		return nil
+	}
+	if ctx != nil && ctx.Value(tenantSkipKey{}) != nil {
+		return nil
+	}
+	cfg := getConfig()
+	for _, table := range cfg.TenantIgnoreTables {
+		if strings.EqualFold(table, sch.Table) {
+			return nil
+		}
+	}
+	tenantIgnoreMu.RLock()
+	_, ignored := tenantIgnored[typ]
+	tenantIgnoreMu.RUnlock()
+	if ignored {
+		return nil
+	}
+	column := cfg.TenantColumn
+	if column == "" {
+		column = defaultTenantColumn
+	}
+	return lookUpField(sch, column)
+}
+
+// currentTenant 获取 ctx 中的租户 ID
+func currentTenant(ctx context.Context) (any, error) {
+	p := tenantProvider.Load()
+	if p == nil {
+		return nil, nil
+	}
+	id, ok := (*p).TenantID(ctx)
+	if !ok || id == nil {
+		return nil, ErrTenantRequired
+	}
+	return id, nil
+}
+
+// tenantCondition 租户条件 tenant_id = ?，实体不需要租户隔离时返回 nil
+func tenantCondition[T any](ctx context.Context, sch *schema.Schema) (clause.Expression, error) {
+	return tenantConditionOf(ctx, sch, entityType[T]())
+}
+
+// tenantConditionOf 类型为 typ 的实体的租户条件
+func tenantConditionOf(ctx context.Context, sch *schema.Schema, typ reflect.Type) (clause.Expression, error) {
+	field := tenantFieldOf(ctx, sch, typ)
+	if field == nil {
+		return nil, nil
+	}
+	id, err := currentTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return clause.Eq{Column: currentColumn(field.DBName), Value: id}, nil
+}
+
+// fillTenant 插入前为实体填充当前租户 ID (以 ctx 中的租户为准)
+func fillTenant[T any](ctx context.Context, sch *schema.Schema, entity *T) error {
+	field := tenantField[T](ctx, sch)
+	if field == nil {
+		return nil
+	}
+	id, err := currentTenant(ctx)
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, reflect.ValueOf(entity), id)
+}
+
+// fillTenantColumn 为 Wrapper 的列值补充租户 ID，返回新的 map
+func fillTenantColumn[T any](ctx context.Context, sch *schema.Schema, values map[string]any) (map[string]any, error) {
+	field := tenantField[T](ctx, sch)
+	if field == nil {
+		return values, nil
+	}
+	id, err := currentTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filled := make(map[string]any, len(values)+1)
+	for k, v := range values {
+		filled[k] = v
+	}
+	filled[field.DBName] = id
+	return filled, nil
+}
@@ -0,0 +1,348 @@
+package gomp
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TxInfo 一个通过 Transaction 开启、尚未结束的事务
+type TxInfo struct {
+	ID       uint64        // 事务编号 (进程内递增)
+	Started  time.Time     // 开启时间
+	Duration time.Duration // 已持续的时间
+	Caller   string        // 开启事务的代码位置 (file:line)
+	Stack    string        // 开启事务时的调用栈
+}
+
+// openTx 进行中的事务
+type openTx struct {
+	id       uint64
+	started  time.Time
+	pcs      []uintptr
+	reported bool // 已报告为长事务
+}
+
+var (
+	openTxsMu sync.Mutex
+	openTxs   = make(map[uint64]*openTx)
+	txSeq     atomic.Uint64
+
+	txWatchdogRunning atomic.Bool
+)
+
+// Transaction 在事务中执行 fn，fn 返回错误或 panic 时回滚，否则提交；事务内的 Service 通过 NewServiceImpl[T](tx) 创建
+// db 已处于事务中时按 gorm 的嵌套事务 (SAVEPOINT) 执行。事务在结束前由 StartTxWatchdog 监控持续时间
+// 事务内写操作引起的实体缓存写入与失效、查询缓存版本更新与实体变更事件在事务提交后执行，回滚时丢弃；直接使用 gorm 的
+// db.Transaction / db.Begin 开启的事务无法得知提交结果，这些操作在语句执行后立即进行 (新增的实体不写入缓存)
+//
+//	err := gomp.Transaction(ctx, db, func(tx *gorm.DB) error {
+//		if err := gomp.NewServiceImpl[Order](tx).Save(ctx, order); err != nil {
+//			return err
+//		}
+//		return gomp.NewServiceImpl[Stock](tx).Update(ctx, deduct)
+//	})
+func Transaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		// 嵌套事务 (SAVEPOINT) 回滚时丢弃其中登记的操作
+		q := lookupCommitQueue(db)
+		mark := q.len()
+		err := db.WithContext(ctx).Transaction(fn, opts...)
+		if err != nil {
+			q.truncate(mark)
+		}
+		return err
+	}
+	t := &openTx{id: txSeq.Add(1), started: time.Now(), pcs: make([]uintptr, 32)}
+	t.pcs = t.pcs[:runtime.Callers(2, t.pcs)]
+	openTxsMu.Lock()
+	openTxs[t.id] = t
+	openTxsMu.Unlock()
+	defer func() {
+		openTxsMu.Lock()
+		delete(openTxs, t.id)
+		openTxsMu.Unlock()
+	}()
+	q := &commitQueue{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		key := basePool(tx.Statement.ConnPool)
+		commitQueues.Store(key, q)
+		defer commitQueues.Delete(key)
+		return fn(tx)
+	}, opts...)
+	if err == nil {
+		q.run()
+	}
+	return err
+}
+
+// commitQueues 通过 Transaction 开启的事务在提交后需要执行的操作，以事务连接为 key
+var commitQueues sync.Map
+
+// commitQueue 事务提交后按登记顺序执行的操作
+type commitQueue struct {
+	mu    sync.Mutex
+	fns   []func()
+	marks map[string]int // SavePoint 创建时已登记的操作数，按保存点名称记录
+}
+
+// lookupCommitQueue 获取 db 所在事务的提交队列，不在 Transaction 开启的事务中时返回 nil
+func lookupCommitQueue(db *gorm.DB) *commitQueue {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return nil
+	}
+	if v, ok := commitQueues.Load(basePool(db.Statement.ConnPool)); ok {
+		return v.(*commitQueue)
+	}
+	return nil
+}
+
+// len 已登记的操作数
+func (q *commitQueue) len() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.fns)
+}
+
+// truncate 丢弃第 n 个之后登记的操作
+func (q *commitQueue) truncate(n int) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n < len(q.fns) {
+		q.fns = q.fns[:n]
+	}
+}
+
+// mark 记录保存点 name 创建时已登记的操作数
+func (q *commitQueue) mark(name string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.marks == nil {
+		q.marks = make(map[string]int)
+	}
+	q.marks[name] = len(q.fns)
+}
+
+// rollbackTo 丢弃保存点 name 之后登记的操作，保存点不是通过 SavePoint 创建时不做处理
+func (q *commitQueue) rollbackTo(name string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n, ok := q.marks[name]; ok && n < len(q.fns) {
+		q.fns = q.fns[:n]
+	}
+}
+
+// run 执行全部已登记的操作
+func (q *commitQueue) run() {
+	q.mu.Lock()
+	fns := q.fns
+	q.fns = nil
+	q.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// inTransaction 判断 db 是否处于事务中
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// afterCommit 在 db 所在事务提交后执行 fn，不在事务中时立即执行
+// 事务不是通过 Transaction 开启时无法得知提交结果，返回 false 且不执行 fn，由调用方决定立即执行或放弃
+func afterCommit(db *gorm.DB, fn func()) bool {
+	if !inTransaction(db) {
+		fn()
+		return true
+	}
+	q := lookupCommitQueue(db)
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	q.fns = append(q.fns, fn)
+	q.mu.Unlock()
+	return true
+}
+
+// OpenTransactions 通过 Transaction 开启、尚未结束的事务，按开启时间排序
+func OpenTransactions() []TxInfo {
+	now := time.Now()
+	openTxsMu.Lock()
+	infos := make([]TxInfo, 0, len(openTxs))
+	for _, t := range openTxs {
+		infos = append(infos, t.info(now))
+	}
+	openTxsMu.Unlock()
+	slices.SortFunc(infos, func(a, b TxInfo) int { return cmp.Compare(a.ID, b.ID) })
+	return infos
+}
+
+// info 事务的信息
+func (t *openTx) info(now time.Time) TxInfo {
+	info := TxInfo{ID: t.id, Started: t.started, Duration: now.Sub(t.started)}
+	var stack strings.Builder
+	frames := runtime.CallersFrames(t.pcs)
+	for {
+		frame, more := frames.Next()
+		if info.Caller == "" && !strings.HasPrefix(frame.Function, "gorm.io/") && !strings.HasPrefix(frame.Function, gompPackage+".") {
+			info.Caller = frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		stack.WriteString(frame.Function + "\n\t" + frame.File + ":" + strconv.Itoa(frame.Line) + "\n")
+		if !more {
+			break
+		}
+	}
+	info.Stack = stack.String()
+	return info
+}
+
+// TxWatchdogOptions 长事务检测的配置
+type TxWatchdogOptions struct {
+	Threshold time.Duration                          // 持续超过该时间的事务视为长事务，默认 10s
+	Interval  time.Duration                          // 检查间隔，默认为 Threshold 的一半
+	OnLongTx  func(ctx context.Context, info TxInfo) // 发现长事务时调用 (每个事务一次)，默认通过 slog.Default() 以 WARN 级别输出，包含开启事务的调用栈
+}
+
+// StartTxWatchdog 定期检查通过 Transaction 开启的事务，持续时间超过 Threshold 时报告，直到 ctx 结束
+// 长事务持有的行锁会导致其他语句的锁等待，报告中的调用栈用于定位开启事务的代码
+func StartTxWatchdog(ctx context.Context, opts TxWatchdogOptions) error {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 10 * time.Second
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = opts.Threshold / 2
+	}
+	if opts.OnLongTx == nil {
+		opts.OnLongTx = func(ctx context.Context, info TxInfo) {
+			slog.Default().WarnContext(ctx, "gomp long transaction",
+				slog.Uint64("id", info.ID),
+				slog.Duration("duration", info.Duration),
+				slog.String("caller", info.Caller),
+				slog.String("stack", info.Stack),
+			)
+		}
+	}
+	if !txWatchdogRunning.CompareAndSwap(false, true) {
+		return errors.New("transaction watchdog is already running")
+	}
+
+	go func() {
+		defer txWatchdogRunning.Store(false)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, info := range longTransactions(opts.Threshold) {
+				opts.OnLongTx(ctx, info)
+			}
+		}
+	}()
+	return nil
+}
+
+// longTransactions 持续超过 threshold 且尚未报告的事务
+func longTransactions(threshold time.Duration) []TxInfo {
+	now := time.Now()
+	var long []*openTx
+	openTxsMu.Lock()
+	for _, t := range openTxs {
+		if !t.reported && now.Sub(t.started) >= threshold {
+			t.reported = true
+			long = append(long, t)
+		}
+	}
+	openTxsMu.Unlock()
+	infos := make([]TxInfo, 0, len(long))
+	for _, t := range long {
+		infos = append(infos, t.info(now))
+	}
+	slices.SortFunc(infos, func(a, b TxInfo) int { return cmp.Compare(a.ID, b.ID) })
+	return infos
+}
+
+// ErrNotInTransaction 需要在事务中执行的操作 (如 SavePoint) 收到了不在事务中的 DB
+var ErrNotInTransaction = errors.New("not in a transaction")
+
+// savePointName 保存点名称只允许标识符，名称会直接拼接到 SQL 中
+var savePointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SavePoint 在事务 tx 中创建保存点，之后可通过 RollbackTo 撤销保存点之后的语句而不影响整个事务；同名保存点会被覆盖
+// 回滚到保存点时，保存点之后的写操作登记的缓存写入、失效与变更事件一并丢弃
+//
+//	err := gomp.Transaction(ctx, db, func(tx *gorm.DB) error {
+//		if err := gomp.NewServiceImpl[Order](tx).Save(ctx, order); err != nil {
+//			return err
+//		}
+//		if err := gomp.SavePoint(ctx, tx, "enrich"); err != nil {
+//			return err
+//		}
+//		if err := enrich(ctx, tx, order); err != nil {
+//			// 补充信息失败不影响下单
+//			return gomp.RollbackTo(ctx, tx, "enrich")
+//		}
+//		return nil
+//	})
+func SavePoint(ctx context.Context, tx *gorm.DB, name string) error {
+	if err := checkSavePoint(tx, name); err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).SavePoint(name).Error; err != nil {
+		return err
+	}
+	lookupCommitQueue(tx).mark(name)
+	return nil
+}
+
+// RollbackTo 回滚到事务 tx 中的保存点 name，保存点之前的语句与事务本身不受影响，保存点在回滚后仍可再次使用
+func RollbackTo(ctx context.Context, tx *gorm.DB, name string) error {
+	if err := checkSavePoint(tx, name); err != nil {
+		return err
+	}
+	if err := tx.WithContext(ctx).RollbackTo(name).Error; err != nil {
+		return err
+	}
+	lookupCommitQueue(tx).rollbackTo(name)
+	return nil
+}
+
+// checkSavePoint 检查 tx 处于事务中且保存点名称合法
+func checkSavePoint(tx *gorm.DB, name string) error {
+	if _, ok := tx.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return ErrNotInTransaction
+	}
+	if !savePointName.MatchString(name) {
+		return fmt.Errorf("invalid savepoint name %q", name)
+	}
+	return nil
+}
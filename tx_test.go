@@ -0,0 +1,56 @@
+package gomp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+	"gorm.io/gorm"
+)
+
+// txUser 事务测试使用的实体
+type txUser struct {
+	ID   int64 `gorm:"primaryKey"`
+	Name string
+}
+
+func TestRollbackToDiscardsCommitQueue(t *testing.T) {
+	ctx := context.Background()
+	gomp.EnableCache[txUser](gomp.NewMemoryCache(100), 0)
+	t.Cleanup(func() { gomp.EnableCache[txUser](nil, 0) })
+	var events []gomp.ChangeEvent[txUser]
+	cancel := gomp.SubscribeChanges[txUser](gomp.ChangeSinkFunc[txUser](func(_ context.Context, e gomp.ChangeEvent[txUser]) {
+		events = append(events, e)
+	}))
+	t.Cleanup(cancel)
+
+	db := gomptest.NewDB(t, &txUser{})
+	err := gomp.Transaction(ctx, db, func(tx *gorm.DB) error {
+		svc := gomp.NewServiceImpl[txUser](tx)
+		if err := svc.Save(ctx, &txUser{ID: 1, Name: "kept"}); err != nil {
+			return err
+		}
+		if err := gomp.SavePoint(ctx, tx, "sp"); err != nil {
+			return err
+		}
+		if err := svc.Save(ctx, &txUser{ID: 2, Name: "ghost"}); err != nil {
+			return err
+		}
+		return gomp.RollbackTo(ctx, tx, "sp")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := gomp.NewServiceImpl[txUser](db)
+	if u, err := svc.GetById(ctx, 1); err != nil || u.Name != "kept" {
+		t.Fatalf("GetById(1) = %+v, %v", u, err)
+	}
+	if u, err := svc.GetById(ctx, 2); u != nil || err != nil {
+		t.Fatalf("GetById(2) = %+v, %v, want rolled back", u, err)
+	}
+	if len(events) != 1 || events[0].Entity.ID != 1 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
@@ -0,0 +1,147 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Upsert 按唯一键插入或更新实体，一条语句完成：conflictColumns 为唯一键列，冲突时更新 updateColumns，
+// updateColumns 为空时更新除主键、创建时间与租户列外的全部列；自动更新时间与 fill:update 字段总会被更新
+// MySQL 生成 INSERT ... ON DUPLICATE KEY UPDATE (按表上的唯一索引判断冲突)，PostgreSQL / SQLite 生成 ON CONFLICT (...) DO UPDATE
+// 冲突更新时实体的主键不保证回填为已有记录的主键
+// 启用多租户时租户列不会被更新，PostgreSQL / SQLite 的 DO UPDATE 只更新当前租户的记录，与其他租户的记录冲突时返回 ErrTenantConflict；
+// MySQL 的 ON DUPLICATE KEY UPDATE 无法限定租户，返回 ErrTenantConflict 而不执行
+//
+//	err := statService.Upsert(ctx, &DailyStat{Day: day, UserId: uid, Views: 1}, []string{"day", "user_id"}, []string{"views"})
+func (s *ServiceImpl[T]) Upsert(ctx context.Context, entity *T, conflictColumns []string, updateColumns []string) error {
+	return s.exec(ctx, "Upsert", nil, []any{entity, conflictColumns, updateColumns}, func(ctx context.Context) error {
+		if entity == nil {
+			return errors.New("upsert entity cannot be nil")
+		}
+		if len(conflictColumns) == 0 {
+			return errors.New("upsert requires conflict columns")
+		}
+		db := s.table(ctx)
+		sch, err := parseSchema[T](db)
+		if err != nil {
+			return err
+		}
+		tenant := tenantField[T](ctx, sch)
+		onConflict, err := upsertClause(sch, tenant, conflictColumns, updateColumns)
+		if err != nil {
+			return err
+		}
+		if tenant != nil {
+			if db.Dialector.Name() == "mysql" {
+				return fmt.Errorf("%w: on duplicate key update cannot be scoped to the tenant of %s", ErrTenantConflict, sch.Name)
+			}
+			cond, err := tenantCondition[T](ctx, sch)
+			if err != nil {
+				return err
+			}
+			onConflict.Where = clause.Where{Exprs: []clause.Expression{cond}}
+		}
+		if err := s.beforeInsert(ctx, db, entity); err != nil {
+			return err
+		}
+		if err := runEntityHooks(ctx, BeforeSave, "Upsert", entity); err != nil {
+			return err
+		}
+		invalidation, err := s.beginInvalidate(s.prepare(s.model(ctx).Where(conflictCondition(ctx, sch, entity, conflictColumns))))
+		if err != nil {
+			return err
+		}
+		result := db.Clauses(onConflict).Create(entity)
+		if result.Error != nil {
+			return result.Error
+		}
+		if tenant != nil && result.RowsAffected == 0 && !db.DryRun {
+			// 冲突记录属于其他租户，DO UPDATE 的租户条件不成立
+			return fmt.Errorf("%w: %s", ErrTenantConflict, sch.Name)
+		}
+		if err := invalidation.commit(ctx); err != nil {
+			return err
+		}
+		if pk := sch.PrioritizedPrimaryField; pk != nil {
+			// 清除该主键缓存的空结果
+			if id, zero := pk.ValueOf(ctx, reflect.ValueOf(entity)); !zero {
+				addToIdFilter[T](ctx, id)
+				if err := s.invalidateIds(ctx, sch, id).commit(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		return s.afterEntityHooks(ctx, AfterSave, "Upsert", result.RowsAffected, entity)
+	})
+}
+
+// upsertClause 构造冲突更新子句，校验列名并补充自动更新时间与 fill:update 字段
+// 主键、创建时间与租户列 (tenant 不为 nil 时) 不会被更新
+func upsertClause(sch *schema.Schema, tenant *schema.Field, conflictColumns, updateColumns []string) (clause.OnConflict, error) {
+	columns := make([]clause.Column, len(conflictColumns))
+	for i, column := range conflictColumns {
+		field := sch.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return clause.OnConflict{}, fmt.Errorf("unknown column %s of %s", column, sch.Name)
+		}
+		columns[i] = clause.Column{Name: field.DBName}
+	}
+	fixed := func(field *schema.Field) bool {
+		return field.PrimaryKey || field.AutoCreateTime > 0 || field == tenant
+	}
+	if len(updateColumns) == 0 {
+		// 与 gorm 的 UpdateAll 相同的列，另外排除租户列
+		updates := make([]string, 0, len(sch.DBNames))
+		for _, field := range sch.Fields {
+			if field.DBName == "" || !field.Creatable || !field.Updatable || fixed(field) {
+				continue
+			}
+			if field.HasDefaultValue && field.DefaultValueInterface == nil && !strings.EqualFold(field.DefaultValue, "NULL") {
+				continue
+			}
+			updates = append(updates, field.DBName)
+		}
+		return clause.OnConflict{Columns: columns, DoUpdates: clause.AssignmentColumns(updates)}, nil
+	}
+	updates := make([]string, 0, len(updateColumns))
+	for _, column := range updateColumns {
+		field := sch.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return clause.OnConflict{}, fmt.Errorf("unknown column %s of %s", column, sch.Name)
+		}
+		if fixed(field) {
+			continue
+		}
+		updates = append(updates, field.DBName)
+	}
+	for _, field := range sch.Fields {
+		if field.AutoUpdateTime > 0 && field.DBName != "" && !slices.Contains(updates, field.DBName) {
+			updates = append(updates, field.DBName)
+		}
+	}
+	for _, f := range fillFields(sch) {
+		if f.mode != FillInsert && !slices.Contains(updates, f.field.DBName) {
+			updates = append(updates, f.field.DBName)
+		}
+	}
+	return clause.OnConflict{Columns: columns, DoUpdates: clause.AssignmentColumns(updates)}, nil
+}
+
+// conflictCondition 按实体唯一键列的值构造条件，用于查询冲突时会被更新的已有记录
+func conflictCondition[T any](ctx context.Context, sch *schema.Schema, entity *T, conflictColumns []string) clause.Expression {
+	rv := reflect.ValueOf(entity)
+	exprs := make([]clause.Expression, 0, len(conflictColumns))
+	for _, column := range conflictColumns {
+		field := sch.LookUpField(column)
+		v, _ := field.ValueOf(ctx, rv)
+		exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: v})
+	}
+	return clause.And(exprs...)
+}
@@ -0,0 +1,80 @@
+package gomp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shelbeii/gomp"
+	"github.com/shelbeii/gomp/gomptest"
+	"gorm.io/driver/mysql"
+)
+
+// tenantCoupon 唯一键不含租户列的多租户实体
+type tenantCoupon struct {
+	ID       int64  `gorm:"primaryKey"`
+	TenantID int64  `gorm:"index"`
+	Code     string `gorm:"uniqueIndex"`
+	Name     string
+}
+
+// tenantKey 测试中携带租户 ID 的 context key
+type tenantKey struct{}
+
+// withTenant 启用从 ctx 读取租户 ID 的多租户，测试结束时关闭
+func withTenant(t *testing.T) {
+	gomp.SetTenantProvider(gomp.TenantProviderFunc(func(ctx context.Context) (any, bool) {
+		id, ok := ctx.Value(tenantKey{}).(int64)
+		return id, ok
+	}))
+	t.Cleanup(func() { gomp.SetTenantProvider(nil) })
+}
+
+func TestUpsertDoesNotTouchOtherTenants(t *testing.T) {
+	withTenant(t)
+	tenant1 := context.WithValue(context.Background(), tenantKey{}, int64(1))
+	tenant2 := context.WithValue(context.Background(), tenantKey{}, int64(2))
+	svc := gomptest.NewService[tenantCoupon](t)
+	if err := svc.Save(tenant1, &tenantCoupon{Code: "a", Name: "tenant1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, updateColumns := range [][]string{nil, {"name", "tenant_id"}} {
+		err := svc.Upsert(tenant2, &tenantCoupon{Code: "a", Name: "tenant2"}, []string{"code"}, updateColumns)
+		if !errors.Is(err, gomp.ErrTenantConflict) {
+			t.Fatalf("upsert %v: err = %v, want ErrTenantConflict", updateColumns, err)
+		}
+	}
+	got, err := svc.GetOne(gomp.WithoutTenant(context.Background()), gomp.NewQueryWrapper[tenantCoupon]().Eq("code", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TenantID != 1 || got.Name != "tenant1" {
+		t.Fatalf("row of tenant 1 changed: %+v", got)
+	}
+
+	if err := svc.Upsert(tenant1, &tenantCoupon{Code: "a", Name: "updated"}, []string{"code"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = svc.GetOne(tenant1, gomp.NewQueryWrapper[tenantCoupon]().Eq("code", "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TenantID != 1 || got.Name != "updated" {
+		t.Fatalf("upsert of tenant 1 = %+v", got)
+	}
+}
+
+func TestUpsertRejectsTenantOnMySQL(t *testing.T) {
+	withTenant(t)
+	rec := gomptest.NewRecorder(mysql.New(mysql.Config{DSN: "gomp:gomp@tcp(localhost:3306)/gomp", SkipInitializeWithVersion: true}))
+	svc := gomp.NewServiceImpl[tenantCoupon](rec.DB())
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(1))
+	err := svc.Upsert(ctx, &tenantCoupon{Code: "a", Name: "x"}, []string{"code"}, nil)
+	if !errors.Is(err, gomp.ErrTenantConflict) {
+		t.Fatalf("err = %v, want ErrTenantConflict", err)
+	}
+	if n := len(rec.Statements()); n != 0 {
+		t.Fatalf("executed %d statements", n)
+	}
+}
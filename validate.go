@@ -0,0 +1,194 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidWrapper Wrapper 存在明显错误的构造，见 QueryWrapper.Validate
+var ErrInvalidWrapper = errors.New("invalid wrapper")
+
+var (
+	// wrapperSchemas Validate 解析实体 schema 的缓存，使用默认命名策略
+	wrapperSchemas sync.Map
+	// plainColumn 不带表名与函数的普通列名
+	plainColumn = regexp.MustCompile("^[`\"]?([A-Za-z_][A-Za-z0-9_]*)[`\"]?$")
+	// eqCondition Eq 生成的等值条件
+	eqCondition = regexp.MustCompile(`^(\S+) = \?$`)
+)
+
+// Validate 检查 Wrapper 中运行时会被静默忽略或产生意外结果的构造，返回 ErrInvalidWrapper 包装的全部问题：
+// 末尾的 Or() 之后没有条件、嵌套条件 (And / Or 的函数参数) 为空、同一列的多个 AND 等值条件互相矛盾 (结果必然为空)、
+// Select 了实体中不存在的列 (只检查不带表名的普通列名，按默认命名策略解析实体)、SelectFields / OmitFields 了实体中不存在的字段
+// 开启 SQLInspector 时 Service 方法会在执行前自动检查并以 SQLRuleWrapper 报告
+//
+//	if err := wrapper.Validate(); err != nil {
+//		log.Printf("bad query: %v", err)
+//	}
+func (w *QueryWrapper[T]) Validate() error {
+	if w == nil {
+		return nil
+	}
+	errs := validateScopes(w.scopes, w.or)
+	errs = append(errs, validateColumns[T]("Select", w.selects)...)
+	errs = append(errs, validateFields[T]("SelectFields", w.fields)...)
+	errs = append(errs, validateFields[T]("OmitFields", w.omits)...)
+	return joinWrapperErrors(errs)
+}
+
+// Validate 检查 Wrapper 中明显错误的构造，规则见 QueryWrapper.Validate；Set 了实体中不存在的列同样报告
+func (w *UpdateWrapper[T]) Validate() error {
+	if w == nil {
+		return nil
+	}
+	errs := validateScopes(w.scopes, w.or)
+	columns := make([]string, 0, len(w.values))
+	for column := range w.values {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+	errs = append(errs, validateColumns[T]("Set", columns)...)
+	return joinWrapperErrors(errs)
+}
+
+// Validate 检查 Wrapper 中明显错误的构造，规则见 QueryWrapper.Validate
+func (w *DeleteWrapper[T]) Validate() error {
+	if w == nil {
+		return nil
+	}
+	return joinWrapperErrors(validateScopes(w.scopes, w.or))
+}
+
+// joinWrapperErrors 合并问题并包装 ErrInvalidWrapper
+func joinWrapperErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidWrapper, errors.Join(errs...))
+}
+
+// validateScopes 检查条件列表，dangling 为构造结束时是否遗留 Or()
+func validateScopes(scopes []scope, dangling bool) []error {
+	var errs []error
+	if dangling {
+		errs = append(errs, errors.New("Or() is not followed by any condition"))
+	}
+	hasOr := false
+	for i := range scopes {
+		hasOr = hasOr || scopes[i].or
+		if scopes[i].group == nil {
+			continue
+		}
+		sub, subDangling := scopes[i].group()
+		if len(sub) == 0 {
+			errs = append(errs, errors.New("nested condition group is empty"))
+			continue
+		}
+		errs = append(errs, validateScopes(sub, subDangling)...)
+	}
+	if hasOr {
+		// 存在 OR 时等值条件不一定同时成立
+		return errs
+	}
+	seen := make(map[string]any)
+	for i := range scopes {
+		query, ok := scopes[i].query.(string)
+		if !ok || scopes[i].fn != nil || !scopes[i].isVal {
+			continue
+		}
+		m := eqCondition.FindStringSubmatch(query)
+		if m == nil {
+			continue
+		}
+		value := scopes[i].val[0]
+		if prev, ok := seen[m[1]]; ok && !sameValue(prev, value) {
+			errs = append(errs, fmt.Errorf("contradictory conditions %s = %v AND %s = %v", m[1], prev, m[1], value))
+			continue
+		}
+		seen[m[1]] = value
+	}
+	return errs
+}
+
+// sameValue 两个条件值是否相等，数值按值比较
+func sameValue(a, b any) bool {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// validateColumns 检查普通列名是否为实体的列，表达式与带表名的列不检查
+func validateColumns[T any](kind string, columns []string) []error {
+	if len(columns) == 0 {
+		return nil
+	}
+	sch, err := schema.Parse(new(T), &wrapperSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil
+	}
+	var errs []error
+	for _, column := range columns {
+		for _, part := range strings.Split(column, ",") {
+			m := plainColumn.FindStringSubmatch(strings.TrimSpace(part))
+			if m == nil {
+				continue
+			}
+			if field := sch.LookUpField(m[1]); field == nil || field.DBName == "" {
+				errs = append(errs, fmt.Errorf("unknown column %s in %s of %s", m[1], kind, sch.Name))
+			}
+		}
+	}
+	return errs
+}
+
+// validateFields 检查结构体字段名是否为实体中对应列的字段
+func validateFields[T any](kind string, fields []string) []error {
+	if len(fields) == 0 {
+		return nil
+	}
+	sch, err := schema.Parse(new(T), &wrapperSchemas, schema.NamingStrategy{})
+	if err != nil {
+		return nil
+	}
+	var errs []error
+	for _, name := range fields {
+		if field := sch.FieldsByName[name]; field == nil || field.DBName == "" {
+			errs = append(errs, fmt.Errorf("unknown field %s in %s of %s", name, kind, sch.Name))
+		}
+	}
+	return errs
+}
+
+// inspectWrapper 开启 SQLInspector 时检查 Service 方法的 Wrapper，同一调用位置的相同问题只报告一次
+func inspectWrapper(ctx context.Context, wrapper any) {
+	inspector := activeSQLInspector.Load()
+	if inspector == nil {
+		return
+	}
+	v, ok := wrapper.(interface{ Validate() error })
+	if !ok {
+		return
+	}
+	err := v.Validate()
+	if err == nil {
+		return
+	}
+	caller := callerLocation()
+	if _, loaded := inspector.seen.LoadOrStore("wrapper\x00"+caller+"\x00"+err.Error(), struct{}{}); loaded {
+		return
+	}
+	inspector.warn(ctx, SQLWarning{
+		Rule:    SQLRuleWrapper,
+		Message: err.Error(),
+		Caller:  caller,
+	})
+}
@@ -0,0 +1,143 @@
+package gomp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrOptimisticLock 乐观锁冲突: 记录已被其他操作修改 (版本号不匹配) 或记录不存在
+var ErrOptimisticLock = errors.New("optimistic lock conflict")
+
+// defaultOptimisticLockRetries UpdateByIdWithRetry 默认重试次数
+const defaultOptimisticLockRetries = 3
+
+// versionField 获取实体中标记为 gomp:"version" 的版本字段
+func versionField(sch *schema.Schema) *schema.Field {
+	for _, field := range sch.Fields {
+		if field.DBName != "" && hasGompTag(field, "version") {
+			return field
+		}
+	}
+	return nil
+}
+
+// optimisticLock 一次乐观锁更新的状态
+type optimisticLock struct {
+	field   *schema.Field
+	entity  reflect.Value
+	current any
+}
+
+// beginOptimisticLock 将实体版本号加一，返回的锁用于追加 version = 原版本号 条件
+// 实体没有版本字段或版本号为零值时返回 nil，按普通更新处理
+func beginOptimisticLock(ctx context.Context, sch *schema.Schema, entity any) (*optimisticLock, error) {
+	field := versionField(sch)
+	if field == nil {
+		return nil, nil
+	}
+	if _, zero := field.ValueOf(ctx, reflect.ValueOf(entity)); zero {
+		return nil, nil
+	}
+	return lockVersion(ctx, sch, field, entity)
+}
+
+// beginVersionLock UpdateByIdWithVersion 使用的乐观锁：版本字段为 gomp:"version" 标签的字段，没有时为 version 列
+// 版本号为零值时同样追加条件；实体没有版本字段时返回错误
+func beginVersionLock(ctx context.Context, sch *schema.Schema, entity any) (*optimisticLock, error) {
+	field := versionField(sch)
+	if field == nil {
+		if field = sch.LookUpField("version"); field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("%s has no version field; tag it with gomp:\"version\" or name the column version", sch.Name)
+		}
+	}
+	return lockVersion(ctx, sch, field, entity)
+}
+
+// lockVersion 将实体的版本号加一并记录原版本号
+func lockVersion(ctx context.Context, sch *schema.Schema, field *schema.Field, entity any) (*optimisticLock, error) {
+	rv := reflect.ValueOf(entity)
+	current, _ := field.ValueOf(ctx, rv)
+	next, err := nextVersion(current)
+	if err != nil {
+		return nil, fmt.Errorf("version field %s of %s: %w", field.Name, sch.Name, err)
+	}
+	if err := field.Set(ctx, rv, next); err != nil {
+		return nil, err
+	}
+	return &optimisticLock{field: field, entity: rv, current: current}, nil
+}
+
+// condition 版本号条件
+func (l *optimisticLock) condition() clause.Expression {
+	return clause.Eq{Column: currentColumn(l.field.DBName), Value: l.current}
+}
+
+// finish 检查更新结果，未更新任何记录时恢复实体版本号并返回 ErrOptimisticLock
+func (l *optimisticLock) finish(ctx context.Context, result *gorm.DB) error {
+	if result.Error != nil {
+		if l != nil {
+			_ = l.field.Set(ctx, l.entity, l.current)
+		}
+		return result.Error
+	}
+	if l != nil && result.RowsAffected == 0 {
+		_ = l.field.Set(ctx, l.entity, l.current)
+		return ErrOptimisticLock
+	}
+	return nil
+}
+
+// nextVersion 版本号加一
+func nextVersion(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("version is nil")
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() + 1, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() + 1, nil
+	}
+	return nil, fmt.Errorf("unsupported version type %s", rv.Type())
+}
+
+// WithOptimisticLockRetry 设置 UpdateByIdWithRetry 在乐观锁冲突时的最大重试次数 (默认 3)
+func (s *ServiceImpl[T]) WithOptimisticLockRetry(retries int) *ServiceImpl[T] {
+	s.optimisticLockRetries = retries
+	return s
+}
+
+// UpdateByIdWithRetry 读取记录并应用 mutate 后根据主键更新，乐观锁冲突时重新读取并再次应用 mutate
+// 超过重试次数仍冲突时返回 ErrOptimisticLock；记录不存在时返回 gorm.ErrRecordNotFound；mutate 返回错误时中止
+func (s *ServiceImpl[T]) UpdateByIdWithRetry(ctx context.Context, id any, mutate func(entity *T) error) error {
+	return s.exec(ctx, "UpdateByIdWithRetry", nil, []any{id, mutate}, func(ctx context.Context) error {
+		retries := s.optimisticLockRetries
+		if retries <= 0 {
+			retries = defaultOptimisticLockRetries
+		}
+		var err error
+		for attempt := 0; attempt <= retries; attempt++ {
+			var entity T
+			if err = s.prepare(s.model(ctx)).Set(primaryOnlyKey, true).First(&entity, id).Error; err != nil {
+				return err
+			}
+			if err = mutate(&entity); err != nil {
+				return err
+			}
+			if err = s.updateById(ctx, "UpdateByIdWithRetry", &entity, updateOptions{}); !errors.Is(err, ErrOptimisticLock) {
+				return err
+			}
+		}
+		return err
+	})
+}